@@ -2,12 +2,13 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -15,142 +16,238 @@ import (
 	deliveryHTTP "github.com/nuryanfa/e-commerse-sqa/internal/delivery/http"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
 	"github.com/nuryanfa/e-commerse-sqa/internal/infrastructure/email"
+	"github.com/nuryanfa/e-commerse-sqa/internal/infrastructure/imaging"
 	"github.com/nuryanfa/e-commerse-sqa/internal/middleware"
 	"github.com/nuryanfa/e-commerse-sqa/internal/repository"
 	"github.com/nuryanfa/e-commerse-sqa/internal/usecase"
+	"github.com/nuryanfa/e-commerse-sqa/internal/worker"
+	"github.com/nuryanfa/e-commerse-sqa/migrations"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/jwt"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/metrics"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/migrate"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/storage"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/tracing"
 	"golang.org/x/time/rate"
 )
 
 func main() {
-	// 1. Init Database
-	db := config.InitDB()
+	// 0. Load konfigurasi (env, .env, YAML, nilai bawaan); berhenti jika tidak valid
+	cfg := config.MustLoad()
+	// Logger terstruktur (JSON di production, teks di development) dipakai seluruh komponen
+	logger := config.InitLogger(cfg.Log)
+	logger.Info("konfigurasi dimuat", "env", cfg.Env, "log_level", cfg.Log.Level)
+	// Tracing OpenTelemetry (none | stdout | otlp); span yang tersisa dikirim saat shutdown
+	shutdownTracing, err := config.InitTracing(cfg.Tracing, cfg.Env)
+	if err != nil {
+		fatal("gagal menyiapkan tracing", err)
+	}
+
+	// 1. Init Database (primary + read replica katalog opsional)
+	db, err := config.InitDB(cfg.Database)
+	if err != nil {
+		fatal("gagal terhubung ke database", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("gagal mengakses pool database", err)
+	}
+	replicas, err := config.InitReplicas(db, cfg.Database, repository.ReplicaCatalog)
+	if err != nil {
+		fatal("gagal terhubung ke read replica", err)
+	}
+	// Durasi setiap query GORM dan statistik pool koneksi diekspos di /metrics; setiap statement juga menjadi span
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		fatal("gagal memasang metrik GORM", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		fatal("gagal memasang tracing GORM", err)
+	}
+	metrics.RegisterDBStats("primary", sqlDB)
+	for i, replica := range replicas {
+		metrics.RegisterDBStats(fmt.Sprintf("replica-%d", i+1), replica)
+	}
 
 	// 1b. Init Redis (opsional — graceful degradation jika tidak tersedia)
-	redisClient := config.InitRedis()
-
-	// Auto Migrate the database structures
-	err := db.AutoMigrate(
-		&domain.User{},
-		&domain.Category{},
-		&domain.Product{},
-		&domain.ProductVariant{},
-		&domain.CartItem{},
-		&domain.Order{},
-		&domain.OrderItem{},
-		&domain.Review{},
-		&domain.Wishlist{},
-		&domain.Voucher{},
-		&domain.AuditLog{},
-		&domain.Dispute{},
-		&domain.DisputeMessage{},
-	)
+	redisClient := config.InitRedis(cfg.Redis)
+	if redisClient != nil {
+		redisClient.AddHook(tracing.RedisHook{})
+	}
+
+	// Skema dikelola migrasi berversi (go run ./cmd/migrate up); API menolak start jika skema tertinggal
+	migrator, err := migrate.New(db, migrations.FS)
 	if err != nil {
-		log.Fatalf("Gagal melakukan migrasi database: %v", err)
+		fatal("file migrasi tidak valid", err)
 	}
-	log.Println("Database migration berhasil.")
+	if err := migrator.Check(context.Background()); err != nil {
+		fatal("skema database tertinggal", err)
+	}
+	logger.Info("skema database sudah terbaru")
 
 	// 2. Setup Gin Router with Custom Middleware
 	router := gin.New() // Menggunakan gin.New() alih-alih Default() agar middleware terkontrol penuh
-	router.Use(middleware.RecoveryMiddleware()) // Menangkap panic agar server tidak crash
-	router.Use(middleware.LoggerMiddleware())   // Logging terstruktur untuk setiap request
+	// ClientIP (rate limit, log, audit) hanya membaca X-Forwarded-For dari proxy yang dipercaya
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("TRUSTED_PROXIES tidak valid", err)
+	}
+	router.Use(middleware.RequestContextMiddleware()) // Request id, IP, dan user agent untuk log & audit log
+	// Span per route (traceparent dari upstream diteruskan); trace id dikirim di X-Trace-ID dan respons error
+	router.Use(middleware.TracingMiddleware(cfg.Tracing.ServiceName))
+	router.Use(middleware.RecoveryMiddleware(logger)) // Menangkap panic agar server tidak crash
+	router.Use(middleware.LoggerMiddleware(logger))   // Logging terstruktur untuk setiap request
+	router.Use(middleware.MetricsMiddleware())        // Histogram latensi per route & status untuk Prometheus
+
+	// Batas waktu request; route group admin, supplier, dan webhook menimpanya di bawah
+	timeouts := cfg.Server.RequestTimeouts
+	router.Use(middleware.TimeoutMiddleware(timeouts.Default))
 
 	// CORS Middleware
 	router.Use(cors.New(cors.Config{
 		AllowAllOrigins:  true,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID", "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID", "X-Trace-ID"},
 	}))
 
-	// Health Check
-	router.GET("/api/v1/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  "success",
-			"message": "Server berjalan dan siap!",
-		})
-	})
+	// Health Check: /livez (proses hidup), /readyz dan /api/v1/health (ping PostgreSQL & Redis + statistik pool)
+	deliveryHTTP.NewHealthHandler(router, sqlDB, replicas, redisClient)
 
-	// Serve Static Files for Uploads
-	router.Static("/uploads", "./uploads")
+	// 2b. Penyimpanan file (disk lokal atau S3-compatible). File lokal disajikan lewat handler
+	// agar objek privat (bukti sengketa) hanya bisa dibuka dengan URL bertanda tangan.
+	blobStore, err := config.InitStorage(cfg.Storage)
+	if err != nil {
+		fatal("gagal menyiapkan penyimpanan file", err)
+	}
+	if localStore, ok := blobStore.(*storage.Local); ok {
+		deliveryHTTP.NewFileHandler(router, localStore)
+	}
+	imageProcessor := imaging.NewProcessor()
 
 	// 3. Dependency Injection for Clean Architecture
 
 	// Users (public — register & login)
 	// SQA Security: Rate limiter diterapkan pada endpoint login
-	// Konfigurasi: rate.Every(12*time.Second) = ~5 request/menit, burst 5
+	// Konfigurasi bawaan: interval 12 detik (~5 request/menit), burst 5 — lihat config.RateLimitConfig
+	tokens := jwt.NewManager(cfg.JWT.Secret, cfg.JWT.Expiry)
+	authMiddleware := middleware.AuthMiddleware(tokens, logger)
 	userRepo := repository.NewUserRepository(db)
-	userUsecase := usecase.NewUserUsecase(userRepo)
-	deliveryHTTP.NewUserHandler(router, userUsecase, middleware.RateLimitMiddleware(rate.Every(12*time.Second), 5))
+	userUsecase := usecase.NewUserUsecase(userRepo, tokens)
+	loginRateLimiter := middleware.RateLimitMiddleware(rate.Every(cfg.RateLimit.LoginInterval), cfg.RateLimit.LoginBurst)
+	deliveryHTTP.NewUserHandler(router, userUsecase, loginRateLimiter, authMiddleware)
 
 	// Repositories for Catalog
 	categoryRepo := repository.NewCategoryRepository(db)
-	categoryUsecase := usecase.NewCategoryUsecase(categoryRepo)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	categoryUsecase := usecase.NewCategoryUsecase(categoryRepo, auditLogRepo, logger)
+	if err := categoryUsecase.EnsureSlugs(context.Background()); err != nil {
+		logger.Warn("gagal mengisi slug kategori lama", "error", err)
+	}
 
 	// SQA Performance: Product repository dibungkus dengan Redis caching
 	baseProductRepo := repository.NewProductRepository(db)
-	productRepo := repository.NewCachedProductRepository(baseProductRepo, redisClient)
-    auditLogRepo := repository.NewAuditLogRepository(db)
-	productUsecase := usecase.NewProductUsecase(productRepo, categoryRepo, auditLogRepo)
+	productRepo := repository.NewCachedProductRepository(baseProductRepo, redisClient, logger)
+	productCache := productRepo.(domain.ProductCacheInvalidator) // Dipakai usecase yang mengubah stok/opsi/galeri produk di luar ProductRepository
+	emailSvc := email.NewMockEmailService(logger)
+
+	// Wishlist + antrian notifikasi restock / turun harga (diproses worker di background)
+	wishlistRepo := repository.NewWishlistRepository(db)
+	wishlistUsecase := usecase.NewWishlistUsecase(wishlistRepo, productRepo, emailSvc, logger)
+	wishlistAlertWorker := worker.NewWishlistAlertWorker(wishlistUsecase, 100, logger)
+
+	// Autocomplete pencarian (pg_trgm), memakai Redis opsional yang sama dengan cache produk
+	searchSuggestionRepo := repository.NewCachedSearchSuggestionRepository(repository.NewSearchSuggestionRepository(db), redisClient)
+	searchSuggestionUsecase := usecase.NewSearchSuggestionUsecase(searchSuggestionRepo)
+
+	productUsecase := usecase.NewProductUsecase(productRepo, categoryRepo, auditLogRepo, wishlistAlertWorker, searchSuggestionRepo, logger)
+
+	// Opsi produk (Ukuran × Grade × Kemasan) dan matriks varian
+	productOptionRepo := repository.NewProductOptionRepository(db)
+	productOptionUsecase := usecase.NewProductOptionUsecase(productOptionRepo, productRepo, auditLogRepo, productCache, logger)
+	productImageRepo := repository.NewProductImageRepository(db)
+	productImageUsecase := usecase.NewProductImageUsecase(productImageRepo, productRepo, imageProcessor, blobStore, auditLogRepo, productCache, logger)
+
+	// Impor/ekspor produk massal (CSV/XLSX) untuk supplier, diproses worker di background
+	productImportWorker := worker.NewProductImportWorker(10, logger)
+	productImportUsecase := usecase.NewProductImportUsecase(repository.NewImportJobRepository(db), productRepo, categoryRepo, productUsecase, productImportWorker)
+	productImportWorker.SetProcessor(productImportUsecase)
+	if failed, err := productImportUsecase.FailInterruptedJobs(context.Background()); err != nil {
+		logger.Warn("gagal menandai job impor yang terputus", "error", err)
+	} else if failed > 0 {
+		logger.Warn("job impor yang terputus ditandai FAILED", "count", failed)
+	}
 
 	reviewRepo := repository.NewReviewRepository(db)
 	reviewUsecase := usecase.NewReviewUsecase(reviewRepo, productRepo)
 
-	wishlistRepo := repository.NewWishlistRepository(db)
-	wishlistUsecase := usecase.NewWishlistUsecase(wishlistRepo, productRepo)
-
 	// Shopping Cart and Orders
 	cartRepo := repository.NewCartRepository(db)
 	cartUsecase := usecase.NewCartUsecase(cartRepo, productRepo)
 
-	emailSvc := email.NewMockEmailService()
+	// Inventory Ledger (riwayat pergerakan stok append-only) + notifikasi stok menipis.
+	// Memakai base repo (tanpa cache) agar pemeriksaan stok selalu membaca angka terbaru.
+	stockMovementRepo := repository.NewStockMovementRepository(db)
+	inventoryUsecase := usecase.NewInventoryUsecase(stockMovementRepo, baseProductRepo, userRepo, emailSvc, logger)
+
 	orderRepo := repository.NewOrderRepository(db)
-	orderUsecase := usecase.NewOrderUsecase(orderRepo, cartRepo, auditLogRepo, emailSvc, userRepo)
+	orderUsecase := usecase.NewOrderUsecase(orderRepo, cartRepo, auditLogRepo, emailSvc, userRepo, inventoryUsecase, productCache, cfg.Payment, logger)
+	auditLogUsecase := usecase.NewAuditLogUsecase(auditLogRepo)
 
 	// Dispute / Pusat Resolusi
 	disputeRepo := repository.NewDisputeRepository(db)
-	disputeUsecase := usecase.NewDisputeUseCase(disputeRepo, orderRepo)
+	disputeUsecase := usecase.NewDisputeUseCase(disputeRepo, orderRepo, imageProcessor, blobStore, logger)
 
 	// 4. Protected Routes
 
 	// 4a. Admin-only routes (JWT + Role "admin")
 	// Digunakan untuk manipulasi Katalog (Create/Update/Delete Produk & Kategori)
 	adminRoutes := router.Group("/api/v1")
-	adminRoutes.Use(middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"))
+	adminRoutes.Use(authMiddleware, middleware.RoleMiddleware("admin"), middleware.TimeoutMiddleware(timeouts.Admin))
 	{
 		deliveryHTTP.NewCategoryHandler(router, adminRoutes, categoryUsecase)
-		deliveryHTTP.NewProductHandler(router, adminRoutes, productUsecase)
+		deliveryHTTP.NewProductHandler(router, adminRoutes, productUsecase, productImageUsecase)
 	}
 
 	// 4b. Auth-only routes (JWT — semua role: pembeli, admin, dll)
 	// Digunakan untuk Keranjang Belanja, Checkout, dan Riwayat Pesanan
 	authRoutes := router.Group("/api/v1")
-	authRoutes.Use(middleware.AuthMiddleware())
+	authRoutes.Use(authMiddleware)
 	{
 		deliveryHTTP.NewCartHandler(authRoutes, cartUsecase)
-		deliveryHTTP.NewOrderHandler(authRoutes, orderUsecase)
+		deliveryHTTP.NewOrderHandler(authRoutes, adminRoutes, orderUsecase)
 	}
 
 	// Open endpoints that also have protected childs
-	deliveryHTTP.NewReviewHandler(router.Group("/api/v1"), reviewUsecase)
-	deliveryHTTP.NewWishlistHandler(router.Group("/api/v1"), wishlistUsecase)
+	deliveryHTTP.NewReviewHandler(router.Group("/api/v1"), reviewUsecase, authMiddleware)
+	deliveryHTTP.NewWishlistHandler(router.Group("/api/v1"), wishlistUsecase, authMiddleware)
 
 	// 4c. Supplier-only routes (JWT + Role "supplier")
 	supplierRoutes := router.Group("/api/v1/supplier")
-	supplierRoutes.Use(middleware.AuthMiddleware(), middleware.RoleMiddleware("supplier"))
+	supplierRoutes.Use(authMiddleware, middleware.RoleMiddleware("supplier"), middleware.TimeoutMiddleware(timeouts.Supplier))
 	{
-		deliveryHTTP.NewSupplierHandler(supplierRoutes, productUsecase, orderUsecase)
+		deliveryHTTP.NewSupplierHandler(supplierRoutes, productUsecase, orderUsecase, productImageUsecase)
+		deliveryHTTP.NewProductImportHandler(supplierRoutes, productImportUsecase)
 	}
 
+	// 4c-1. Varian produk: admin (/products/:id/variants) & supplier (/supplier/products/:id/variants)
+	deliveryHTTP.NewVariantHandler(router, adminRoutes, supplierRoutes, productUsecase)
+	deliveryHTTP.NewOptionHandler(router, adminRoutes, supplierRoutes, productOptionUsecase)
+	deliveryHTTP.NewProductImageHandler(router, adminRoutes, supplierRoutes, productImageUsecase)
+	deliveryHTTP.NewProductModerationHandler(adminRoutes, supplierRoutes, productUsecase)
+	deliveryHTTP.NewAuditLogHandler(adminRoutes, auditLogUsecase, logger)
+	deliveryHTTP.NewSearchSuggestionHandler(router, searchSuggestionUsecase)
+
+	// 4c-2. Inventory Ledger: supplier melihat riwayat stok miliknya, admin memeriksa konsistensi
+	deliveryHTTP.NewInventoryHandler(supplierRoutes, adminRoutes, inventoryUsecase)
+
 	// 4d. Courier-only routes (JWT + Role "courier")
 	courierRoutes := router.Group("/api/v1/courier")
-	courierRoutes.Use(middleware.AuthMiddleware(), middleware.RoleMiddleware("courier"))
+	courierRoutes.Use(authMiddleware, middleware.RoleMiddleware("courier"))
 	{
 		deliveryHTTP.NewCourierHandler(courierRoutes, orderUsecase, disputeUsecase)
 	}
 
 	// 4e. Dispute / Pusat Resolusi (Campuran Role)
 	disputeRoutes := router.Group("/api/v1/disputes")
-	disputeRoutes.Use(middleware.AuthMiddleware()) // Harus login
+	disputeRoutes.Use(authMiddleware) // Harus login
 	{
 		disputeHandler := deliveryHTTP.NewDisputeHandler(disputeUsecase)
 
@@ -166,56 +263,94 @@ func main() {
 		{
 			adminDispute.PUT("/:id/resolve", disputeHandler.ResolveDispute)
 		}
+
+		// Supplier menginspeksi barang retur yang sudah diterima: restock (penuh/sebagian) atau write-off
+		supplierDispute := disputeRoutes.Group("")
+		supplierDispute.Use(middleware.RoleMiddleware("supplier"))
+		{
+			supplierDispute.POST("/:id/inspect-return", disputeHandler.InspectReturn)
+		}
 	}
 
 	// 4e. Webhook Public Endpoints (Tanpa Auth / Token JWT)
 	webhookRoutes := router.Group("/api/v1/payments")
+	webhookRoutes.Use(middleware.TimeoutMiddleware(timeouts.Webhook))
 	{
-		deliveryHTTP.NewWebhookHandler(webhookRoutes, orderUsecase)
+		deliveryHTTP.NewWebhookHandler(webhookRoutes, orderUsecase, cfg.Payment, logger)
 	}
 
-	// 5. Setup Worker for Background Jobs (Cron)
-	go func() {
-		log.Println("[WORKER] Background Service untuk membatalkan pesanan kedaluwarsa telah aktif.")
-		// Jalankan setiap 1 jam
-		ticker := time.NewTicker(1 * time.Hour)
-		for {
-			<-ticker.C
-			canceled, err := orderUsecase.ProcessCancelExpiredJobs()
-			if err != nil {
-				log.Printf("[CRON ERROR] Gagal mengeksekusi cronjob pembatalan pesanan: %v", err)
-			} else if canceled > 0 {
-				log.Printf("[CRON SUCCESS] Membatalkan %d pesanan kedaluwarsa (Limit stok dikembalikan).", canceled)
-			}
-		}
-	}()
+	// 5. Setup Worker for Background Jobs
+	// Reservasi stok dilepas tepat saat kedaluwarsa; maxWait (bawaan 1 menit) hanya sebagai batas atas tidur scheduler.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go worker.NewReservationScheduler(orderUsecase, cfg.Worker.ReservationMaxWait, logger).Run(workerCtx)
+	go wishlistAlertWorker.Run(workerCtx)
+	go productImportWorker.Run(workerCtx)
 
 	// 6. Setup Server with Graceful Shutdown
+	// Semua context request diturunkan dari baseCtx agar query yang masih berjalan bisa dibatalkan saat shutdown
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: router,
+		Addr:        fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
 
 	// Menjalankan server dalam goroutine terpisah
 	go func() {
-		log.Println("Server Golang menyala di http://localhost:8080")
+		logger.Info("server menyala", "addr", srv.Addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Gagal menjalankan server: %v", err)
+			fatal("gagal menjalankan server", err)
 		}
 	}()
 
+	// Metrik Prometheus disajikan di listener internal agar tidak bisa di-scrape dari internet
+	var metricsSrv *http.Server
+	if cfg.Server.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler())
+		metricsSrv = &http.Server{Addr: cfg.Server.MetricsAddr, Handler: mux}
+		go func() {
+			logger.Info("endpoint metrik menyala", "addr", metricsSrv.Addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("gagal menjalankan endpoint metrik", err)
+			}
+		}()
+	}
+
 	// Menunggu sinyal interrupt untuk graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Mematikan server...")
+	logger.Info("mematikan server")
+	stopWorkers()
 
 	// Timeout untuk menunda mematikan server yang sedang melayani request
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
+	// Jika batas waktu shutdown habis, context request dibatalkan sehingga query GORM/Redis ikut berhenti
+	context.AfterFunc(ctx, cancelRequests)
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server dihentikan paksa:", err)
+		fatal("server dihentikan paksa", err)
+	}
+	if metricsSrv != nil {
+		metricsSrv.Close()
+	}
+	// Pool koneksi ditutup setelah semua request selesai
+	for _, replica := range replicas {
+		replica.Close()
 	}
+	sqlDB.Close()
+	if err := shutdownTracing(context.Background()); err != nil {
+		logger.Warn("gagal mengirim sisa span tracing", "error", err)
+	}
+
+	logger.Info("server berhasil dimatikan dengan aman")
+}
 
-	log.Println("Server berhasil dimatikan dengan aman.")
+// fatal mencatat error lewat logger default (lihat config.InitLogger) lalu menghentikan proses
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
\ No newline at end of file
@@ -0,0 +1,120 @@
+// Command migrate mengelola migrasi skema database berversi (folder migrations/).
+//
+//	go run ./cmd/migrate up [N]        jalankan semua (atau N) migrasi tertunda
+//	go run ./cmd/migrate down [N|all]  batalkan N migrasi terakhir (bawaan 1)
+//	go run ./cmd/migrate status        tampilkan versi yang sudah/belum dijalankan
+//	go run ./cmd/migrate create <nama> buat pasangan file up/down kosong di migrations/
+//
+// API menolak start jika masih ada migrasi tertunda, jadi jalankan `up` sebelum deploy.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nuryanfa/e-commerse-sqa/config"
+	"github.com/nuryanfa/e-commerse-sqa/migrations"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/migrate"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Penggunaan: migrate [-dir migrations] <up [N] | down [N|all] | status | create <nama>>")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+// parseSteps membaca argumen jumlah langkah; "all" berarti semua (0)
+func parseSteps(args []string, fallback int) int {
+	if len(args) == 0 {
+		return fallback
+	}
+	if strings.EqualFold(args[0], "all") {
+		return 0
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		log.Fatalf("Jumlah langkah tidak valid: %s", args[0])
+	}
+	return n
+}
+
+func main() {
+	dir := flag.String("dir", "migrations", "direktori file migrasi (dipakai oleh create)")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+	}
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	// create hanya menulis file sehingga tidak butuh koneksi database
+	if command == "create" {
+		if len(args) != 1 {
+			usage()
+		}
+		up, down, err := migrate.Create(*dir, args[0])
+		if err != nil {
+			log.Fatalf("Gagal membuat migrasi: %v", err)
+		}
+		log.Printf("✅ Dibuat %s dan %s", up, down)
+		return
+	}
+
+	cfg := config.MustLoad()
+	db, err := config.InitDB(cfg.Database)
+	if err != nil {
+		log.Fatalf("Gagal terhubung ke database: %v", err)
+	}
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("File migrasi tidak valid: %v", err)
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		done, err := migrator.Up(ctx, parseSteps(args, 0))
+		for _, m := range done {
+			log.Printf("✅ %s", m)
+		}
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if len(done) == 0 {
+			log.Println("Skema sudah terbaru, tidak ada migrasi yang dijalankan.")
+		}
+	case "down":
+		done, err := migrator.Down(ctx, parseSteps(args, 1))
+		for _, m := range done {
+			log.Printf("↩️  %s", m)
+		}
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if len(done) == 0 {
+			log.Println("Tidak ada migrasi yang bisa di-rollback.")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Gagal membaca status migrasi: %v", err)
+		}
+		for _, s := range statuses {
+			state := "tertunda"
+			if s.AppliedAt != nil {
+				state = "dijalankan " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Missing {
+				state += " (file tidak ditemukan)"
+			}
+			fmt.Printf("%06d_%-40s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		usage()
+	}
+}
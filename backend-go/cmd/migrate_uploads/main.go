@@ -0,0 +1,202 @@
+// Command migrate_uploads memindahkan file lama di ./uploads ke BlobStore yang dikonfigurasi
+// (STORAGE_DRIVER) dan memperbarui referensi URL di database.
+//
+//	go run ./cmd/migrate_uploads -dry-run
+//	go run ./cmd/migrate_uploads
+//	go run ./cmd/migrate_uploads -keep-source
+//
+// Bukti sengketa (disputes.image_url) dipindahkan ke ruang privat sehingga hanya bisa dibuka lewat
+// URL bertanda tangan. File sumber dihapus setelah berhasil dipindahkan; -keep-source hanya
+// menyimpan file publik, karena salinan lama bukti sengketa masih bisa dibuka tanpa tanda tangan
+// di /uploads. Command aman dijalankan ulang: objek yang sudah ada tidak diunggah lagi.
+package main
+
+import (
+	"context"
+	"flag"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nuryanfa/e-commerse-sqa/config"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// legacyURLPrefix adalah prefix URL file lama yang disajikan router.Static
+const legacyURLPrefix = "/uploads/"
+
+type stats struct {
+	uploaded, skipped, rewritten, deleted, failed int
+}
+
+func main() {
+	dir := flag.String("dir", "uploads", "direktori sumber file lama")
+	dryRun := flag.Bool("dry-run", false, "tampilkan rencana tanpa mengubah apa pun")
+	keepSource := flag.Bool("keep-source", false, "simpan file sumber publik setelah dipindahkan (bukti sengketa tetap dihapus)")
+	flag.Parse()
+
+	cfg := config.MustLoad()
+	// Format log sama dengan server (JSON di production, field sensitif disamarkan)
+	logger := config.InitLogger(cfg.Log).With("component", "migrate_uploads", "dry_run", *dryRun)
+	db, err := config.InitDB(cfg.Database)
+	if err != nil {
+		fatal("gagal terhubung ke database", err)
+	}
+	store, err := config.InitStorage(cfg.Storage)
+	if err != nil {
+		fatal("gagal menyiapkan penyimpanan file", err)
+	}
+	ctx := context.Background()
+
+	evidence, err := legacyDisputeFiles(db)
+	if err != nil {
+		fatal("gagal membaca bukti sengketa", err)
+	}
+
+	var st stats
+	err = filepath.WalkDir(*dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(*dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if storage.ValidateKey(rel) != nil {
+			logger.Warn("file dilewati: nama file tidak valid sebagai key", "rel", rel)
+			st.skipped++
+			return nil
+		}
+
+		key := rel
+		if evidence[rel] && !storage.IsPrivate(rel) {
+			key = storage.PrivatePrefix + "disputes/" + path.Base(rel)
+		}
+		deleteSource := !*keepSource || storage.IsPrivate(key)
+		migrateFile(ctx, logger, db, store, p, rel, key, *dryRun, deleteSource, &st)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		fatal("gagal membaca direktori sumber", err, "dir", *dir)
+	}
+
+	logger.Info("migrasi file selesai", "uploaded", st.uploaded, "skipped", st.skipped,
+		"rewritten", st.rewritten, "deleted", st.deleted, "failed", st.failed)
+	if st.failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// fatal mencatat error lewat logger default (lihat config.InitLogger) lalu menghentikan proses
+func fatal(msg string, err error, args ...interface{}) {
+	slog.Error(msg, append(args, "error", err)...)
+	os.Exit(1)
+}
+
+// legacyDisputeFiles mengembalikan path relatif file yang dipakai sebagai bukti sengketa
+func legacyDisputeFiles(db *gorm.DB) (map[string]bool, error) {
+	var urls []string
+	if err := db.Table("disputes").Where("image_url LIKE ?", legacyURLPrefix+"%").Pluck("image_url", &urls).Error; err != nil {
+		return nil, err
+	}
+	files := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		files[strings.TrimPrefix(u, legacyURLPrefix)] = true
+	}
+	return files, nil
+}
+
+func migrateFile(ctx context.Context, logger *slog.Logger, db *gorm.DB, store storage.BlobStore, src string, rel string, key string, dryRun bool, deleteSource bool, st *stats) {
+	oldURL := legacyURLPrefix + rel
+	private := storage.IsPrivate(key)
+	logger = logger.With("rel", rel, "key", key)
+
+	if dryRun {
+		logger.Info("rencana migrasi file", "old_url", oldURL, "private", private, "delete_source", deleteSource)
+		st.uploaded++
+		return
+	}
+
+	exists, err := store.Exists(ctx, key)
+	if err != nil {
+		logger.Error("gagal memeriksa objek tujuan", "error", err)
+		st.failed++
+		return
+	}
+	// Pada storage lokal dengan direktori yang sama, file publik sudah berada di tempatnya
+	sameFile := false
+	if local, ok := store.(*storage.Local); ok {
+		if target, err := local.Path(key); err == nil {
+			sameFile = filepath.Clean(target) == filepath.Clean(src)
+		}
+	}
+
+	if exists {
+		st.skipped++
+	} else {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			logger.Error("gagal membaca file sumber", "error", err)
+			st.failed++
+			return
+		}
+		if err := store.Put(ctx, key, data, http.DetectContentType(data)); err != nil {
+			logger.Error("gagal mengunggah file", "error", err)
+			st.failed++
+			return
+		}
+		st.uploaded++
+	}
+
+	// Bukti sengketa disimpan sebagai key (ditandatangani saat dibaca), file publik sebagai URL
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if private {
+			res := tx.Exec("UPDATE disputes SET image_url = ? WHERE image_url = ?", key, oldURL)
+			st.rewritten += int(res.RowsAffected)
+			return res.Error
+		}
+		newURL := store.URL(key)
+		if newURL == oldURL {
+			return nil
+		}
+		for _, stmt := range []string{
+			"UPDATE products SET image_url = ? WHERE image_url = ?",
+			"UPDATE product_images SET thumb_url = ? WHERE thumb_url = ?",
+			"UPDATE product_images SET medium_url = ? WHERE medium_url = ?",
+			"UPDATE product_images SET large_url = ? WHERE large_url = ?",
+		} {
+			res := tx.Exec(stmt, newURL, oldURL)
+			if res.Error != nil {
+				return res.Error
+			}
+			st.rewritten += int(res.RowsAffected)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("gagal memperbarui referensi", "error", err)
+		st.failed++
+		return
+	}
+
+	if deleteSource && !sameFile {
+		if err := os.Remove(src); err != nil {
+			logger.Warn("gagal menghapus file sumber", "private", private, "error", err)
+			// Salinan bukti sengketa yang tertinggal masih bisa dibuka publik, jadi dihitung gagal
+			if private {
+				st.failed++
+			}
+			return
+		}
+		st.deleted++
+	}
+}
@@ -1,23 +1,43 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/nuryanfa/e-commerse-sqa/config"
+	"github.com/nuryanfa/e-commerse-sqa/migrations"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/migrate"
 )
 
+// reset membatalkan seluruh migrasi (semua tabel dan data hilang) lalu membangun ulang skema dari awal
 func main() {
-	db := config.InitDB()
+	cfg := config.MustLoad()
+	db, err := config.InitDB(cfg.Database)
+	if err != nil {
+		log.Fatalf("Gagal terhubung ke database: %v", err)
+	}
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("File migrasi tidak valid: %v", err)
+	}
+	ctx := context.Background()
 
-	tables := []string{"order_items", "orders", "cart_items", "products", "categories", "users"}
+	down, err := migrator.Down(ctx, 0)
+	for _, m := range down {
+		log.Printf("↩️  %s", m)
+	}
+	if err != nil {
+		log.Fatalf("❌ Gagal rollback: %v", err)
+	}
 
-	for _, table := range tables {
-		if err := db.Exec("DROP TABLE IF EXISTS \"" + table + "\" CASCADE").Error; err != nil {
-			log.Printf("❌ Gagal drop table %s: %v", table, err)
-		} else {
-			log.Printf("✅ Table '%s' berhasil di-drop", table)
-		}
+	up, err := migrator.Up(ctx, 0)
+	for _, m := range up {
+		log.Printf("✅ %s", m)
+	}
+	if err != nil {
+		log.Fatalf("❌ Gagal migrasi ulang: %v", err)
 	}
 
-	log.Println("\n🎉 Semua table lama berhasil di-drop. Jalankan seeder ulang: go run cmd/seed/main.go")
+	log.Println("\n🎉 Skema database berhasil dibangun ulang. Jalankan seeder ulang: go run cmd/seed/main.go")
 }
@@ -1,20 +1,33 @@
 package main
 
 import (
+	"context"
 	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nuryanfa/e-commerse-sqa/config"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/migrations"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/migrate"
 	"github.com/nuryanfa/e-commerse-sqa/pkg/password"
 )
 
 func main() {
-	db := config.InitDB()
+	cfg := config.MustLoad()
+	db, err := config.InitDB(cfg.Database)
+	if err != nil {
+		log.Fatalf("Gagal terhubung ke database: %v", err)
+	}
 
-	// Auto Migrate
-	_ = db.AutoMigrate(&domain.User{}, &domain.Category{}, &domain.Product{}, &domain.ProductVariant{})
+	// Skema dibuat lewat migrasi berversi, seeder hanya mengisi data
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("File migrasi tidak valid: %v", err)
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		log.Fatalf("%v", err)
+	}
 
 	// Hash password — semua dummy user pakai password yang sama: "password123"
 	hashed, err := password.HashPassword("password123")
@@ -4,6 +4,7 @@ package main
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/nuryanfa/e-commerse-sqa/config"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
@@ -11,7 +12,11 @@ import (
 )
 
 func main() {
-	db := config.InitDB()
+	cfg := config.MustLoad()
+	db, err := config.InitDB(cfg.Database)
+	if err != nil {
+		log.Fatalf("Gagal terhubung ke database: %v", err)
+	}
 	var users []domain.User
 	db.Find(&users)
 
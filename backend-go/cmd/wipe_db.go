@@ -4,19 +4,28 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"strings"
 
 	"github.com/nuryanfa/e-commerse-sqa/config"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/migrate"
 )
 
 func main() {
-	db := config.InitDB()
+	cfg := config.MustLoad()
+	db, err := config.InitDB(cfg.Database)
+	if err != nil {
+		log.Fatalf("Gagal terhubung ke database: %v", err)
+	}
 	
-	// Delete all data in all tables
-	err := db.Exec(`TRUNCATE TABLE 
-		users, categories, products, product_variants, 
-		cart_items, orders, order_items, reviews, 
-		wishlists, vouchers, audit_logs, disputes, dispute_messages 
-		CASCADE;`).Error
+	// Kosongkan semua tabel skema aktif kecuali schema_migrations agar versi skema tetap tercatat
+	var tables []string
+	db.Raw(`SELECT quote_ident(tablename) FROM pg_tables WHERE schemaname = current_schema() AND tablename <> ?`, migrate.TableName).Scan(&tables)
+	if len(tables) == 0 {
+		fmt.Println("Tidak ada tabel untuk dibersihkan.")
+		return
+	}
+	err = db.Exec("TRUNCATE TABLE " + strings.Join(tables, ", ") + " CASCADE").Error
 	
 	if err != nil {
 		fmt.Printf("Gagal membersihkan database: %v\n", err)
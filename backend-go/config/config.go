@@ -0,0 +1,511 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+
+	// devJWTSecret hanya dipakai di development tanpa JWT_SECRET; ditolak saat production
+	devJWTSecret = "CHANGE-ME-SET-JWT_SECRET-IN-ENV"
+	// minJWTSecretLength mengikuti rekomendasi .env.example (openssl rand -base64 32)
+	minJWTSecretLength = 32
+)
+
+// Config adalah seluruh pengaturan aplikasi. Urutan prioritas (tertinggi lebih dulu):
+// environment variable, file .env, file YAML (CONFIG_FILE atau ./config.yaml), lalu nilai bawaan.
+type Config struct {
+	Env       string          `yaml:"env"` // APP_ENV: development | production
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Redis     RedisConfig     `yaml:"redis"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	Payment   PaymentConfig   `yaml:"payment"`
+	Storage   StorageConfig   `yaml:"storage"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Worker    WorkerConfig    `yaml:"worker"`
+	Log       LogConfig       `yaml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+}
+
+type ServerConfig struct {
+	Port            int             `yaml:"port"`             // APP_PORT
+	ShutdownTimeout time.Duration   `yaml:"shutdown_timeout"` // SHUTDOWN_TIMEOUT
+	RequestTimeouts RequestTimeouts `yaml:"request_timeouts"`
+	// TrustedProxies adalah IP/CIDR reverse proxy yang boleh mengisi X-Forwarded-For (TRUSTED_PROXIES).
+	// Kosong = tidak ada proxy dipercaya, IP klien diambil dari koneksi langsung.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// MetricsAddr adalah alamat listener internal GET /metrics (METRICS_ADDR), terpisah dari port API publik.
+	// Bawaan hanya localhost; kosong = endpoint metrik dimatikan.
+	MetricsAddr string `yaml:"metrics_addr"`
+}
+
+// RequestTimeouts adalah batas waktu request per route group. Nilai 0 berarti tanpa batas.
+type RequestTimeouts struct {
+	Default  time.Duration `yaml:"default"`  // REQUEST_TIMEOUT: semua route (publik, pembeli, kurir)
+	Admin    time.Duration `yaml:"admin"`    // REQUEST_TIMEOUT_ADMIN: termasuk ekspor audit log
+	Supplier time.Duration `yaml:"supplier"` // REQUEST_TIMEOUT_SUPPLIER: termasuk impor/ekspor produk massal
+	Webhook  time.Duration `yaml:"webhook"`  // REQUEST_TIMEOUT_WEBHOOK: webhook payment gateway
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`     // DB_HOST
+	Port     string `yaml:"port"`     // DB_PORT
+	User     string `yaml:"user"`     // DB_USER
+	Password string `yaml:"password"` // DB_PASSWORD
+	Name     string `yaml:"name"`     // DB_NAME
+	SSLMode  string `yaml:"sslmode"`  // DB_SSLMODE
+	TimeZone string `yaml:"timezone"` // DB_TIMEZONE
+
+	// Pool koneksi (berlaku juga untuk setiap replika). Nilai 0 berarti tanpa batas,
+	// kecuali MaxIdleConns: 0 berarti koneksi idle langsung ditutup.
+	MaxOpenConns    int           `yaml:"max_open_conns"`     // DB_MAX_OPEN_CONNS
+	MaxIdleConns    int           `yaml:"max_idle_conns"`     // DB_MAX_IDLE_CONNS
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`  // DB_CONN_MAX_LIFETIME
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"` // DB_CONN_MAX_IDLE_TIME
+
+	// ReplicaHosts adalah read replica (host atau host:port) untuk query katalog dan listing.
+	// Kosong berarti semua query ke primary. Kredensial dan nama database mengikuti primary.
+	ReplicaHosts []string `yaml:"replica_hosts"` // DB_REPLICA_HOSTS: dipisah koma
+}
+
+// DSN merangkai string koneksi PostgreSQL
+func (d DatabaseConfig) DSN() string {
+	return d.dsn(d.Host, d.Port)
+}
+
+// ReplicaDSNs merangkai string koneksi setiap read replica; port bawaan mengikuti DB_PORT
+func (d DatabaseConfig) ReplicaDSNs() []string {
+	dsns := make([]string, 0, len(d.ReplicaHosts))
+	for _, hostPort := range d.ReplicaHosts {
+		host, port, err := net.SplitHostPort(hostPort)
+		if err != nil {
+			host, port = hostPort, d.Port
+		}
+		dsns = append(dsns, d.dsn(host, port))
+	}
+	return dsns
+}
+
+func (d DatabaseConfig) dsn(host string, port string) string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
+		host, d.User, d.Password, d.Name, port, d.SSLMode, d.TimeZone)
+}
+
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`     // REDIS_ADDR
+	Password string `yaml:"password"` // REDIS_PASSWORD
+	DB       int    `yaml:"db"`       // REDIS_DB
+}
+
+type JWTConfig struct {
+	Secret string        `yaml:"secret"` // JWT_SECRET
+	Expiry time.Duration `yaml:"expiry"` // JWT_EXPIRY
+}
+
+type PaymentConfig struct {
+	MidtransServerKey string        `yaml:"midtrans_server_key"` // MIDTRANS_SERVER_KEY
+	FrontendURL       string        `yaml:"frontend_url"`        // APP_FRONTEND_URL: redirect setelah pembayaran
+	Expiry            time.Duration `yaml:"expiry"`              // PAYMENT_EXPIRY_MINUTES: batas bayar & masa reservasi stok
+}
+
+type StorageConfig struct {
+	Driver     string   `yaml:"driver"`      // STORAGE_DRIVER: local | s3
+	LocalDir   string   `yaml:"local_dir"`   // STORAGE_LOCAL_DIR
+	SigningKey string   `yaml:"signing_key"` // STORAGE_SIGNING_KEY, wajib & terpisah dari JWT secret di production
+	S3         S3Config `yaml:"s3"`
+}
+
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`   // S3_ENDPOINT
+	Region    string `yaml:"region"`     // S3_REGION
+	Bucket    string `yaml:"bucket"`     // S3_BUCKET
+	AccessKey string `yaml:"access_key"` // S3_ACCESS_KEY
+	SecretKey string `yaml:"secret_key"` // S3_SECRET_KEY
+	PathStyle bool   `yaml:"path_style"` // S3_PATH_STYLE: MinIO membutuhkan path-style
+	PublicURL string `yaml:"public_url"` // S3_PUBLIC_URL
+}
+
+// RateLimitConfig mengatur rate limiter endpoint login per IP
+type RateLimitConfig struct {
+	LoginInterval time.Duration `yaml:"login_interval"` // LOGIN_RATE_LIMIT_INTERVAL: satu token baru tiap interval
+	LoginBurst    int           `yaml:"login_burst"`    // LOGIN_RATE_LIMIT_BURST
+}
+
+type WorkerConfig struct {
+	ReservationMaxWait time.Duration `yaml:"reservation_max_wait"` // RESERVATION_MAX_WAIT: batas atas tidur scheduler reservasi
+}
+
+// LogConfig mengatur logger slog aplikasi
+type LogConfig struct {
+	Level  string `yaml:"level"`  // LOG_LEVEL: debug | info | warn | error
+	Format string `yaml:"format"` // LOG_FORMAT: json | text, kosong = json di production, text di development
+}
+
+const (
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingOTLP   = "otlp"
+)
+
+// TracingConfig mengatur ekspor span OpenTelemetry
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter"`      // TRACING_EXPORTER: none | stdout | otlp
+	OTLPEndpoint string  `yaml:"otlp_endpoint"` // OTEL_EXPORTER_OTLP_ENDPOINT: URL collector OTLP/HTTP, misalnya http://localhost:4318
+	ServiceName  string  `yaml:"service_name"`  // OTEL_SERVICE_NAME
+	SampleRatio  float64 `yaml:"sample_ratio"`  // TRACING_SAMPLE_RATIO: 0-1, porsi trace baru yang direkam
+}
+
+// Defaults mengembalikan nilai bawaan yang cocok untuk development lokal
+func Defaults() Config {
+	return Config{
+		Env: EnvDevelopment,
+		Server: ServerConfig{
+			Port:            8080,
+			ShutdownTimeout: 5 * time.Second,
+			MetricsAddr:     "127.0.0.1:9091",
+			RequestTimeouts: RequestTimeouts{
+				Default:  15 * time.Second,
+				Admin:    60 * time.Second,
+				Supplier: 60 * time.Second,
+				Webhook:  10 * time.Second,
+			},
+		},
+		Database: DatabaseConfig{
+			Host:     "localhost",
+			Port:     "5432",
+			User:     "postgres",
+			Name:     "ecommerce_sqa",
+			SSLMode:  "disable",
+			TimeZone: "Asia/Jakarta",
+
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Redis:   RedisConfig{Addr: "localhost:6379"},
+		JWT:     JWTConfig{Expiry: 24 * time.Hour},
+		Payment: PaymentConfig{FrontendURL: "http://localhost:5173", Expiry: 60 * time.Minute},
+		Storage: StorageConfig{
+			Driver:   "local",
+			LocalDir: "uploads",
+			S3:       S3Config{Region: "us-east-1", PathStyle: true},
+		},
+		RateLimit: RateLimitConfig{LoginInterval: 12 * time.Second, LoginBurst: 5}, // ~5 request/menit
+		Worker:    WorkerConfig{ReservationMaxWait: time.Minute},
+		Log:       LogConfig{Level: "info"},
+		Tracing: TracingConfig{
+			Exporter:     TracingNone,
+			OTLPEndpoint: "http://localhost:4318",
+			ServiceName:  "ecommerce-api",
+			SampleRatio:  1,
+		},
+	}
+}
+
+// Load membaca konfigurasi dari nilai bawaan, file YAML, .env, dan environment lalu memvalidasinya
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil {
+		slog.Debug("file .env tidak ditemukan, memakai environment sistem")
+	}
+
+	cfg := Defaults()
+	if err := cfg.loadYAML(os.Getenv("CONFIG_FILE")); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// MustLoad menghentikan proses saat konfigurasi tidak valid agar server tidak berjalan setengah terkonfigurasi
+func MustLoad() *Config {
+	cfg, err := Load()
+	if err != nil {
+		slog.Error("konfigurasi tidak valid", "error", err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// loadYAML membaca path atau ./config.yaml jika ada. File eksplisit yang tidak ada dianggap error.
+func (c *Config) loadYAML(path string) error {
+	explicit := path != ""
+	if !explicit {
+		path = "config.yaml"
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("gagal membaca file konfigurasi %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("file konfigurasi %s tidak valid: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv menimpa konfigurasi dengan environment variable yang terisi (nilai kosong diabaikan)
+func (c *Config) loadEnv() error {
+	e := &envReader{}
+	e.str("APP_ENV", &c.Env)
+	e.int("APP_PORT", &c.Server.Port)
+	e.duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	e.duration("REQUEST_TIMEOUT", &c.Server.RequestTimeouts.Default)
+	e.duration("REQUEST_TIMEOUT_ADMIN", &c.Server.RequestTimeouts.Admin)
+	e.duration("REQUEST_TIMEOUT_SUPPLIER", &c.Server.RequestTimeouts.Supplier)
+	e.duration("REQUEST_TIMEOUT_WEBHOOK", &c.Server.RequestTimeouts.Webhook)
+	e.list("TRUSTED_PROXIES", &c.Server.TrustedProxies)
+	e.str("METRICS_ADDR", &c.Server.MetricsAddr)
+
+	e.str("DB_HOST", &c.Database.Host)
+	e.str("DB_PORT", &c.Database.Port)
+	e.str("DB_USER", &c.Database.User)
+	e.str("DB_PASSWORD", &c.Database.Password)
+	e.str("DB_NAME", &c.Database.Name)
+	e.str("DB_SSLMODE", &c.Database.SSLMode)
+	e.str("DB_TIMEZONE", &c.Database.TimeZone)
+	e.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	e.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	e.duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	e.duration("DB_CONN_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime)
+	e.list("DB_REPLICA_HOSTS", &c.Database.ReplicaHosts)
+
+	e.str("REDIS_ADDR", &c.Redis.Addr)
+	e.str("REDIS_PASSWORD", &c.Redis.Password)
+	e.int("REDIS_DB", &c.Redis.DB)
+
+	e.str("JWT_SECRET", &c.JWT.Secret)
+	e.duration("JWT_EXPIRY", &c.JWT.Expiry)
+
+	e.str("MIDTRANS_SERVER_KEY", &c.Payment.MidtransServerKey)
+	e.str("APP_FRONTEND_URL", &c.Payment.FrontendURL)
+	e.minutes("PAYMENT_EXPIRY_MINUTES", &c.Payment.Expiry)
+
+	e.str("STORAGE_DRIVER", &c.Storage.Driver)
+	e.str("STORAGE_LOCAL_DIR", &c.Storage.LocalDir)
+	e.str("STORAGE_SIGNING_KEY", &c.Storage.SigningKey)
+	e.str("S3_ENDPOINT", &c.Storage.S3.Endpoint)
+	e.str("S3_REGION", &c.Storage.S3.Region)
+	e.str("S3_BUCKET", &c.Storage.S3.Bucket)
+	e.str("S3_ACCESS_KEY", &c.Storage.S3.AccessKey)
+	e.str("S3_SECRET_KEY", &c.Storage.S3.SecretKey)
+	e.bool("S3_PATH_STYLE", &c.Storage.S3.PathStyle)
+	e.str("S3_PUBLIC_URL", &c.Storage.S3.PublicURL)
+
+	e.duration("LOGIN_RATE_LIMIT_INTERVAL", &c.RateLimit.LoginInterval)
+	e.int("LOGIN_RATE_LIMIT_BURST", &c.RateLimit.LoginBurst)
+	e.duration("RESERVATION_MAX_WAIT", &c.Worker.ReservationMaxWait)
+	e.str("LOG_LEVEL", &c.Log.Level)
+	e.str("LOG_FORMAT", &c.Log.Format)
+	e.str("TRACING_EXPORTER", &c.Tracing.Exporter)
+	e.str("OTEL_EXPORTER_OTLP_ENDPOINT", &c.Tracing.OTLPEndpoint)
+	e.str("OTEL_SERVICE_NAME", &c.Tracing.ServiceName)
+	e.float("TRACING_SAMPLE_RATIO", &c.Tracing.SampleRatio)
+	return errors.Join(e.errs...)
+}
+
+// Validate memeriksa konfigurasi saat startup. Di production, secret wajib diisi.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	c.Env = strings.ToLower(c.Env)
+	production := c.Env == EnvProduction
+	check(c.Env == EnvDevelopment || production, "APP_ENV harus %s atau %s, didapat %q", EnvDevelopment, EnvProduction, c.Env)
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "APP_PORT harus di antara 1-65535, didapat %d", c.Server.Port)
+	check(c.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT harus lebih dari 0")
+	t := c.Server.RequestTimeouts
+	check(t.Default >= 0 && t.Admin >= 0 && t.Supplier >= 0 && t.Webhook >= 0, "REQUEST_TIMEOUT* tidak boleh negatif")
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil, "TRUSTED_PROXIES harus berisi IP atau CIDR, didapat %q", proxy)
+	}
+	if c.Server.MetricsAddr != "" {
+		_, _, addrErr := net.SplitHostPort(c.Server.MetricsAddr)
+		check(addrErr == nil, "METRICS_ADDR harus berformat host:port, didapat %q", c.Server.MetricsAddr)
+	}
+
+	check(c.Database.Host != "" && c.Database.Port != "" && c.Database.User != "" && c.Database.Name != "",
+		"DB_HOST, DB_PORT, DB_USER, dan DB_NAME wajib diisi")
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("DB_SSLMODE tidak dikenal: %q", c.Database.SSLMode))
+	}
+	if _, err := time.LoadLocation(c.Database.TimeZone); err != nil {
+		errs = append(errs, fmt.Errorf("DB_TIMEZONE tidak valid: %q", c.Database.TimeZone))
+	}
+	db := c.Database
+	check(db.MaxOpenConns >= 0 && db.MaxIdleConns >= 0, "DB_MAX_OPEN_CONNS dan DB_MAX_IDLE_CONNS tidak boleh negatif")
+	check(db.MaxOpenConns == 0 || db.MaxIdleConns <= db.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS (%d) tidak boleh melebihi DB_MAX_OPEN_CONNS (%d)", db.MaxIdleConns, db.MaxOpenConns)
+	check(db.ConnMaxLifetime >= 0 && db.ConnMaxIdleTime >= 0, "DB_CONN_MAX_LIFETIME dan DB_CONN_MAX_IDLE_TIME tidak boleh negatif")
+	for _, host := range db.ReplicaHosts {
+		check(strings.TrimSpace(host) != "", "DB_REPLICA_HOSTS berisi host kosong")
+	}
+
+	if c.JWT.Secret == "" && !production {
+		slog.Warn("JWT_SECRET tidak diset, memakai secret development yang TIDAK aman")
+		c.JWT.Secret = devJWTSecret
+	}
+	check(!production || (c.JWT.Secret != "" && c.JWT.Secret != devJWTSecret), "JWT_SECRET wajib diisi di production")
+	check(!production || len(c.JWT.Secret) >= minJWTSecretLength, "JWT_SECRET minimal %d karakter di production", minJWTSecretLength)
+	check(c.JWT.Expiry > 0, "JWT_EXPIRY harus lebih dari 0")
+
+	check(!production || c.Payment.MidtransServerKey != "", "MIDTRANS_SERVER_KEY wajib diisi di production (verifikasi signature webhook)")
+	check(c.Payment.Expiry > 0, "PAYMENT_EXPIRY_MINUTES harus lebih dari 0")
+
+	// Kunci URL bertanda tangan dipisah dari JWT secret agar rotasi salah satunya tidak memengaruhi yang lain
+	if c.Storage.SigningKey == "" && !production {
+		c.Storage.SigningKey = c.JWT.Secret
+	}
+	check(!production || c.Storage.SigningKey != "", "STORAGE_SIGNING_KEY wajib diisi di production")
+	check(!production || c.Storage.SigningKey == "" || c.Storage.SigningKey != c.JWT.Secret, "STORAGE_SIGNING_KEY harus berbeda dari JWT_SECRET di production")
+	c.Storage.Driver = strings.ToLower(c.Storage.Driver)
+	switch c.Storage.Driver {
+	case "local":
+		check(c.Storage.LocalDir != "", "STORAGE_LOCAL_DIR wajib diisi")
+	case "s3":
+		check(c.Storage.S3.Bucket != "", "S3_BUCKET wajib diisi untuk STORAGE_DRIVER=s3")
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_DRIVER tidak dikenal: %q (gunakan local atau s3)", c.Storage.Driver))
+	}
+
+	check(c.RateLimit.LoginInterval > 0 && c.RateLimit.LoginBurst > 0, "LOGIN_RATE_LIMIT_INTERVAL dan LOGIN_RATE_LIMIT_BURST harus lebih dari 0")
+	check(c.Worker.ReservationMaxWait > 0, "RESERVATION_MAX_WAIT harus lebih dari 0")
+
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %w", err))
+	}
+	c.Log.Format = strings.ToLower(c.Log.Format)
+	if c.Log.Format == "" {
+		c.Log.Format = logger.FormatText
+		if production {
+			c.Log.Format = logger.FormatJSON
+		}
+	}
+	check(c.Log.Format == logger.FormatJSON || c.Log.Format == logger.FormatText,
+		"LOG_FORMAT tidak dikenal: %q (gunakan json atau text)", c.Log.Format)
+
+	c.Tracing.Exporter = strings.ToLower(c.Tracing.Exporter)
+	switch c.Tracing.Exporter {
+	case TracingNone, TracingStdout:
+	case TracingOTLP:
+		if u, err := url.Parse(c.Tracing.OTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT harus berupa URL http(s), didapat %q", c.Tracing.OTLPEndpoint))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER tidak dikenal: %q (gunakan none, stdout, atau otlp)", c.Tracing.Exporter))
+	}
+	check(c.Tracing.ServiceName != "", "OTEL_SERVICE_NAME wajib diisi")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO harus di antara 0 dan 1, didapat %v", c.Tracing.SampleRatio)
+	return errors.Join(errs...)
+}
+
+// envReader mengumpulkan semua kesalahan parsing agar dilaporkan sekaligus
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) lookup(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
+}
+
+func (e *envReader) str(key string, dst *string) {
+	if v, ok := e.lookup(key); ok {
+		*dst = v
+	}
+}
+
+func (e *envReader) int(key string, dst *int) {
+	if v, ok := e.lookup(key); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s harus berupa angka, didapat %q", key, v))
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envReader) bool(key string, dst *bool) {
+	if v, ok := e.lookup(key); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s harus true/false, didapat %q", key, v))
+			return
+		}
+		*dst = b
+	}
+}
+
+func (e *envReader) float(key string, dst *float64) {
+	if v, ok := e.lookup(key); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s harus berupa angka desimal, didapat %q", key, v))
+			return
+		}
+		*dst = f
+	}
+}
+
+// list membaca nilai yang dipisah koma, misalnya "replica-1:5432,replica-2"
+func (e *envReader) list(key string, dst *[]string) {
+	if v, ok := e.lookup(key); ok {
+		items := strings.Split(v, ",")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+		*dst = items
+	}
+}
+
+// duration memakai format time.ParseDuration, misalnya "30s" atau "24h"
+func (e *envReader) duration(key string, dst *time.Duration) {
+	if v, ok := e.lookup(key); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s harus berupa durasi (contoh 30s), didapat %q", key, v))
+			return
+		}
+		*dst = d
+	}
+}
+
+func (e *envReader) minutes(key string, dst *time.Duration) {
+	if _, ok := e.lookup(key); !ok {
+		return
+	}
+	n := -1
+	e.int(key, &n)
+	*dst = time.Duration(n) * time.Minute
+}
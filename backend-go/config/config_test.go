@@ -0,0 +1,231 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv memastikan environment mesin pengembang tidak memengaruhi test
+func clearEnv(t *testing.T) {
+	for _, key := range []string{"APP_ENV", "APP_PORT", "JWT_SECRET", "MIDTRANS_SERVER_KEY", "DB_HOST", "DB_SSLMODE",
+		"PAYMENT_EXPIRY_MINUTES", "REQUEST_TIMEOUT_ADMIN", "TRUSTED_PROXIES", "METRICS_ADDR", "CONFIG_FILE", "STORAGE_SIGNING_KEY", "STORAGE_DRIVER",
+		"DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_REPLICA_HOSTS", "LOG_LEVEL", "LOG_FORMAT",
+		"TRACING_EXPORTER", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_SERVICE_NAME", "TRACING_SAMPLE_RATIO"} {
+		t.Setenv(key, "")
+	}
+	t.Chdir(t.TempDir()) // Tanpa .env dan config.yaml dari direktori kerja
+}
+
+func TestLoad_PrecedenceEnvOverYAMLOverDefaults(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	yamlBody := "server:\n  port: 9090\n  request_timeouts:\n    admin: 2m\ndatabase:\n  host: db-yaml\n  sslmode: require\n"
+	if err := os.WriteFile(path, []byte(yamlBody), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("DB_HOST", "db-env")
+	t.Setenv("PAYMENT_EXPIRY_MINUTES", "15")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Konfigurasi valid seharusnya berhasil dimuat: %v", err)
+	}
+	if cfg.Server.Port != 9090 || cfg.Server.RequestTimeouts.Admin != 2*time.Minute || cfg.Database.SSLMode != "require" {
+		t.Errorf("Nilai YAML harus menimpa bawaan, didapat %+v", cfg.Server)
+	}
+	if cfg.Database.Host != "db-env" || cfg.Payment.Expiry != 15*time.Minute {
+		t.Errorf("Environment harus menimpa YAML, didapat host=%s expiry=%v", cfg.Database.Host, cfg.Payment.Expiry)
+	}
+	if cfg.Database.TimeZone != "Asia/Jakarta" || cfg.JWT.Expiry != 24*time.Hour {
+		t.Errorf("Nilai bawaan harus dipakai jika tidak diisi, didapat %+v", cfg.Database)
+	}
+	if cfg.JWT.Secret == "" || cfg.Storage.SigningKey != cfg.JWT.Secret {
+		t.Error("Development tanpa JWT_SECRET memakai secret dev dan signing key mengikutinya")
+	}
+}
+
+func TestLoad_ProductionRequiresSecrets(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("APP_ENV", "production")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Production tanpa JWT_SECRET seharusnya gagal")
+	}
+	for _, key := range []string{"JWT_SECRET", "MIDTRANS_SERVER_KEY", "STORAGE_SIGNING_KEY"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Pesan error harus menyebut %s, didapat: %v", key, err)
+		}
+	}
+
+	t.Setenv("JWT_SECRET", "terlalu-pendek")
+	t.Setenv("MIDTRANS_SERVER_KEY", "SB-Mid-server-xxx")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "minimal") {
+		t.Errorf("JWT_SECRET pendek seharusnya ditolak di production, didapat %v", err)
+	}
+
+	t.Setenv("JWT_SECRET", strings.Repeat("s", minJWTSecretLength))
+	t.Setenv("STORAGE_SIGNING_KEY", strings.Repeat("s", minJWTSecretLength))
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "berbeda") {
+		t.Errorf("STORAGE_SIGNING_KEY yang sama dengan JWT_SECRET seharusnya ditolak, didapat %v", err)
+	}
+
+	t.Setenv("STORAGE_SIGNING_KEY", strings.Repeat("k", minJWTSecretLength))
+	if _, err := Load(); err != nil {
+		t.Errorf("Production dengan secret lengkap seharusnya valid: %v", err)
+	}
+}
+
+func TestLoad_ReportsAllInvalidValues(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("APP_PORT", "delapan")
+	t.Setenv("REQUEST_TIMEOUT_ADMIN", "sebentar")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "APP_PORT") || !strings.Contains(err.Error(), "REQUEST_TIMEOUT_ADMIN") {
+		t.Errorf("Semua nilai env yang tidak valid harus dilaporkan sekaligus, didapat %v", err)
+	}
+
+	clearEnv(t)
+	t.Setenv("DB_SSLMODE", "kadang")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DB_SSLMODE") {
+		t.Errorf("DB_SSLMODE tidak dikenal seharusnya ditolak, didapat %v", err)
+	}
+
+	clearEnv(t)
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "tidak-ada.yaml"))
+	if _, err := Load(); err == nil {
+		t.Error("CONFIG_FILE yang tidak ada seharusnya gagal")
+	}
+}
+
+func TestLoad_DatabasePoolAndReplicas(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_MAX_OPEN_CONNS", "40")
+	t.Setenv("DB_REPLICA_HOSTS", "replica-1, replica-2:6432")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Konfigurasi valid seharusnya berhasil dimuat: %v", err)
+	}
+	if cfg.Database.MaxOpenConns != 40 || cfg.Database.MaxIdleConns != 10 || cfg.Database.ConnMaxLifetime != 30*time.Minute {
+		t.Errorf("Pool harus memakai env lalu nilai bawaan, didapat %+v", cfg.Database)
+	}
+	dsns := cfg.Database.ReplicaDSNs()
+	if len(dsns) != 2 || !strings.Contains(dsns[0], "host=replica-1 ") || !strings.Contains(dsns[0], "port=5432") ||
+		!strings.Contains(dsns[1], "host=replica-2 ") || !strings.Contains(dsns[1], "port=6432") {
+		t.Errorf("DSN replika harus mengikuti host:port dan port bawaan primary, didapat %v", dsns)
+	}
+
+	t.Setenv("DB_MAX_IDLE_CONNS", "50")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DB_MAX_IDLE_CONNS") {
+		t.Errorf("Idle melebihi max open seharusnya ditolak, didapat %v", err)
+	}
+
+	t.Setenv("DB_MAX_IDLE_CONNS", "")
+	t.Setenv("DB_REPLICA_HOSTS", "replica-1,,replica-2")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DB_REPLICA_HOSTS") {
+		t.Errorf("Host replika kosong seharusnya ditolak, didapat %v", err)
+	}
+}
+
+func TestLoad_TrustedProxies(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Konfigurasi valid seharusnya berhasil dimuat: %v", err)
+	}
+	if len(cfg.Server.TrustedProxies) != 0 {
+		t.Errorf("Bawaan tidak boleh mempercayai proxy mana pun, didapat %v", cfg.Server.TrustedProxies)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.10")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Konfigurasi valid seharusnya berhasil dimuat: %v", err)
+	}
+	if len(cfg.Server.TrustedProxies) != 2 || cfg.Server.TrustedProxies[1] != "192.168.1.10" {
+		t.Errorf("TRUSTED_PROXIES harus dipisah koma, didapat %v", cfg.Server.TrustedProxies)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "load-balancer")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "TRUSTED_PROXIES") {
+		t.Errorf("Proxy yang bukan IP/CIDR seharusnya ditolak, didapat %v", err)
+	}
+}
+
+func TestLoad_MetricsAddr(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Konfigurasi valid seharusnya berhasil dimuat: %v", err)
+	}
+	if cfg.Server.MetricsAddr != "127.0.0.1:9091" {
+		t.Errorf("Metrik bawaan hanya boleh didengarkan di localhost, didapat %q", cfg.Server.MetricsAddr)
+	}
+
+	t.Setenv("METRICS_ADDR", "9091")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "METRICS_ADDR") {
+		t.Errorf("METRICS_ADDR tanpa host:port seharusnya ditolak, didapat %v", err)
+	}
+}
+
+func TestLoad_LogFormatFollowsEnvironment(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Konfigurasi valid seharusnya berhasil dimuat: %v", err)
+	}
+	if cfg.Log.Level != "info" || cfg.Log.Format != "text" {
+		t.Errorf("Development seharusnya memakai log teks level info, didapat %+v", cfg.Log)
+	}
+
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("JWT_SECRET", strings.Repeat("s", minJWTSecretLength))
+	t.Setenv("MIDTRANS_SERVER_KEY", "SB-Mid-server-xyz")
+	t.Setenv("STORAGE_SIGNING_KEY", strings.Repeat("k", minJWTSecretLength))
+	if cfg, err = Load(); err != nil || cfg.Log.Format != "json" {
+		t.Errorf("Production seharusnya memakai log JSON, didapat %+v (err %v)", cfg.Log, err)
+	}
+
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("LOG_FORMAT", "xml")
+	_, err = Load()
+	if err == nil || !strings.Contains(err.Error(), "LOG_LEVEL") || !strings.Contains(err.Error(), "LOG_FORMAT") {
+		t.Errorf("LOG_LEVEL dan LOG_FORMAT tidak dikenal seharusnya ditolak, didapat %v", err)
+	}
+}
+
+func TestLoad_TracingExporter(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Konfigurasi valid seharusnya berhasil dimuat: %v", err)
+	}
+	if cfg.Tracing.Exporter != TracingNone || cfg.Tracing.SampleRatio != 1 {
+		t.Errorf("Tracing bawaan seharusnya nonaktif dengan rasio 1, didapat %+v", cfg.Tracing)
+	}
+
+	t.Setenv("TRACING_EXPORTER", "OTLP")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+	if cfg, err = Load(); err != nil || cfg.Tracing.Exporter != TracingOTLP || cfg.Tracing.SampleRatio != 0.25 {
+		t.Errorf("Exporter OTLP dari env seharusnya valid, didapat %+v (err %v)", cfg.Tracing, err)
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "otel-collector:4318")
+	t.Setenv("TRACING_SAMPLE_RATIO", "2")
+	_, err = Load()
+	if err == nil || !strings.Contains(err.Error(), "OTEL_EXPORTER_OTLP_ENDPOINT") || !strings.Contains(err.Error(), "TRACING_SAMPLE_RATIO") {
+		t.Errorf("Endpoint tanpa skema dan rasio di luar 0-1 seharusnya ditolak, didapat %v", err)
+	}
+
+	t.Setenv("TRACING_EXPORTER", "jaeger")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "TRACING_EXPORTER") {
+		t.Errorf("Exporter tidak dikenal seharusnya ditolak, didapat %v", err)
+	}
+}
@@ -1,38 +1,106 @@
 package config
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"time"
 
-	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
-// InitDB bertugas membaca .env dan membuka koneksi ke PostgreSQL
-func InitDB() *gorm.DB {
-	// Membaca file .env
-	err := godotenv.Load()
+// dbPingTimeout membatasi pengecekan koneksi saat startup
+const dbPingTimeout = 5 * time.Second
+
+// dbSlowQueryThreshold adalah batas query yang dicatat sebagai peringatan "SQL executed"
+const dbSlowQueryThreshold = 200 * time.Millisecond
+
+// InitDB membuka koneksi ke PostgreSQL, menerapkan pengaturan pool, lalu memastikan database
+// bisa dijangkau. Pemanggil yang memutuskan apakah error menghentikan proses.
+func InitDB(cfg DatabaseConfig) (*gorm.DB, error) {
+	// Log GORM lewat slog.Default (lihat InitLogger): hanya error dan query lambat, tanpa nilai parameter
+	// agar hash password/token tidak ikut tercatat
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+		Logger: gormlogger.NewSlogLogger(slog.Default(), gormlogger.Config{
+			LogLevel:                  gormlogger.Warn,
+			SlowThreshold:             dbSlowQueryThreshold,
+			ParameterizedQueries:      true,
+			IgnoreRecordNotFoundError: true,
+		}),
+	})
 	if err != nil {
-		log.Println("Peringatan: File .env tidak ditemukan, menggunakan environment system")
+		return nil, fmt.Errorf("gagal terhubung ke database %s:%s: %w", cfg.Host, cfg.Port, err)
 	}
-
-	// Merangkai string koneksi (DSN)
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Jakarta",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
-
-	// Membuka koneksi menggunakan GORM
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Gagal terhubung ke database: %v", err)
+		return nil, err
+	}
+	applyPool(sqlDB, cfg)
+	if err := ping(sqlDB); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("database %s:%s tidak merespons: %w", cfg.Host, cfg.Port, err)
+	}
+
+	slog.Info("terhubung ke PostgreSQL", "host", cfg.Host, "port", cfg.Port, "database", cfg.Name)
+	return db, nil
+}
+
+// InitReplicas membuka koneksi ke setiap DB_REPLICA_HOSTS dan mendaftarkannya sebagai resolver
+// bernama resolverName. Repository memilih resolver itu secara eksplisit hanya untuk query katalog dan
+// listing publik, sehingga alur baca-setelah-tulis (checkout, update produk) tetap membaca dari primary.
+// Handle *sql.DB dikembalikan untuk health check dan statistik pool. Tanpa replika, query yang memilih
+// resolver ini tetap berjalan di primary.
+func InitReplicas(db *gorm.DB, cfg DatabaseConfig, resolverName string) ([]*sql.DB, error) {
+	dsns := cfg.ReplicaDSNs()
+	if len(dsns) == 0 {
+		return nil, nil
 	}
 
-	log.Println("Koneksi ke PostgreSQL berhasil!")
-	return db
-}
\ No newline at end of file
+	replicas := make([]*sql.DB, 0, len(dsns))
+	closeAll := func() {
+		for _, r := range replicas {
+			r.Close()
+		}
+	}
+	dialectors := make([]gorm.Dialector, 0, len(dsns))
+	for i, dsn := range dsns {
+		sqlDB, err := sql.Open("pgx", dsn)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("gagal membuka read replica %s: %w", cfg.ReplicaHosts[i], err)
+		}
+		replicas = append(replicas, sqlDB)
+		applyPool(sqlDB, cfg)
+		if err := ping(sqlDB); err != nil {
+			closeAll()
+			return nil, fmt.Errorf("read replica %s tidak merespons: %w", cfg.ReplicaHosts[i], err)
+		}
+		dialectors = append(dialectors, postgres.New(postgres.Config{Conn: sqlDB}))
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{Replicas: dialectors, Policy: dbresolver.RandomPolicy{}}, resolverName)
+	if err := db.Use(resolver); err != nil {
+		closeAll()
+		return nil, fmt.Errorf("gagal mendaftarkan read replica: %w", err)
+	}
+
+	slog.Info("read replica katalog aktif", "hosts", cfg.ReplicaHosts)
+	return replicas, nil
+}
+
+func applyPool(sqlDB *sql.DB, cfg DatabaseConfig) {
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
+func ping(sqlDB *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbPingTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
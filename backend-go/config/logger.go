@@ -0,0 +1,17 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+)
+
+// InitLogger membuat logger aplikasi ke stdout dan menjadikannya slog.Default, sehingga
+// pemanggilan package log yang tersisa (library, tool cmd/) ikut memakai format yang sama
+func InitLogger(cfg LogConfig) *slog.Logger {
+	level, _ := logger.ParseLevel(cfg.Level) // Sudah divalidasi oleh Config.Validate
+	log := logger.New(os.Stdout, level, cfg.Format)
+	slog.SetDefault(log)
+	return log
+}
@@ -2,8 +2,7 @@ package config
 
 import (
 	"context"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -11,18 +10,12 @@ import (
 
 // InitRedis membuat koneksi ke Redis server.
 // Jika Redis tidak tersedia, aplikasi tetap berjalan tanpa cache (graceful degradation).
-func InitRedis() *redis.Client {
-	addr := os.Getenv("REDIS_ADDR")
-	if addr == "" {
-		addr = "localhost:6379" // Default Redis address
-	}
-
-	password := os.Getenv("REDIS_PASSWORD")
-
+func InitRedis(cfg RedisConfig) *redis.Client {
+	addr := cfg.Addr
 	client := redis.NewClient(&redis.Options{
 		Addr:     addr,
-		Password: password,
-		DB:       0, // Default DB
+		Password: cfg.Password,
+		DB:       cfg.DB,
 	})
 
 	// Test koneksi dengan timeout
@@ -31,10 +24,10 @@ func InitRedis() *redis.Client {
 
 	_, err := client.Ping(ctx).Result()
 	if err != nil {
-		log.Printf("[REDIS] Gagal terhubung ke Redis (%s): %v. Aplikasi berjalan TANPA cache.", addr, err)
+		slog.Warn("gagal terhubung ke Redis, aplikasi berjalan tanpa cache", "addr", addr, "error", err)
 		return nil // Return nil agar aplikasi tetap jalan tanpa cache
 	}
 
-	log.Printf("[REDIS] Terhubung ke Redis di %s", addr)
+	slog.Info("terhubung ke Redis", "addr", addr)
 	return client
 }
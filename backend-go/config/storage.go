@@ -0,0 +1,36 @@
+package config
+
+import (
+	"log/slog"
+
+	"github.com/nuryanfa/e-commerse-sqa/pkg/storage"
+)
+
+// InitStorage memilih backend penyimpanan file dari STORAGE_DRIVER (divalidasi saat Load):
+//   - local (default): disk di STORAGE_LOCAL_DIR, disajikan lewat /uploads
+//   - s3: bucket S3-compatible (AWS S3, MinIO) sehingga aman untuk banyak replika
+func InitStorage(cfg StorageConfig) (storage.BlobStore, error) {
+	switch cfg.Driver {
+	case "s3":
+		store, err := storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+			PublicURL: cfg.S3.PublicURL,
+		})
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("penyimpanan file memakai S3", "bucket", cfg.S3.Bucket, "endpoint", cfg.S3.Endpoint)
+		return store, nil
+	default:
+		if cfg.SigningKey == "" {
+			slog.Warn("STORAGE_SIGNING_KEY/JWT_SECRET kosong, URL file privat tidak dapat dibuat")
+		}
+		slog.Info("penyimpanan file memakai disk lokal", "dir", cfg.LocalDir)
+		return storage.NewLocal(cfg.LocalDir, "/uploads", []byte(cfg.SigningKey)), nil
+	}
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+)
+
+type AuditLogHandler struct {
+	auditLogUsecase domain.AuditLogUsecase
+	logger          *slog.Logger
+}
+
+// NewAuditLogHandler registers admin audit log routes (/admin/audit-logs)
+func NewAuditLogHandler(adminRouter *gin.RouterGroup, uc domain.AuditLogUsecase, logger *slog.Logger) {
+	handler := &AuditLogHandler{
+		auditLogUsecase: uc,
+		logger:          logger,
+	}
+
+	group := adminRouter.Group("/admin/audit-logs")
+	{
+		group.GET("", handler.List)
+		group.GET("/export", handler.Export)
+		group.GET("/verify", handler.Verify)
+	}
+}
+
+// parseAuditLogFilter membaca ?id_user=&request_id=&action=&entity=&entity_id=&from=&to= (from/to RFC3339)
+func parseAuditLogFilter(c *gin.Context) (domain.AuditLogFilter, error) {
+	filter := domain.AuditLogFilter{
+		UserID:    c.Query("id_user"),
+		RequestID: c.Query("request_id"),
+		Action:    strings.ToUpper(c.Query("action")),
+		Entity:    c.Query("entity"),
+		EntityID:  c.Query("entity_id"),
+	}
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, errors.New("Format 'from' tidak valid, gunakan RFC3339")
+		}
+		filter.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, errors.New("Format 'to' tidak valid, gunakan RFC3339")
+		}
+		filter.To = &t
+	}
+	return filter, nil
+}
+
+// List — GET /admin/audit-logs?limit=&cursor= beserta filter, terbaru lebih dulu
+func (h *AuditLogHandler) List(c *gin.Context) {
+	filter, err := parseAuditLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := pagination.FromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logs, nextCursor, err := h.auditLogUsecase.List(c.Request.Context(), filter, page)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pagination.Respond(c, http.StatusOK, logs, nextCursor, page, nil)
+}
+
+// Export — GET /admin/audit-logs/export?format=csv|ndjson, filter sama dengan List.
+// Hasil di-stream urut seq sehingga bisa diverifikasi ulang di luar sistem.
+func (h *AuditLogHandler) Export(c *gin.Context) {
+	filter, err := parseAuditLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", domain.AuditExportCSV))
+	var contentType string
+	switch format {
+	case domain.AuditExportCSV:
+		contentType = "text/csv; charset=utf-8"
+	case domain.AuditExportNDJSON:
+		contentType = "application/x-ndjson"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format ekspor tidak didukung, gunakan csv atau ndjson"})
+		return
+	}
+
+	fileName := fmt.Sprintf("audit_logs_%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Status(http.StatusOK)
+
+	if err := h.auditLogUsecase.Export(c.Request.Context(), filter, format, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			c.Writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Header sudah terkirim; klien akan menerima file terpotong
+		h.logger.ErrorContext(c.Request.Context(), "ekspor audit log terhenti", "format", format, "error", err)
+	}
+}
+
+// Verify — GET /admin/audit-logs/verify, menghitung ulang rantai hash seluruh audit log
+func (h *AuditLogHandler) Verify(c *gin.Context) {
+	report, err := h.auditLogUsecase.VerifyChain(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
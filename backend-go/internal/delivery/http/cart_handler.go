@@ -40,7 +40,7 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 		return
 	}
 
-	if err := h.cartUsecase.AddToCart(uid, &req); err != nil {
+	if err := h.cartUsecase.AddToCart(c.Request.Context(), uid, &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -56,7 +56,7 @@ func (h *CartHandler) ViewCart(c *gin.Context) {
 	}
 	uid := userID.(string)
 
-	items, err := h.cartUsecase.ViewCart(uid)
+	items, err := h.cartUsecase.ViewCart(c.Request.Context(), uid)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal merender keranjang", "detail": err.Error()})
 		return
@@ -83,7 +83,7 @@ func (h *CartHandler) UpdateQuantity(c *gin.Context) {
 		return
 	}
 
-	if err := h.cartUsecase.UpdateQuantity(uid, itemID, req.Quantity); err != nil {
+	if err := h.cartUsecase.UpdateQuantity(c.Request.Context(), uid, itemID, req.Quantity); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -100,7 +100,7 @@ func (h *CartHandler) RemoveFromCart(c *gin.Context) {
 	uid := userID.(string)
 	itemID := c.Param("id")
 
-	if err := h.cartUsecase.RemoveFromCart(uid, itemID); err != nil {
+	if err := h.cartUsecase.RemoveFromCart(c.Request.Context(), uid, itemID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
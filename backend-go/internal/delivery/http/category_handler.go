@@ -23,6 +23,8 @@ func NewCategoryHandler(publicRouter *gin.Engine, adminRouter *gin.RouterGroup,
 	publicGroup := publicRouter.Group("/api/v1/categories")
 	{
 		publicGroup.GET("", handler.FindAll)
+		publicGroup.GET("/tree", handler.Tree)
+		publicGroup.GET("/slug/:slug", handler.FindBySlug)
 		publicGroup.GET("/:id", handler.FindByID)
 	}
 
@@ -42,8 +44,8 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 		return
 	}
 
-	if err := h.categoryUsecase.Create(&req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.categoryUsecase.Create(c.Request.Context(), c.GetString("user_id"), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -51,7 +53,7 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 }
 
 func (h *CategoryHandler) FindAll(c *gin.Context) {
-	categories, err := h.categoryUsecase.FindAll()
+	categories, err := h.categoryUsecase.FindAll(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -59,9 +61,28 @@ func (h *CategoryHandler) FindAll(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": categories})
 }
 
+// Tree — GET /api/v1/categories/tree, seluruh hierarki dalam satu respons
+func (h *CategoryHandler) Tree(c *gin.Context) {
+	tree, err := h.categoryUsecase.Tree(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tree})
+}
+
+func (h *CategoryHandler) FindBySlug(c *gin.Context) {
+	category, err := h.categoryUsecase.FindBySlug(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": category})
+}
+
 func (h *CategoryHandler) FindByID(c *gin.Context) {
 	id := c.Param("id")
-	category, err := h.categoryUsecase.FindByID(id)
+	category, err := h.categoryUsecase.FindByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -71,14 +92,20 @@ func (h *CategoryHandler) FindByID(c *gin.Context) {
 
 func (h *CategoryHandler) Update(c *gin.Context) {
 	id := c.Param("id")
-	var req domain.Category
-	if err := c.ShouldBindJSON(&req); err != nil {
+	// sort_order dibaca sebagai pointer agar nilai 0 bisa dibedakan dari field yang tidak dikirim
+	var body struct {
+		domain.Category
+		SortOrder *int `json:"sort_order"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req := body.Category
+	req.SortOrderInput = body.SortOrder
 
-	if err := h.categoryUsecase.Update(id, &req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.categoryUsecase.Update(c.Request.Context(), c.GetString("user_id"), id, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -87,8 +114,8 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 
 func (h *CategoryHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.categoryUsecase.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.categoryUsecase.Delete(c.Request.Context(), c.GetString("user_id"), id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Kategori berhasil dihapus"})
@@ -32,7 +32,7 @@ func NewCourierHandler(courierRouter *gin.RouterGroup, ouc domain.OrderUsecase,
 
 // AvailableOrders — daftar pesanan PAID siap diambil kurir
 func (h *CourierHandler) AvailableOrders(c *gin.Context) {
-	orders, err := h.orderUsecase.GetPaidOrders()
+	orders, err := h.orderUsecase.GetPaidOrders(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -45,7 +45,7 @@ func (h *CourierHandler) ShipOrder(c *gin.Context) {
 	courierID := c.GetString("user_id")
 	orderID := c.Param("id")
 
-	if err := h.orderUsecase.AssignAndShip(orderID, courierID); err != nil {
+	if err := h.orderUsecase.AssignAndShip(c.Request.Context(), orderID, courierID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -58,7 +58,7 @@ func (h *CourierHandler) DeliverOrder(c *gin.Context) {
 	courierID := c.GetString("user_id")
 	orderID := c.Param("id")
 
-	if err := h.orderUsecase.MarkDelivered(orderID, courierID); err != nil {
+	if err := h.orderUsecase.MarkDelivered(c.Request.Context(), orderID, courierID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -69,7 +69,7 @@ func (h *CourierHandler) DeliverOrder(c *gin.Context) {
 // MyOrders — daftar pesanan yang sedang saya kirim
 func (h *CourierHandler) MyOrders(c *gin.Context) {
 	courierID := c.GetString("user_id")
-	orders, err := h.orderUsecase.GetCourierOrders(courierID)
+	orders, err := h.orderUsecase.GetCourierOrders(c.Request.Context(), courierID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -82,7 +82,7 @@ func (h *CourierHandler) PickupReturn(c *gin.Context) {
 	courierID := c.GetString("user_id")
 	disputeID := c.Param("id")
 
-	if err := h.disputeUsecase.AssignReturnCourier(disputeID, courierID); err != nil {
+	if err := h.disputeUsecase.AssignReturnCourier(c.Request.Context(), disputeID, courierID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -94,7 +94,7 @@ func (h *CourierHandler) DeliverReturn(c *gin.Context) {
 	courierID := c.GetString("user_id")
 	disputeID := c.Param("id")
 
-	if err := h.disputeUsecase.MarkReturnDelivered(disputeID, courierID); err != nil {
+	if err := h.disputeUsecase.MarkReturnDelivered(c.Request.Context(), disputeID, courierID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
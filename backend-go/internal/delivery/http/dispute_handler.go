@@ -1,13 +1,12 @@
 package http
 
 import (
-	"fmt"
 	"net/http"
-	"path/filepath"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
 	"github.com/nuryanfa/e-commerse-sqa/internal/usecase"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 type DisputeHandler struct {
@@ -21,7 +20,7 @@ func NewDisputeHandler(uc usecase.DisputeUseCase) *DisputeHandler {
 // POST /api/disputes/:id
 func (h *DisputeHandler) OpenDispute(c *gin.Context) {
 	orderID := c.Param("id") // Extract 'id' which serves as order_id here
-	userID, _ := c.Get("userID")
+	userID := c.GetString("user_id")
 
 	// Ambil input form-data karena mungkin ada gambar
 	reason := c.PostForm("reason")
@@ -30,19 +29,18 @@ func (h *DisputeHandler) OpenDispute(c *gin.Context) {
 		return
 	}
 
-	imageURL := ""
-	file, _ := c.FormFile("image")
-	if file != nil {
-		filename := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-		uploadPath := filepath.Join("uploads", filename)
-		if err := c.SaveUploadedFile(file, uploadPath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Gagal mengunggah gambar bukti"})
+	// Bukti disimpan sebagai objek privat; klien hanya menerima URL bertanda tangan yang kedaluwarsa
+	var evidence []byte
+	if _, err := c.FormFile("image"); err == nil {
+		data, err := readImageUpload(c, "image")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Gagal mengunggah gambar bukti: " + err.Error()})
 			return
 		}
-		imageURL = "/uploads/" + filename
+		evidence = data
 	}
 
-	dispute, err := h.disputeUC.OpenDispute(orderID, userID.(string), reason, imageURL)
+	dispute, err := h.disputeUC.OpenDispute(c.Request.Context(), orderID, userID, reason, evidence)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
 		return
@@ -51,25 +49,28 @@ func (h *DisputeHandler) OpenDispute(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"status": "success", "message": "Komplain sengketa berhasil diajukan, menunggu respons penjual.", "data": dispute})
 }
 
-// GET /api/disputes
+// GET /api/disputes?limit=20&cursor=...
 func (h *DisputeHandler) GetMyDisputes(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	role, _ := c.Get("role")
+	page, err := pagination.FromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
 
-	disputes, err := h.disputeUC.GetDisputes(role.(string), userID.(string))
+	disputes, nextCursor, err := h.disputeUC.GetDisputes(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Gagal memuat daftar sengketa"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Berhasil memuat sengketa", "data": disputes})
+	pagination.Respond(c, http.StatusOK, disputes, nextCursor, page, gin.H{"status": "success", "message": "Berhasil memuat sengketa"})
 }
 
 // GET /api/disputes/:id
 func (h *DisputeHandler) GetDisputeDetail(c *gin.Context) {
 	disputeID := c.Param("id")
 
-	dispute, messages, err := h.disputeUC.GetDisputeDetail(disputeID)
+	dispute, messages, err := h.disputeUC.GetDisputeDetail(c.Request.Context(), disputeID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": err.Error()})
 		return
@@ -99,7 +100,7 @@ func (h *DisputeHandler) ReplyDispute(c *gin.Context) {
 		return
 	}
 
-	msg, err := h.disputeUC.AddReply(disputeID, userID.(string), input.Message)
+	msg, err := h.disputeUC.AddReply(c.Request.Context(), disputeID, userID.(string), input.Message)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
 		return
@@ -123,7 +124,7 @@ func (h *DisputeHandler) ResolveDispute(c *gin.Context) {
 		return
 	}
 
-	err := h.disputeUC.ResolveDispute(disputeID, adminID.(string), input.Decision, input.AdminNote)
+	err := h.disputeUC.ResolveDispute(c.Request.Context(), disputeID, adminID.(string), input.Decision, input.AdminNote)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
 		return
@@ -131,3 +132,29 @@ func (h *DisputeHandler) ResolveDispute(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Sengketa ditutup dan putusan telah dieksekusi"})
 }
+
+// POST /api/disputes/:id/inspect-return (Hanya Supplier)
+// Body JSON: { "action": "RESTOCK", "items": [{ "id_order_item": "...", "restock_quantity": 1 }], "note": "1 unit penyok" }
+// Item yang tidak disebut pada action RESTOCK dikembalikan penuh ke stok
+func (h *DisputeHandler) InspectReturn(c *gin.Context) {
+	disputeID := c.Param("id")
+	supplierID := c.GetString("user_id")
+
+	var input struct {
+		Action string                        `json:"action" binding:"required"`
+		Items  []domain.ReturnInspectionItem `json:"items" binding:"dive"`
+		Note   string                        `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Data inspeksi retur tidak valid"})
+		return
+	}
+
+	results, err := h.disputeUC.InspectReturn(c.Request.Context(), disputeID, supplierID, input.Action, input.Items, input.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Hasil inspeksi retur tersimpan dan stok telah disesuaikan", "data": results})
+}
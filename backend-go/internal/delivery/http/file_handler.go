@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/storage"
+)
+
+type FileHandler struct {
+	store *storage.Local
+}
+
+// NewFileHandler menyajikan file dari storage lokal di /uploads/*key, menggantikan router.Static.
+// Objek di bawah "private/" hanya bisa diakses dengan URL bertanda tangan yang belum kedaluwarsa.
+// Tidak dipakai saat STORAGE_DRIVER=s3 karena file disajikan langsung oleh bucket/CDN.
+func NewFileHandler(router *gin.Engine, store *storage.Local) {
+	handler := &FileHandler{store: store}
+	router.GET("/uploads/*key", handler.Serve)
+	router.HEAD("/uploads/*key", handler.Serve)
+}
+
+func (h *FileHandler) Serve(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	path, err := h.store.Path(key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File tidak ditemukan"})
+		return
+	}
+
+	if storage.IsPrivate(key) {
+		if err := h.store.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Cache-Control", "private, no-store")
+	} else {
+		// Key berbasis hash isi tidak pernah berubah sehingga aman di-cache lama
+		c.Header("Cache-Control", "public, max-age=86400")
+	}
+
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File tidak ditemukan"})
+		return
+	}
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.File(path)
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// healthCheckTimeout membatasi setiap ping agar probe orchestrator tidak ikut menggantung
+const healthCheckTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	db       *sql.DB
+	replicas []*sql.DB
+	redis    *redis.Client
+	started  time.Time
+}
+
+// NewHealthHandler mendaftarkan probe untuk orchestrator/load balancer:
+//   - GET /livez: proses hidup dan bisa melayani HTTP, tanpa menyentuh dependency
+//   - GET /readyz: ping PostgreSQL (primary dan replika) serta Redis, disertai statistik pool
+//
+// /api/v1/health dipertahankan untuk klien lama dan sama dengan /readyz.
+// redisClient boleh nil (aplikasi berjalan tanpa cache).
+func NewHealthHandler(router *gin.Engine, db *sql.DB, replicas []*sql.DB, redisClient *redis.Client) {
+	handler := &HealthHandler{db: db, replicas: replicas, redis: redisClient, started: time.Now()}
+	router.GET("/livez", handler.Live)
+	router.GET("/readyz", handler.Ready)
+	router.GET("/api/v1/health", handler.Ready)
+}
+
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"uptime": time.Since(h.started).Round(time.Second).String(),
+	})
+}
+
+// Ready mengembalikan 503 jika primary atau salah satu replika tidak bisa dijangkau.
+// Redis yang mati hanya menurunkan status menjadi "degraded" karena cache bersifat opsional.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx := c.Request.Context()
+	primary := dbCheck(ctx, h.db)
+	ready := primary["status"] == "up"
+
+	replicas := make([]gin.H, 0, len(h.replicas))
+	for _, replica := range h.replicas {
+		check := dbCheck(ctx, replica)
+		ready = ready && check["status"] == "up"
+		replicas = append(replicas, check)
+	}
+
+	cache := gin.H{"status": "disabled"}
+	if h.redis != nil {
+		cache = ping(ctx, func(ctx context.Context) error { return h.redis.Ping(ctx).Err() })
+	}
+
+	code, status, message := http.StatusOK, "success", "Server siap menerima request"
+	switch {
+	case !ready:
+		code, status, message = http.StatusServiceUnavailable, "error", "Database tidak dapat dijangkau"
+	case cache["status"] == "down":
+		status, message = "degraded", "Redis tidak dapat dijangkau, berjalan tanpa cache"
+	}
+
+	c.JSON(code, gin.H{
+		"status":  status,
+		"message": message,
+		"checks": gin.H{
+			"database": primary,
+			"replicas": replicas,
+			"redis":    cache,
+		},
+	})
+}
+
+// dbCheck melakukan ping dan melampirkan statistik pool koneksi
+func dbCheck(ctx context.Context, db *sql.DB) gin.H {
+	check := ping(ctx, db.PingContext)
+	stats := db.Stats()
+	check["pool"] = gin.H{
+		"max_open":             stats.MaxOpenConnections,
+		"open":                 stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration_ms":     stats.WaitDuration.Milliseconds(),
+		"max_idle_closed":      stats.MaxIdleClosed,
+		"max_idle_time_closed": stats.MaxIdleTimeClosed,
+		"max_lifetime_closed":  stats.MaxLifetimeClosed,
+	}
+	return check
+}
+
+// ping menjalankan fn dengan batas waktu dan mencatat latensinya
+func ping(ctx context.Context, fn func(context.Context) error) gin.H {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(ctx)
+	check := gin.H{"status": "up", "latency_ms": time.Since(start).Milliseconds()}
+	if err != nil {
+		check["status"] = "down"
+		check["error"] = err.Error()
+	}
+	return check
+}
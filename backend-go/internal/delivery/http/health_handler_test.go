@@ -0,0 +1,121 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// --- Driver SQL palsu: hanya mendukung Ping, cukup untuk health check tanpa PostgreSQL ---
+
+type fakePingDriver struct{}
+
+type fakePingConn struct{ err error }
+
+var (
+	fakePingMu   sync.Mutex
+	fakePingErrs = map[string]error{}
+)
+
+func (fakePingDriver) Open(name string) (driver.Conn, error) {
+	fakePingMu.Lock()
+	defer fakePingMu.Unlock()
+	return &fakePingConn{err: fakePingErrs[name]}, nil
+}
+
+func (c *fakePingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("tidak didukung")
+}
+func (c *fakePingConn) Close() error                   { return nil }
+func (c *fakePingConn) Begin() (driver.Tx, error)      { return nil, errors.New("tidak didukung") }
+func (c *fakePingConn) Ping(ctx context.Context) error { return c.err }
+
+func init() {
+	sql.Register("fakeping", fakePingDriver{})
+}
+
+// openFakeDB membuka *sql.DB yang ping-nya berhasil atau gagal sesuai pingErr
+func openFakeDB(t *testing.T, name string, pingErr error) *sql.DB {
+	t.Helper()
+	fakePingMu.Lock()
+	fakePingErrs[name] = pingErr
+	fakePingMu.Unlock()
+	db, err := sql.Open("fakeping", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func serveHealth(t *testing.T, db *sql.DB, replicas []*sql.DB, redisClient *redis.Client, path string) (int, map[string]interface{}) {
+	t.Helper()
+	router := gin.New()
+	NewHealthHandler(router, db, replicas, redisClient)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	router.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Respons %s bukan JSON: %v", path, err)
+	}
+	return w.Code, body
+}
+
+func TestHealth_LiveDoesNotTouchDependencies(t *testing.T) {
+	code, body := serveHealth(t, openFakeDB(t, "live-primary", errors.New("down")), nil, nil, "/livez")
+	if code != http.StatusOK || body["status"] != "success" {
+		t.Errorf("Expected /livez 200 even when the database is down, got %d %v", code, body)
+	}
+}
+
+func TestHealth_ReadyWithoutRedis(t *testing.T) {
+	code, body := serveHealth(t, openFakeDB(t, "ready-primary", nil), nil, nil, "/readyz")
+	if code != http.StatusOK || body["status"] != "success" {
+		t.Fatalf("Expected 200 success, got %d %v", code, body)
+	}
+	checks := body["checks"].(map[string]interface{})
+	if checks["redis"].(map[string]interface{})["status"] != "disabled" {
+		t.Errorf("Expected redis disabled when no client is configured, got %v", checks["redis"])
+	}
+	if _, ok := checks["database"].(map[string]interface{})["pool"]; !ok {
+		t.Error("Expected pool statistics in the database check")
+	}
+}
+
+func TestHealth_ReadyFailsWhenReplicaDown(t *testing.T) {
+	replicas := []*sql.DB{openFakeDB(t, "replica-up", nil), openFakeDB(t, "replica-down", errors.New("down"))}
+	code, body := serveHealth(t, openFakeDB(t, "replica-primary", nil), replicas, nil, "/api/v1/health")
+	if code != http.StatusServiceUnavailable || body["status"] != "error" {
+		t.Fatalf("Expected 503 when a replica is unreachable, got %d %v", code, body)
+	}
+	checks := body["checks"].(map[string]interface{})["replicas"].([]interface{})
+	if len(checks) != 2 || checks[1].(map[string]interface{})["status"] != "down" {
+		t.Errorf("Expected the second replica reported down, got %v", checks)
+	}
+}
+
+func TestHealth_ReadyDegradedWhenRedisDown(t *testing.T) {
+	// Port 1 tidak menerima koneksi sehingga ping Redis langsung gagal
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() { redisClient.Close() })
+
+	code, body := serveHealth(t, openFakeDB(t, "degraded-primary", nil), nil, redisClient, "/readyz")
+	if code != http.StatusOK || body["status"] != "degraded" {
+		t.Errorf("Expected 200 degraded when only Redis is down, got %d %v", code, body)
+	}
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+type InventoryHandler struct {
+	inventoryUsecase domain.InventoryUsecase
+}
+
+// NewInventoryHandler registers inventory ledger routes.
+// Supplier routes hanya melihat pergerakan stok produk miliknya sendiri.
+// Admin routes berisi pemeriksa konsistensi stok vs ledger.
+func NewInventoryHandler(supplierRouter *gin.RouterGroup, adminRouter *gin.RouterGroup, uc domain.InventoryUsecase) {
+	handler := &InventoryHandler{
+		inventoryUsecase: uc,
+	}
+
+	supplierRouter.GET("/inventory/movements", handler.MyMovements)
+
+	adminGroup := adminRouter.Group("/admin/inventory")
+	{
+		adminGroup.GET("/consistency", handler.CheckConsistency)
+		adminGroup.POST("/ledger/backfill", handler.BackfillOpeningBalances)
+	}
+}
+
+// MyMovements — GET /supplier/inventory/movements?id_product=&id_variant=&reason=&from=&to=&page=&limit=
+// from/to memakai format RFC3339
+func (h *InventoryHandler) MyMovements(c *gin.Context) {
+	supplierID := c.GetString("user_id")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 50
+	}
+
+	filter := domain.StockMovementFilter{
+		ProductID: c.Query("id_product"),
+		VariantID: c.Query("id_variant"),
+		Reason:    c.Query("reason"),
+		Limit:     limit,
+		Offset:    (page - 1) * limit,
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format 'from' tidak valid, gunakan RFC3339"})
+			return
+		}
+		filter.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format 'to' tidak valid, gunakan RFC3339"})
+			return
+		}
+		filter.To = &t
+	}
+
+	movements, total, err := h.inventoryUsecase.GetSupplierMovements(c.Request.Context(), supplierID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  movements,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// CheckConsistency — GET /admin/inventory/consistency
+// Mengembalikan daftar produk/varian yang stok tersimpannya tidak sama dengan rekap ledger.
+func (h *InventoryHandler) CheckConsistency(c *gin.Context) {
+	discrepancies, err := h.inventoryUsecase.CheckConsistency(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"consistent": len(discrepancies) == 0,
+		"data":       discrepancies,
+		"total":      len(discrepancies),
+	})
+}
+
+// BackfillOpeningBalances — POST /admin/inventory/ledger/backfill
+func (h *InventoryHandler) BackfillOpeningBalances(c *gin.Context) {
+	created, err := h.inventoryUsecase.BackfillOpeningBalances(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saldo awal ledger berhasil dibuat", "created": created})
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+type OptionHandler struct {
+	optionUsecase domain.ProductOptionUsecase
+}
+
+// NewOptionHandler registers product option (atribut varian) routes.
+// Public: GET /products/:id/options.
+// Admin (/products/:id/...) dan Supplier (/supplier/products/:id/...) memakai handler yang sama;
+// pembatasan kepemilikan produk ditentukan dari role di JWT.
+func NewOptionHandler(publicRouter *gin.Engine, adminRouter *gin.RouterGroup, supplierRouter *gin.RouterGroup, uc domain.ProductOptionUsecase) {
+	handler := &OptionHandler{
+		optionUsecase: uc,
+	}
+
+	publicRouter.GET("/api/v1/products/:id/options", handler.List)
+	supplierRouter.GET("/products/:id/options", handler.List)
+
+	for _, group := range []*gin.RouterGroup{adminRouter.Group("/products/:id"), supplierRouter.Group("/products/:id")} {
+		group.PUT("/options", handler.Replace)
+		group.POST("/variants/generate", handler.GenerateVariants)
+	}
+}
+
+func (h *OptionHandler) List(c *gin.Context) {
+	options, err := h.optionUsecase.GetOptions(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": options})
+}
+
+// Replace — PUT /products/:id/options
+// Body JSON: { "options": [{ "name": "Ukuran", "type": "NUMBER", "unit": "g", "values": [{ "value": "250" }, { "value": "500" }] }] }
+func (h *OptionHandler) Replace(c *gin.Context) {
+	var req struct {
+		Options []domain.ProductOption `json:"options" binding:"dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options, err := h.optionUsecase.SetOptions(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"), req.Options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Opsi produk berhasil disimpan", "data": options})
+}
+
+// GenerateVariants — POST /products/:id/variants/generate
+// Body JSON: { "price": 5000, "stock": 0, "remove_stale": false }
+func (h *OptionHandler) GenerateVariants(c *gin.Context) {
+	var req domain.GenerateVariantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.optionUsecase.GenerateVariants(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Matriks varian berhasil dibuat", "data": result})
+}
@@ -5,17 +5,23 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 type OrderHandler struct {
 	orderUsecase domain.OrderUsecase
 }
 
-func NewOrderHandler(r *gin.RouterGroup, uc domain.OrderUsecase) {
+// NewOrderHandler registers order routes.
+// Auth: checkout, riwayat, dan pembayaran pesanan milik sendiri (/orders/...).
+// Admin: tindak lanjut pesanan PAYMENT_REVIEW (/admin/orders/:id/payment-review).
+func NewOrderHandler(r *gin.RouterGroup, adminRouter *gin.RouterGroup, uc domain.OrderUsecase) {
 	handler := &OrderHandler{
 		orderUsecase: uc,
 	}
 
+	adminRouter.POST("/admin/orders/:id/payment-review", handler.ResolvePaymentReview)
+
 	// Semua routes ini berada di bawah Group dengan AuthMiddleware di main.go
 	orderGroup := r.Group("/orders")
 	{
@@ -42,7 +48,7 @@ func (h *OrderHandler) Checkout(c *gin.Context) {
 	// Bind JSON dapat gagal jika tidak ada body, yang mana tidak masalah (voucherCode opsional)
 	_ = c.ShouldBindJSON(&req)
 
-	order, err := h.orderUsecase.Checkout(uid, req.VoucherCode)
+	order, err := h.orderUsecase.Checkout(c.Request.Context(), uid, req.VoucherCode)
 	if err != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
@@ -74,7 +80,7 @@ func (h *OrderHandler) InstantCheckout(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderUsecase.InstantCheckout(uid, req.ProductID, req.VariantID, req.Quantity, req.VoucherCode)
+	order, err := h.orderUsecase.InstantCheckout(c.Request.Context(), uid, req.ProductID, req.VariantID, req.Quantity, req.VoucherCode)
 	if err != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
@@ -93,14 +99,19 @@ func (h *OrderHandler) GetMyOrders(c *gin.Context) {
 		return
 	}
 	uid := userID.(string)
+	page, err := pagination.FromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	
-	orders, err := h.orderUsecase.GetMyOrders(uid)
+	orders, nextCursor, err := h.orderUsecase.GetMyOrders(c.Request.Context(), uid, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": orders})
+	pagination.Respond(c, http.StatusOK, orders, nextCursor, page, nil)
 }
 
 func (h *OrderHandler) GetOrderDetail(c *gin.Context) {
@@ -112,7 +123,7 @@ func (h *OrderHandler) GetOrderDetail(c *gin.Context) {
 	uid := userID.(string)
 	orderID := c.Param("id")
 
-	order, err := h.orderUsecase.GetOrderDetail(uid, orderID)
+	order, err := h.orderUsecase.GetOrderDetail(c.Request.Context(), uid, orderID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -124,10 +135,29 @@ func (h *OrderHandler) GetOrderDetail(c *gin.Context) {
 func (h *OrderHandler) SimulatePayment(c *gin.Context) {
 	orderID := c.Param("id")
 
-	if err := h.orderUsecase.PayOrder(orderID); err != nil {
+	if err := h.orderUsecase.PayOrder(c.Request.Context(), orderID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Pembayaran disimulasikan sukses! Status sekarang PAID."})
 }
+
+// ResolvePaymentReview — POST /admin/orders/:id/payment-review
+// Body JSON: { "action": "APPROVE" } setelah stok ditambah, atau { "action": "REFUND" } setelah dana dikembalikan
+func (h *OrderHandler) ResolvePaymentReview(c *gin.Context) {
+	var req struct {
+		Action string `json:"action" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "aksi (action) wajib diisi: APPROVE atau REFUND"})
+		return
+	}
+
+	order, err := h.orderUsecase.ResolvePaymentReview(c.Request.Context(), c.GetString("user_id"), c.Param("id"), req.Action)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Peninjauan pembayaran selesai, status pesanan " + order.Status, "data": order})
+}
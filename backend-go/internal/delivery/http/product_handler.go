@@ -1,27 +1,30 @@
 package http
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
-	"path/filepath"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 type ProductHandler struct {
 	productUsecase domain.ProductUsecase
+	imageUsecase   domain.ProductImageUsecase
 }
 
 // NewProductHandler registers routes.
 // Public routes (GET) are on the main router.
 // Admin routes (POST/PUT/DELETE) are on the protected admin router group.
-func NewProductHandler(publicRouter *gin.Engine, adminRouter *gin.RouterGroup, uc domain.ProductUsecase) {
+func NewProductHandler(publicRouter *gin.Engine, adminRouter *gin.RouterGroup, uc domain.ProductUsecase, iuc domain.ProductImageUsecase) {
 	handler := &ProductHandler{
 		productUsecase: uc,
+		imageUsecase:   iuc,
 	}
 
 	// Public routes — tanpa login
@@ -30,6 +33,7 @@ func NewProductHandler(publicRouter *gin.Engine, adminRouter *gin.RouterGroup, u
 		publicGroup.GET("", handler.FindAll)
 		publicGroup.GET("/search", handler.Search) // Harus sebelum /:id agar tidak tertangkap wildcard
 		publicGroup.GET("/:id", handler.FindByID)
+		publicGroup.GET("/:id/price-history", handler.PriceHistory)
 	}
 
 	// Admin-only routes — butuh JWT + role "admin"
@@ -41,10 +45,13 @@ func NewProductHandler(publicRouter *gin.Engine, adminRouter *gin.RouterGroup, u
 	}
 }
 
-func (h *ProductHandler) parseProductRequest(c *gin.Context, req *domain.Product) error {
+// parseProductRequest mengisi req dari JSON atau form-data. Untuk form-data, isi file "image"
+// (jika ada) dikembalikan terpisah agar bisa diproses setelah produk memiliki ID.
+func (h *ProductHandler) parseProductRequest(c *gin.Context, req *domain.Product) (image []byte, err error) {
 	contentType := c.GetHeader("Content-Type")
 	if len(contentType) >= 19 && contentType[:19] == "multipart/form-data" {
 		req.Name = c.PostForm("name")
+		req.SKU = c.PostForm("sku")
 		req.Description = c.PostForm("description")
 		req.CategoryID = c.PostForm("id_category")
 		
@@ -58,91 +65,193 @@ func (h *ProductHandler) parseProductRequest(c *gin.Context, req *domain.Product
 			fmt.Sscanf(stockStr, "%d", &stock)
 			req.Stock = stock
 		}
-
-		file, err := c.FormFile("image")
-		if err == nil {
-			filename := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-			filepath := filepath.Join("uploads", filename)
-			if err := c.SaveUploadedFile(file, filepath); err == nil {
-				req.ImageURL = "/uploads/" + filename
+		if thresholdStr := c.PostForm("low_stock_threshold"); thresholdStr != "" {
+			fmt.Sscanf(thresholdStr, "%d", &req.LowStockThreshold)
+		}
+		if value, ok := c.GetPostForm("auto_hide_out_of_stock"); ok {
+			hide := value == "true"
+			req.AutoHideOutOfStock = hide
+			req.AutoHideOutOfStockInput = &hide
+		}
+		// Varian dikirim sebagai string JSON array pada form-data
+		if variantsJSON := c.PostForm("variants"); variantsJSON != "" {
+			if err := json.Unmarshal([]byte(variantsJSON), &req.Variants); err != nil {
+				return nil, errors.New("format variants tidak valid, harus berupa JSON array")
 			}
-		} else {
-			if existingImage := c.PostForm("image_url"); existingImage != "" {
-				req.ImageURL = existingImage
+		}
+
+		// File gambar tidak lagi disimpan mentah; diproses lewat galeri setelah produk tersimpan
+		if _, err := c.FormFile("image"); err == nil {
+			data, err := readImageUpload(c, "image")
+			if err != nil {
+				return nil, err
 			}
+			image = data
+		} else if existingImage := c.PostForm("image_url"); existingImage != "" {
+			req.ImageURL = existingImage
 		}
 
 		if req.Name == "" || req.CategoryID == "" || req.Price <= 0 || req.Stock < 0 {
-			return errors.New("Validasi gagal: pastikan semua field wajib terisi dengan benar")
+			return nil, errors.New("Validasi gagal: pastikan semua field wajib terisi dengan benar")
 		}
-		return nil
+		return image, nil
 	}
 
-	return c.ShouldBindJSON(req)
+	return nil, bindProductJSON(c, req)
+}
+
+// bindProductJSON mengikat body JSON produk sekaligus mencatat apakah auto_hide_out_of_stock dikirim,
+// sehingga update yang tidak menyertakannya tidak mereset pengaturan yang sudah ada
+func bindProductJSON(c *gin.Context, req *domain.Product) error {
+	var body struct {
+		domain.Product
+		AutoHideOutOfStock *bool `json:"auto_hide_out_of_stock"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		return err
+	}
+	*req = body.Product
+	if body.AutoHideOutOfStock != nil {
+		req.AutoHideOutOfStock = *body.AutoHideOutOfStock
+		req.AutoHideOutOfStockInput = body.AutoHideOutOfStock
+	}
+	return nil
 }
 
 func (h *ProductHandler) Create(c *gin.Context) {
 	var req domain.Product
-	if err := h.parseProductRequest(c, &req); err != nil {
+	image, err := h.parseProductRequest(c, &req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.productUsecase.Create(&req); err != nil {
+	if err := h.productUsecase.Create(c.Request.Context(), c.GetString("user_id"), &req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Produk berhasil dibuat", "data": req})
+	if len(image) > 0 {
+		img, err := setPrimaryImage(h.imageUsecase, c, req.ID, image, req.Name)
+		if err != nil {
+			// Produk baru dibatalkan agar gambar yang ditolak tidak menyisakan listing tanpa gambar
+			if delErr := h.productUsecase.Delete(c.Request.Context(), c.GetString("user_id"), req.ID); delErr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Gambar ditolak dan produk gagal dibatalkan: " + delErr.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Gambar produk ditolak: " + err.Error()})
+			return
+		}
+		req.ImageURL = img.MediumURL
+		req.Images = []domain.ProductImage{*img}
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Produk berhasil dibuat", "data": &req})
 }
 
 func (h *ProductHandler) FindAll(c *gin.Context) {
-	products, err := h.productUsecase.FindAll()
+	page, err := pagination.FromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	products, nextCursor, err := h.productUsecase.FindAll(c.Request.Context(), page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": products})
+	pagination.Respond(c, http.StatusOK, products, nextCursor, page, nil)
 }
 
 func (h *ProductHandler) FindByID(c *gin.Context) {
 	id := c.Param("id")
-	product, err := h.productUsecase.FindByID(id)
+	product, err := h.productUsecase.FindByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	// Produk yang belum disetujui atau sudah diarsipkan tidak terlihat publik
+	if !product.IsPublished() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "produk tidak ditemukan"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"data": product})
 }
 
 func (h *ProductHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 	var req domain.Product
-	if err := h.parseProductRequest(c, &req); err != nil {
+	image, err := h.parseProductRequest(c, &req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.productUsecase.Update(id, &req); err != nil {
+	if err := h.productUsecase.Update(c.Request.Context(), c.GetString("user_id"), id, &req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Produk berhasil diupdate", "data": req})
+	resp := gin.H{"message": "Produk berhasil diupdate", "data": &req}
+	if len(image) > 0 {
+		if img, err := setPrimaryImage(h.imageUsecase, c, id, image, req.Name); err != nil {
+			resp["warning"] = "Produk tersimpan, namun gambar ditolak: " + err.Error()
+		} else {
+			req.ImageURL = img.MediumURL
+		}
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *ProductHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.productUsecase.Delete(id); err != nil {
+	if err := h.productUsecase.Delete(c.Request.Context(), c.GetString("user_id"), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Produk berhasil dihapus"})
 }
 
+// PriceHistory — GET /products/:id/price-history, deret harga produk dan varian untuk grafik
+func (h *ProductHandler) PriceHistory(c *gin.Context) {
+	history, err := h.productUsecase.PriceHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
+// parseFloatQuery membaca angka opsional dari query string; kosong berarti 0 (filter tidak dipakai)
+func parseFloatQuery(c *gin.Context, name string) (float64, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("%s harus berupa angka", name)
+	}
+	return value, nil
+}
+
 func (h *ProductHandler) Search(c *gin.Context) {
 	keyword := c.Query("q")
 	categoryID := c.Query("category")
+	inStockOnly := c.Query("in_stock") == "true"
+	attributes := c.QueryMap("attr") // ?attr[Ukuran]=250&attr[Grade]=A
+	var minPrice, maxPrice, minRating float64
+	for _, param := range []struct {
+		name string
+		dst  *float64
+	}{{"min_price", &minPrice}, {"max_price", &maxPrice}, {"min_rating", &minRating}} {
+		value, err := parseFloatQuery(c, param.name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		*param.dst = value
+	}
 	
 	pageStr := c.DefaultQuery("page", "1")
 	limitStr := c.DefaultQuery("limit", "0") // 0 means no limit for backward compatibility
@@ -158,18 +267,35 @@ func (h *ProductHandler) Search(c *gin.Context) {
 		offset = 0 // Ignore offset if no limit is applied
 	}
 
-	products, err := h.productUsecase.Search(keyword, categoryID, limit, offset)
+	result, err := h.productUsecase.Search(c.Request.Context(), domain.ProductSearchFilter{
+		Keyword:     keyword,
+		CategoryID:  categoryID,
+		SupplierID:  c.Query("supplier"),
+		MinPrice:    minPrice,
+		MaxPrice:    maxPrice,
+		MinRating:   minRating,
+		InStockOnly: inStockOnly,
+		Attributes:  attributes,
+		Sort:        c.Query("sort"), // relevance | newest | price_asc | price_desc | rating | best_selling
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if errors.Is(err, domain.ErrInvalidSearchFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencari produk"})
 		return
 	}
 
-	// For infinite scroll, frontend might need 'has_more' flag or total products info.
-	// Since we don't have a Count query right now, returning current length is sufficient for basic next-page checks
+	// total adalah jumlah seluruh produk yang cocok (bukan hanya halaman ini) agar frontend bisa menghitung has_more
 	c.JSON(http.StatusOK, gin.H{
-		"data": products, 
-		"total": len(products),
+		"data": result.Products, 
+		"total": result.Total,
 		"page": page,
 		"limit": limit,
+		"has_more": limit > 0 && int64(offset+len(result.Products)) < result.Total,
+		"facets": gin.H{"categories": result.Facets},
 	})
 }
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseFloatQuery(t *testing.T) {
+	cases := []struct {
+		query   string
+		want    float64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"min_price=2500.5", 2500.5, false},
+		{"min_price=murah", 0, true},
+		{"min_price=NaN", 0, true},
+		{"min_price=Inf", 0, true},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/v1/products/search?"+tc.query, nil)
+
+		got, err := parseFloatQuery(c, "min_price")
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("%q: expected (%v, err=%v), got (%v, %v)", tc.query, tc.want, tc.wantErr, got, err)
+		}
+	}
+}
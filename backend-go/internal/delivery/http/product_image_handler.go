@@ -0,0 +1,131 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+type ProductImageHandler struct {
+	imageUsecase domain.ProductImageUsecase
+}
+
+// NewProductImageHandler registers product gallery routes.
+// Public: GET /products/:id/images.
+// Admin (/products/:id/images) dan Supplier (/supplier/products/:id/images) memakai handler yang sama;
+// pembatasan kepemilikan produk ditentukan dari role di JWT.
+func NewProductImageHandler(publicRouter *gin.Engine, adminRouter *gin.RouterGroup, supplierRouter *gin.RouterGroup, uc domain.ProductImageUsecase) {
+	handler := &ProductImageHandler{
+		imageUsecase: uc,
+	}
+
+	publicRouter.GET("/api/v1/products/:id/images", handler.List)
+	supplierRouter.GET("/products/:id/images", handler.List)
+
+	for _, group := range []*gin.RouterGroup{adminRouter.Group("/products/:id"), supplierRouter.Group("/products/:id")} {
+		group.POST("/images", handler.Upload)
+		group.PUT("/images/order", handler.Reorder)
+		group.DELETE("/images/:imageId", handler.Delete)
+	}
+}
+
+// readImageUpload membaca file gambar dari form-data dengan batas ukuran. Isi file divalidasi
+// ulang dari magic bytes oleh ImageProcessor; nama file dan Content-Type dari klien diabaikan.
+func readImageUpload(c *gin.Context, field string) ([]byte, error) {
+	file, err := c.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+	if file.Size > domain.MaxProductImageBytes {
+		return nil, fmt.Errorf("ukuran file gambar maksimal %d MB", domain.MaxProductImageBytes>>20)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, domain.MaxProductImageBytes+1))
+}
+
+func (h *ProductImageHandler) List(c *gin.Context) {
+	images, err := h.imageUsecase.List(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": images})
+}
+
+// Upload — POST /products/:id/images
+// Form-data: image (file), id_variant (opsional), alt_text (opsional)
+func (h *ProductImageHandler) Upload(c *gin.Context) {
+	data, err := readImageUpload(c, "image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File gambar (image) wajib diunggah: " + err.Error()})
+		return
+	}
+
+	image, err := h.imageUsecase.Upload(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"), c.PostForm("id_variant"), data, c.PostForm("alt_text"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Gambar produk berhasil diunggah", "data": image})
+}
+
+// Reorder — PUT /products/:id/images/order
+// Body JSON: { "image_ids": ["id-1", "id-3", "id-2"] }
+func (h *ProductImageHandler) Reorder(c *gin.Context) {
+	var req struct {
+		ImageIDs []string `json:"image_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Daftar ID gambar (image_ids) tidak boleh kosong"})
+		return
+	}
+
+	images, err := h.imageUsecase.Reorder(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"), req.ImageIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Urutan gambar berhasil disimpan", "data": images})
+}
+
+func (h *ProductImageHandler) Delete(c *gin.Context) {
+	if err := h.imageUsecase.Delete(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"), c.Param("imageId")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Gambar produk berhasil dihapus"})
+}
+
+// setPrimaryImage memproses field "image" lama pada form produk lewat pipeline galeri, lalu
+// menjadikannya gambar pertama sehingga image_url produk ikut menunjuk ke gambar tersebut
+func setPrimaryImage(uc domain.ProductImageUsecase, c *gin.Context, productID string, data []byte, altText string) (*domain.ProductImage, error) {
+	role, actorID := c.GetString("role"), c.GetString("user_id")
+	image, err := uc.Upload(c.Request.Context(), role, actorID, productID, "", data, altText)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := uc.List(c.Request.Context(), role, actorID, productID)
+	if err != nil {
+		return nil, err
+	}
+	order := []string{image.ID}
+	for _, img := range images {
+		if img.ID != image.ID {
+			order = append(order, img.ID)
+		}
+	}
+	if _, err := uc.Reorder(c.Request.Context(), role, actorID, productID, order); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+type ProductImportHandler struct {
+	importUsecase domain.ProductImportUsecase
+}
+
+// NewProductImportHandler registers supplier bulk import/export routes.
+// Impor berjalan di background: POST mengembalikan job, hasil per baris dibaca lewat GET job.
+func NewProductImportHandler(supplierRouter *gin.RouterGroup, uc domain.ProductImportUsecase) {
+	handler := &ProductImportHandler{
+		importUsecase: uc,
+	}
+
+	supplierRouter.POST("/products/import", handler.Import)
+	supplierRouter.GET("/products/import/:jobId", handler.GetJob)
+	supplierRouter.GET("/products/export", handler.Export)
+}
+
+// Import — POST /supplier/products/import
+// Form-data: file (.csv atau .xlsx), dry_run=true untuk validasi saja tanpa menyimpan
+func (h *ProductImportHandler) Import(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File impor (file) wajib diunggah: " + err.Error()})
+		return
+	}
+	if file.Size > domain.MaxImportFileBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("ukuran file impor maksimal %d MB", domain.MaxImportFileBytes>>20)})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, domain.MaxImportFileBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dryRun := c.PostForm("dry_run") == "true" || c.Query("dry_run") == "true"
+	job, err := h.importUsecase.StartImport(c.Request.Context(), c.GetString("user_id"), file.Filename, data, dryRun)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Impor produk sedang diproses",
+		"data":       job,
+		"status_url": "/api/v1/supplier/products/import/" + job.ID,
+	})
+}
+
+// GetJob — GET /supplier/products/import/:jobId
+func (h *ProductImportHandler) GetJob(c *gin.Context) {
+	job, err := h.importUsecase.GetJob(c.Request.Context(), c.GetString("user_id"), c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// Export — GET /supplier/products/export?format=csv|xlsx
+// File yang dihasilkan bisa diedit lalu diunggah kembali lewat endpoint impor.
+func (h *ProductImportHandler) Export(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", domain.ImportFormatCSV))
+	data, err := h.importUsecase.Export(c.Request.Context(), c.GetString("user_id"), format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == domain.ImportFormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	fileName := fmt.Sprintf("produk_%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+)
+
+type ProductModerationHandler struct {
+	productUsecase domain.ProductUsecase
+}
+
+// NewProductModerationHandler registers product moderation routes.
+// Admin: antrian review, approve, reject, archive (/admin/products/...).
+// Supplier: ajukan review dan arsipkan produk miliknya (/supplier/products/:id/...).
+func NewProductModerationHandler(adminRouter *gin.RouterGroup, supplierRouter *gin.RouterGroup, uc domain.ProductUsecase) {
+	handler := &ProductModerationHandler{
+		productUsecase: uc,
+	}
+
+	adminGroup := adminRouter.Group("/admin/products")
+	{
+		adminGroup.GET("/review-queue", handler.ReviewQueue)
+		adminGroup.POST("/:id/approve", handler.Approve)
+		adminGroup.POST("/:id/reject", handler.Reject)
+		adminGroup.POST("/:id/archive", handler.Archive)
+	}
+
+	supplierRouter.POST("/products/:id/submit", handler.Submit)
+	supplierRouter.POST("/products/:id/archive", handler.Archive)
+}
+
+// ReviewQueue — GET /admin/products/review-queue?limit=&cursor=
+func (h *ProductModerationHandler) ReviewQueue(c *gin.Context) {
+	page, err := pagination.FromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	products, nextCursor, err := h.productUsecase.ReviewQueue(c.Request.Context(), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	pagination.Respond(c, http.StatusOK, products, nextCursor, page, nil)
+}
+
+func (h *ProductModerationHandler) Approve(c *gin.Context) {
+	product, err := h.productUsecase.Approve(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Produk disetujui dan tayang di katalog", "data": product})
+}
+
+// Reject — POST /admin/products/:id/reject, body {"reason": "..."}
+func (h *ProductModerationHandler) Reject(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "alasan penolakan (reason) wajib diisi"})
+		return
+	}
+
+	product, err := h.productUsecase.Reject(c.Request.Context(), c.GetString("user_id"), c.Param("id"), req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Produk ditolak", "data": product})
+}
+
+func (h *ProductModerationHandler) Submit(c *gin.Context) {
+	product, err := h.productUsecase.SubmitForReview(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Produk diajukan untuk review", "data": product})
+}
+
+// Archive dipakai admin dan supplier; kepemilikan produk dicek dari role di JWT
+func (h *ProductModerationHandler) Archive(c *gin.Context) {
+	product, err := h.productUsecase.Archive(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Produk diarsipkan", "data": product})
+}
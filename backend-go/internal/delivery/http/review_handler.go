@@ -5,7 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
-	"github.com/nuryanfa/e-commerse-sqa/internal/middleware"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 	"github.com/nuryanfa/e-commerse-sqa/internal/usecase"
 )
 
@@ -13,7 +13,7 @@ type ReviewHandler struct {
 	reviewUsecase usecase.ReviewUsecase
 }
 
-func NewReviewHandler(router *gin.RouterGroup, ru usecase.ReviewUsecase) {
+func NewReviewHandler(router *gin.RouterGroup, ru usecase.ReviewUsecase, auth gin.HandlerFunc) {
 	handler := &ReviewHandler{reviewUsecase: ru}
 	
 	// Open endpoints
@@ -21,7 +21,7 @@ func NewReviewHandler(router *gin.RouterGroup, ru usecase.ReviewUsecase) {
 	
 	// Protected endpoints
 	protected := router.Group("/products/:id/reviews")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(auth)
 	{
 		protected.POST("", handler.AddReview)
 	}
@@ -29,22 +29,34 @@ func NewReviewHandler(router *gin.RouterGroup, ru usecase.ReviewUsecase) {
 
 func (h *ReviewHandler) GetProductReviews(c *gin.Context) {
 	productID := c.Param("id")
+	page, err := pagination.FromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	reviews, err := h.reviewUsecase.GetProductReviews(productID)
+	reviews, nextCursor, err := h.reviewUsecase.GetProductReviews(c.Request.Context(), productID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memuat ulasan"})
 		return
 	}
 
-	avgRating, _ := h.reviewUsecase.GetProductAverageRating(productID)
+	avgRating, _ := h.reviewUsecase.GetProductAverageRating(c.Request.Context(), productID)
+	total, err := h.reviewUsecase.CountProductReviews(c.Request.Context(), productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memuat ulasan"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Ulasan berhasil dimuat",
 		"data": gin.H{
 			"reviews": reviews,
 			"average": avgRating,
-			"count":   len(reviews),
+			"count":   total, // Total seluruh ulasan; halaman berikutnya dimuat lewat next_cursor
 		},
+		"next_cursor": nextCursor,
+		"limit":       page.Limit,
 	})
 }
 
@@ -74,7 +86,7 @@ func (h *ReviewHandler) AddReview(c *gin.Context) {
 		Comment:   req.Comment,
 	}
 
-	if err := h.reviewUsecase.AddReview(review); err != nil {
+	if err := h.reviewUsecase.AddReview(c.Request.Context(), review); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
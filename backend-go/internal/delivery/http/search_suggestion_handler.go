@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+type SearchSuggestionHandler struct {
+	suggestionUsecase domain.SearchSuggestionUsecase
+}
+
+// NewSearchSuggestionHandler registers the public autocomplete route.
+func NewSearchSuggestionHandler(publicRouter *gin.Engine, uc domain.SearchSuggestionUsecase) {
+	handler := &SearchSuggestionHandler{
+		suggestionUsecase: uc,
+	}
+
+	publicRouter.GET("/api/v1/products/suggest", handler.Suggest)
+}
+
+// Suggest — GET /api/v1/products/suggest?q=brocoli
+func (h *SearchSuggestionHandler) Suggest(c *gin.Context) {
+	suggestions, err := h.suggestionUsecase.Suggest(c.Request.Context(), c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": suggestions})
+}
@@ -1,26 +1,28 @@
 package http
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"path/filepath"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 type SupplierHandler struct {
 	productUsecase domain.ProductUsecase
 	orderUsecase   domain.OrderUsecase
+	imageUsecase   domain.ProductImageUsecase
 }
 
 // NewSupplierHandler registers supplier-only routes
-func NewSupplierHandler(supplierRouter *gin.RouterGroup, puc domain.ProductUsecase, ouc domain.OrderUsecase) {
+func NewSupplierHandler(supplierRouter *gin.RouterGroup, puc domain.ProductUsecase, ouc domain.OrderUsecase, iuc domain.ProductImageUsecase) {
 	handler := &SupplierHandler{
 		productUsecase: puc,
 		orderUsecase:   ouc,
+		imageUsecase:   iuc,
 	}
 
 	supplierRouter.GET("/products", handler.MyProducts)
@@ -34,18 +36,32 @@ func NewSupplierHandler(supplierRouter *gin.RouterGroup, puc domain.ProductUseca
 
 func (h *SupplierHandler) MyProducts(c *gin.Context) {
 	supplierID := c.GetString("user_id")
-	products, err := h.productUsecase.FindBySupplierID(supplierID)
+	products, err := h.productUsecase.FindBySupplierID(c.Request.Context(), supplierID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// ?status=PENDING_REVIEW dll. menyaring produk berdasarkan status moderasi
+	if status := c.Query("status"); status != "" {
+		filtered := make([]domain.Product, 0, len(products))
+		for _, p := range products {
+			if p.Status == status {
+				filtered = append(filtered, p)
+			}
+		}
+		products = filtered
+	}
 	c.JSON(http.StatusOK, gin.H{"data": products, "total": len(products)})
 }
 
-func (h *SupplierHandler) parseProductRequest(c *gin.Context, req *domain.Product) error {
+// parseProductRequest mengisi req dari JSON atau form-data. Untuk form-data, isi file "image"
+// (jika ada) dikembalikan terpisah agar bisa diproses setelah produk memiliki ID.
+func (h *SupplierHandler) parseProductRequest(c *gin.Context, req *domain.Product) (image []byte, err error) {
 	contentType := c.GetHeader("Content-Type")
 	if len(contentType) >= 19 && contentType[:19] == "multipart/form-data" {
 		req.Name = c.PostForm("name")
+		req.SKU = c.PostForm("sku")
+		req.Status = c.PostForm("status")
 		req.Description = c.PostForm("description")
 		req.CategoryID = c.PostForm("id_category")
 		
@@ -59,66 +75,104 @@ func (h *SupplierHandler) parseProductRequest(c *gin.Context, req *domain.Produc
 			fmt.Sscanf(stockStr, "%d", &stock)
 			req.Stock = stock
 		}
-
-		file, err := c.FormFile("image")
-		if err == nil {
-			filename := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-			filepath := filepath.Join("uploads", filename)
-			if err := c.SaveUploadedFile(file, filepath); err == nil {
-				req.ImageURL = "/uploads/" + filename
+		if thresholdStr := c.PostForm("low_stock_threshold"); thresholdStr != "" {
+			fmt.Sscanf(thresholdStr, "%d", &req.LowStockThreshold)
+		}
+		if value, ok := c.GetPostForm("auto_hide_out_of_stock"); ok {
+			hide := value == "true"
+			req.AutoHideOutOfStock = hide
+			req.AutoHideOutOfStockInput = &hide
+		}
+		// Varian dikirim sebagai string JSON array pada form-data
+		if variantsJSON := c.PostForm("variants"); variantsJSON != "" {
+			if err := json.Unmarshal([]byte(variantsJSON), &req.Variants); err != nil {
+				return nil, errors.New("format variants tidak valid, harus berupa JSON array")
 			}
-		} else {
-			if existingImage := c.PostForm("image_url"); existingImage != "" {
-				req.ImageURL = existingImage
+		}
+
+		// File gambar tidak lagi disimpan mentah; diproses lewat galeri setelah produk tersimpan
+		if _, err := c.FormFile("image"); err == nil {
+			data, err := readImageUpload(c, "image")
+			if err != nil {
+				return nil, err
 			}
+			image = data
+		} else if existingImage := c.PostForm("image_url"); existingImage != "" {
+			req.ImageURL = existingImage
 		}
 
 		if req.Name == "" || req.CategoryID == "" || req.Price <= 0 || req.Stock < 0 {
-			return errors.New("validasi gagal: pastikan semua field wajib terisi dengan benar")
+			return nil, errors.New("validasi gagal: pastikan semua field wajib terisi dengan benar")
 		}
-		return nil
+		return image, nil
 	}
-	return c.ShouldBindJSON(req)
+	return nil, bindProductJSON(c, req)
 }
 
 func (h *SupplierHandler) CreateProduct(c *gin.Context) {
 	supplierID := c.GetString("user_id")
 	var req domain.Product
-	if err := h.parseProductRequest(c, &req); err != nil {
+	image, err := h.parseProductRequest(c, &req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.productUsecase.CreateBySupplier(supplierID, &req); err != nil {
+	if err := h.productUsecase.CreateBySupplier(c.Request.Context(), supplierID, &req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Produk berhasil dibuat", "data": req})
+	message := "Produk berhasil dibuat dan menunggu review admin"
+	if req.Status == domain.ProductStatusDraft {
+		message = "Produk disimpan sebagai draft"
+	}
+	if len(image) > 0 {
+		img, err := setPrimaryImage(h.imageUsecase, c, req.ID, image, req.Name)
+		if err != nil {
+			// Produk baru dibatalkan agar gambar yang ditolak tidak menyisakan listing tanpa gambar
+			if delErr := h.productUsecase.DeleteBySupplier(c.Request.Context(), supplierID, req.ID); delErr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Gambar ditolak dan produk gagal dibatalkan: " + delErr.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Gambar produk ditolak: " + err.Error()})
+			return
+		}
+		req.ImageURL = img.MediumURL
+		req.Images = []domain.ProductImage{*img}
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": message, "data": &req})
 }
 
 func (h *SupplierHandler) UpdateProduct(c *gin.Context) {
 	supplierID := c.GetString("user_id")
 	productID := c.Param("id")
 	var req domain.Product
-	if err := h.parseProductRequest(c, &req); err != nil {
+	image, err := h.parseProductRequest(c, &req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.productUsecase.UpdateBySupplier(supplierID, productID, &req); err != nil {
+	if err := h.productUsecase.UpdateBySupplier(c.Request.Context(), supplierID, productID, &req); err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Produk berhasil diupdate"})
+	resp := gin.H{"message": "Produk berhasil diupdate"}
+	if len(image) > 0 {
+		if _, err := setPrimaryImage(h.imageUsecase, c, productID, image, req.Name); err != nil {
+			resp["warning"] = "Produk tersimpan, namun gambar ditolak: " + err.Error()
+		}
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *SupplierHandler) DeleteProduct(c *gin.Context) {
 	supplierID := c.GetString("user_id")
 	productID := c.Param("id")
 
-	if err := h.productUsecase.DeleteBySupplier(supplierID, productID); err != nil {
+	if err := h.productUsecase.DeleteBySupplier(c.Request.Context(), supplierID, productID); err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
 	}
@@ -128,19 +182,25 @@ func (h *SupplierHandler) DeleteProduct(c *gin.Context) {
 
 func (h *SupplierHandler) MyOrders(c *gin.Context) {
 	supplierID := c.GetString("user_id")
-	orders, err := h.orderUsecase.GetSupplierOrders(supplierID)
+	page, err := pagination.FromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orders, nextCursor, err := h.orderUsecase.GetSupplierOrders(c.Request.Context(), supplierID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": orders, "total": len(orders)})
+	pagination.Respond(c, http.StatusOK, orders, nextCursor, page, gin.H{"page_count": len(orders)})
 }
 
 func (h *SupplierHandler) ProcessOrder(c *gin.Context) {
 	supplierID := c.GetString("user_id")
 	orderID := c.Param("id")
 
-	if err := h.orderUsecase.ProcessSupplierOrder(supplierID, orderID); err != nil {
+	if err := h.orderUsecase.ProcessSupplierOrder(c.Request.Context(), supplierID, orderID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -160,7 +220,7 @@ func (h *SupplierHandler) BatchProcessOrders(c *gin.Context) {
 		return
 	}
 
-	if err := h.orderUsecase.BatchProcessSupplierOrders(supplierID, req.OrderIDs); err != nil {
+	if err := h.orderUsecase.BatchProcessSupplierOrders(c.Request.Context(), supplierID, req.OrderIDs); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
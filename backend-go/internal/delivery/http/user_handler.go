@@ -6,7 +6,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
-	"github.com/nuryanfa/e-commerse-sqa/internal/middleware"
 )
 
 type UserHandler struct {
@@ -15,7 +14,8 @@ type UserHandler struct {
 
 // NewUserHandler initialize user routing
 // loginRateLimiter: middleware rate limiter khusus untuk endpoint login (SQA: Brute Force Prevention)
-func NewUserHandler(r *gin.Engine, us domain.UserUsecase, loginRateLimiter gin.HandlerFunc) {
+// auth: middleware.AuthMiddleware yang sudah dikonfigurasi dengan JWT manager
+func NewUserHandler(r *gin.Engine, us domain.UserUsecase, loginRateLimiter gin.HandlerFunc, auth gin.HandlerFunc) {
 	handler := &UserHandler{
 		userUsecase: us,
 	}
@@ -27,7 +27,7 @@ func NewUserHandler(r *gin.Engine, us domain.UserUsecase, loginRateLimiter gin.H
 	}
 
 	protected := r.Group("/api/v1/users")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(auth)
 	{
 		protected.PUT("/profile", handler.UpdateProfile)
 	}
@@ -42,7 +42,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 	user.Email = strings.ToLower(user.Email)
 
-	if err := h.userUsecase.Register(&user); err != nil {
+	if err := h.userUsecase.Register(c.Request.Context(), &user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -71,7 +71,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 
 	loginReq.Email = strings.ToLower(loginReq.Email)
 
-	token, role, err := h.userUsecase.Login(loginReq.Email, loginReq.Password)
+	token, role, err := h.userUsecase.Login(c.Request.Context(), loginReq.Email, loginReq.Password)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -103,7 +103,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	if err := h.userUsecase.UpdateProfile(uid, req.Name, req.Phone, req.Address); err != nil {
+	if err := h.userUsecase.UpdateProfile(c.Request.Context(), uid, req.Name, req.Phone, req.Address); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
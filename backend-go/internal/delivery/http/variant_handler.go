@@ -0,0 +1,101 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+type VariantHandler struct {
+	productUsecase domain.ProductUsecase
+}
+
+// NewVariantHandler registers nested variant routes.
+// Public: GET /products/:id/variants.
+// Admin (/products/:id/variants) dan Supplier (/supplier/products/:id/variants) memakai handler yang sama;
+// pembatasan kepemilikan produk ditentukan dari role di JWT.
+func NewVariantHandler(publicRouter *gin.Engine, adminRouter *gin.RouterGroup, supplierRouter *gin.RouterGroup, uc domain.ProductUsecase) {
+	handler := &VariantHandler{
+		productUsecase: uc,
+	}
+
+	// Admin cukup memakai GET publik (path-nya sama persis dengan grup admin)
+	publicRouter.GET("/api/v1/products/:id/variants", handler.List)
+	supplierRouter.GET("/products/:id/variants", handler.List)
+
+	for _, group := range []*gin.RouterGroup{adminRouter.Group("/products/:id/variants"), supplierRouter.Group("/products/:id/variants")} {
+		group.POST("", handler.Create)
+		group.PUT("", handler.ReplaceAll) // Bulk replace seluruh varian dalam satu transaksi
+		group.PUT("/:variantId", handler.Update)
+		group.DELETE("/:variantId", handler.Delete)
+	}
+}
+
+func (h *VariantHandler) List(c *gin.Context) {
+	variants, err := h.productUsecase.ListVariants(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": variants, "total": len(variants)})
+}
+
+func (h *VariantHandler) Create(c *gin.Context) {
+	var req domain.ProductVariant
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.productUsecase.CreateVariant(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Varian berhasil dibuat", "data": req})
+}
+
+func (h *VariantHandler) Update(c *gin.Context) {
+	var req domain.ProductVariant
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.productUsecase.UpdateVariant(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"), c.Param("variantId"), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Varian berhasil diupdate", "data": req})
+}
+
+func (h *VariantHandler) Delete(c *gin.Context) {
+	if err := h.productUsecase.DeleteVariant(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"), c.Param("variantId")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Varian berhasil dihapus"})
+}
+
+// ReplaceAll — PUT /products/:id/variants
+// Body JSON: { "variants": [{ "id_variant": "...", "name_label": "250g", "price": 5000, "stock": 10, "sku_code": "KK-250" }, ...] }
+// Varian tanpa id_variant dibuat baru; varian lama yang tidak disebut dihapus.
+func (h *VariantHandler) ReplaceAll(c *gin.Context) {
+	var req struct {
+		Variants []domain.ProductVariant `json:"variants" binding:"dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variants, err := h.productUsecase.ReplaceVariants(c.Request.Context(), c.GetString("role"), c.GetString("user_id"), c.Param("id"), req.Variants)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Seluruh varian berhasil diganti", "data": variants, "total": len(variants)})
+}
@@ -4,22 +4,28 @@ import (
 	"crypto/sha512"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/config"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/metrics"
 )
 
 type WebhookHandler struct {
 	orderUsecase domain.OrderUsecase
+	serverKey    string
+	logger       *slog.Logger
 }
 
 // NewWebhookHandler mendaftarkan endpoint pendengar Webhook Midtrans
-func NewWebhookHandler(router *gin.RouterGroup, u domain.OrderUsecase) {
+func NewWebhookHandler(router *gin.RouterGroup, u domain.OrderUsecase, payment config.PaymentConfig, logger *slog.Logger) {
 	handler := &WebhookHandler{
 		orderUsecase: u,
+		serverKey:    payment.MidtransServerKey,
+		logger:       logger.With("component", "midtrans_webhook"),
 	}
 
 	router.POST("/webhook", handler.MidtransNotification)
@@ -52,36 +58,41 @@ func verifyMidtransSignature(payload map[string]interface{}, serverKey string) b
 // [A1 SQA FIX]: Tambahkan verifikasi tanda tangan (signature_key) sebelum memproses payload.
 func (h *WebhookHandler) MidtransNotification(c *gin.Context) {
 	var payload map[string]interface{}
+	ctx := c.Request.Context()
 
 	// Dekode body request JSON dari webhook midtrans
 	if err := json.NewDecoder(c.Request.Body).Decode(&payload); err != nil {
+		metrics.PaymentWebhooks.WithLabelValues("invalid_payload").Inc()
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload webhook dari Midtrans tidak valid"})
 		return
 	}
 
-	// [A1] Ambil Server Key dari environment untuk verifikasi
-	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
+	// [A1] Server Key dari konfigurasi untuk verifikasi (wajib diisi di production, lihat config.Validate)
+	serverKey := h.serverKey
 	if serverKey == "" {
 		// Di lingkungan Sandbox/Dev tanpa key, lewati verifikasi tapi catat peringatan
-		log.Printf("[WEBHOOK WARNING] MIDTRANS_SERVER_KEY tidak diset. Verifikasi signature dilewati.")
+		h.logger.WarnContext(ctx, "MIDTRANS_SERVER_KEY tidak diset, verifikasi signature dilewati")
 	} else {
 		// Verifikasi tanda tangan Midtrans — tolak jika tidak cocok
 		if !verifyMidtransSignature(payload, serverKey) {
-			log.Printf("[WEBHOOK SECURITY] Signature tidak valid untuk OrderID: %v. Request ditolak.", payload["order_id"])
+			h.logger.WarnContext(ctx, "signature webhook tidak valid, request ditolak", "order_id", payload["order_id"], "payload", logger.RedactMap(payload))
+			metrics.PaymentWebhooks.WithLabelValues("invalid_signature").Inc()
 			c.JSON(http.StatusForbidden, gin.H{"error": "Signature tidak valid. Akses ditolak."})
 			return
 		}
 	}
 
-	log.Printf("[WEBHOOK] Notifikasi Midtrans terverifikasi untuk OrderID: %v", payload["order_id"])
+	h.logger.InfoContext(ctx, "notifikasi Midtrans terverifikasi", "order_id", payload["order_id"], "transaction_status", payload["transaction_status"])
 
 	// Masukkan logika bisnis ke Order Usecase
-	err := h.orderUsecase.ProcessPaymentWebhook(payload)
+	err := h.orderUsecase.ProcessPaymentWebhook(ctx, payload)
 	if err != nil {
-		log.Printf("[WEBHOOK ERROR] Gagal set Webhook status: %v", err)
+		h.logger.ErrorContext(ctx, "gagal memproses webhook pembayaran", "order_id", payload["order_id"], "payload", logger.RedactMap(payload), "error", err)
+		metrics.PaymentWebhooks.WithLabelValues("failed").Inc()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update status pesanan"})
 		return
 	}
 
+	metrics.PaymentWebhooks.WithLabelValues("processed").Inc()
 	c.JSON(http.StatusOK, gin.H{"status": "sukses", "message": "notifikasi terekam"})
 }
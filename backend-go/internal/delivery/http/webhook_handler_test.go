@@ -0,0 +1,36 @@
+package http
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/config"
+)
+
+func TestMidtransNotification_InvalidSignatureLogsRedactedPayload(t *testing.T) {
+	// Logger polos tanpa ReplaceAttr: penyamaran harus tetap terjadi di handler
+	var logs bytes.Buffer
+	router := gin.New()
+	NewWebhookHandler(router.Group("/api/v1/payment"), nil, config.PaymentConfig{MidtransServerKey: "server-key"}, slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	body := `{"order_id":"ord-1","status_code":"200","gross_amount":"10000.00","signature_key":"sig-palsu","transaction_status":"settlement"}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/payment/webhook", strings.NewReader(body))
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403 for an invalid signature, got %d", w.Code)
+	}
+	out := logs.String()
+	if strings.Contains(out, "sig-palsu") {
+		t.Errorf("Expected signature_key redacted from the log, got %s", out)
+	}
+	if !strings.Contains(out, "ord-1") || !strings.Contains(out, "settlement") {
+		t.Errorf("Expected the rest of the payload logged, got %s", out)
+	}
+}
@@ -4,19 +4,19 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/nuryanfa/e-commerse-sqa/internal/middleware"
 	"github.com/nuryanfa/e-commerse-sqa/internal/usecase"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 type WishlistHandler struct {
 	wishlistUsecase usecase.WishlistUsecase
 }
 
-func NewWishlistHandler(router *gin.RouterGroup, wu usecase.WishlistUsecase) {
+func NewWishlistHandler(router *gin.RouterGroup, wu usecase.WishlistUsecase, auth gin.HandlerFunc) {
 	handler := &WishlistHandler{wishlistUsecase: wu}
 	
 	wishlistGroup := router.Group("/wishlist")
-	wishlistGroup.Use(middleware.AuthMiddleware())
+	wishlistGroup.Use(auth)
 	{
 		wishlistGroup.GET("", handler.GetMyWishlist)
 		wishlistGroup.POST("/toggle", handler.ToggleWishlist)
@@ -31,17 +31,19 @@ func (h *WishlistHandler) GetMyWishlist(c *gin.Context) {
 		return
 	}
 	uid := userID.(string)
+	page, err := pagination.FromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	wishlist, err := h.wishlistUsecase.GetMyWishlist(uid)
+	wishlist, nextCursor, err := h.wishlistUsecase.GetMyWishlist(c.Request.Context(), uid, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memuat wishlist"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Wishlist berhasil dimuat",
-		"data":    wishlist,
-	})
+	pagination.Respond(c, http.StatusOK, wishlist, nextCursor, page, gin.H{"message": "Wishlist berhasil dimuat"})
 }
 
 func (h *WishlistHandler) ToggleWishlist(c *gin.Context) {
@@ -60,7 +62,7 @@ func (h *WishlistHandler) ToggleWishlist(c *gin.Context) {
 		return
 	}
 
-	added, err := h.wishlistUsecase.ToggleWishlist(uid, req.ProductID)
+	added, err := h.wishlistUsecase.ToggleWishlist(c.Request.Context(), uid, req.ProductID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -86,7 +88,7 @@ func (h *WishlistHandler) CheckWishlist(c *gin.Context) {
 	uid := userID.(string)
 	productID := c.Param("id")
 
-	existsStatus, err := h.wishlistUsecase.CheckIsWishlisted(uid, productID)
+	existsStatus, err := h.wishlistUsecase.CheckIsWishlisted(c.Request.Context(), uid, productID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
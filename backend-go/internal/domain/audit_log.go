@@ -1,19 +1,108 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"time"
 
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+)
+
+// AuditLog bersifat append-only: tidak ada Update/Delete di repository dan trigger database menolak
+// UPDATE/DELETE. Setiap baris menyimpan hash baris sebelumnya (urut Seq) sehingga perubahan atau
+// penghapusan baris lama memutus rantai dan terdeteksi oleh VerifyChain.
 type AuditLog struct {
 	ID        string    `json:"id_audit_log" gorm:"column:id_audit_log;primaryKey"`
-	UserID    string    `json:"id_user" gorm:"column:id_user"` // Siapa pelakunya (Admin/Supplier)
-	Action    string    `json:"action" gorm:"column:action"`   // Misalnya: UPDATE_PRICE, UPDATE_STOCK, PROCESS_ORDER
-	Entity    string    `json:"entity" gorm:"column:entity"`   // Tabel yang berubah (products, orders)
+	Seq       int64     `json:"seq" gorm:"column:seq;autoIncrement;uniqueIndex"`                    // Urutan rantai hash
+	UserID    string    `json:"id_user" gorm:"column:id_user;index"`                                // Siapa pelakunya (Admin/Supplier)
+	Actor     *User     `json:"actor,omitempty" gorm:"-:migration;foreignKey:UserID;references:ID"` // Tanpa FK: pelaku bisa sistem, misalnya SYSTEM/MIDTRANS
+	Action    string    `json:"action" gorm:"column:action;index"`                                  // Misalnya: UPDATE_PRICE, UPDATE_STOCK, PROCESS_ORDER
+	Entity    string    `json:"entity" gorm:"column:entity"`                                        // Tabel yang berubah (products, orders)
 	EntityID  string    `json:"entity_id" gorm:"column:entity_id"`
-	OldValues string    `json:"old_values" gorm:"column:old_values;type:json"` // Bebas format JSON Text
+	ParentID  string    `json:"parent_id,omitempty" gorm:"column:parent_id;index"` // Entitas induk, misalnya produk pemilik varian/gambar
+	OldValues string    `json:"old_values" gorm:"column:old_values;type:json"`     // Bebas format JSON Text
 	NewValues string    `json:"new_values" gorm:"column:new_values;type:json"`
-	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	ActorRole string    `json:"actor_role,omitempty" gorm:"column:actor_role"`
+	RequestID string    `json:"request_id,omitempty" gorm:"column:request_id;index"` // Sama dengan header X-Request-ID dan log request
+	IPAddress string    `json:"ip_address,omitempty" gorm:"column:ip_address"`
+	UserAgent string    `json:"user_agent,omitempty" gorm:"column:user_agent"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;index"`
+	PrevHash  string    `json:"prev_hash" gorm:"column:prev_hash;type:varchar(64);not null;default:''"`
+	Hash      string    `json:"hash" gorm:"column:hash;type:varchar(64);not null;default:''"`
+}
+
+// ChainHash menghitung SHA-256 dari PrevHash dan seluruh isi baris. CreatedAt dinormalisasi ke UTC
+// dengan presisi mikrodetik agar hasilnya sama setelah dibaca ulang dari PostgreSQL.
+// Konteks request (role, request id, IP, user agent) hanya ikut dihitung jika ada, sehingga hash
+// baris yang dibuat sebelum kolom tersebut ditambahkan tetap valid.
+func (l *AuditLog) ChainHash() string {
+	parts := []string{
+		l.PrevHash, l.ID, l.UserID, l.Action, l.Entity, l.EntityID, l.ParentID, l.OldValues, l.NewValues,
+		l.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	}
+	if l.ActorRole != "" || l.RequestID != "" || l.IPAddress != "" || l.UserAgent != "" {
+		parts = append(parts, l.ActorRole, l.RequestID, l.IPAddress, l.UserAgent)
+	}
+
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditLogFilter dipakai endpoint admin dan ekspor. Field kosong/nil berarti tidak difilter.
+type AuditLogFilter struct {
+	UserID    string
+	RequestID string
+	Action    string
+	Entity    string
+	EntityID  string
+	From      *time.Time
+	To        *time.Time
+}
+
+// Pelaku audit untuk perubahan yang tidak dilakukan pengguna
+const (
+	AuditActorRoleSystem = "system"
+	SystemActorMidtrans  = "SYSTEM/MIDTRANS"
+)
+
+const (
+	AuditExportCSV    = "csv"
+	AuditExportNDJSON = "ndjson"
+)
+
+// AuditChainReport adalah hasil verifikasi rantai hash. Legacy menghitung baris lama yang dibuat
+// sebelum rantai hash ada; baris tersebut tidak bisa diverifikasi.
+type AuditChainReport struct {
+	Valid       bool   `json:"valid"`
+	Checked     int    `json:"checked"`
+	Legacy      int    `json:"legacy"`
+	LastSeq     int64  `json:"last_seq"`
+	LastHash    string `json:"last_hash"` // Simpan di luar sistem untuk mendeteksi baris terakhir yang dipotong
+	BrokenAtSeq int64  `json:"broken_at_seq,omitempty"`
+	BrokenID    string `json:"broken_id,omitempty"`
+	Reason      string `json:"reason,omitempty"`
 }
 
 type AuditLogRepository interface {
-	Insert(log *AuditLog) error
-	FindByEntity(entity string, entityID string) ([]AuditLog, error)
+	Insert(ctx context.Context, log *AuditLog) error
+	FindByEntity(ctx context.Context, entity string, entityID string) ([]AuditLog, error)
+	// FindByEntityWithChildren mengembalikan log entitas beserta log anaknya (ParentID = entityID), terlama lebih dulu
+	FindByEntityWithChildren(ctx context.Context, entity string, entityID string) ([]AuditLog, error)
+	// FindAll mengembalikan log terbaru lebih dulu (cursor pagination) beserta data pelakunya
+	FindAll(ctx context.Context, filter AuditLogFilter, page pagination.Params) ([]AuditLog, string, error)
+	// Stream membaca log satu per satu urut Seq tanpa memuat seluruh hasil ke memori
+	Stream(ctx context.Context, filter AuditLogFilter, fn func(log *AuditLog) error) error
+}
+
+type AuditLogUsecase interface {
+	List(ctx context.Context, filter AuditLogFilter, page pagination.Params) ([]AuditLog, string, error)
+	// Export menulis log ke w dalam format csv atau ndjson
+	Export(ctx context.Context, filter AuditLogFilter, format string, w io.Writer) error
+	VerifyChain(ctx context.Context) (*AuditChainReport, error)
 }
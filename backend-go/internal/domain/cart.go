@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type CartItem struct {
 	ID        string    `json:"id_cart_item" gorm:"column:id_cart_item;primaryKey"`
@@ -15,17 +18,17 @@ type CartItem struct {
 }
 
 type CartRepository interface {
-	UpsertItem(item *CartItem) error // Create or Update quantity if exists
-	UpdateItem(item *CartItem) error // Override/Update cart item exactly
-	FindByUserID(userID string) ([]CartItem, error)
-	DeleteByUserID(userID string) error // Clear cart after checkout
-	RemoveItem(itemID string, userID string) error
-	FindByID(itemID string) (*CartItem, error)
+	UpsertItem(ctx context.Context, item *CartItem) error // Create or Update quantity if exists
+	UpdateItem(ctx context.Context, item *CartItem) error // Override/Update cart item exactly
+	FindByUserID(ctx context.Context, userID string) ([]CartItem, error)
+	DeleteByUserID(ctx context.Context, userID string) error // Clear cart after checkout
+	RemoveItem(ctx context.Context, itemID string, userID string) error
+	FindByID(ctx context.Context, itemID string) (*CartItem, error)
 }
 
 type CartUsecase interface {
-	AddToCart(userID string, req *CartItem) error // req contains ProductID & Quantity
-	ViewCart(userID string) ([]CartItem, error)
-	UpdateQuantity(userID string, itemID string, quantity int) error
-	RemoveFromCart(userID string, itemID string) error
+	AddToCart(ctx context.Context, userID string, req *CartItem) error // req contains ProductID & Quantity
+	ViewCart(ctx context.Context, userID string) ([]CartItem, error)
+	UpdateQuantity(ctx context.Context, userID string, itemID string, quantity int) error
+	RemoveFromCart(ctx context.Context, userID string, itemID string) error
 }
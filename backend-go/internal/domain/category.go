@@ -1,32 +1,53 @@
 package domain
 
 import (
+	"context"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type Category struct {
-	ID          string    `json:"id_category" gorm:"column:id_category;primaryKey"`
-	Name        string    `json:"name" gorm:"column:name" binding:"required,min=3"`
-	Description string    `json:"description" gorm:"column:description"`
+	ID          string         `json:"id_category" gorm:"column:id_category;primaryKey"`
+	ParentID    *string        `json:"parent_id" gorm:"column:parent_id;index"` // nil = kategori akar, kedalaman tidak dibatasi
+	Name        string         `json:"name" gorm:"column:name" binding:"required,min=3"`
+	Slug        string         `json:"slug" gorm:"column:slug;uniqueIndex:idx_categories_slug,where:slug <> '' AND deleted_at IS NULL"` // Untuk URL, kosong = dibuat dari Name
+	Description string         `json:"description" gorm:"column:description"`
+	SortOrder   int            `json:"sort_order" gorm:"column:sort_order;default:0"` // Urutan di antara saudara, kecil lebih dulu
+	IconURL     string         `json:"icon_url" gorm:"column:icon_url"`
+	Children    []Category     `json:"children,omitempty" gorm:"-"` // Hanya diisi oleh Tree()
 	CreatedAt   time.Time      `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"column:updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index;column:deleted_at"`
+
+	// SortOrderInput diisi handler update jika sort_order dikirim; nil = urutan lama dipertahankan, 0 tetap bisa disimpan
+	SortOrderInput *int `json:"-" gorm:"-"`
 }
 
 type CategoryRepository interface {
-	Create(category *Category) error
-	FindAll() ([]Category, error)
-	FindByID(id string) (*Category, error)
-	Update(category *Category) error
-	Delete(id string) error
+	Create(ctx context.Context, category *Category) error
+	// FindAll mengembalikan daftar datar terurut sort_order, name
+	FindAll(ctx context.Context) ([]Category, error)
+	FindByID(ctx context.Context, id string) (*Category, error)
+	FindBySlug(ctx context.Context, slug string) (*Category, error)
+	// FindDescendantIDs mengembalikan ID kategori beserta seluruh turunannya (recursive CTE)
+	FindDescendantIDs(ctx context.Context, id string) ([]string, error)
+	SlugExists(ctx context.Context, slug string, excludeID string) (bool, error)
+	CountChildren(ctx context.Context, id string) (int64, error)
+	CountProducts(ctx context.Context, id string) (int64, error)
+	Update(ctx context.Context, category *Category) error
+	Delete(ctx context.Context, id string) error
 }
 
 type CategoryUsecase interface {
-	Create(category *Category) error
-	FindAll() ([]Category, error)
-	FindByID(id string) (*Category, error)
-	Update(id string, category *Category) error
-	Delete(id string) error
+	Create(ctx context.Context, adminID string, category *Category) error
+	FindAll(ctx context.Context) ([]Category, error)
+	// Tree mengembalikan seluruh hierarki kategori (akar beserta Children) dalam satu panggilan
+	Tree(ctx context.Context) ([]Category, error)
+	FindByID(ctx context.Context, id string) (*Category, error)
+	FindBySlug(ctx context.Context, slug string) (*Category, error)
+	Update(ctx context.Context, adminID string, id string, category *Category) error
+	Delete(ctx context.Context, adminID string, id string) error
+	// EnsureSlugs mengisi slug kategori lama yang dibuat sebelum kolom slug ada
+	EnsureSlugs(ctx context.Context) error
 }
@@ -17,6 +17,31 @@ type Dispute struct {
 	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
 	AdminNote string    `json:"admin_note,omitempty" gorm:"column:admin_note"`
+	// ReturnItems berisi hasil inspeksi supplier atas barang retur (terisi setelah status RETURNED)
+	ReturnItems []DisputeReturnItem `json:"return_items,omitempty" gorm:"foreignKey:DisputeID;references:ID"`
+}
+
+// DisputeReturnItem mencatat keputusan supplier untuk satu item pesanan yang diretur:
+// sebagian/seluruh kuantitas dikembalikan ke stok (restock), sisanya dihapusbukukan (write-off).
+// Satu item pesanan hanya boleh diinspeksi sekali per sengketa.
+type DisputeReturnItem struct {
+	ID                 string    `json:"id_return_item" gorm:"column:id_return_item;primaryKey"`
+	DisputeID          string    `json:"id_dispute" gorm:"column:id_dispute;uniqueIndex:idx_dispute_return_item"`
+	OrderItemID        string    `json:"id_order_item" gorm:"column:id_order_item;uniqueIndex:idx_dispute_return_item"`
+	ProductID          string    `json:"id_product" gorm:"column:id_product"`
+	VariantID          *string   `json:"id_variant,omitempty" gorm:"column:id_variant"`
+	ReturnedQuantity   int       `json:"returned_quantity" gorm:"column:returned_quantity"`
+	RestockedQuantity  int       `json:"restocked_quantity" gorm:"column:restocked_quantity"`
+	WrittenOffQuantity int       `json:"written_off_quantity" gorm:"column:written_off_quantity"`
+	InspectedBy        string    `json:"inspected_by" gorm:"column:inspected_by"`
+	Note               string    `json:"note,omitempty" gorm:"column:note"`
+	CreatedAt          time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+// ReturnInspectionItem adalah input supplier per item: berapa unit yang layak dijual kembali
+type ReturnInspectionItem struct {
+	OrderItemID     string `json:"id_order_item" binding:"required"`
+	RestockQuantity int    `json:"restock_quantity" binding:"gte=0"`
 }
 
 // DisputeMessage merepresentasikan mini-chat room di dalam tiket Sengketa tersebut
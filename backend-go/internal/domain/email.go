@@ -3,4 +3,6 @@ package domain
 type EmailService interface {
 	SendInvoiceEmail(customerEmail string, order *Order) error
 	SendReviewReminderEmail(customerEmail string, order *Order) error
+	SendLowStockAlertEmail(supplierEmail string, items []LowStockItem) error
+	SendWishlistAlertEmail(customerEmail string, job WishlistAlertJob) error
 }
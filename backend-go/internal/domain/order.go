@@ -1,8 +1,11 @@
 package domain
 
 import (
+	"context"
+	"errors"
 	"time"
 
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 	"gorm.io/gorm"
 )
 
@@ -18,12 +21,35 @@ type Order struct {
 	PaymentURL     *string     `json:"payment_url" gorm:"column:payment_url"`
 	ShippedAt      *time.Time  `json:"shipped_at" gorm:"column:shipped_at"`
 	DeliveredAt    *time.Time  `json:"delivered_at" gorm:"column:delivered_at"`
+	ExpiresAt      *time.Time  `json:"expires_at" gorm:"column:expires_at;index"` // Batas waktu pembayaran (sama dengan masa berlaku reservasi stok)
 	Items       []OrderItem `json:"items" gorm:"foreignKey:OrderID;references:ID"`
 	CreatedAt   time.Time   `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt   time.Time   `json:"updated_at" gorm:"column:updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index;column:deleted_at"`
 }
 
+const (
+	OrderStatusPaid = "PAID"
+	// OrderStatusPaymentReview: pembayaran masuk setelah reservasi dilepas dan stoknya sudah terjual.
+	// Stok tidak dipotong; admin harus menindaklanjuti (refund atau pengadaan ulang).
+	OrderStatusPaymentReview = "PAYMENT_REVIEW"
+	// OrderStatusRefunded: pesanan PAYMENT_REVIEW yang dananya dikembalikan admin
+	OrderStatusRefunded = "REFUNDED"
+)
+
+// Aksi admin untuk pesanan PAYMENT_REVIEW
+const (
+	PaymentReviewApprove = "APPROVE" // Stok sudah ditambah, pembayaran dikonfirmasi dan stok dipotong
+	PaymentReviewRefund  = "REFUND"  // Dana dikembalikan, pesanan ditutup tanpa menyentuh stok
+)
+
+// ErrOrderNotPayable dikembalikan ConfirmPayment jika pesanan tidak lagi menunggu pembayaran
+// (mis. webhook settlement ganda untuk pesanan yang sudah diproses); tidak ada perubahan yang dilakukan
+var ErrOrderNotPayable = errors.New("pesanan tidak sedang menunggu pembayaran")
+
+// ErrOrderNotCancellable dikembalikan CancelOrder jika pesanan sudah diproses supplier atau dikirim
+var ErrOrderNotCancellable = errors.New("pesanan yang sudah diproses tidak dapat dibatalkan")
+
 type OrderItem struct {
 	ID              string    `json:"id_order_item" gorm:"column:id_order_item;primaryKey"`
 	OrderID         string    `json:"id_order" gorm:"column:id_order;index" binding:"required"`
@@ -38,41 +64,56 @@ type OrderItem struct {
 }
 
 type OrderRepository interface {
-	CheckoutTransaction(userID string, cartItems []CartItem, voucherCode string) (*Order, error)
-	InstantCheckoutTransaction(userID string, item CartItem, voucherCode string) (*Order, error)
-	FindByUserID(userID string) ([]Order, error)
-	FindByID(orderID string) (*Order, error)
+	// expiresAt menentukan batas pembayaran pesanan sekaligus masa berlaku StockReservation
+	CheckoutTransaction(ctx context.Context, userID string, cartItems []CartItem, voucherCode string, expiresAt time.Time) (*Order, error)
+	InstantCheckoutTransaction(ctx context.Context, userID string, item CartItem, voucherCode string, expiresAt time.Time) (*Order, error)
+	FindByUserID(ctx context.Context, userID string, page pagination.Params) ([]Order, string, error)
+	FindByID(ctx context.Context, orderID string) (*Order, error)
 	// [B4] FindByIDs mengambil banyak pesanan sekaligus dengan satu query SQL IN
-	FindByIDs(orderIDs []string) ([]Order, error)
-	UpdateStatus(orderID string, status string) error
-	FindPaidOrders() ([]Order, error)
-	FindProcessedOrders() ([]Order, error)
-	AssignCourier(orderID string, courierID string) error
-	FindByCourierID(courierID string) ([]Order, error)
-	FindByProductSupplier(supplierID string) ([]Order, error)
+	FindByIDs(ctx context.Context, orderIDs []string) ([]Order, error)
+	UpdateStatus(ctx context.Context, orderID string, status string) error
+	// ConfirmPayment menandai pesanan PAID dan mengonsumsi reservasi stoknya (stok fisik dipotong).
+	// Hanya pesanan PENDING, EXPIRED, atau PAYMENT_REVIEW yang diproses; selain itu ErrOrderNotPayable.
+	// Mengembalikan OrderStatusPaymentReview jika stok fisik tidak cukup lagi.
+	ConfirmPayment(ctx context.Context, orderID string) (string, error)
+	// CancelOrder membatalkan pesanan: reservasi PENDING dilepas, stok pesanan PAID dikembalikan.
+	// Pesanan yang sudah diproses atau dikirim ditolak dengan ErrOrderNotCancellable.
+	CancelOrder(ctx context.Context, orderID string, status string) error
+	FindPaidOrders(ctx context.Context) ([]Order, error)
+	FindProcessedOrders(ctx context.Context) ([]Order, error)
+	AssignCourier(ctx context.Context, orderID string, courierID string) error
+	FindByCourierID(ctx context.Context, courierID string) ([]Order, error)
+	FindByProductSupplier(ctx context.Context, supplierID string, page pagination.Params) ([]Order, string, error)
 	// Cronjob Methods
-	CancelExpiredOrders(cutoffTime time.Time) (int, error)
+	// CancelExpiredOrders meng-EXPIRED-kan pesanan PENDING yang reservasinya lewat batas `now`.
+	// legacyCutoff dipakai untuk pesanan lama yang dibuat sebelum kolom expires_at ada.
+	CancelExpiredOrders(ctx context.Context, now time.Time, legacyCutoff time.Time) (int, error)
+	FindNextReservationExpiry(ctx context.Context) (*time.Time, error)
 	// Bulk Operations
-	BatchUpdateStatus(orderIDs []string, status string) error
+	BatchUpdateStatus(ctx context.Context, orderIDs []string, status string) error
 }
 
 type OrderUsecase interface {
-	Checkout(userID string, voucherCode string) (*Order, error)
-	InstantCheckout(userID string, productID string, variantID *string, quantity int, voucherCode string) (*Order, error)
-	GetMyOrders(userID string) ([]Order, error)
-	GetOrderDetail(userID string, orderID string) (*Order, error)
-	PayOrder(orderID string) error
+	Checkout(ctx context.Context, userID string, voucherCode string) (*Order, error)
+	InstantCheckout(ctx context.Context, userID string, productID string, variantID *string, quantity int, voucherCode string) (*Order, error)
+	GetMyOrders(ctx context.Context, userID string, page pagination.Params) ([]Order, string, error)
+	GetOrderDetail(ctx context.Context, userID string, orderID string) (*Order, error)
+	PayOrder(ctx context.Context, orderID string) error
+	// Admin methods
+	// ResolvePaymentReview menindaklanjuti pesanan PAYMENT_REVIEW dengan PaymentReviewApprove atau PaymentReviewRefund
+	ResolvePaymentReview(ctx context.Context, adminID string, orderID string, action string) (*Order, error)
 	// Courier methods
-	GetPaidOrders() ([]Order, error)
-	AssignAndShip(orderID string, courierID string) error
-	MarkDelivered(orderID string, courierID string) error
-	GetCourierOrders(courierID string) ([]Order, error)
+	GetPaidOrders(ctx context.Context) ([]Order, error)
+	AssignAndShip(ctx context.Context, orderID string, courierID string) error
+	MarkDelivered(ctx context.Context, orderID string, courierID string) error
+	GetCourierOrders(ctx context.Context, courierID string) ([]Order, error)
 	// Supplier methods
-	GetSupplierOrders(supplierID string) ([]Order, error)
-	ProcessSupplierOrder(supplierID string, orderID string) error
-	BatchProcessSupplierOrders(supplierID string, orderIDs []string) error
+	GetSupplierOrders(ctx context.Context, supplierID string, page pagination.Params) ([]Order, string, error)
+	ProcessSupplierOrder(ctx context.Context, supplierID string, orderID string) error
+	BatchProcessSupplierOrders(ctx context.Context, supplierID string, orderIDs []string) error
 	// Webhook method
-	ProcessPaymentWebhook(payload map[string]interface{}) error
+	ProcessPaymentWebhook(ctx context.Context, payload map[string]interface{}) error
 	// Cronjob Task
-	ProcessCancelExpiredJobs() (int, error)
+	ProcessCancelExpiredJobs(ctx context.Context) (int, error)
+	NextReservationExpiry(ctx context.Context) (*time.Time, error)
 }
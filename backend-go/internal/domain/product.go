@@ -1,27 +1,45 @@
 package domain
 
 import (
+	"context"
+	"errors"
 	"time"
 
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+
 	"gorm.io/gorm"
 )
 
 type Product struct {
 	ID          string    `json:"id_product" gorm:"column:id_product;primaryKey"`
 	Name        string    `json:"name" gorm:"column:name;index" binding:"required,min=3"`
+	SKU         string    `json:"sku" gorm:"column:sku;uniqueIndex:idx_products_supplier_sku,priority:2,where:sku <> '' AND deleted_at IS NULL"` // Kode produk milik supplier, kunci upsert impor massal
 	Description string    `json:"description" gorm:"column:description"`
 	Price       float64   `json:"price" gorm:"column:price" binding:"required,gt=0"`
 	Stock       int       `json:"stock" gorm:"column:stock" binding:"required,gte=0"`
 	CategoryID  string    `json:"id_category" gorm:"column:id_category;index" binding:"required"`
 	Category    *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID;references:ID"`
-	SupplierID     string    `json:"supplier_id" gorm:"column:supplier_id;index"`
+	SupplierID     string    `json:"supplier_id" gorm:"column:supplier_id;index;uniqueIndex:idx_products_supplier_sku,priority:1"`
 	Supplier       *User     `json:"supplier,omitempty" gorm:"foreignKey:SupplierID;references:ID"`
 	SupplierRating float64   `json:"supplier_rating,omitempty" gorm:"-"` // Dihitung run-time
+	AvailableStock *int      `json:"available_stock,omitempty" gorm:"-"` // Stok dikurangi reservasi aktif, dihitung run-time
+	IsAvailable    bool      `json:"is_available" gorm:"-"`              // false = stok habis (dihitung run-time)
+	LowStockThreshold  int        `json:"low_stock_threshold" gorm:"column:low_stock_threshold;default:5"`          // Supplier diberi tahu saat stok <= ambang ini
+	AutoHideOutOfStock bool       `json:"auto_hide_out_of_stock" gorm:"column:auto_hide_out_of_stock;default:false"` // Sembunyikan dari katalog publik saat stok habis
+	LowStockAlertedAt  *time.Time `json:"-" gorm:"column:low_stock_alerted_at"`                                      // Mencegah notifikasi stok menipis berulang
+	AutoHideOutOfStockInput *bool `json:"-" gorm:"-"` // Diisi handler update jika auto_hide_out_of_stock dikirim; nil = pengaturan lama dipertahankan
 	ImageURL       string           `json:"image_url" gorm:"column:image_url"`
+	Status          string     `json:"status" gorm:"column:status;default:ACTIVE;index"` // Salah satu konstanta ProductStatus*
+	RejectionReason string     `json:"rejection_reason,omitempty" gorm:"column:rejection_reason"`
+	SubmittedAt     *time.Time `json:"submitted_at,omitempty" gorm:"column:submitted_at"` // Waktu terakhir masuk antrian review
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty" gorm:"column:reviewed_at"`
+	ReviewedBy      string     `json:"reviewed_by,omitempty" gorm:"column:reviewed_by"`
 	CreatedAt      time.Time        `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt      time.Time        `json:"updated_at" gorm:"column:updated_at"`
 	DeletedAt      gorm.DeletedAt   `json:"-" gorm:"index;column:deleted_at"`
 	Variants       []ProductVariant `json:"variants,omitempty" gorm:"foreignKey:ProductID;references:ID"`
+	Options        []ProductOption  `json:"options,omitempty" gorm:"foreignKey:ProductID;references:ID"`
+	Images         []ProductImage   `json:"images,omitempty" gorm:"foreignKey:ProductID;references:ID"`
 }
 
 type ProductVariant struct {
@@ -30,9 +48,126 @@ type ProductVariant struct {
 	NameLabel string    `json:"name_label" gorm:"column:name_label" binding:"required"` // Contoh: "250g", "1 Kg"
 	Price     float64   `json:"price" gorm:"column:price" binding:"required,gt=0"`
 	Stock     int       `json:"stock" gorm:"column:stock" binding:"required,gte=0"`
-	SKUCode   string    `json:"sku_code" gorm:"column:sku_code"`
+	SKUCode   string    `json:"sku_code" gorm:"column:sku_code;uniqueIndex:idx_product_variants_sku,where:sku_code <> '' AND deleted_at IS NULL"`
+	AvailableStock *int `json:"available_stock,omitempty" gorm:"-"` // Stok dikurangi reservasi aktif, dihitung run-time
+	IsAvailable    bool `json:"is_available" gorm:"-"`
 	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index;column:deleted_at"` // Soft delete: varian lama tetap bisa dirujuk order_items
+	// OptionValues menghubungkan varian ke kombinasi nilai opsi produk (misalnya 250g × Grade A × Plastik)
+	OptionValues []ProductOptionValue `json:"option_values,omitempty" gorm:"many2many:variant_option_values;foreignKey:ID;joinForeignKey:id_variant;references:ID;joinReferences:id_option_value"`
+}
+
+// Status moderasi produk. Hanya ACTIVE yang tampil di katalog publik dan bisa dibeli.
+const (
+	ProductStatusDraft         = "DRAFT"          // Disimpan supplier, belum diajukan
+	ProductStatusPendingReview = "PENDING_REVIEW" // Menunggu review admin
+	ProductStatusActive        = "ACTIVE"
+	ProductStatusRejected      = "REJECTED" // Ditolak admin dengan alasan, bisa diperbaiki lalu diajukan ulang
+	ProductStatusArchived      = "ARCHIVED" // Ditarik dari katalog tanpa dihapus
+)
+
+// productStatusTransitions adalah perpindahan status yang diizinkan
+var productStatusTransitions = map[string][]string{
+	ProductStatusDraft:         {ProductStatusPendingReview, ProductStatusArchived},
+	ProductStatusPendingReview: {ProductStatusActive, ProductStatusRejected, ProductStatusArchived},
+	ProductStatusActive:        {ProductStatusPendingReview, ProductStatusArchived},
+	ProductStatusRejected:      {ProductStatusPendingReview, ProductStatusArchived},
+	ProductStatusArchived:      {ProductStatusPendingReview},
+}
+
+// CanTransitionTo mengecek apakah status produk boleh berpindah ke status tujuan
+func (p *Product) CanTransitionTo(status string) bool {
+	for _, next := range productStatusTransitions[p.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPublished bernilai true jika produk boleh dilihat dan dibeli publik.
+// Status kosong diperlakukan sebagai ACTIVE (data sebelum moderasi diperkenalkan).
+func (p *Product) IsPublished() bool {
+	return p.Status == ProductStatusActive || p.Status == ""
+}
+
+// HasStock menentukan status tersedia dari stok fisik: produk bisa dibeli selama stok dasarnya
+// atau salah satu variannya masih ada
+func (p *Product) HasStock() bool {
+	if p.Stock > 0 {
+		return true
+	}
+	for _, v := range p.Variants {
+		if v.Stock > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Opsi urutan hasil pencarian katalog
+const (
+	SortRelevance   = "relevance" // Default jika ada keyword
+	SortNewest      = "newest"    // Default tanpa keyword
+	SortPriceAsc    = "price_asc"
+	SortPriceDesc   = "price_desc"
+	SortRating      = "rating"
+	SortBestSelling = "best_selling"
+)
+
+// ErrInvalidSearchFilter membungkus kesalahan validasi filter pencarian sehingga handler bisa
+// membedakannya (400) dari kegagalan database (500)
+var ErrInvalidSearchFilter = errors.New("filter pencarian tidak valid")
+
+// ProductSearchFilter adalah parameter pencarian katalog publik
+type ProductSearchFilter struct {
+	Keyword     string // Dicocokkan dengan full-text search pada nama, deskripsi, dan kategori
+	CategoryID  string // ID atau slug, termasuk seluruh subkategorinya
+	SupplierID  string
+	MinPrice    float64 // 0 = tanpa batas bawah
+	MaxPrice    float64 // 0 = tanpa batas atas
+	MinRating   float64 // Rata-rata rating ulasan minimal (1-5), 0 = tanpa filter
+	InStockOnly bool    // Hanya produk yang masih bisa dibeli
+	// Attributes memfilter produk yang punya varian dengan nilai opsi tertentu, contoh {"Ukuran": "250", "Grade": "A"}
+	Attributes map[string]string
+	Sort       string // Salah satu konstanta Sort*, kosong = default
+	Limit      int
+	Offset     int
+}
+
+// CategoryFacet adalah jumlah produk per kategori untuk hasil pencarian yang sama (tanpa filter kategori)
+type CategoryFacet struct {
+	CategoryID string `json:"id_category" gorm:"column:id_category"`
+	Name       string `json:"name" gorm:"column:name"`
+	Count      int64  `json:"count" gorm:"column:count"`
+}
+
+// ProductSearchResult adalah satu halaman hasil pencarian beserta total dan facet kategori
+type ProductSearchResult struct {
+	Products []Product       `json:"data"`
+	Total    int64           `json:"total"`
+	Facets   []CategoryFacet `json:"facets"`
+}
+
+// PricePoint adalah satu titik pada grafik riwayat harga: harga berlaku mulai At sampai titik berikutnya
+type PricePoint struct {
+	At        time.Time `json:"at"`
+	Price     float64   `json:"price"`
+}
+
+// PriceSeries adalah deret harga produk dasar (VariantID nil) atau satu varian
+type PriceSeries struct {
+	VariantID *string      `json:"id_variant"`
+	Label     string       `json:"label"`
+	Points    []PricePoint `json:"points"`
+}
+
+// PriceHistory disusun dari audit log perubahan harga produk dan variannya
+type PriceHistory struct {
+	ProductID    string        `json:"id_product"`
+	CurrentPrice float64       `json:"current_price"`
+	Series       []PriceSeries `json:"series"`
 }
 
 type Review struct {
@@ -51,31 +186,89 @@ type Wishlist struct {
 	ID        string    `json:"id_wishlist" gorm:"column:id_wishlist;primaryKey"`
 	UserID    string    `json:"id_user" gorm:"column:id_user"`
 	ProductID string    `json:"id_product" gorm:"column:id_product"`
+	PriceAtAdd float64  `json:"price_at_add" gorm:"column:price_at_add"` // Harga saat produk ditambahkan, acuan notifikasi turun harga
 	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
 	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
 	Product   *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID;references:ID"`
 }
 
+// ProductCacheInvalidator dipakai usecase lain yang mengubah stok produk di luar ProductRepository
+// (misalnya konsumsi reservasi saat pembayaran dikonfirmasi) agar katalog tidak menampilkan stok basi
+type ProductCacheInvalidator interface {
+	InvalidateProductCache(ctx context.Context)
+}
+
 type ProductRepository interface {
-	Create(product *Product) error
-	FindAll() ([]Product, error)
-	FindByID(id string) (*Product, error)
-	Update(product *Product) error
-	Delete(id string) error
-	Search(keyword string, categoryID string, limit int, offset int) ([]Product, error)
-	FindBySupplierID(supplierID string) ([]Product, error)
-	GetSupplierRating(supplierID string) float64
+	// actorID dicatat sebagai pelaku di ledger StockMovement (kosong untuk proses sistem)
+	Create(ctx context.Context, product *Product, actorID string) error
+	FindAll(ctx context.Context, page pagination.Params) ([]Product, string, error)
+	FindByID(ctx context.Context, id string) (*Product, error)
+	Update(ctx context.Context, product *Product, actorID string) error
+	Delete(ctx context.Context, id string) error
+	Search(ctx context.Context, filter ProductSearchFilter) ([]Product, error)
+	// CountSearch menghitung total produk yang cocok dengan filter (mengabaikan Limit/Offset/Sort)
+	CountSearch(ctx context.Context, filter ProductSearchFilter) (int64, error)
+	// CategoryFacets menghitung jumlah produk per kategori untuk filter yang sama tanpa CategoryID
+	CategoryFacets(ctx context.Context, filter ProductSearchFilter) ([]CategoryFacet, error)
+	FindBySupplierID(ctx context.Context, supplierID string) ([]Product, error)
+	FindByIDs(ctx context.Context, ids []string) ([]Product, error)
+	// SetLowStockAlertedAt menandai (atau mereset dengan nil) waktu notifikasi stok menipis terakhir
+	SetLowStockAlertedAt(ctx context.Context, productID string, alertedAt *time.Time) error
+	GetSupplierRating(ctx context.Context, supplierID string) float64
+	// GetReservedStock menjumlahkan kuantitas StockReservation ACTIVE yang belum kedaluwarsa
+	GetReservedStock(ctx context.Context, productID string, variantID *string) (int, error)
+	// TotalStock menjumlahkan stok produk dan seluruh variannya langsung dari primary (tanpa cache),
+	// dipakai mendeteksi produk yang kembali tersedia untuk notifikasi wishlist
+	TotalStock(ctx context.Context, productID string) (int, error)
+
+	FindVariantsByProductID(ctx context.Context, productID string) ([]ProductVariant, error)
+	FindVariantByID(ctx context.Context, productID string, variantID string) (*ProductVariant, error)
+	// SKUExists mengecek SKU aktif di seluruh katalog, excludeVariantID diabaikan (untuk update)
+	SKUExists(ctx context.Context, sku string, excludeVariantID string) (bool, error)
+	// ProductSKUExists mengecek SKU produk milik satu supplier, excludeProductID diabaikan (untuk update)
+	ProductSKUExists(ctx context.Context, supplierID string, sku string, excludeProductID string) (bool, error)
+	CreateVariant(ctx context.Context, variant *ProductVariant, actorID string) error
+	UpdateVariant(ctx context.Context, variant *ProductVariant, actorID string) error
+	DeleteVariant(ctx context.Context, productID string, variantID string, actorID string) error
+	// ReplaceVariants mengganti seluruh varian produk dalam satu transaksi: varian dengan ID yang cocok
+	// diperbarui, varian baru dibuat, dan varian lama yang tidak disebut dihapus (soft delete)
+	ReplaceVariants(ctx context.Context, productID string, variants []ProductVariant, actorID string) error
+	// ApplyGeneratedVariants membuat varian hasil matriks opsi dan menghapus varian usang dalam satu
+	// transaksi, sehingga kegagalan di tengah tidak meninggalkan matriks setengah jadi
+	ApplyGeneratedVariants(ctx context.Context, productID string, created []ProductVariant, removedIDs []string, actorID string) error
+
+	// FindByStatus dipakai antrian review admin, tanpa filter visibilitas katalog
+	FindByStatus(ctx context.Context, status string, page pagination.Params) ([]Product, string, error)
+	// UpdateStatus hanya menyimpan kolom moderasi (status, alasan penolakan, waktu & reviewer)
+	UpdateStatus(ctx context.Context, product *Product) error
 }
 
 type ProductUsecase interface {
-	Create(product *Product) error
-	FindAll() ([]Product, error)
-	FindByID(id string) (*Product, error)
-	Update(id string, product *Product) error
-	Delete(id string) error
-	Search(keyword string, categoryID string, limit int, offset int) ([]Product, error)
-	FindBySupplierID(supplierID string) ([]Product, error)
-	CreateBySupplier(supplierID string, product *Product) error
-	UpdateBySupplier(supplierID string, productID string, product *Product) error
-	DeleteBySupplier(supplierID string, productID string) error
+	Create(ctx context.Context, adminID string, product *Product) error
+	FindAll(ctx context.Context, page pagination.Params) ([]Product, string, error)
+	FindByID(ctx context.Context, id string) (*Product, error)
+	Update(ctx context.Context, adminID string, id string, product *Product) error
+	Delete(ctx context.Context, adminID string, id string) error
+	Search(ctx context.Context, filter ProductSearchFilter) (*ProductSearchResult, error)
+	// PriceHistory mengembalikan deret waktu harga produk dan variannya untuk grafik
+	PriceHistory(ctx context.Context, productID string) (*PriceHistory, error)
+	FindBySupplierID(ctx context.Context, supplierID string) ([]Product, error)
+	CreateBySupplier(ctx context.Context, supplierID string, product *Product) error
+	UpdateBySupplier(ctx context.Context, supplierID string, productID string, product *Product) error
+	DeleteBySupplier(ctx context.Context, supplierID string, productID string) error
+
+	// Manajemen varian. role "admin" boleh mengelola semua produk, role "supplier" hanya produk miliknya.
+	ListVariants(ctx context.Context, role string, actorID string, productID string) ([]ProductVariant, error)
+	CreateVariant(ctx context.Context, role string, actorID string, productID string, variant *ProductVariant) error
+	UpdateVariant(ctx context.Context, role string, actorID string, productID string, variantID string, variant *ProductVariant) error
+	DeleteVariant(ctx context.Context, role string, actorID string, productID string, variantID string) error
+	ReplaceVariants(ctx context.Context, role string, actorID string, productID string, variants []ProductVariant) ([]ProductVariant, error)
+
+	// Moderasi. Produk baru dari supplier masuk PENDING_REVIEW (atau DRAFT jika diminta) dan baru
+	// tampil di katalog setelah disetujui admin.
+	ReviewQueue(ctx context.Context, page pagination.Params) ([]Product, string, error)
+	Approve(ctx context.Context, adminID string, productID string) (*Product, error)
+	Reject(ctx context.Context, adminID string, productID string, reason string) (*Product, error)
+	SubmitForReview(ctx context.Context, supplierID string, productID string) (*Product, error)
+	Archive(ctx context.Context, role string, actorID string, productID string) (*Product, error)
 }
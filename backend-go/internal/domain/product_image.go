@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// MaxProductImageBytes membatasi ukuran file gambar produk yang diunggah
+const MaxProductImageBytes = 10 << 20
+
+// Ukuran rendisi gambar produk yang dihasilkan server
+const (
+	ImageSizeThumb  = "thumb"
+	ImageSizeMedium = "medium"
+	ImageSizeLarge  = "large"
+)
+
+// ProductImage adalah satu gambar pada galeri produk. VariantID kosong berarti gambar milik produk;
+// jika diisi, gambar hanya ditampilkan untuk varian tersebut. Urutan tampilan mengikuti Position
+// di dalam cakupan yang sama (produk atau satu varian).
+type ProductImage struct {
+	ID        string    `json:"id_image" gorm:"column:id_image;primaryKey"`
+	ProductID string    `json:"id_product" gorm:"column:id_product;index"`
+	VariantID *string   `json:"id_variant,omitempty" gorm:"column:id_variant;index"`
+	Position  int       `json:"position" gorm:"column:position"`
+	AltText   string    `json:"alt_text" gorm:"column:alt_text"`
+	Hash      string    `json:"hash" gorm:"column:hash;index"` // SHA-256 dari file asli, sekaligus nama file rendisi
+	Width     int       `json:"width" gorm:"column:width"`     // Dimensi rendisi large
+	Height    int       `json:"height" gorm:"column:height"`
+	ThumbURL  string    `json:"thumb_url" gorm:"column:thumb_url"`
+	MediumURL string    `json:"medium_url" gorm:"column:medium_url"`
+	LargeURL  string    `json:"large_url" gorm:"column:large_url"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+// ImageRendition adalah satu hasil re-encode gambar pada ukuran tertentu
+type ImageRendition struct {
+	Size   string // thumb | medium | large
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// ProcessedImage adalah hasil pemrosesan file unggahan: sudah divalidasi, diputar sesuai orientasi,
+// dan di-encode ulang tanpa metadata EXIF
+type ProcessedImage struct {
+	Hash       string
+	Renditions []ImageRendition
+}
+
+// ImageProcessor memvalidasi isi file (magic bytes) lalu menghasilkan rendisi thumb/medium/large
+type ImageProcessor interface {
+	Process(data []byte) (*ProcessedImage, error)
+}
+
+type ProductImageRepository interface {
+	FindByProductID(ctx context.Context, productID string) ([]ProductImage, error)
+	FindByID(ctx context.Context, imageID string) (*ProductImage, error)
+	// Create, Delete, dan Reorder juga menyelaraskan products.image_url dengan gambar utama galeri
+	Create(ctx context.Context, image *ProductImage) error
+	Delete(ctx context.Context, image *ProductImage) error
+	Reorder(ctx context.Context, productID string, positions map[string]int) error
+	CountByHash(ctx context.Context, hash string) (int64, error)
+}
+
+type ProductImageUsecase interface {
+	List(ctx context.Context, role string, actorID string, productID string) ([]ProductImage, error)
+	Upload(ctx context.Context, role string, actorID string, productID string, variantID string, data []byte, altText string) (*ProductImage, error)
+	Reorder(ctx context.Context, role string, actorID string, productID string, imageIDs []string) ([]ProductImage, error)
+	Delete(ctx context.Context, role string, actorID string, productID string, imageID string) error
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+)
+
+// Status job impor produk
+const (
+	ImportStatusPending   = "PENDING"
+	ImportStatusRunning   = "RUNNING"
+	ImportStatusCompleted = "COMPLETED"
+	ImportStatusFailed    = "FAILED"
+)
+
+// Format file impor/ekspor produk
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatXLSX = "xlsx"
+)
+
+// MaxImportFileBytes membatasi ukuran file impor produk
+const MaxImportFileBytes = 5 << 20
+
+// ImportColumns adalah header file impor/ekspor produk, satu baris per varian. Kolom produk boleh
+// diulang di setiap baris varian atau hanya diisi di baris pertama. id_product hanya diisi oleh
+// ekspor agar produk lama yang belum punya SKU tetap bisa dicocokkan saat diimpor ulang.
+var ImportColumns = []string{
+	"id_product", "product_sku", "name", "description", "id_category", "price", "stock",
+	"low_stock_threshold", "auto_hide_out_of_stock", "image_url",
+	"variant_sku", "variant_name", "variant_price", "variant_stock",
+}
+
+// ImportRowError adalah kesalahan validasi pada satu baris file. Row dihitung seperti di
+// spreadsheet (baris 1 adalah header).
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportJob mencatat satu proses impor produk yang dijalankan di background
+type ImportJob struct {
+	ID             string           `json:"id_job" gorm:"column:id_job;primaryKey"`
+	SupplierID     string           `json:"supplier_id" gorm:"column:supplier_id;index"`
+	Status         string           `json:"status" gorm:"column:status;default:PENDING"`
+	DryRun         bool             `json:"dry_run" gorm:"column:dry_run"` // true = hanya validasi, tidak ada data yang disimpan
+	FileName       string           `json:"file_name" gorm:"column:file_name"`
+	Format         string           `json:"format" gorm:"column:format"`
+	TotalRows      int              `json:"total_rows" gorm:"column:total_rows"`
+	CreatedCount   int              `json:"created_count" gorm:"column:created_count"` // Produk baru (pada dry run: yang akan dibuat)
+	UpdatedCount   int              `json:"updated_count" gorm:"column:updated_count"`
+	UnchangedCount int              `json:"unchanged_count" gorm:"column:unchanged_count"` // Produk cocok tanpa perubahan (misalnya ekspor yang diimpor ulang apa adanya)
+	ErrorCount     int              `json:"error_count" gorm:"column:error_count"`
+	Errors         []ImportRowError `json:"errors" gorm:"column:errors;serializer:json"`
+	Message        string           `json:"message,omitempty" gorm:"column:message"` // Alasan job FAILED
+	StartedAt      *time.Time       `json:"started_at,omitempty" gorm:"column:started_at"`
+	FinishedAt     *time.Time       `json:"finished_at,omitempty" gorm:"column:finished_at"`
+	CreatedAt      time.Time        `json:"created_at" gorm:"column:created_at"`
+}
+
+// ImportTask adalah pekerjaan antrian impor. Isi file dibawa di memori sehingga job yang masih
+// PENDING saat server berhenti perlu diunggah ulang.
+type ImportTask struct {
+	JobID   string
+	Data    []byte
+	Request reqctx.Info // Konteks request unggahan, dipasang ulang oleh worker agar audit log impor membawa request id yang sama
+}
+
+// ImportQueue menerima task impor (implementasi: worker.ProductImportWorker).
+// Enqueue mengembalikan false jika antrian penuh.
+type ImportQueue interface {
+	Enqueue(task ImportTask) bool
+}
+
+type ImportJobRepository interface {
+	Create(ctx context.Context, job *ImportJob) error
+	Update(ctx context.Context, job *ImportJob) error
+	FindByID(ctx context.Context, jobID string) (*ImportJob, error)
+	// FailUnfinished menandai seluruh job PENDING/RUNNING sebagai FAILED dan mengembalikan jumlahnya
+	FailUnfinished(ctx context.Context, message string, finishedAt time.Time) (int64, error)
+}
+
+type ProductImportUsecase interface {
+	// StartImport memvalidasi format file lalu mengantrikan job; hasil per baris dibaca lewat GetJob
+	StartImport(ctx context.Context, supplierID string, fileName string, data []byte, dryRun bool) (*ImportJob, error)
+	GetJob(ctx context.Context, supplierID string, jobID string) (*ImportJob, error)
+	// ProcessImport dijalankan oleh worker
+	ProcessImport(ctx context.Context, task ImportTask) error
+	// Export menghasilkan file dengan format yang sama dengan impor
+	Export(ctx context.Context, supplierID string, format string) ([]byte, error)
+	// FailInterruptedJobs dipanggil saat server start: isi file job lama hanya ada di memori proses
+	// sebelumnya, jadi job yang belum selesai tidak akan pernah diproses lagi
+	FailInterruptedJobs(ctx context.Context) (int64, error)
+}
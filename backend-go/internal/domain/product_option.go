@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ProductOption adalah satu dimensi varian produk, misalnya "Ukuran", "Grade", atau "Kemasan"
+type ProductOption struct {
+	ID        string               `json:"id_option" gorm:"column:id_option;primaryKey"`
+	ProductID string               `json:"id_product" gorm:"column:id_product;index"`
+	Name      string               `json:"name" gorm:"column:name" binding:"required"`
+	Type      string               `json:"type" gorm:"column:type"`           // TEXT, NUMBER
+	Unit      string               `json:"unit,omitempty" gorm:"column:unit"` // Opsional untuk NUMBER, misalnya "g" atau "kg"
+	Position  int                  `json:"position" gorm:"column:position"`
+	Values    []ProductOptionValue `json:"values" gorm:"foreignKey:OptionID;references:ID"`
+	CreatedAt time.Time            `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time            `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// ProductOptionValue adalah satu nilai dari ProductOption, misalnya "250" (Ukuran) atau "A" (Grade)
+type ProductOptionValue struct {
+	ID       string `json:"id_option_value" gorm:"column:id_option_value;primaryKey"`
+	OptionID string `json:"id_option" gorm:"column:id_option;index"`
+	Value    string `json:"value" gorm:"column:value"`
+	Position int    `json:"position" gorm:"column:position"`
+}
+
+// GenerateVariantsRequest adalah parameter pembuatan matriks varian dari kombinasi nilai opsi
+type GenerateVariantsRequest struct {
+	Price       float64 `json:"price" binding:"required,gt=0"` // Harga awal untuk kombinasi baru
+	Stock       int     `json:"stock" binding:"gte=0"`
+	RemoveStale bool    `json:"remove_stale"` // Hapus varian yang tidak lagi cocok dengan kombinasi mana pun
+}
+
+// GenerateVariantsResult merangkum hasil pembuatan matriks varian
+type GenerateVariantsResult struct {
+	Created []ProductVariant `json:"created"`
+	Kept    int              `json:"kept"`
+	Removed int              `json:"removed"`
+}
+
+type ProductOptionRepository interface {
+	FindByProductID(ctx context.Context, productID string) ([]ProductOption, error)
+	// ReplaceOptions menyimpan seluruh opsi produk dalam satu transaksi. Opsi dan nilai dicocokkan
+	// berdasarkan nama/teks (case-insensitive) sehingga ID lama (dan relasi ke varian) tetap terjaga.
+	ReplaceOptions(ctx context.Context, productID string, options []ProductOption) error
+}
+
+type ProductOptionUsecase interface {
+	GetOptions(ctx context.Context, role string, actorID string, productID string) ([]ProductOption, error)
+	SetOptions(ctx context.Context, role string, actorID string, productID string, options []ProductOption) ([]ProductOption, error)
+	GenerateVariants(ctx context.Context, role string, actorID string, productID string, req GenerateVariantsRequest) (*GenerateVariantsResult, error)
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SearchQuery mencatat keyword pencarian yang pernah menghasilkan produk, dipakai sebagai saran "pencarian populer"
+type SearchQuery struct {
+	Query          string    `json:"query" gorm:"column:query;primaryKey"` // Sudah dinormalisasi (huruf kecil, spasi tunggal)
+	Hits           int64     `json:"hits" gorm:"column:hits;default:1"`
+	LastSearchedAt time.Time `json:"last_searched_at" gorm:"column:last_searched_at"`
+}
+
+type ProductSuggestion struct {
+	ID   string `json:"id_product" gorm:"column:id_product"`
+	Name string `json:"name" gorm:"column:name"`
+}
+
+type CategorySuggestion struct {
+	ID   string `json:"id_category" gorm:"column:id_category"`
+	Name string `json:"name" gorm:"column:name"`
+}
+
+// SearchSuggestions adalah hasil autocomplete: cocok awalan dulu, lalu cocok mirip (toleran salah ketik)
+type SearchSuggestions struct {
+	Products   []ProductSuggestion  `json:"products"`
+	Categories []CategorySuggestion `json:"categories"`
+	Queries    []string             `json:"queries"`
+}
+
+type SearchSuggestionRepository interface {
+	// Suggest mencari maksimal limit saran per kelompok untuk query yang sudah dinormalisasi
+	Suggest(ctx context.Context, query string, limit int) (*SearchSuggestions, error)
+	// RecordQuery menambah hitungan popularitas keyword
+	RecordQuery(ctx context.Context, query string) error
+}
+
+type SearchSuggestionUsecase interface {
+	Suggest(ctx context.Context, query string) (*SearchSuggestions, error)
+}
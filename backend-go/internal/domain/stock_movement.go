@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// StockMovement adalah catatan append-only untuk setiap perubahan Product.Stock / ProductVariant.Stock.
+// Jumlah seluruh Delta untuk satu produk/varian harus sama dengan stok saat ini (lihat CheckConsistency).
+type StockMovement struct {
+	ID           string    `json:"id_movement" gorm:"column:id_movement;primaryKey"`
+	ProductID    string    `json:"id_product" gorm:"column:id_product;index"`
+	VariantID    *string   `json:"id_variant,omitempty" gorm:"column:id_variant;index"`
+	Delta        int       `json:"delta" gorm:"column:delta"`
+	Reason       string    `json:"reason" gorm:"column:reason;index"` // INITIAL_STOCK, OPENING_BALANCE, ADJUSTMENT, SALE, ORDER_CANCELLED_RESTOCK, RETURN_RESTOCK
+	OrderID      *string   `json:"id_order,omitempty" gorm:"column:id_order;index"`
+	DisputeID    *string   `json:"id_dispute,omitempty" gorm:"column:id_dispute"`
+	UserID       *string   `json:"id_user,omitempty" gorm:"column:id_user"` // Pelaku perubahan (nil untuk proses sistem)
+	BalanceAfter int       `json:"balance_after" gorm:"column:balance_after"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at;index"`
+}
+
+// StockMovementFilter adalah parameter query riwayat pergerakan stok
+type StockMovementFilter struct {
+	SupplierID string
+	ProductID  string
+	VariantID  string
+	Reason     string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// StockDiscrepancy adalah hasil pemeriksaan konsistensi: stok tersimpan berbeda dengan hasil rekap ledger
+type StockDiscrepancy struct {
+	ProductID    string  `json:"id_product"`
+	VariantID    *string `json:"id_variant,omitempty"`
+	Name         string  `json:"name"`
+	CurrentStock int     `json:"current_stock"`
+	LedgerStock  int     `json:"ledger_stock"`
+	Difference   int     `json:"difference"` // current_stock - ledger_stock
+}
+
+// LowStockItem adalah satu baris notifikasi stok menipis untuk supplier
+type LowStockItem struct {
+	ProductID      string  `json:"id_product"`
+	VariantID      *string `json:"id_variant,omitempty"`
+	Name           string  `json:"name"`
+	AvailableStock int     `json:"available_stock"`
+	Threshold      int     `json:"low_stock_threshold"`
+}
+
+type StockMovementRepository interface {
+	FindByFilter(ctx context.Context, filter StockMovementFilter) ([]StockMovement, int64, error)
+	CheckConsistency(ctx context.Context) ([]StockDiscrepancy, error)
+	// BackfillOpeningBalances menulis OPENING_BALANCE untuk produk/varian yang belum punya catatan ledger sama sekali
+	BackfillOpeningBalances(ctx context.Context) (int, error)
+}
+
+type InventoryUsecase interface {
+	GetSupplierMovements(ctx context.Context, supplierID string, filter StockMovementFilter) ([]StockMovement, int64, error)
+	CheckConsistency(ctx context.Context) ([]StockDiscrepancy, error)
+	BackfillOpeningBalances(ctx context.Context) (int, error)
+	// CheckLowStock memeriksa produk yang baru dibeli dan mengirim notifikasi ke supplier
+	// jika stok tersedia sudah menyentuh ambang LowStockThreshold
+	CheckLowStock(ctx context.Context, productIDs []string) (int, error)
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// StockReservation menahan sejumlah stok untuk satu item pesanan selama pesanan belum dibayar.
+// Stok fisik (Product.Stock / ProductVariant.Stock) baru dipotong ketika reservasi dikonsumsi (PAID),
+// sedangkan reservasi yang lewat ExpiresAt dilepas kembali tanpa menyentuh stok fisik.
+type StockReservation struct {
+	ID          string     `json:"id_reservation" gorm:"column:id_reservation;primaryKey"`
+	OrderID     string     `json:"id_order" gorm:"column:id_order;index"`
+	OrderItemID string     `json:"id_order_item" gorm:"column:id_order_item"`
+	ProductID   string     `json:"id_product" gorm:"column:id_product;index"`
+	VariantID   *string    `json:"id_variant,omitempty" gorm:"column:id_variant;index"`
+	Quantity    int        `json:"quantity" gorm:"column:quantity"`
+	Status      string     `json:"status" gorm:"column:status;index"` // ACTIVE, CONSUMED, RELEASED
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"column:expires_at;index"`
+	ReleasedAt  *time.Time `json:"released_at,omitempty" gorm:"column:released_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"column:updated_at"`
+}
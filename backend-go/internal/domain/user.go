@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"time"
 
 	"gorm.io/gorm"
@@ -22,15 +23,15 @@ type User struct {
 
 
 type UserRepository interface {
-	Create(user *User) error
-	FindByEmail(email string) (*User, error)
-	FindByID(id string) (*User, error)
-	Update(user *User) error
+	Create(ctx context.Context, user *User) error
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByID(ctx context.Context, id string) (*User, error)
+	Update(ctx context.Context, user *User) error
 }
 
 // UserUsecase defines the business logic operations
 type UserUsecase interface {
-	Register(user *User) error
-	Login(email, password string) (string, string, error)
-	UpdateProfile(userID, name, phone, address string) error
+	Register(ctx context.Context, user *User) error
+	Login(ctx context.Context, email, password string) (string, string, error)
+	UpdateProfile(ctx context.Context, userID, name, phone, address string) error
 }
\ No newline at end of file
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type Voucher struct {
 	ID             string    `json:"id_voucher" gorm:"column:id_voucher;primaryKey"`
@@ -16,6 +19,6 @@ type Voucher struct {
 }
 
 type VoucherRepository interface {
-	FindByCode(code string) (*Voucher, error)
-	UpdateUsedCount(tx interface{}, voucherID string) error // tx interface{} agar dapat menerima *gorm.DB dari luar
+	FindByCode(ctx context.Context, code string) (*Voucher, error)
+	UpdateUsedCount(ctx context.Context, tx interface{}, voucherID string) error // tx interface{} agar dapat menerima *gorm.DB dari luar
 }
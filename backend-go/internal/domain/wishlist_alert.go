@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// WishlistAlertJob adalah pekerjaan antrian yang dibuat saat produk di-restock dari nol
+// atau harganya turun. Job diproses di background oleh worker, bukan di request supplier/admin.
+type WishlistAlertJob struct {
+	ProductID    string
+	ProductName  string
+	BackInStock  bool
+	PriceDropped bool
+	OldPrice     float64
+	NewPrice     float64
+	CreatedAt    time.Time
+}
+
+// WishlistAlertQueue menerima job notifikasi wishlist (implementasi: worker.WishlistAlertWorker)
+type WishlistAlertQueue interface {
+	Enqueue(job WishlistAlertJob)
+}
+
+// WishlistNotification mencatat notifikasi yang sudah dikirim, dipakai untuk throttle
+// maksimal satu notifikasi per pengguna per produk per hari
+type WishlistNotification struct {
+	ID        string    `json:"id_notification" gorm:"column:id_notification;primaryKey"`
+	UserID    string    `json:"id_user" gorm:"column:id_user;index:idx_wishlist_notif_user_product"`
+	ProductID string    `json:"id_product" gorm:"column:id_product;index:idx_wishlist_notif_user_product"`
+	Type      string    `json:"type" gorm:"column:type"` // BACK_IN_STOCK, PRICE_DROP
+	OldPrice  float64   `json:"old_price" gorm:"column:old_price"`
+	NewPrice  float64   `json:"new_price" gorm:"column:new_price"`
+	SentAt    time.Time `json:"sent_at" gorm:"column:sent_at;index"`
+}
@@ -1,34 +1,47 @@
 package email
 
 import (
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
 )
 
-type mockEmailService struct{}
+// mockEmailService tidak mengirim email sungguhan; setiap email dicatat sebagai satu baris log
+type mockEmailService struct {
+	logger *slog.Logger
+}
 
-func NewMockEmailService() domain.EmailService {
-	return &mockEmailService{}
+func NewMockEmailService(logger *slog.Logger) domain.EmailService {
+	return &mockEmailService{logger: logger.With("component", "email_mock")}
 }
 
 func (s *mockEmailService) SendInvoiceEmail(customerEmail string, order *domain.Order) error {
 	// Mensimulasikan jeda jaringan pengiriman SMTP yang riil (misal 2 detik)
 	time.Sleep(2 * time.Second)
-	
-	log.Printf("\n=======================================================\n")
-	log.Printf("📧 [MOCK SMTP SERVER] Email Berhasil Terkirim Secara Asinkron!\n")
-	log.Printf("   Tujuan : %s\n", customerEmail)
-	log.Printf("   Subjek : Pembayaran Telah Diterima (Order #%s...)\n", order.ID[:8])
-	log.Printf("   Pesan  : Terima kasih, uang sejumlah Rp%.0f telah masuk ke sistem kami.\n", order.TotalAmount)
-	log.Printf("=======================================================\n")
-	
+
+	s.logger.Info("email invoice terkirim", "to", logger.MaskEmail(customerEmail), "order_id", order.ID, "total_amount", order.TotalAmount)
 	return nil
 }
 
 func (s *mockEmailService) SendReviewReminderEmail(customerEmail string, order *domain.Order) error {
 	time.Sleep(1 * time.Second)
-	log.Printf("📧 [EMAIL REMINDER] Ke: %s | Sayur sudah diletakkan di depan rumah. Beri ulasan segera!\n", customerEmail)
+	s.logger.Info("email pengingat ulasan terkirim", "to", logger.MaskEmail(customerEmail), "order_id", order.ID)
+	return nil
+}
+
+func (s *mockEmailService) SendLowStockAlertEmail(supplierEmail string, items []domain.LowStockItem) error {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	s.logger.Info("email stok menipis terkirim", "to", logger.MaskEmail(supplierEmail), "items", len(items), "products", names)
+	return nil
+}
+
+func (s *mockEmailService) SendWishlistAlertEmail(customerEmail string, job domain.WishlistAlertJob) error {
+	s.logger.Info("email wishlist terkirim", "to", logger.MaskEmail(customerEmail), "product_id", job.ProductID,
+		"back_in_stock", job.BackInStock, "price_dropped", job.PriceDropped, "old_price", job.OldPrice, "new_price", job.NewPrice)
 	return nil
 }
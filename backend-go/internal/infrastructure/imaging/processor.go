@@ -0,0 +1,172 @@
+package imaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// maxSourcePixels mencegah decompression bomb: header gambar dicek sebelum piksel di-decode.
+// 24 MP cukup untuk foto kamera 6000×4000; satu decode menahan ±2 salinan RGBA (≈200 MB).
+const maxSourcePixels = 24_000_000
+
+// maxConcurrentDecodes membatasi decode paralel agar upload serentak tidak menjumlahkan memori
+// puncak tiap gambar; permintaan berikutnya menunggu slot kosong
+const maxConcurrentDecodes = 2
+
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedImage = errors.New("format gambar tidak didukung, gunakan JPEG, PNG, GIF, atau WebP")
+	ErrImageTooLarge    = fmt.Errorf("ukuran file gambar maksimal %d MB", domain.MaxProductImageBytes>>20)
+	ErrImageDimensions  = errors.New("dimensi gambar terlalu besar")
+	ErrCorruptImage     = errors.New("file gambar rusak atau tidak dapat dibaca")
+)
+
+// renditionSpecs adalah sisi terpanjang (px) tiap rendisi. Gambar tidak pernah diperbesar.
+var renditionSpecs = []struct {
+	size    string
+	maxEdge int
+}{
+	{domain.ImageSizeThumb, 200},
+	{domain.ImageSizeMedium, 600},
+	{domain.ImageSizeLarge, 1200},
+}
+
+type processor struct {
+	decodeSlots chan struct{}
+}
+
+func NewProcessor() domain.ImageProcessor {
+	return &processor{decodeSlots: make(chan struct{}, maxConcurrentDecodes)}
+}
+
+// DetectFormat menentukan format gambar dari magic bytes, bukan dari nama file atau Content-Type
+func DetectFormat(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg", nil
+	case bytes.HasPrefix(data, []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}):
+		return "png", nil
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif", nil
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return "webp", nil
+	}
+	return "", ErrUnsupportedImage
+}
+
+// Process memvalidasi file lalu menghasilkan rendisi JPEG. Encoder JPEG tidak menulis ulang
+// segmen APP1, sehingga seluruh metadata EXIF (termasuk lokasi GPS) otomatis terbuang.
+func (p *processor) Process(data []byte) (*domain.ProcessedImage, error) {
+	if len(data) > domain.MaxProductImageBytes {
+		return nil, ErrImageTooLarge
+	}
+	format, err := DetectFormat(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := decodeConfig(format, data)
+	if err != nil {
+		return nil, ErrCorruptImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxSourcePixels {
+		return nil, ErrImageDimensions
+	}
+
+	p.decodeSlots <- struct{}{}
+	defer func() { <-p.decodeSlots }()
+
+	src, err := decode(format, data)
+	if err != nil {
+		return nil, ErrCorruptImage
+	}
+	base := flatten(src)
+	if format == "jpeg" {
+		base = applyOrientation(base, exifOrientation(data))
+	}
+
+	sum := sha256.Sum256(data)
+	result := &domain.ProcessedImage{Hash: hex.EncodeToString(sum[:])}
+	for _, spec := range renditionSpecs {
+		resized := resize(base, spec.maxEdge)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		b := resized.Bounds()
+		result.Renditions = append(result.Renditions, domain.ImageRendition{
+			Size:   spec.size,
+			Width:  b.Dx(),
+			Height: b.Dy(),
+			Data:   buf.Bytes(),
+		})
+	}
+	return result, nil
+}
+
+func decodeConfig(format string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case "jpeg":
+		return jpeg.DecodeConfig(r)
+	case "png":
+		return png.DecodeConfig(r)
+	case "gif":
+		return gif.DecodeConfig(r)
+	default:
+		return webp.DecodeConfig(r)
+	}
+}
+
+func decode(format string, data []byte) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case "jpeg":
+		return jpeg.Decode(r)
+	case "png":
+		return png.Decode(r)
+	case "gif":
+		return gif.Decode(r) // Hanya frame pertama
+	default:
+		return webp.Decode(r)
+	}
+}
+
+// flatten menggambar sumber di atas latar putih karena JPEG tidak mendukung transparansi
+func flatten(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+func resize(src *image.RGBA, maxEdge int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= maxEdge && h <= maxEdge {
+		return src
+	}
+	if w >= h {
+		h = max(1, h*maxEdge/w)
+		w = maxEdge
+	} else {
+		w = max(1, w*maxEdge/h)
+		h = maxEdge
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,161 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// jpegWithOrientation menyisipkan segmen APP1 Exif berisi tag Orientation tepat setelah SOI
+func jpegWithOrientation(t *testing.T, w, h int, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")       // Big-endian, IFD0 di offset 8
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)      // Jumlah entri
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112) // Tag Orientation
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)      // Tipe SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)      // Count
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0) // Padding nilai + offset IFD berikutnya
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(payload)+2))
+	app1 = append(app1, payload...)
+
+	src := buf.Bytes()
+	return append(append(append([]byte{}, src[:2]...), app1...), src[2:]...)
+}
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string][]byte{
+		"jpeg": {0xFF, 0xD8, 0xFF, 0xE0},
+		"png":  encodePNG(t, 1, 1),
+		"gif":  []byte("GIF89a...."),
+		"webp": []byte("RIFF\x00\x00\x00\x00WEBPVP8 "),
+	}
+	for want, data := range cases {
+		if got, err := DetectFormat(data); err != nil || got != want {
+			t.Errorf("Expected %s, got %q (err %v)", want, got, err)
+		}
+	}
+
+	// SQA CHECK: skrip/HTML yang diberi ekstensi .jpg tetap ditolak karena dicek dari isinya
+	for _, data := range [][]byte{[]byte("<?php echo 1; ?>"), []byte("<svg onload=alert(1)>"), {}} {
+		if _, err := DetectFormat(data); err != ErrUnsupportedImage {
+			t.Errorf("Expected ErrUnsupportedImage for %q, got %v", data, err)
+		}
+	}
+}
+
+func TestProcess_Renditions(t *testing.T) {
+	p := NewProcessor()
+	data := encodePNG(t, 2000, 1000)
+
+	result, err := p.Process(data)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(result.Hash) != 64 {
+		t.Errorf("Expected SHA-256 hex hash, got %q", result.Hash)
+	}
+
+	want := map[string][2]int{
+		domain.ImageSizeThumb:  {200, 100},
+		domain.ImageSizeMedium: {600, 300},
+		domain.ImageSizeLarge:  {1200, 600},
+	}
+	for _, r := range result.Renditions {
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(r.Data))
+		if err != nil {
+			t.Fatalf("Expected %s rendition to be JPEG, got %v", r.Size, err)
+		}
+		if dims := want[r.Size]; cfg.Width != dims[0] || cfg.Height != dims[1] {
+			t.Errorf("Expected %s %dx%d, got %dx%d", r.Size, dims[0], dims[1], cfg.Width, cfg.Height)
+		}
+	}
+
+	// Gambar kecil tidak diperbesar
+	small, _ := p.Process(encodePNG(t, 150, 80))
+	for _, r := range small.Renditions {
+		if r.Width != 150 || r.Height != 80 {
+			t.Errorf("Expected %s to keep 150x80, got %dx%d", r.Size, r.Width, r.Height)
+		}
+	}
+}
+
+func TestProcess_OrientationAndExifStripped(t *testing.T) {
+	data := jpegWithOrientation(t, 40, 20, 6)
+	if got := exifOrientation(data); got != 6 {
+		t.Fatalf("Expected orientation 6, got %d", got)
+	}
+
+	result, err := NewProcessor().Process(data)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for _, r := range result.Renditions {
+		// Orientasi 6 = putar 90°, jadi lebar dan tinggi tertukar
+		if r.Width != 20 || r.Height != 40 {
+			t.Errorf("Expected %s rotated to 20x40, got %dx%d", r.Size, r.Width, r.Height)
+		}
+		if bytes.Contains(r.Data, []byte("Exif\x00\x00")) {
+			t.Errorf("Expected EXIF stripped from %s rendition", r.Size)
+		}
+	}
+}
+
+func TestProcess_Rejects(t *testing.T) {
+	p := NewProcessor()
+
+	// SQA CHECK: magic bytes PNG dengan isi rusak
+	if _, err := p.Process([]byte("\x89PNG\r\n\x1a\ngarbage")); err != ErrCorruptImage {
+		t.Errorf("Expected ErrCorruptImage, got %v", err)
+	}
+	if _, err := p.Process(make([]byte, domain.MaxProductImageBytes+1)); err != ErrImageTooLarge {
+		t.Errorf("Expected ErrImageTooLarge, got %v", err)
+	}
+}
+
+// SQA CHECK: header dengan dimensi melebihi batas ditolak sebelum piksel di-decode
+func TestProcess_RejectsOversizedDimensions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// Ubah lebar/tinggi di chunk IHDR menjadi 6000×4001 (sedikit di atas 24 MP) lalu hitung ulang CRC-nya
+	binary.BigEndian.PutUint32(data[16:20], 6000)
+	binary.BigEndian.PutUint32(data[20:24], 4001)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, err := NewProcessor().Process(data); err != ErrImageDimensions {
+		t.Errorf("Expected ErrImageDimensions, got %v", err)
+	}
+}
@@ -1,16 +1,17 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nuryanfa/e-commerse-sqa/pkg/jwt"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
 )
 
 // AuthMiddleware ensures the request has a valid JWT token
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(tokens *jwt.Manager, logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -27,9 +28,9 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
-		claims, err := jwt.ValidateToken(tokenString)
+		claims, err := tokens.ValidateToken(tokenString)
 		if err != nil {
-			log.Printf("Token validation error: %v", err)
+			logger.DebugContext(c.Request.Context(), "validasi token gagal", "error", err)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak valid atau sudah kadaluarsa"})
 			c.Abort()
 			return
@@ -38,6 +39,9 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Set variables to be accessed by handlers
 		c.Set("user_id", claims["user_id"])
 		c.Set("role", claims["role"])
+		userID, _ := claims["user_id"].(string)
+		role, _ := claims["role"].(string)
+		c.Request = c.Request.WithContext(reqctx.WithActor(c.Request.Context(), userID, role))
 
 		c.Next()
 	}
@@ -1,40 +1,55 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
+	"runtime/debug"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// LoggerMiddleware logs each HTTP request with method, path, status, latency, and client IP.
-func LoggerMiddleware() gin.HandlerFunc {
+// LoggerMiddleware logs each HTTP request with method, path, route, status, latency, and client IP.
+// Request id and user id are added by the logger from the request context (see pkg/logger).
+// 5xx responses are logged at ERROR and 4xx at WARN so production can run at LOG_LEVEL=warn.
+func LoggerMiddleware(logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startTime := time.Now()
 
 		// Process the request
 		c.Next()
 
-		// Calculate latency
-		latency := time.Since(startTime)
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
 
-		// Log details
-		log.Printf("[%s] %s | %d | %v | %s",
-			c.Request.Method,
-			c.Request.URL.Path,
-			c.Writer.Status(),
-			latency,
-			c.ClientIP(),
-		)
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(startTime).Microseconds())/1000),
+			slog.String("ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "http request", attrs...)
 	}
 }
 
 // RecoveryMiddleware catches panics in handlers and returns 500 error
-func RecoveryMiddleware() gin.HandlerFunc {
+func RecoveryMiddleware(logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("[PANIC RECOVERED] %v", err)
+				logger.ErrorContext(c.Request.Context(), "panic ditangkap",
+					"panic", err, "path", c.Request.URL.Path, "stack", string(debug.Stack()))
 				c.JSON(500, gin.H{
 					"status":  "error",
 					"message": "Terjadi kesalahan fatal pada server",
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+)
+
+func TestLoggerMiddleware_LogsRequestIDAsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	router := gin.New()
+	router.Use(RequestContextMiddleware(), LoggerMiddleware(logger.New(&buf, slog.LevelInfo, logger.FormatJSON)))
+	router.GET("/api/v1/products/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/products/p-1?token=rahasia", nil)
+	req.Header.Set(reqctx.HeaderRequestID, "req-dari-gateway")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Log request harus satu baris JSON: %v (%s)", err, buf.String())
+	}
+	if record["request_id"] != "req-dari-gateway" {
+		t.Errorf("X-Request-ID harus ikut tercatat, didapat %v", record["request_id"])
+	}
+	if record["route"] != "/api/v1/products/:id" || record["status"] != float64(http.StatusNotFound) {
+		t.Errorf("Route template dan status harus tercatat, didapat %v", record)
+	}
+	if record["level"] != "WARN" {
+		t.Errorf("Respons 4xx seharusnya dicatat sebagai WARN, didapat %v", record["level"])
+	}
+	if bytes.Contains(buf.Bytes(), []byte("rahasia")) {
+		t.Errorf("Query string tidak boleh dicatat: %s", buf.String())
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/time/rate"
+)
+
+func TestMetricsMiddleware_LabelsByRouteTemplate(t *testing.T) {
+	router := gin.New()
+	router.Use(MetricsMiddleware())
+	router.GET("/api/v1/products/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	before := testutil.CollectAndCount(metrics.HTTPRequestDuration)
+	for _, path := range []string{"/api/v1/products/a", "/api/v1/products/b", "/tidak-ada/1", "/tidak-ada/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Dua ID berbeda masuk ke satu seri route, semua path tak dikenal ke seri "unmatched"
+	if got := testutil.CollectAndCount(metrics.HTTPRequestDuration) - before; got != 2 {
+		t.Errorf("Seharusnya hanya 2 seri baru (route template + unmatched), didapat %d", got)
+	}
+}
+
+func TestRateLimiter_CountsRejections(t *testing.T) {
+	router := setupRateLimitedRouter(rate.Limit(0.01), 1)
+	counter := metrics.RateLimitRejections.WithLabelValues("/api/v1/auth/login")
+	before := testutil.ToFloat64(counter)
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("POST", "/api/v1/auth/login", nil)
+		req.RemoteAddr = "10.9.9.9:12345"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Errorf("Dua request terakhir seharusnya tercatat ditolak, didapat %v", got)
+	}
+}
+
+func TestRateLimiter_UnmatchedPathsShareOneSeries(t *testing.T) {
+	router := gin.New()
+	router.Use(RateLimitMiddleware(rate.Limit(0.01), 1))
+	counter := metrics.RateLimitRejections.WithLabelValues("unmatched")
+	before := testutil.ToFloat64(counter)
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("GET", "/tidak-ada-"+strconv.Itoa(i), nil)
+		req.RemoteAddr = "10.9.9.8:12345"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Errorf("Penolakan pada path tak dikenal seharusnya tercatat sebagai unmatched, didapat %v", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/metrics"
 	"golang.org/x/time/rate"
 )
 
@@ -92,6 +93,7 @@ func RateLimitMiddleware(rps rate.Limit, burst int) gin.HandlerFunc {
 		l := limiter.getVisitor(ip)
 
 		if !l.Allow() {
+			metrics.RateLimitRejections.WithLabelValues(routeLabel(c)).Inc()
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"status":  "error",
 				"message": "Terlalu banyak percobaan. Silakan coba lagi nanti.",
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+)
+
+// maxRequestIDLength membatasi X-Request-ID dari klien agar tidak membengkakkan log dan audit log
+const maxRequestIDLength = 128
+
+// validRequestID hanya menerima karakter ASCII yang aman ditulis ke log dan header
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestContextMiddleware memasang reqctx.Info (request id, IP, user agent) ke context request.
+// X-Request-ID dari klien/proxy dipakai jika valid, selain itu dibuat baru; nilainya selalu
+// dikembalikan di header response. AuthMiddleware menambahkan pelaku ke Info yang sama.
+func RequestContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(reqctx.HeaderRequestID)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Header(reqctx.HeaderRequestID, requestID)
+		c.Set("request_id", requestID)
+
+		c.Request = c.Request.WithContext(reqctx.With(c.Request.Context(), reqctx.Info{
+			RequestID: requestID,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}))
+		c.Next()
+	}
+}
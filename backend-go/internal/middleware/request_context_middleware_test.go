@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+)
+
+// setupRequestContextRouter mengembalikan router yang menyalin reqctx.Info dari handler ke *captured
+func setupRequestContextRouter(captured *reqctx.Info) *gin.Engine {
+	router := gin.New()
+	router.Use(RequestContextMiddleware())
+	router.GET("/ping", func(c *gin.Context) {
+		*captured = reqctx.From(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRequestContext_GeneratesRequestID(t *testing.T) {
+	var info reqctx.Info
+	router := setupRequestContextRouter(&info)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	req.RemoteAddr = "192.168.1.7:5555"
+	req.Header.Set("User-Agent", "test-agent/1.0")
+	router.ServeHTTP(w, req)
+
+	requestID := w.Header().Get(reqctx.HeaderRequestID)
+	if requestID == "" || info.RequestID != requestID {
+		t.Fatalf("Request id di header (%q) harus sama dengan di context (%q)", requestID, info.RequestID)
+	}
+	if info.IP != "192.168.1.7" || info.UserAgent != "test-agent/1.0" {
+		t.Errorf("IP/user agent tidak tersimpan di context: %+v", info)
+	}
+}
+
+func TestRequestContext_HonorsValidIncomingID(t *testing.T) {
+	var info reqctx.Info
+	router := setupRequestContextRouter(&info)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	req.Header.Set(reqctx.HeaderRequestID, "lb-abc-123")
+	router.ServeHTTP(w, req)
+	if info.RequestID != "lb-abc-123" || w.Header().Get(reqctx.HeaderRequestID) != "lb-abc-123" {
+		t.Errorf("Request id dari proxy harus dipakai ulang, got %q", info.RequestID)
+	}
+
+	// Nilai yang terlalu panjang atau berisi spasi diganti dengan id baru
+	for _, invalid := range []string{strings.Repeat("x", maxRequestIDLength+1), "id dengan spasi"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/ping", nil)
+		req.Header.Set(reqctx.HeaderRequestID, invalid)
+		router.ServeHTTP(w, req)
+		if info.RequestID == invalid || info.RequestID == "" {
+			t.Errorf("Request id tidak valid %q seharusnya diganti", invalid)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestBaseContextKey menyimpan context request sebelum batas waktu pertama dipasang
+const requestBaseContextKey = "request_base_context"
+
+// TimeoutMiddleware memasang batas waktu pada context request sehingga query database dan Redis
+// ikut dibatalkan saat waktunya habis. Dapat dipasang global lalu ditimpa per route group:
+// pemasangan berikutnya menggantikan batas sebelumnya (lebih pendek maupun lebih panjang),
+// sedangkan pembatalan dari klien yang terputus atau shutdown server tetap diteruskan.
+// Durasi 0 berarti tanpa batas waktu.
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var base context.Context
+		if stored, ok := c.Get(requestBaseContextKey); ok {
+			base = stored.(context.Context)
+		} else {
+			base = c.Request.Context()
+			c.Set(requestBaseContextKey, base)
+		}
+
+		// Nilai context (request id, pelaku) dipertahankan, batas waktu lama dilepas
+		parent := context.WithoutCancel(c.Request.Context())
+		ctx, cancel := context.WithCancel(parent)
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(parent, timeout)
+		}
+		stop := context.AfterFunc(base, cancel)
+		defer func() {
+			stop()
+			cancel()
+		}()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"status": "error", "message": "Waktu pemrosesan request habis"})
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+)
+
+func TestTimeout_GroupOverridesGlobalDeadline(t *testing.T) {
+	router := gin.New()
+	router.Use(RequestContextMiddleware(), TimeoutMiddleware(50*time.Millisecond))
+
+	var shortDeadline, longDeadline time.Duration
+	router.GET("/short", func(c *gin.Context) {
+		deadline, _ := c.Request.Context().Deadline()
+		shortDeadline = time.Until(deadline)
+		c.Status(http.StatusOK)
+	})
+	admin := router.Group("/admin")
+	admin.Use(TimeoutMiddleware(time.Minute))
+	var info reqctx.Info
+	admin.GET("/long", func(c *gin.Context) {
+		deadline, _ := c.Request.Context().Deadline()
+		longDeadline = time.Until(deadline)
+		info = reqctx.From(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/short", "/admin/long"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if shortDeadline <= 0 || shortDeadline > 50*time.Millisecond {
+		t.Errorf("Route publik harus memakai batas global 50ms, didapat %v", shortDeadline)
+	}
+	if longDeadline < 50*time.Second {
+		t.Errorf("Route group harus bisa memperpanjang batas waktu, didapat %v", longDeadline)
+	}
+	if info.RequestID == "" {
+		t.Error("Nilai context (request id) harus tetap terbawa setelah batas waktu diganti")
+	}
+}
+
+func TestTimeout_CancelsQueryAndReturnsGatewayTimeout(t *testing.T) {
+	router := gin.New()
+	router.Use(TimeoutMiddleware(20 * time.Millisecond))
+	router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done() // Meniru query database yang dibatalkan lewat ctx
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/slow", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("Request yang melewati batas waktu harus 504, didapat %d", w.Code)
+	}
+}
+
+func TestTimeout_PropagatesClientCancellation(t *testing.T) {
+	router := gin.New()
+	router.Use(TimeoutMiddleware(time.Minute))
+	group := router.Group("/g")
+	group.Use(TimeoutMiddleware(0))
+
+	var err error
+	group.GET("/wait", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		err = c.Request.Context().Err()
+		c.Status(http.StatusOK)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/g/wait", nil)
+	time.AfterFunc(10*time.Millisecond, cancel)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if err != context.Canceled {
+		t.Errorf("Pembatalan dari klien harus diteruskan ke handler, didapat %v", err)
+	}
+}
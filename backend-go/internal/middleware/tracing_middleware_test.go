@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupTracedRouter memasang TracerProvider yang merekam span ke memori
+func setupTracedRouter(t *testing.T) (*gin.Engine, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	router := gin.New()
+	router.Use(TracingMiddleware("test-api"))
+	router.GET("/api/v1/products/:id", func(c *gin.Context) {
+		if c.Param("id") == "tidak-ada" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Produk tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+	})
+	router.GET("/livez", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router, recorder
+}
+
+func TestTracingMiddleware_AddsTraceIDToErrorResponse(t *testing.T) {
+	router, recorder := setupTracedRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/products/tidak-ada", nil))
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Body error harus tetap JSON valid: %v (%s)", err, w.Body.String())
+	}
+	traceID := w.Header().Get(tracing.HeaderTraceID)
+	if traceID == "" || body["trace_id"] != traceID {
+		t.Errorf("trace_id di body (%v) harus sama dengan header X-Trace-ID (%q)", body["trace_id"], traceID)
+	}
+	if body["error"] != "Produk tidak ditemukan" {
+		t.Errorf("Isi body asli harus dipertahankan, didapat %v", body)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "GET /api/v1/products/:id" || spans[0].SpanContext().TraceID().String() != traceID {
+		t.Errorf("Seharusnya satu span bernama route template dengan trace id yang sama, didapat %d span", len(spans))
+	}
+}
+
+func TestTracingMiddleware_LeavesSuccessBodyAndProbes(t *testing.T) {
+	router, recorder := setupTracedRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/products/p-1", nil))
+	if w.Body.String() != `{"id":"p-1"}` {
+		t.Errorf("Respons sukses tidak boleh diubah, didapat %s", w.Body.String())
+	}
+	if w.Header().Get(tracing.HeaderTraceID) == "" {
+		t.Error("Header X-Trace-ID tetap dikirim untuk respons sukses")
+	}
+
+	// Trace id dari upstream (traceparent) diteruskan
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/products/p-2", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if got := w.Header().Get(tracing.HeaderTraceID); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Trace id dari traceparent harus dipakai, didapat %q", got)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if got := len(recorder.Ended()); got != 2 {
+		t.Errorf("Probe /livez tidak boleh membuat span, didapat %d span", got)
+	}
+}
@@ -1,10 +1,18 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 	"gorm.io/gorm"
 )
 
+// auditChainLockKey adalah kunci pg_advisory_xact_lock untuk penulisan audit log. Penulisan
+// diserialkan agar setiap baris merujuk hash baris sebelumnya dan rantai tidak bercabang.
+const auditChainLockKey = 4100421
+
 type auditLogRepository struct {
 	db *gorm.DB
 }
@@ -13,12 +21,100 @@ func NewAuditLogRepository(db *gorm.DB) domain.AuditLogRepository {
 	return &auditLogRepository{db: db}
 }
 
-func (r *auditLogRepository) Insert(log *domain.AuditLog) error {
-	return r.db.Create(log).Error
+// appendAuditLog menyambungkan log ke rantai hash lalu menyimpannya. Harus dipanggil di dalam
+// transaksi; advisory lock dilepas saat transaksi selesai.
+func appendAuditLog(tx *gorm.DB, log *domain.AuditLog) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+		return err
+	}
+	var last domain.AuditLog
+	if err := tx.Select("hash").Order("seq DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	log.CreatedAt = log.CreatedAt.Truncate(time.Microsecond)
+	log.PrevHash = last.Hash
+	log.Hash = log.ChainHash()
+	return tx.Omit("Actor").Create(log).Error
+}
+
+func (r *auditLogRepository) Insert(ctx context.Context, log *domain.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return appendAuditLog(tx, log)
+	})
+}
+
+func (r *auditLogRepository) FindByEntity(ctx context.Context, entity string, entityID string) ([]domain.AuditLog, error) {
+	var logs []domain.AuditLog
+	err := r.db.WithContext(ctx).Where("entity = ? AND entity_id = ?", entity, entityID).Order("created_at desc").Find(&logs).Error
+	return logs, err
 }
 
-func (r *auditLogRepository) FindByEntity(entity string, entityID string) ([]domain.AuditLog, error) {
+func (r *auditLogRepository) FindByEntityWithChildren(ctx context.Context, entity string, entityID string) ([]domain.AuditLog, error) {
 	var logs []domain.AuditLog
-	err := r.db.Where("entity = ? AND entity_id = ?", entity, entityID).Order("created_at desc").Find(&logs).Error
+	err := r.db.WithContext(ctx).Where("(entity = ? AND entity_id = ?) OR parent_id = ?", entity, entityID, entityID).
+		Order("created_at asc").Find(&logs).Error
 	return logs, err
 }
+
+func applyAuditLogFilter(query *gorm.DB, filter domain.AuditLogFilter) *gorm.DB {
+	if filter.UserID != "" {
+		query = query.Where("audit_logs.id_user = ?", filter.UserID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("audit_logs.request_id = ?", filter.RequestID)
+	}
+	if filter.Action != "" {
+		query = query.Where("audit_logs.action = ?", filter.Action)
+	}
+	if filter.Entity != "" {
+		query = query.Where("audit_logs.entity = ?", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("audit_logs.entity_id = ?", filter.EntityID)
+	}
+	if filter.From != nil {
+		query = query.Where("audit_logs.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("audit_logs.created_at <= ?", *filter.To)
+	}
+	return query
+}
+
+func (r *auditLogRepository) FindAll(ctx context.Context, filter domain.AuditLogFilter, page pagination.Params) ([]domain.AuditLog, string, error) {
+	var logs []domain.AuditLog
+	query := r.db.WithContext(ctx).Preload("Actor", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id_user", "nama", "email", "role") // Jangan ikut memuat hash password pelaku
+	})
+	query = pagination.Apply(applyAuditLogFilter(query, filter), page, "audit_logs.created_at", "audit_logs.id_audit_log")
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, "", err
+	}
+	logs, next := pagination.Trim(logs, page, func(l domain.AuditLog) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: l.CreatedAt, ID: l.ID}
+	})
+	return logs, next, nil
+}
+
+func (r *auditLogRepository) Stream(ctx context.Context, filter domain.AuditLogFilter, fn func(log *domain.AuditLog) error) error {
+	rows, err := applyAuditLogFilter(r.db.WithContext(ctx).Model(&domain.AuditLog{}), filter).Order("audit_logs.seq ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log domain.AuditLog
+		if err := r.db.WithContext(ctx).ScanRows(rows, &log); err != nil {
+			return err
+		}
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
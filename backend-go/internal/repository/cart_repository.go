@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
@@ -15,9 +16,9 @@ func NewCartRepository(db *gorm.DB) domain.CartRepository {
 	return &cartRepository{db: db}
 }
 
-func (r *cartRepository) UpsertItem(item *domain.CartItem) error {
+func (r *cartRepository) UpsertItem(ctx context.Context, item *domain.CartItem) error {
 	var existingItem domain.CartItem
-	query := r.db.Where("id_user = ? AND id_product = ?", item.UserID, item.ProductID)
+	query := r.db.WithContext(ctx).Where("id_user = ? AND id_product = ?", item.UserID, item.ProductID)
 	if item.VariantID != nil {
 		query = query.Where("id_variant = ?", *item.VariantID)
 	} else {
@@ -28,7 +29,7 @@ func (r *cartRepository) UpsertItem(item *domain.CartItem) error {
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// Jika belum ada, buat baru
-			return r.db.Create(item).Error
+			return r.db.WithContext(ctx).Create(item).Error
 		}
 		return err
 	}
@@ -36,23 +37,23 @@ func (r *cartRepository) UpsertItem(item *domain.CartItem) error {
 	// Jika sudah ada barang yang sama, tambahkan kuantitasnya
 	existingItem.Quantity += item.Quantity
 	existingItem.UpdatedAt = item.UpdatedAt
-	return r.db.Save(&existingItem).Error
+	return r.db.WithContext(ctx).Save(&existingItem).Error
 }
 
-func (r *cartRepository) UpdateItem(item *domain.CartItem) error {
-	return r.db.Save(item).Error
+func (r *cartRepository) UpdateItem(ctx context.Context, item *domain.CartItem) error {
+	return r.db.WithContext(ctx).Save(item).Error
 }
 
-func (r *cartRepository) FindByUserID(userID string) ([]domain.CartItem, error) {
+func (r *cartRepository) FindByUserID(ctx context.Context, userID string) ([]domain.CartItem, error) {
 	var items []domain.CartItem
 	// Preload Product and its Category, and also Variant to show complete details in Cart
-	err := r.db.Preload("Product.Category").Preload("Variant").Where("id_user = ?", userID).Find(&items).Error
+	err := r.db.WithContext(ctx).Preload("Product.Category").Preload("Variant").Where("id_user = ?", userID).Find(&items).Error
 	return items, err
 }
 
-func (r *cartRepository) FindByID(itemID string) (*domain.CartItem, error) {
+func (r *cartRepository) FindByID(ctx context.Context, itemID string) (*domain.CartItem, error) {
 	var item domain.CartItem
-	err := r.db.Where("id_cart_item = ?", itemID).First(&item).Error
+	err := r.db.WithContext(ctx).Where("id_cart_item = ?", itemID).First(&item).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("cart item tidak ditemukan")
@@ -62,11 +63,11 @@ func (r *cartRepository) FindByID(itemID string) (*domain.CartItem, error) {
 	return &item, nil
 }
 
-func (r *cartRepository) DeleteByUserID(userID string) error {
-	return r.db.Where("id_user = ?", userID).Delete(&domain.CartItem{}).Error
+func (r *cartRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("id_user = ?", userID).Delete(&domain.CartItem{}).Error
 }
 
-func (r *cartRepository) RemoveItem(itemID string, userID string) error {
+func (r *cartRepository) RemoveItem(ctx context.Context, itemID string, userID string) error {
 	// Pastikan user hanya menghapus miliknya sendiri
-	return r.db.Where("id_cart_item = ? AND id_user = ?", itemID, userID).Delete(&domain.CartItem{}).Error
+	return r.db.WithContext(ctx).Where("id_cart_item = ? AND id_user = ?", itemID, userID).Delete(&domain.CartItem{}).Error
 }
@@ -1,12 +1,21 @@
 package repository
 
 import (
+	"context"
 	"errors"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
 	"gorm.io/gorm"
 )
 
+// categoryTreeCTE mengumpulkan kategori (berdasarkan ID atau slug) beserta seluruh turunannya.
+// Dipakai juga oleh pencarian produk untuk filter kategori induk.
+const categoryTreeCTE = `WITH RECURSIVE category_tree AS (
+		SELECT id_category FROM categories WHERE (id_category = ? OR slug = ?) AND deleted_at IS NULL
+		UNION
+		SELECT c.id_category FROM categories c JOIN category_tree t ON c.parent_id = t.id_category WHERE c.deleted_at IS NULL
+	) SELECT id_category FROM category_tree`
+
 type categoryRepository struct {
 	db *gorm.DB
 }
@@ -15,19 +24,19 @@ func NewCategoryRepository(db *gorm.DB) domain.CategoryRepository {
 	return &categoryRepository{db: db}
 }
 
-func (r *categoryRepository) Create(category *domain.Category) error {
-	return r.db.Create(category).Error
+func (r *categoryRepository) Create(ctx context.Context, category *domain.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
 }
 
-func (r *categoryRepository) FindAll() ([]domain.Category, error) {
+func (r *categoryRepository) FindAll(ctx context.Context) ([]domain.Category, error) {
 	var categories []domain.Category
-	err := r.db.Find(&categories).Error
+	err := catalogRead(ctx, r.db).Order("sort_order ASC, name ASC").Find(&categories).Error
 	return categories, err
 }
 
-func (r *categoryRepository) FindByID(id string) (*domain.Category, error) {
+func (r *categoryRepository) FindByID(ctx context.Context, id string) (*domain.Category, error) {
 	var category domain.Category
-	err := r.db.Where("id_category = ?", id).First(&category).Error
+	err := r.db.WithContext(ctx).Where("id_category = ?", id).First(&category).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("kategori tidak ditemukan")
@@ -37,10 +46,46 @@ func (r *categoryRepository) FindByID(id string) (*domain.Category, error) {
 	return &category, nil
 }
 
-func (r *categoryRepository) Update(category *domain.Category) error {
-	return r.db.Save(category).Error
+func (r *categoryRepository) FindBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	var category domain.Category
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&category).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("kategori tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) FindDescendantIDs(ctx context.Context, id string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Raw(categoryTreeCTE, id, id).Scan(&ids).Error
+	return ids, err
+}
+
+func (r *categoryRepository) SlugExists(ctx context.Context, slug string, excludeID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Category{}).Where("slug = ? AND id_category <> ?", slug, excludeID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *categoryRepository) CountChildren(ctx context.Context, id string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *categoryRepository) CountProducts(ctx context.Context, id string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Product{}).Where("id_category = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *categoryRepository) Update(ctx context.Context, category *domain.Category) error {
+	return r.db.WithContext(ctx).Save(category).Error
 }
 
-func (r *categoryRepository) Delete(id string) error {
-	return r.db.Where("id_category = ?", id).Delete(&domain.Category{}).Error
+func (r *categoryRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id_category = ?", id).Delete(&domain.Category{}).Error
 }
@@ -1,21 +1,30 @@
 package repository
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type DisputeRepository interface {
-	CreateDispute(dispute *domain.Dispute) error
-	GetDisputeByID(id string) (*domain.Dispute, error)
-	GetDisputeByOrderID(orderID string) (*domain.Dispute, error)
-	GetDisputesByRole(role string, userID string) ([]domain.Dispute, error)
-	UpdateDisputeStatus(id string, status string, adminNote string) error
-	AssignCourier(disputeID string, courierID string) error
-	AddMessage(msg *domain.DisputeMessage) error
-	GetMessagesByDisputeID(disputeID string) ([]domain.DisputeMessage, error)
+	CreateDispute(ctx context.Context, dispute *domain.Dispute) error
+	GetDisputeByID(ctx context.Context, id string) (*domain.Dispute, error)
+	GetDisputeByOrderID(ctx context.Context, orderID string) (*domain.Dispute, error)
+	GetDisputesByRole(ctx context.Context, role string, userID string, page pagination.Params) ([]domain.Dispute, string, error)
+	UpdateDisputeStatus(ctx context.Context, id string, status string, adminNote string) error
+	AssignCourier(ctx context.Context, disputeID string, courierID string) error
+	// MarkReturned menandai sengketa dan pesanannya RETURNED dalam satu transaksi
+	MarkReturned(ctx context.Context, disputeID string, orderID string, note string) error
+	AddMessage(ctx context.Context, msg *domain.DisputeMessage) error
+	GetMessagesByDisputeID(ctx context.Context, disputeID string) ([]domain.DisputeMessage, error)
+	// InspectReturn menyimpan hasil inspeksi barang retur, mengembalikan stok yang di-restock,
+	// dan menulis audit log dalam satu transaksi
+	InspectReturn(ctx context.Context, disputeID string, items []domain.DisputeReturnItem, audit *domain.AuditLog) error
 }
 
 type disputeRepository struct {
@@ -26,77 +35,152 @@ func NewDisputeRepository(db *gorm.DB) DisputeRepository {
 	return &disputeRepository{db}
 }
 
-func (r *disputeRepository) CreateDispute(dispute *domain.Dispute) error {
-	return r.db.Create(dispute).Error
+func (r *disputeRepository) CreateDispute(ctx context.Context, dispute *domain.Dispute) error {
+	return r.db.WithContext(ctx).Create(dispute).Error
 }
 
-func (r *disputeRepository) GetDisputeByID(id string) (*domain.Dispute, error) {
+func (r *disputeRepository) GetDisputeByID(ctx context.Context, id string) (*domain.Dispute, error) {
 	var dispute domain.Dispute
-	err := r.db.Preload("Order").Preload("Buyer").First(&dispute, "id_dispute = ?", id).Error
+	err := r.db.WithContext(ctx).Preload("Order").Preload("Buyer").Preload("ReturnItems").First(&dispute, "id_dispute = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &dispute, nil
 }
 
-func (r *disputeRepository) GetDisputeByOrderID(orderID string) (*domain.Dispute, error) {
+func (r *disputeRepository) GetDisputeByOrderID(ctx context.Context, orderID string) (*domain.Dispute, error) {
 	var dispute domain.Dispute
-	err := r.db.Preload("Order").Preload("Buyer").First(&dispute, "id_order = ?", orderID).Error
+	err := r.db.WithContext(ctx).Preload("Order").Preload("Buyer").First(&dispute, "id_order = ?", orderID).Error
 	if err != nil {
 		return nil, err
 	}
 	return &dispute, nil
 }
 
-func (r *disputeRepository) GetDisputesByRole(role string, userID string) ([]domain.Dispute, error) {
+func (r *disputeRepository) GetDisputesByRole(ctx context.Context, role string, userID string, page pagination.Params) ([]domain.Dispute, string, error) {
 	var disputes []domain.Dispute
-	query := r.db.Preload("Order").Preload("Order.OrderItems").Preload("Order.OrderItems.Product").Preload("Buyer")
-
-	log.Printf("[DEBUG-DISPUTE-REPO] Role: %s, UserID: %s", role, userID)
+	query := r.db.WithContext(ctx).Preload("Order").Preload("Order.Items").Preload("Order.Items.Product").Preload("Buyer")
 
 	switch role {
 	case "pembeli":
 		query = query.Where("id_buyer = ?", userID)
 	case "supplier":
-		// Mencari Sengketa (Dispute) di mana Order bersangkutan memuat produk milik Supplier ini
-		query = query.Joins("JOIN orders ON disputes.id_order = orders.id_order").
-			Joins("JOIN order_items ON orders.id_order = order_items.id_order").
-			Joins("JOIN products ON order_items.id_product = products.id_product").
-			Where("products.supplier_id = ?", userID).Distinct("disputes.id_dispute")
+		// Mencari Sengketa (Dispute) di mana Order bersangkutan memuat produk milik Supplier ini.
+		// Memakai EXISTS (bukan JOIN + DISTINCT) agar satu sengketa tidak muncul ganda dan urutan cursor tetap valid.
+		query = query.Where(`EXISTS (SELECT 1 FROM order_items oi
+			JOIN products p ON p.id_product = oi.id_product
+			WHERE oi.id_order = disputes.id_order AND p.supplier_id = ?)`, userID)
 	case "admin":
 		// Admin melihat seluruh komplain masuk
 	case "courier":
 		// Kurir melihat komplain yang butuh di-pickup ATAU yang dia bawa
 		query = query.Where("status = 'APPROVED_FOR_RETURN' OR disputes.courier_id = ?", userID)
 	default:
-		return nil, gorm.ErrRecordNotFound
+		return nil, "", gorm.ErrRecordNotFound
 	}
 
-	err := query.Order("created_at desc").Find(&disputes).Error
-	return disputes, err
+	if err := pagination.Apply(query, page, "disputes.created_at", "disputes.id_dispute").Find(&disputes).Error; err != nil {
+		return nil, "", err
+	}
+	disputes, nextCursor := pagination.Trim(disputes, page, func(d domain.Dispute) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: d.CreatedAt, ID: d.ID}
+	})
+	return disputes, nextCursor, nil
 }
 
-func (r *disputeRepository) UpdateDisputeStatus(id string, status string, adminNote string) error {
+func (r *disputeRepository) UpdateDisputeStatus(ctx context.Context, id string, status string, adminNote string) error {
 	updates := map[string]interface{}{"status": status}
 	if adminNote != "" {
 		updates["admin_note"] = adminNote
 	}
-	return r.db.Model(&domain.Dispute{}).Where("id_dispute = ?", id).Updates(updates).Error
+	return r.db.WithContext(ctx).Model(&domain.Dispute{}).Where("id_dispute = ?", id).Updates(updates).Error
 }
 
-func (r *disputeRepository) AssignCourier(disputeID string, courierID string) error {
-	return r.db.Model(&domain.Dispute{}).Where("id_dispute = ?", disputeID).Updates(map[string]interface{}{
+func (r *disputeRepository) AssignCourier(ctx context.Context, disputeID string, courierID string) error {
+	return r.db.WithContext(ctx).Model(&domain.Dispute{}).Where("id_dispute = ?", disputeID).Updates(map[string]interface{}{
 		"courier_id": courierID,
 		"status":     "RETURNING",
 	}).Error
 }
 
-func (r *disputeRepository) AddMessage(msg *domain.DisputeMessage) error {
-	return r.db.Create(msg).Error
+func (r *disputeRepository) MarkReturned(ctx context.Context, disputeID string, orderID string, note string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Dispute{}).Where("id_dispute = ?", disputeID).
+			Updates(map[string]interface{}{"status": "RETURNED", "admin_note": note}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Order{}).Where("id_order = ?", orderID).Update("status", "RETURNED").Error; err != nil {
+			return fmt.Errorf("gagal memperbarui status pesanan %s: %w", orderID, err)
+		}
+		return nil
+	})
+}
+
+func (r *disputeRepository) AddMessage(ctx context.Context, msg *domain.DisputeMessage) error {
+	return r.db.WithContext(ctx).Create(msg).Error
 }
 
-func (r *disputeRepository) GetMessagesByDisputeID(disputeID string) ([]domain.DisputeMessage, error) {
+func (r *disputeRepository) GetMessagesByDisputeID(ctx context.Context, disputeID string) ([]domain.DisputeMessage, error) {
 	var messages []domain.DisputeMessage
-	err := r.db.Preload("Sender").Where("id_dispute = ?", disputeID).Order("created_at asc").Find(&messages).Error
+	err := r.db.WithContext(ctx).Preload("Sender").Where("id_dispute = ?", disputeID).Order("created_at asc").Find(&messages).Error
 	return messages, err
 }
+
+func (r *disputeRepository) InspectReturn(ctx context.Context, disputeID string, items []domain.DisputeReturnItem, audit *domain.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Kunci baris sengketa agar dua inspeksi paralel tidak me-restock item yang sama dua kali
+		var dispute domain.Dispute
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&dispute, "id_dispute = ?", disputeID).Error; err != nil {
+			return errors.New("sengketa tidak ditemukan")
+		}
+		if dispute.Status != "RETURNED" {
+			return errors.New("barang retur belum diterima, sengketa harus berstatus RETURNED")
+		}
+
+		for _, item := range items {
+			var count int64
+			if err := tx.Model(&domain.DisputeReturnItem{}).
+				Where("id_dispute = ? AND id_order_item = ?", disputeID, item.OrderItemID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return fmt.Errorf("item pesanan %s sudah pernah diinspeksi", item.OrderItemID)
+			}
+
+			if item.RestockedQuantity > 0 {
+				if item.VariantID != nil {
+					if err := tx.Unscoped().Model(&domain.ProductVariant{}).
+						Where("id_variant = ?", *item.VariantID).
+						Update("stock", gorm.Expr("stock + ?", item.RestockedQuantity)).Error; err != nil {
+						return fmt.Errorf("gagal restock varian %s: %w", *item.VariantID, err)
+					}
+				} else {
+					if err := tx.Model(&domain.Product{}).
+						Where("id_product = ?", item.ProductID).
+						Update("stock", gorm.Expr("stock + ?", item.RestockedQuantity)).Error; err != nil {
+						return fmt.Errorf("gagal restock produk %s: %w", item.ProductID, err)
+					}
+				}
+				inspectedBy := item.InspectedBy
+				if err := recordStockMovement(tx, domain.StockMovement{
+					ProductID: item.ProductID, VariantID: item.VariantID, Delta: item.RestockedQuantity,
+					Reason: "RETURN_RESTOCK", OrderID: &dispute.OrderID, DisputeID: &disputeID, UserID: &inspectedBy,
+				}); err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+
+		if audit != nil {
+			if err := appendAuditLog(tx, audit); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
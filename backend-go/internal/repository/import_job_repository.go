@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"gorm.io/gorm"
+)
+
+type importJobRepository struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepository(db *gorm.DB) domain.ImportJobRepository {
+	return &importJobRepository{db: db}
+}
+
+func (r *importJobRepository) Create(ctx context.Context, job *domain.ImportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *importJobRepository) Update(ctx context.Context, job *domain.ImportJob) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
+
+func (r *importJobRepository) FailUnfinished(ctx context.Context, message string, finishedAt time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&domain.ImportJob{}).
+		Where("status IN ?", []string{domain.ImportStatusPending, domain.ImportStatusRunning}).
+		Updates(map[string]interface{}{"status": domain.ImportStatusFailed, "message": message, "finished_at": finishedAt})
+	return result.RowsAffected, result.Error
+}
+
+func (r *importJobRepository) FindByID(ctx context.Context, jobID string) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	if err := r.db.WithContext(ctx).Where("id_job = ?", jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("job impor tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &job, nil
+}
@@ -1,12 +1,14 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -20,49 +22,21 @@ func NewOrderRepository(db *gorm.DB) domain.OrderRepository {
 }
 
 // CheckoutTransaction mengeksekusi perpindahan Cart -> Order secara Atomik (ACID)
-func (r *orderRepository) CheckoutTransaction(userID string, cartItems []domain.CartItem, voucherCode string) (*domain.Order, error) {
+func (r *orderRepository) CheckoutTransaction(ctx context.Context, userID string, cartItems []domain.CartItem, voucherCode string, expiresAt time.Time) (*domain.Order, error) {
 	var createdOrder domain.Order
 
 	// Memulai Database Transaction
-	err := r.db.Transaction(func(tx *gorm.DB) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var totalAmount float64
 		var orderItems []domain.OrderItem
 
 		orderID := uuid.New().String()
 
-		// 1. Validasi Stok dan Kumpulkan Total Harga
+		// 1. Validasi Stok Tersedia, Reservasi Stok, dan Kumpulkan Total Harga
 		for _, item := range cartItems {
-			var product domain.Product
-			// PENTING SQA: Kita ambil produk terbaru dari database dalam transaksi ini, ditambah LOCKING (FOR UPDATE)
-			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_product = ?", item.ProductID).First(&product).Error; err != nil {
-				return errors.New("produk " + item.ProductID + " tidak ditemukan")
-			}
-
-			priceAtPurchase := product.Price
-
-			if item.VariantID != nil {
-				var variant domain.ProductVariant
-				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_variant = ?", *item.VariantID).First(&variant).Error; err != nil {
-					return errors.New("varian produk tidak ditemukan")
-				}
-				if variant.Stock < item.Quantity {
-					return fmt.Errorf("stok varian '%s' tidak mencukupi. Stok tersisa: %d", variant.NameLabel, variant.Stock)
-				}
-				variant.Stock -= item.Quantity
-				if err := tx.Save(&variant).Error; err != nil {
-					return err
-				}
-				priceAtPurchase = variant.Price
-			} else {
-				// PENTING SQA: Race condition check. Pastikan stok tidak minus saat di checkout bersamaan
-				if product.Stock < item.Quantity {
-					return fmt.Errorf("stok produk '%s' tidak mencukupi. Stok tersisa: %d", product.Name, product.Stock)
-				}
-				// Potong Stok
-				product.Stock -= item.Quantity
-				if err := tx.Save(&product).Error; err != nil {
-					return err
-				}
+			product, priceAtPurchase, err := reserveStock(tx, item)
+			if err != nil {
+				return err
 			}
 
 			// Hitung subtotal dan buat record Item Pesanan (Snapshot harga saat ini)
@@ -133,6 +107,7 @@ func (r *orderRepository) CheckoutTransaction(userID string, cartItems []domain.
 			Status:         "PENDING",
 			DiscountAmount: discount,
 			VoucherCode:    appliedVoucher,
+			ExpiresAt:      &expiresAt,
 			CreatedAt:      time.Now(),
 			UpdatedAt:      time.Now(),
 		}
@@ -141,10 +116,14 @@ func (r *orderRepository) CheckoutTransaction(userID string, cartItems []domain.
 			return err
 		}
 
-		// 3. Masukkan semua item ke order_items
+		// 3. Masukkan semua item ke order_items, lalu aktifkan reservasi stoknya
 		if err := tx.Create(&orderItems).Error; err != nil {
 			return err
 		}
+		if err := createReservations(tx, orderItems, expiresAt); err != nil {
+			return err
+		}
+		createdOrder.Items = orderItems
 
 		// 4. Kosongkan keranjang belanja user ini
 		if err := tx.Where("id_user = ?", userID).Delete(&domain.CartItem{}).Error; err != nil {
@@ -163,43 +142,18 @@ func (r *orderRepository) CheckoutTransaction(userID string, cartItems []domain.
 }
 
 // InstantCheckoutTransaction mengeksekusi perpindahan Direct Buy secara Atomik (ACID)
-func (r *orderRepository) InstantCheckoutTransaction(userID string, item domain.CartItem, voucherCode string) (*domain.Order, error) {
+func (r *orderRepository) InstantCheckoutTransaction(ctx context.Context, userID string, item domain.CartItem, voucherCode string, expiresAt time.Time) (*domain.Order, error) {
 	var createdOrder domain.Order
 
-	err := r.db.Transaction(func(tx *gorm.DB) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var totalAmount float64
 		var orderItem domain.OrderItem
 
 		orderID := uuid.New().String()
 
-		var product domain.Product
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_product = ?", item.ProductID).First(&product).Error; err != nil {
-			return errors.New("produk tidak ditemukan")
-		}
-
-		priceAtPurchase := product.Price
-
-		if item.VariantID != nil {
-			var variant domain.ProductVariant
-			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_variant = ?", *item.VariantID).First(&variant).Error; err != nil {
-				return errors.New("varian tidak ditemukan")
-			}
-			if variant.Stock < item.Quantity {
-				return fmt.Errorf("stok varian '%s' tidak mencukupi", variant.NameLabel)
-			}
-			variant.Stock -= item.Quantity
-			if err := tx.Save(&variant).Error; err != nil {
-				return err
-			}
-			priceAtPurchase = variant.Price
-		} else {
-			if product.Stock < item.Quantity {
-				return fmt.Errorf("stok produk '%s' tidak mencukupi", product.Name)
-			}
-			product.Stock -= item.Quantity
-			if err := tx.Save(&product).Error; err != nil {
-				return err
-			}
+		product, priceAtPurchase, err := reserveStock(tx, item)
+		if err != nil {
+			return err
 		}
 
 		totalAmount = priceAtPurchase * float64(item.Quantity)
@@ -264,6 +218,7 @@ func (r *orderRepository) InstantCheckoutTransaction(userID string, item domain.
 			Status:         "PENDING",
 			DiscountAmount: discount,
 			VoucherCode:    appliedVoucher,
+			ExpiresAt:      &expiresAt,
 			CreatedAt:      time.Now(),
 			UpdatedAt:      time.Now(),
 		}
@@ -275,6 +230,10 @@ func (r *orderRepository) InstantCheckoutTransaction(userID string, item domain.
 		if err := tx.Create(&orderItem).Error; err != nil {
 			return err
 		}
+		if err := createReservations(tx, []domain.OrderItem{orderItem}, expiresAt); err != nil {
+			return err
+		}
+		createdOrder.Items = []domain.OrderItem{orderItem}
 
 		// TIDAK ada penghapusan dari keranjang (Bypass Cart)
 		return nil
@@ -287,16 +246,25 @@ func (r *orderRepository) InstantCheckoutTransaction(userID string, item domain.
 	return &createdOrder, nil
 }
 
-func (r *orderRepository) FindByUserID(userID string) ([]domain.Order, error) {
+// orderCursor adalah kunci urutan halaman pesanan (created_at, id_order)
+func orderCursor(o domain.Order) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: o.CreatedAt, ID: o.ID}
+}
+
+func (r *orderRepository) FindByUserID(ctx context.Context, userID string, page pagination.Params) ([]domain.Order, string, error) {
 	var orders []domain.Order
 	// Tampilkan history tanpa perlu load detail item (untuk efisiensi listing)
-	err := r.db.Where("id_user = ?", userID).Order("created_at desc").Find(&orders).Error
-	return orders, err
+	query := r.db.WithContext(ctx).Where("id_user = ?", userID)
+	if err := pagination.Apply(query, page, "created_at", "id_order").Find(&orders).Error; err != nil {
+		return nil, "", err
+	}
+	orders, nextCursor := pagination.Trim(orders, page, orderCursor)
+	return orders, nextCursor, nil
 }
 
-func (r *orderRepository) FindByID(orderID string) (*domain.Order, error) {
+func (r *orderRepository) FindByID(ctx context.Context, orderID string) (*domain.Order, error) {
 	var order domain.Order
-	err := r.db.Preload("Items.Product").Where("id_order = ?", orderID).First(&order).Error
+	err := r.db.WithContext(ctx).Preload("Items.Product").Where("id_order = ?", orderID).First(&order).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("pesanan tidak ditemukan")
@@ -308,36 +276,36 @@ func (r *orderRepository) FindByID(orderID string) (*domain.Order, error) {
 
 // [B4] FindByIDs mengambil banyak pesanan sekaligus dengan satu query SQL IN
 // Menggantikan pola N+1 loop FindByID yang sebelumnya dipakai di BatchProcessSupplierOrders
-func (r *orderRepository) FindByIDs(orderIDs []string) ([]domain.Order, error) {
+func (r *orderRepository) FindByIDs(ctx context.Context, orderIDs []string) ([]domain.Order, error) {
 	var orders []domain.Order
-	err := r.db.Preload("Items.Product").
+	err := r.db.WithContext(ctx).Preload("Items.Product").
 		Where("id_order IN ?", orderIDs).
 		Find(&orders).Error
 	return orders, err
 }
 
-func (r *orderRepository) UpdateStatus(orderID string, status string) error {
-	return r.db.Model(&domain.Order{}).Where("id_order = ?", orderID).Update("status", status).Error
+func (r *orderRepository) UpdateStatus(ctx context.Context, orderID string, status string) error {
+	return r.db.WithContext(ctx).Model(&domain.Order{}).Where("id_order = ?", orderID).Update("status", status).Error
 }
 
 // FindPaidOrders mengembalikan pesanan dengan status PAID (siap diambil kurir)
-func (r *orderRepository) FindPaidOrders() ([]domain.Order, error) {
+func (r *orderRepository) FindPaidOrders(ctx context.Context) ([]domain.Order, error) {
 	var orders []domain.Order
-	err := r.db.Preload("Items.Product").Where("status = ?", "PAID").Order("created_at desc").Find(&orders).Error
+	err := r.db.WithContext(ctx).Preload("Items.Product").Where("status = ?", "PAID").Order("created_at desc").Find(&orders).Error
 	return orders, err
 }
 
 // FindProcessedOrders mengembalikan pesanan yang sudah di PROCESSED Supplier (Siap Antar)
-func (r *orderRepository) FindProcessedOrders() ([]domain.Order, error) {
+func (r *orderRepository) FindProcessedOrders(ctx context.Context) ([]domain.Order, error) {
 	var orders []domain.Order
-	err := r.db.Preload("Items.Product").Where("status = ?", "PROCESSED").Order("created_at desc").Find(&orders).Error
+	err := r.db.WithContext(ctx).Preload("Items.Product").Where("status = ?", "PROCESSED").Order("created_at desc").Find(&orders).Error
 	return orders, err
 }
 
 // AssignCourier meng-assign kurir ke pesanan dan set status SHIPPED
-func (r *orderRepository) AssignCourier(orderID string, courierID string) error {
+func (r *orderRepository) AssignCourier(ctx context.Context, orderID string, courierID string) error {
 	now := time.Now()
-	return r.db.Model(&domain.Order{}).Where("id_order = ?", orderID).Updates(map[string]interface{}{
+	return r.db.WithContext(ctx).Model(&domain.Order{}).Where("id_order = ?", orderID).Updates(map[string]interface{}{
 		"courier_id": courierID,
 		"status":     "SHIPPED",
 		"shipped_at": now,
@@ -346,75 +314,364 @@ func (r *orderRepository) AssignCourier(orderID string, courierID string) error
 }
 
 // FindByCourierID mengembalikan pesanan milik kurir tertentu
-func (r *orderRepository) FindByCourierID(courierID string) ([]domain.Order, error) {
+func (r *orderRepository) FindByCourierID(ctx context.Context, courierID string) ([]domain.Order, error) {
 	var orders []domain.Order
-	err := r.db.Preload("Items.Product").Where("courier_id = ?", courierID).Order("created_at desc").Find(&orders).Error
+	err := r.db.WithContext(ctx).Preload("Items.Product").Where("courier_id = ?", courierID).Order("created_at desc").Find(&orders).Error
 	return orders, err
 }
 
 // FindByProductSupplier mengembalikan pesanan yang mengandung produk milik supplier
-func (r *orderRepository) FindByProductSupplier(supplierID string) ([]domain.Order, error) {
+func (r *orderRepository) FindByProductSupplier(ctx context.Context, supplierID string, page pagination.Params) ([]domain.Order, string, error) {
 	var orders []domain.Order
-	err := r.db.Preload("Items.Product").
+	query := r.db.WithContext(ctx).Preload("Items.Product").
 		Joins("JOIN order_items ON order_items.id_order = orders.id_order").
 		Joins("JOIN products ON products.id_product = order_items.id_product").
 		Where("products.supplier_id = ?", supplierID).
-		Group("orders.id_order").
-		Order("orders.created_at desc").
-		Find(&orders).Error
-	return orders, err
+		Group("orders.id_order")
+	if err := pagination.Apply(query, page, "orders.created_at", "orders.id_order").Find(&orders).Error; err != nil {
+		return nil, "", err
+	}
+	orders, nextCursor := pagination.Trim(orders, page, orderCursor)
+	return orders, nextCursor, nil
 }
 
-// CancelExpiredOrders membatalkan pesanan tertinggal (PENDING) dan memulihkan stok.
-// [A2 SQA FIX]: Restock varian (ProductVariant) juga direstorasi, tidak hanya produk biasa.
-func (r *orderRepository) CancelExpiredOrders(cutoffTime time.Time) (int, error) {
+// CancelExpiredOrders meng-EXPIRED-kan pesanan PENDING yang batas bayarnya (expires_at) sudah lewat
+// dan melepas reservasi stoknya. Stok fisik tidak disentuh karena belum pernah dipotong.
+// Pesanan lama tanpa expires_at (dibuat sebelum reservasi ada) tetap memakai legacyCutoff dan
+// stoknya dikembalikan seperti semula karena dulu stok langsung dipotong saat checkout.
+func (r *orderRepository) CancelExpiredOrders(ctx context.Context, now time.Time, legacyCutoff time.Time) (int, error) {
 	var canceledCount int
-	err := r.db.Transaction(func(tx *gorm.DB) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var expiredOrders []domain.Order
-		// Cari semua order PENDING yang usianya sudah lebih lama dari cutoffTime
-		// Preload Items.Variant agar kita juga bisa mengembalikan stok varian
-		if err := tx.Preload("Items").Where("status = ? AND created_at < ?", "PENDING", cutoffTime).Find(&expiredOrders).Error; err != nil {
+		if err := tx.Preload("Items").
+			Where("status = ?", "PENDING").
+			Where("expires_at <= ? OR (expires_at IS NULL AND created_at < ?)", now, legacyCutoff).
+			Find(&expiredOrders).Error; err != nil {
 			return err
 		}
 
 		for _, order := range expiredOrders {
-			// Ubah status order menjadi EXPIRED
-			if err := tx.Model(&order).Update("status", "EXPIRED").Error; err != nil {
+			if err := releaseOrder(tx, &order, "EXPIRED", now); err != nil {
 				return err
 			}
-
-			// [A2] Pulihkan stok untuk setiap item — bedakan antara varian dan produk biasa
-			for _, item := range order.Items {
-				if item.VariantID != nil {
-					// Item memiliki varian: kembalikan stok ke tabel product_variants
-					if err := tx.Model(&domain.ProductVariant{}).
-						Where("id_variant = ?", *item.VariantID).
-						Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
-						return fmt.Errorf("gagal restorasi stok varian %s: %w", *item.VariantID, err)
-					}
-				} else {
-					// Item produk biasa tanpa varian: kembalikan stok ke tabel products
-					if err := tx.Model(&domain.Product{}).
-						Where("id_product = ?", item.ProductID).
-						Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
-						return fmt.Errorf("gagal restorasi stok produk %s: %w", item.ProductID, err)
-					}
-				}
-			}
 			canceledCount++
 		}
-		// Selesai memodifikasi. Otomatis ter-Commit oleh return nil Gorm Transaction
 		return nil
 	})
 
 	return canceledCount, err
 }
 
+// payableStatuses adalah status pesanan yang masih boleh menerima konfirmasi pembayaran
+var payableStatuses = map[string]bool{"PENDING": true, "EXPIRED": true, domain.OrderStatusPaymentReview: true}
+
+// ConfirmPayment menandai pesanan PAID dan mengonsumsi reservasinya: stok fisik baru dipotong di sini.
+// Baris pesanan dikunci lebih dulu; pesanan di luar payableStatuses (mis. settlement ganda untuk pesanan
+// yang sudah PROCESSED) ditolak dengan domain.ErrOrderNotPayable tanpa perubahan apa pun.
+// Reservasi yang sudah RELEASED (pembayaran terlambat) hanya dikonsumsi jika stok tersedia masih cukup,
+// dan stok fisik yang kurang saat dipotong juga membatalkan seluruh pemotongan. Dalam kedua kasus
+// pesanan dipindah ke PAYMENT_REVIEW agar admin memproses refund. Status akhir pesanan dikembalikan.
+func (r *orderRepository) ConfirmPayment(ctx context.Context, orderID string) (string, error) {
+	status := domain.OrderStatusPaid
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id_order", "status").
+			Where("id_order = ?", orderID).First(&order).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("pesanan tidak ditemukan")
+			}
+			return err
+		}
+		if !payableStatuses[order.Status] {
+			return fmt.Errorf("%w (status %s)", domain.ErrOrderNotPayable, order.Status)
+		}
+
+		var reservations []domain.StockReservation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id_order = ? AND status IN ?", orderID, []string{"ACTIVE", "RELEASED"}).
+			Find(&reservations).Error; err != nil {
+			return err
+		}
+
+		// Stok yang dilepas sudah bisa dibeli pesanan lain: periksa ulang sebelum memotong
+		for _, res := range reservations {
+			if res.Status != "RELEASED" {
+				continue
+			}
+			available, err := lockAvailableStock(tx, res.ProductID, res.VariantID)
+			if err != nil {
+				return err
+			}
+			if available < res.Quantity {
+				status = domain.OrderStatusPaymentReview
+				return tx.Model(&order).Update("status", status).Error
+			}
+		}
+
+		// Savepoint: jika salah satu item kekurangan stok fisik, pemotongan item sebelumnya ikut dibatalkan
+		err := tx.Transaction(func(inner *gorm.DB) error {
+			for _, res := range reservations {
+				if err := decrementStock(inner, res.ProductID, res.VariantID, res.Quantity, orderID); err != nil {
+					return err
+				}
+			}
+			if len(reservations) == 0 {
+				return nil
+			}
+			return inner.Model(&domain.StockReservation{}).
+				Where("id_order = ? AND status IN ?", orderID, []string{"ACTIVE", "RELEASED"}).
+				Updates(map[string]interface{}{"status": "CONSUMED", "updated_at": time.Now()}).Error
+		})
+		if errors.Is(err, errInsufficientStock) {
+			status = domain.OrderStatusPaymentReview
+		} else if err != nil {
+			return err
+		}
+
+		return tx.Model(&order).Update("status", status).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// lockAvailableStock mengunci baris produk/varian lalu mengembalikan stok fisik dikurangi reservasi ACTIVE
+func lockAvailableStock(tx *gorm.DB, productID string, variantID *string) (int, error) {
+	var stock int
+	var err error
+	if variantID != nil {
+		err = tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Model(&domain.ProductVariant{}).
+			Where("id_variant = ?", *variantID).Select("stock").Row().Scan(&stock)
+	} else {
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&domain.Product{}).
+			Where("id_product = ?", productID).Select("stock").Row().Scan(&stock)
+	}
+	if err != nil {
+		return 0, err
+	}
+	reserved, err := sumActiveReservations(tx, productID, variantID)
+	if err != nil {
+		return 0, err
+	}
+	return stock - reserved, nil
+}
+
+// CancelOrder membatalkan pesanan sesuai status saat ini (baris pesanan dikunci lebih dulu):
+//   - PENDING: reservasi dilepas (lihat releaseOrder)
+//   - PAID: stok sudah dipotong saat pembayaran, jadi dikembalikan beserta catatan ORDER_CANCELLED_RESTOCK
+//   - EXPIRED / PAYMENT_REVIEW: tidak memegang stok, cukup ubah statusnya
+//   - status tujuan yang sama: tidak ada perubahan (webhook ulang)
+//
+// Pesanan yang sudah diproses supplier atau dikirim ditolak dengan domain.ErrOrderNotCancellable.
+func (r *orderRepository) CancelOrder(ctx context.Context, orderID string, status string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_order = ?", orderID).First(&order).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("pesanan tidak ditemukan")
+			}
+			return err
+		}
+		if err := tx.Where("id_order = ?", orderID).Find(&order.Items).Error; err != nil {
+			return err
+		}
+
+		switch order.Status {
+		case status:
+			return nil
+		case "PENDING":
+			return releaseOrder(tx, &order, status, time.Now())
+		case domain.OrderStatusPaid:
+			if err := tx.Model(&order).Update("status", status).Error; err != nil {
+				return err
+			}
+			return restockOrderItems(tx, &order)
+		case "EXPIRED", domain.OrderStatusPaymentReview:
+			return tx.Model(&order).Update("status", status).Error
+		default:
+			return fmt.Errorf("%w (status %s)", domain.ErrOrderNotCancellable, order.Status)
+		}
+	})
+}
+
+// FindNextReservationExpiry mengembalikan waktu kedaluwarsa reservasi ACTIVE terdekat (nil jika tidak ada)
+func (r *orderRepository) FindNextReservationExpiry(ctx context.Context) (*time.Time, error) {
+	var res domain.StockReservation
+	err := r.db.WithContext(ctx).Where("status = ?", "ACTIVE").Order("expires_at asc").First(&res).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &res.ExpiresAt, nil
+}
+
+// reserveStock mengunci baris produk/varian dan memvalidasi stok tersedia (stok fisik dikurangi
+// reservasi aktif). Stok fisik TIDAK dipotong; yang dibuat hanyalah StockReservation.
+func reserveStock(tx *gorm.DB, item domain.CartItem) (*domain.Product, float64, error) {
+	var product domain.Product
+	// PENTING SQA: Kita ambil produk terbaru dari database dalam transaksi ini, ditambah LOCKING (FOR UPDATE)
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_product = ?", item.ProductID).First(&product).Error; err != nil {
+		return nil, 0, errors.New("produk " + item.ProductID + " tidak ditemukan")
+	}
+
+	if item.VariantID != nil {
+		var variant domain.ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_variant = ? AND id_product = ?", *item.VariantID, product.ID).First(&variant).Error; err != nil {
+			return nil, 0, errors.New("varian produk tidak ditemukan")
+		}
+		reserved, err := sumActiveReservations(tx, product.ID, item.VariantID)
+		if err != nil {
+			return nil, 0, err
+		}
+		available := variant.Stock - reserved
+		if available < item.Quantity {
+			return nil, 0, fmt.Errorf("stok varian '%s' tidak mencukupi. Stok tersedia: %d", variant.NameLabel, available)
+		}
+		return &product, variant.Price, nil
+	}
+
+	// PENTING SQA: Race condition check. Stok tersedia = stok fisik - reservasi pesanan lain yang belum dibayar
+	reserved, err := sumActiveReservations(tx, product.ID, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	available := product.Stock - reserved
+	if available < item.Quantity {
+		return nil, 0, fmt.Errorf("stok produk '%s' tidak mencukupi. Stok tersedia: %d", product.Name, available)
+	}
+	return &product, product.Price, nil
+}
+
+// createReservations membuat satu StockReservation ACTIVE per item pesanan
+func createReservations(tx *gorm.DB, items []domain.OrderItem, expiresAt time.Time) error {
+	now := time.Now()
+	reservations := make([]domain.StockReservation, 0, len(items))
+	for _, item := range items {
+		reservations = append(reservations, domain.StockReservation{
+			ID:          uuid.New().String(),
+			OrderID:     item.OrderID,
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			Quantity:    item.Quantity,
+			Status:      "ACTIVE",
+			ExpiresAt:   expiresAt,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+	if len(reservations) == 0 {
+		return nil
+	}
+	return tx.Create(&reservations).Error
+}
+
+// sumActiveReservations menjumlahkan kuantitas reservasi ACTIVE yang belum lewat masa berlakunya
+func sumActiveReservations(db *gorm.DB, productID string, variantID *string) (int, error) {
+	var reserved int
+	query := db.Model(&domain.StockReservation{}).
+		Where("id_product = ? AND status = ? AND expires_at > ?", productID, "ACTIVE", time.Now())
+	if variantID != nil {
+		query = query.Where("id_variant = ?", *variantID)
+	} else {
+		query = query.Where("id_variant IS NULL")
+	}
+	err := query.Select("COALESCE(SUM(quantity), 0)").Row().Scan(&reserved)
+	return reserved, err
+}
+
+// releaseOrder mengubah status pesanan PENDING dan melepas reservasinya.
+// [A2 SQA FIX]: Pesanan lama tanpa reservasi (stok sudah dipotong saat checkout) tetap dipulihkan,
+// termasuk stok varian (ProductVariant), tidak hanya produk biasa.
+func releaseOrder(tx *gorm.DB, order *domain.Order, status string, now time.Time) error {
+	if err := tx.Model(order).Update("status", status).Error; err != nil {
+		return err
+	}
+
+	result := tx.Model(&domain.StockReservation{}).
+		Where("id_order = ? AND status = ?", order.ID, "ACTIVE").
+		Updates(map[string]interface{}{"status": "RELEASED", "released_at": now, "updated_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var reservationCount int64
+	if err := tx.Model(&domain.StockReservation{}).Where("id_order = ?", order.ID).Count(&reservationCount).Error; err != nil {
+		return err
+	}
+	if reservationCount > 0 {
+		return nil
+	}
+	return restockOrderItems(tx, order)
+}
+
+// restockOrderItems mengembalikan stok fisik seluruh item pesanan yang sudah dipotong (pesanan lama tanpa
+// reservasi atau pesanan PAID yang dibatalkan) dan mencatat ORDER_CANCELLED_RESTOCK di ledger
+func restockOrderItems(tx *gorm.DB, order *domain.Order) error {
+	for _, item := range order.Items {
+		if item.VariantID != nil {
+			if err := tx.Unscoped().Model(&domain.ProductVariant{}).
+				Where("id_variant = ?", *item.VariantID).
+				Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+				return fmt.Errorf("gagal restorasi stok varian %s: %w", *item.VariantID, err)
+			}
+		} else {
+			if err := tx.Model(&domain.Product{}).
+				Where("id_product = ?", item.ProductID).
+				Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+				return fmt.Errorf("gagal restorasi stok produk %s: %w", item.ProductID, err)
+			}
+		}
+		if err := recordStockMovement(tx, domain.StockMovement{
+			ProductID: item.ProductID, VariantID: item.VariantID, Delta: item.Quantity,
+			Reason: "ORDER_CANCELLED_RESTOCK", OrderID: &order.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errInsufficientStock menandai stok fisik yang lebih kecil dari kuantitas yang akan dipotong
+var errInsufficientStock = errors.New("stok fisik tidak mencukupi")
+
+// decrementStock memotong stok fisik produk/varian dan mencatat SALE di ledger.
+// Stok yang kurang tidak dipotong sebagian: errInsufficientStock dikembalikan agar pemanggil
+// memindahkan pesanan ke PAYMENT_REVIEW, sehingga stok tidak pernah minus dan ledger tetap sama dengan stok.
+func decrementStock(tx *gorm.DB, productID string, variantID *string, quantity int, orderID string) error {
+	var before int
+	var update *gorm.DB
+	if variantID != nil {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Model(&domain.ProductVariant{}).Where("id_variant = ?", *variantID).Select("stock").Row().Scan(&before); err != nil {
+			return err
+		}
+		update = tx.Unscoped().Model(&domain.ProductVariant{}).Where("id_variant = ?", *variantID)
+	} else {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&domain.Product{}).Where("id_product = ?", productID).Select("stock").Row().Scan(&before); err != nil {
+			return err
+		}
+		update = tx.Model(&domain.Product{}).Where("id_product = ?", productID)
+	}
+
+	if before < quantity {
+		return fmt.Errorf("%w: produk %s tersisa %d, dibutuhkan %d", errInsufficientStock, productID, before, quantity)
+	}
+	if err := update.Update("stock", before-quantity).Error; err != nil {
+		return err
+	}
+
+	return recordStockMovement(tx, domain.StockMovement{
+		ProductID: productID, VariantID: variantID, Delta: -quantity, Reason: "SALE", OrderID: &orderID,
+	})
+}
 
 // BatchUpdateStatus memperbarui status lebih dari satu Order ID berbarengan (Bulk)
-func (r *orderRepository) BatchUpdateStatus(orderIDs []string, status string) error {
+func (r *orderRepository) BatchUpdateStatus(ctx context.Context, orderIDs []string, status string) error {
 	// Memanfaatkan 'IN' operator untuk membungkus perintah SQL dalam sekali panggilan (jauh lebih lekas dibanding 'for loop')
-	err := r.db.Model(&domain.Order{}).
+	err := r.db.WithContext(ctx).Model(&domain.Order{}).
 		Where("id_order IN ?", orderIDs).
 		Update("status", status).Error
 	
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"gorm.io/gorm"
+)
+
+// seedOrder membuat pesanan o1 berstatus status dengan item v1 (qty 2) dan p1 tanpa varian (qty 1),
+// masing-masing dengan reservasi berstatus reservationStatus
+func seedOrder(t *testing.T, db *gorm.DB, status string, reservationStatus string) {
+	t.Helper()
+	execSeed(t, db,
+		`INSERT INTO orders (id_order, id_user, total_amount, status, created_at, updated_at) VALUES ('o1', 'u1', 15000, '`+status+`', now(), now())`,
+		`INSERT INTO order_items (id_order_item, id_order, id_product, id_variant, quantity, price_at_purchase, created_at, updated_at) VALUES
+			('oi1', 'o1', 'p1', 'v1', 2, 5000, now(), now()),
+			('oi2', 'o1', 'p1', NULL, 1, 5000, now(), now())`,
+		`INSERT INTO stock_reservations (id_reservation, id_order, id_product, id_variant, quantity, status, expires_at, created_at, updated_at) VALUES
+			('r1', 'o1', 'p1', 'v1', 2, '`+reservationStatus+`', now() + interval '10 minutes', now(), now()),
+			('r2', 'o1', 'p1', NULL, 1, '`+reservationStatus+`', now() + interval '10 minutes', now(), now())`,
+	)
+}
+
+func orderStatusOf(t *testing.T, db *gorm.DB, orderID string) string {
+	t.Helper()
+	var order domain.Order
+	if err := db.Select("status").Where("id_order = ?", orderID).First(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+	return order.Status
+}
+
+func stockOf(t *testing.T, db *gorm.DB, table string, idColumn string, id string) int {
+	t.Helper()
+	var stock int
+	if err := db.Table(table).Where(idColumn+" = ?", id).Select("stock").Row().Scan(&stock); err != nil {
+		t.Fatal(err)
+	}
+	return stock
+}
+
+func TestConfirmPayment_ConsumesReservationsOnce(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	seedOrder(t, db, "PENDING", "ACTIVE")
+	repo := NewOrderRepository(db)
+
+	status, err := repo.ConfirmPayment(context.Background(), "o1")
+	if err != nil || status != domain.OrderStatusPaid {
+		t.Fatalf("Expected PAID, got %q (%v)", status, err)
+	}
+	if stockOf(t, db, "product_variants", "id_variant", "v1") != 2 || stockOf(t, db, "products", "id_product", "p1") != 4 {
+		t.Fatal("Expected v1 and p1 stock decremented by the order quantities")
+	}
+
+	// Settlement ulang setelah pesanan diproses supplier tidak boleh mengubah apa pun
+	execSeed(t, db, `UPDATE orders SET status = 'PROCESSED' WHERE id_order = 'o1'`)
+	if _, err := repo.ConfirmPayment(context.Background(), "o1"); !errors.Is(err, domain.ErrOrderNotPayable) {
+		t.Fatalf("Expected ErrOrderNotPayable for a PROCESSED order, got %v", err)
+	}
+	if got := orderStatusOf(t, db, "o1"); got != "PROCESSED" {
+		t.Errorf("Expected status to stay PROCESSED, got %s", got)
+	}
+	if stockOf(t, db, "product_variants", "id_variant", "v1") != 2 || len(movementsOf(t, db, "p1", "v1")) != 1 {
+		t.Error("Expected no further stock or ledger changes for the duplicate webhook")
+	}
+}
+
+func TestConfirmPayment_ShortStockMovesToReviewWithoutPartialDecrement(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	seedOrder(t, db, "PENDING", "ACTIVE")
+	// Stok fisik v1 diturunkan di luar reservasi sehingga tidak cukup untuk 2 unit
+	execSeed(t, db, `UPDATE product_variants SET stock = 1 WHERE id_variant = 'v1'`)
+	repo := NewOrderRepository(db)
+
+	status, err := repo.ConfirmPayment(context.Background(), "o1")
+	if err != nil || status != domain.OrderStatusPaymentReview {
+		t.Fatalf("Expected PAYMENT_REVIEW, got %q (%v)", status, err)
+	}
+	if got := orderStatusOf(t, db, "o1"); got != domain.OrderStatusPaymentReview {
+		t.Errorf("Expected order stored as PAYMENT_REVIEW, got %s", got)
+	}
+	if stockOf(t, db, "products", "id_product", "p1") != 5 || stockOf(t, db, "product_variants", "id_variant", "v1") != 1 {
+		t.Error("Expected no stock decremented when one item is short")
+	}
+	if len(movementsOf(t, db, "p1", "")) != 0 || len(movementsOf(t, db, "p1", "v1")) != 0 {
+		t.Error("Expected no SALE entries when payment moved to review")
+	}
+}
+
+func TestCancelOrder_PaidOrderRestocksStock(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	seedOrder(t, db, "PENDING", "ACTIVE")
+	repo := NewOrderRepository(db)
+	if _, err := repo.ConfirmPayment(context.Background(), "o1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.CancelOrder(context.Background(), "o1", "CANCELLED"); err != nil {
+		t.Fatalf("Expected PAID order cancelled, got %v", err)
+	}
+	if got := orderStatusOf(t, db, "o1"); got != "CANCELLED" {
+		t.Errorf("Expected CANCELLED, got %s", got)
+	}
+	if stockOf(t, db, "product_variants", "id_variant", "v1") != 4 || stockOf(t, db, "products", "id_product", "p1") != 5 {
+		t.Error("Expected stock taken at payment returned on cancel")
+	}
+	movements := movementsOf(t, db, "p1", "v1")
+	if len(movements) != 2 || movements[1].Reason != "ORDER_CANCELLED_RESTOCK" || movements[1].Delta != 2 || movements[1].BalanceAfter != 4 {
+		t.Errorf("Expected SALE followed by ORDER_CANCELLED_RESTOCK +2, got %+v", movements)
+	}
+
+	// Webhook cancel ulang tidak mengembalikan stok dua kali
+	if err := repo.CancelOrder(context.Background(), "o1", "CANCELLED"); err != nil {
+		t.Fatal(err)
+	}
+	if len(movementsOf(t, db, "p1", "v1")) != 2 {
+		t.Error("Expected no extra restock for a repeated cancel")
+	}
+}
+
+func TestCancelOrder_RejectedAfterProcessing(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	seedOrder(t, db, "SHIPPED", "CONSUMED")
+	repo := NewOrderRepository(db)
+
+	if err := repo.CancelOrder(context.Background(), "o1", "CANCELLED"); !errors.Is(err, domain.ErrOrderNotCancellable) {
+		t.Fatalf("Expected ErrOrderNotCancellable, got %v", err)
+	}
+	if got := orderStatusOf(t, db, "o1"); got != "SHIPPED" {
+		t.Errorf("Expected status to stay SHIPPED, got %s", got)
+	}
+}
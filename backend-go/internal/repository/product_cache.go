@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/metrics"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -19,68 +22,79 @@ const (
 // cachedProductRepository adalah decorator yang membungkus ProductRepository asli
 // dengan lapisan Redis caching. Mengikuti pola Decorator dari Clean Architecture.
 type cachedProductRepository struct {
-	base  domain.ProductRepository // Repository asli (PostgreSQL)
-	cache *redis.Client            // Redis client (bisa nil jika Redis tidak tersedia)
+	base   domain.ProductRepository // Repository asli (PostgreSQL)
+	cache  *redis.Client            // Redis client (bisa nil jika Redis tidak tersedia)
+	logger *slog.Logger
 }
 
 // NewCachedProductRepository membuat product repository dengan Redis caching.
 // Jika redisClient nil, maka semua operasi langsung ke database (fallback).
-func NewCachedProductRepository(base domain.ProductRepository, redisClient *redis.Client) domain.ProductRepository {
+func NewCachedProductRepository(base domain.ProductRepository, redisClient *redis.Client, logger *slog.Logger) domain.ProductRepository {
 	return &cachedProductRepository{
-		base:  base,
-		cache: redisClient,
+		base:   base,
+		cache:  redisClient,
+		logger: logger,
 	}
 }
 
-func (r *cachedProductRepository) Create(product *domain.Product) error {
-	err := r.base.Create(product)
+func (r *cachedProductRepository) Create(ctx context.Context, product *domain.Product, actorID string) error {
+	err := r.base.Create(ctx, product, actorID)
 	if err == nil {
-		r.invalidateCache() // Hapus cache setelah data berubah
+		r.invalidateCache(ctx) // Hapus cache setelah data berubah
 	}
 	return err
 }
 
-func (r *cachedProductRepository) FindAll() ([]domain.Product, error) {
+// cachedProductPage adalah isi cache satu halaman katalog
+type cachedProductPage struct {
+	Products   []domain.Product `json:"products"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+func (r *cachedProductRepository) FindAll(ctx context.Context, page pagination.Params) ([]domain.Product, string, error) {
 	// Jika Redis tidak tersedia, langsung ke database
 	if r.cache == nil {
-		return r.base.FindAll()
+		return r.base.FindAll(ctx, page)
 	}
 
-	ctx := context.Background()
+	// Setiap kombinasi limit + cursor punya entri cache sendiri di bawah prefix products:all
+	cacheKey := fmt.Sprintf("%s:%d:", productAllKey, page.Limit)
+	if page.After != nil {
+		cacheKey += page.After.Encode()
+	}
 
 	// 1. Coba ambil dari cache
-	cached, err := r.cache.Get(ctx, productAllKey).Result()
+	cached, err := r.cache.Get(ctx, cacheKey).Result()
 	if err == nil {
-		var products []domain.Product
-		if json.Unmarshal([]byte(cached), &products) == nil {
-			log.Println("[CACHE HIT] products:all")
-			return products, nil
+		var cachedPage cachedProductPage
+		if json.Unmarshal([]byte(cached), &cachedPage) == nil {
+			metrics.CacheRequests.WithLabelValues("product", metrics.ResultHit).Inc()
+			return cachedPage.Products, cachedPage.NextCursor, nil
 		}
 	}
 
-	// 2. Cache miss — ambil dari database
-	log.Println("[CACHE MISS] products:all — query database")
-	products, err := r.base.FindAll()
+	// 2. Cache miss — ambil dari primary agar hasil yang di-cache tidak tertinggal dari replika
+	metrics.CacheRequests.WithLabelValues("product", metrics.ResultMiss).Inc()
+	products, nextCursor, err := r.base.FindAll(withPrimaryRead(ctx), page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	// 3. Simpan ke cache
-	data, marshalErr := json.Marshal(products)
+	data, marshalErr := json.Marshal(cachedProductPage{Products: products, NextCursor: nextCursor})
 	if marshalErr == nil {
-		r.cache.Set(ctx, productAllKey, data, cacheTTL)
+		r.cache.Set(ctx, cacheKey, data, cacheTTL)
 	}
 
-	return products, nil
+	return products, nextCursor, nil
 }
 
-func (r *cachedProductRepository) FindByID(id string) (*domain.Product, error) {
+func (r *cachedProductRepository) FindByID(ctx context.Context, id string) (*domain.Product, error) {
 	// Jika Redis tidak tersedia, langsung ke database
 	if r.cache == nil {
-		return r.base.FindByID(id)
+		return r.base.FindByID(ctx, id)
 	}
 
-	ctx := context.Background()
 	cacheKey := productKeyPrefix + id
 
 	// 1. Coba ambil dari cache
@@ -88,14 +102,14 @@ func (r *cachedProductRepository) FindByID(id string) (*domain.Product, error) {
 	if err == nil {
 		var product domain.Product
 		if json.Unmarshal([]byte(cached), &product) == nil {
-			log.Printf("[CACHE HIT] %s", cacheKey)
+			metrics.CacheRequests.WithLabelValues("product", metrics.ResultHit).Inc()
 			return &product, nil
 		}
 	}
 
 	// 2. Cache miss — ambil dari database
-	log.Printf("[CACHE MISS] %s — query database", cacheKey)
-	product, err := r.base.FindByID(id)
+	metrics.CacheRequests.WithLabelValues("product", metrics.ResultMiss).Inc()
+	product, err := r.base.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -109,55 +123,172 @@ func (r *cachedProductRepository) FindByID(id string) (*domain.Product, error) {
 	return product, nil
 }
 
-func (r *cachedProductRepository) Update(product *domain.Product) error {
-	err := r.base.Update(product)
+func (r *cachedProductRepository) Update(ctx context.Context, product *domain.Product, actorID string) error {
+	err := r.base.Update(ctx, product, actorID)
 	if err == nil {
-		r.invalidateCache() // Hapus semua cache produk setelah update
+		r.invalidateCache(ctx) // Hapus semua cache produk setelah update
 	}
 	return err
 }
 
-func (r *cachedProductRepository) Delete(id string) error {
-	err := r.base.Delete(id)
+func (r *cachedProductRepository) Delete(ctx context.Context, id string) error {
+	err := r.base.Delete(ctx, id)
 	if err == nil {
-		r.invalidateCache() // Hapus semua cache produk setelah delete
+		r.invalidateCache(ctx) // Hapus semua cache produk setelah delete
 	}
 	return err
 }
 
 // Search langsung diteruskan ke base repository (tidak di-cache karena query dinamis)
-func (r *cachedProductRepository) Search(keyword string, categoryID string, limit int, offset int) ([]domain.Product, error) {
-	return r.base.Search(keyword, categoryID, limit, offset)
+func (r *cachedProductRepository) Search(ctx context.Context, filter domain.ProductSearchFilter) ([]domain.Product, error) {
+	return r.base.Search(ctx, filter)
+}
+
+func (r *cachedProductRepository) CountSearch(ctx context.Context, filter domain.ProductSearchFilter) (int64, error) {
+	return r.base.CountSearch(ctx, filter)
+}
+
+func (r *cachedProductRepository) CategoryFacets(ctx context.Context, filter domain.ProductSearchFilter) ([]domain.CategoryFacet, error) {
+	return r.base.CategoryFacets(ctx, filter)
 }
 
 // FindBySupplierID langsung ke base repository (query spesifik per supplier)
-func (r *cachedProductRepository) FindBySupplierID(supplierID string) ([]domain.Product, error) {
-	log.Printf("[CACHE BYPASS] FindBySupplierID")
-	return r.base.FindBySupplierID(supplierID)
+func (r *cachedProductRepository) FindBySupplierID(ctx context.Context, supplierID string) ([]domain.Product, error) {
+	return r.base.FindBySupplierID(ctx, supplierID)
 }
 
-func (r *cachedProductRepository) GetSupplierRating(supplierID string) float64 {
+// FindByIDs dipakai pemeriksaan stok menipis, harus selalu membaca stok terbaru dari database
+func (r *cachedProductRepository) FindByIDs(ctx context.Context, ids []string) ([]domain.Product, error) {
+	return r.base.FindByIDs(ctx, ids)
+}
+
+func (r *cachedProductRepository) SetLowStockAlertedAt(ctx context.Context, productID string, alertedAt *time.Time) error {
+	return r.base.SetLowStockAlertedAt(ctx, productID, alertedAt)
+}
+
+// TotalStock selalu dari database karena dipakai membandingkan stok sebelum dan sesudah update
+func (r *cachedProductRepository) TotalStock(ctx context.Context, productID string) (int, error) {
+	return r.base.TotalStock(ctx, productID)
+}
+
+func (r *cachedProductRepository) GetSupplierRating(ctx context.Context, supplierID string) float64 {
 	// Metrik rating tidak perlu dicache agar halaman detail produk selalu akurat memuat reputasi toko terbaru.
-	return r.base.GetSupplierRating(supplierID)
+	return r.base.GetSupplierRating(ctx, supplierID)
+}
+
+func (r *cachedProductRepository) GetReservedStock(ctx context.Context, productID string, variantID *string) (int, error) {
+	// Reservasi berubah setiap checkout/pembayaran, jadi tidak dicache
+	return r.base.GetReservedStock(ctx, productID, variantID)
+}
+
+func (r *cachedProductRepository) FindVariantsByProductID(ctx context.Context, productID string) ([]domain.ProductVariant, error) {
+	return r.base.FindVariantsByProductID(ctx, productID)
+}
+
+func (r *cachedProductRepository) FindVariantByID(ctx context.Context, productID string, variantID string) (*domain.ProductVariant, error) {
+	return r.base.FindVariantByID(ctx, productID, variantID)
+}
+
+func (r *cachedProductRepository) SKUExists(ctx context.Context, sku string, excludeVariantID string) (bool, error) {
+	return r.base.SKUExists(ctx, sku, excludeVariantID)
+}
+
+// ProductSKUExists tidak di-cache: cek keunikan SKU harus membaca data terbaru
+func (r *cachedProductRepository) ProductSKUExists(ctx context.Context, supplierID string, sku string, excludeProductID string) (bool, error) {
+	return r.base.ProductSKUExists(ctx, supplierID, sku, excludeProductID)
+}
+
+// Mutasi varian mengubah isi produk (detail & daftar), jadi cache produk ikut dihapus
+func (r *cachedProductRepository) CreateVariant(ctx context.Context, variant *domain.ProductVariant, actorID string) error {
+	err := r.base.CreateVariant(ctx, variant, actorID)
+	if err == nil {
+		r.invalidateCache(ctx)
+	}
+	return err
+}
+
+func (r *cachedProductRepository) UpdateVariant(ctx context.Context, variant *domain.ProductVariant, actorID string) error {
+	err := r.base.UpdateVariant(ctx, variant, actorID)
+	if err == nil {
+		r.invalidateCache(ctx)
+	}
+	return err
+}
+
+func (r *cachedProductRepository) DeleteVariant(ctx context.Context, productID string, variantID string, actorID string) error {
+	err := r.base.DeleteVariant(ctx, productID, variantID, actorID)
+	if err == nil {
+		r.invalidateCache(ctx)
+	}
+	return err
+}
+
+func (r *cachedProductRepository) ReplaceVariants(ctx context.Context, productID string, variants []domain.ProductVariant, actorID string) error {
+	err := r.base.ReplaceVariants(ctx, productID, variants, actorID)
+	if err == nil {
+		r.invalidateCache(ctx)
+	}
+	return err
+}
+
+func (r *cachedProductRepository) ApplyGeneratedVariants(ctx context.Context, productID string, created []domain.ProductVariant, removedIDs []string, actorID string) error {
+	err := r.base.ApplyGeneratedVariants(ctx, productID, created, removedIDs, actorID)
+	if err == nil {
+		r.invalidateCache(ctx)
+	}
+	return err
+}
+
+// FindByStatus dipakai antrian review admin yang harus selalu terbaru
+func (r *cachedProductRepository) FindByStatus(ctx context.Context, status string, page pagination.Params) ([]domain.Product, string, error) {
+	return r.base.FindByStatus(ctx, status, page)
+}
+
+// Perubahan status menentukan produk tampil atau tidak di katalog, jadi cache langsung dihapus
+func (r *cachedProductRepository) UpdateStatus(ctx context.Context, product *domain.Product) error {
+	err := r.base.UpdateStatus(ctx, product)
+	if err == nil {
+		r.invalidateCache(ctx)
+	}
+	return err
+}
+
+// InvalidateProductCache dipanggil usecase lain setelah stok berubah di luar repository ini
+func (r *cachedProductRepository) InvalidateProductCache(ctx context.Context) {
+	r.invalidateCache(ctx)
 }
 
 // invalidateCache menghapus semua cache produk dari Redis.
 // Dipanggil setiap kali ada Create/Update/Delete yang mengubah data.
-func (r *cachedProductRepository) invalidateCache() {
+func (r *cachedProductRepository) invalidateCache(ctx context.Context) {
 	if r.cache == nil {
 		return
 	}
 
-	ctx := context.Background()
+	// Data sudah tersimpan; invalidasi tetap dijalankan walau request dibatalkan agar cache tidak basi
+	ctx = context.WithoutCancel(ctx)
 
-	// Hapus cache "all products"
-	r.cache.Del(ctx, productAllKey)
+	// Hapus cache semua halaman "all products"
+	iter := r.cache.Scan(ctx, 0, productAllKey+":*", 100).Iterator()
+	for iter.Next(ctx) {
+		r.cache.Del(ctx, iter.Val())
+	}
 
 	// Hapus semua cache detail produk menggunakan pattern matching
-	iter := r.cache.Scan(ctx, 0, productKeyPrefix+"*", 100).Iterator()
+	iter = r.cache.Scan(ctx, 0, productKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		r.cache.Del(ctx, iter.Val())
+	}
+
+	// Saran autocomplete memuat nama produk, jadi ikut dihapus
+	iter = r.cache.Scan(ctx, 0, productSuggestPrefix+"*", 100).Iterator()
 	for iter.Next(ctx) {
 		r.cache.Del(ctx, iter.Val())
 	}
 
-	log.Println("[CACHE INVALIDATED] Semua cache produk dihapus")
+	if err := iter.Err(); err != nil {
+		r.logger.WarnContext(ctx, "gagal menghapus cache produk", "error", err)
+		return
+	}
+	r.logger.DebugContext(ctx, "cache produk dihapus")
 }
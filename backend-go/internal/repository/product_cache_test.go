@@ -1,11 +1,15 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 // --- Mock Product Repository for Cache Testing ---
@@ -22,22 +26,22 @@ func newMockProductRepoForCache() *mockProductRepoForCache {
 	}
 }
 
-func (m *mockProductRepoForCache) Create(p *domain.Product) error {
+func (m *mockProductRepoForCache) Create(ctx context.Context, p *domain.Product, actorID string) error {
 	m.callCount["Create"]++
 	m.products[p.ID] = p
 	return nil
 }
 
-func (m *mockProductRepoForCache) FindAll() ([]domain.Product, error) {
+func (m *mockProductRepoForCache) FindAll(ctx context.Context, page pagination.Params) ([]domain.Product, string, error) {
 	m.callCount["FindAll"]++
 	var result []domain.Product
 	for _, p := range m.products {
 		result = append(result, *p)
 	}
-	return result, nil
+	return result, "", nil
 }
 
-func (m *mockProductRepoForCache) FindByID(id string) (*domain.Product, error) {
+func (m *mockProductRepoForCache) FindByID(ctx context.Context, id string) (*domain.Product, error) {
 	m.callCount["FindByID"]++
 	p, ok := m.products[id]
 	if !ok {
@@ -46,26 +50,26 @@ func (m *mockProductRepoForCache) FindByID(id string) (*domain.Product, error) {
 	return p, nil
 }
 
-func (m *mockProductRepoForCache) Update(p *domain.Product) error {
+func (m *mockProductRepoForCache) Update(ctx context.Context, p *domain.Product, actorID string) error {
 	m.callCount["Update"]++
 	m.products[p.ID] = p
 	return nil
 }
 
-func (m *mockProductRepoForCache) Delete(id string) error {
+func (m *mockProductRepoForCache) Delete(ctx context.Context, id string) error {
 	m.callCount["Delete"]++
 	delete(m.products, id)
 	return nil
 }
 
-func (m *mockProductRepoForCache) Search(keyword string, categoryID string, limit int, offset int) ([]domain.Product, error) {
+func (m *mockProductRepoForCache) Search(ctx context.Context, filter domain.ProductSearchFilter) ([]domain.Product, error) {
 	m.callCount["Search"]++
 	var result []domain.Product
 	for _, p := range m.products {
-		if keyword != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(keyword)) {
+		if filter.Keyword != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(filter.Keyword)) {
 			continue
 		}
-		if categoryID != "" && p.CategoryID != categoryID {
+		if filter.CategoryID != "" && p.CategoryID != filter.CategoryID {
 			continue
 		}
 		result = append(result, *p)
@@ -73,7 +77,15 @@ func (m *mockProductRepoForCache) Search(keyword string, categoryID string, limi
 	return result, nil
 }
 
-func (m *mockProductRepoForCache) FindBySupplierID(supplierID string) ([]domain.Product, error) {
+func (m *mockProductRepoForCache) CountSearch(ctx context.Context, filter domain.ProductSearchFilter) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockProductRepoForCache) CategoryFacets(ctx context.Context, filter domain.ProductSearchFilter) ([]domain.CategoryFacet, error) {
+	return nil, nil
+}
+
+func (m *mockProductRepoForCache) FindBySupplierID(ctx context.Context, supplierID string) ([]domain.Product, error) {
 	m.callCount["FindBySupplierID"]++
 	var result []domain.Product
 	for _, p := range m.products {
@@ -83,24 +95,73 @@ func (m *mockProductRepoForCache) FindBySupplierID(supplierID string) ([]domain.
 	}
 	return result, nil
 }
-func (m *mockProductRepoForCache) GetSupplierRating(supplierID string) float64 { return 0 }
+func (m *mockProductRepoForCache) FindByIDs(ctx context.Context, ids []string) ([]domain.Product, error) {
+	m.callCount["FindByIDs"]++
+	return nil, nil
+}
+func (m *mockProductRepoForCache) SetLowStockAlertedAt(ctx context.Context, productID string, alertedAt *time.Time) error {
+	return nil
+}
+func (m *mockProductRepoForCache) GetSupplierRating(ctx context.Context, supplierID string) float64 {
+	return 0
+}
+func (m *mockProductRepoForCache) GetReservedStock(ctx context.Context, productID string, variantID *string) (int, error) {
+	return 0, nil
+}
+func (m *mockProductRepoForCache) TotalStock(ctx context.Context, productID string) (int, error) {
+	return 0, nil
+}
+
+func (m *mockProductRepoForCache) FindVariantsByProductID(ctx context.Context, productID string) ([]domain.ProductVariant, error) {
+	return nil, nil
+}
+func (m *mockProductRepoForCache) FindVariantByID(ctx context.Context, productID string, variantID string) (*domain.ProductVariant, error) {
+	return nil, errors.New("not found")
+}
+func (m *mockProductRepoForCache) SKUExists(ctx context.Context, sku string, excludeVariantID string) (bool, error) {
+	return false, nil
+}
+func (m *mockProductRepoForCache) ProductSKUExists(ctx context.Context, supplierID string, sku string, excludeProductID string) (bool, error) {
+	return false, nil
+}
+func (m *mockProductRepoForCache) CreateVariant(ctx context.Context, v *domain.ProductVariant, actorID string) error {
+	return nil
+}
+func (m *mockProductRepoForCache) UpdateVariant(ctx context.Context, v *domain.ProductVariant, actorID string) error {
+	return nil
+}
+func (m *mockProductRepoForCache) DeleteVariant(ctx context.Context, productID string, variantID string, actorID string) error {
+	return nil
+}
+func (m *mockProductRepoForCache) ApplyGeneratedVariants(ctx context.Context, productID string, created []domain.ProductVariant, removedIDs []string, actorID string) error {
+	return nil
+}
+func (m *mockProductRepoForCache) ReplaceVariants(ctx context.Context, productID string, variants []domain.ProductVariant, actorID string) error {
+	return nil
+}
+func (m *mockProductRepoForCache) FindByStatus(ctx context.Context, status string, page pagination.Params) ([]domain.Product, string, error) {
+	return nil, "", nil
+}
+func (m *mockProductRepoForCache) UpdateStatus(ctx context.Context, p *domain.Product) error {
+	return nil
+}
 
 // --- Tests: Cache tanpa Redis (nil client fallback) ---
 
 // TestCachedRepo_FallbackWithoutRedis — Tanpa Redis, harus tetap bekerja via base repository
 func TestCachedRepo_FallbackWithoutRedis(t *testing.T) {
 	baseRepo := newMockProductRepoForCache()
-	cachedRepo := NewCachedProductRepository(baseRepo, nil) // nil = Redis tidak tersedia
+	cachedRepo := NewCachedProductRepository(baseRepo, nil, logger.Discard()) // nil = Redis tidak tersedia
 
 	// Create product
 	product := &domain.Product{ID: "prod-1", Name: "Kangkung Segar", Price: 15000000, Stock: 10, CategoryID: "cat-1"}
-	err := cachedRepo.Create(product)
+	err := cachedRepo.Create(context.Background(), product, "admin-1")
 	if err != nil {
 		t.Fatalf("Create failed: %v", err)
 	}
 
 	// FindAll harus bisa jalan tanpa Redis
-	products, err := cachedRepo.FindAll()
+	products, _, err := cachedRepo.FindAll(context.Background(), pagination.Params{Limit: pagination.DefaultLimit})
 	if err != nil {
 		t.Fatalf("FindAll failed: %v", err)
 	}
@@ -117,11 +178,11 @@ func TestCachedRepo_FallbackWithoutRedis(t *testing.T) {
 // TestCachedRepo_FindByID_FallbackWithoutRedis — FindByID tanpa Redis
 func TestCachedRepo_FindByID_FallbackWithoutRedis(t *testing.T) {
 	baseRepo := newMockProductRepoForCache()
-	cachedRepo := NewCachedProductRepository(baseRepo, nil)
+	cachedRepo := NewCachedProductRepository(baseRepo, nil, logger.Discard())
 
 	baseRepo.products["prod-1"] = &domain.Product{ID: "prod-1", Name: "Bayam Hijau", Price: 250000}
 
-	product, err := cachedRepo.FindByID("prod-1")
+	product, err := cachedRepo.FindByID(context.Background(), "prod-1")
 	if err != nil {
 		t.Fatalf("FindByID failed: %v", err)
 	}
@@ -137,9 +198,9 @@ func TestCachedRepo_FindByID_FallbackWithoutRedis(t *testing.T) {
 // TestCachedRepo_FindByID_NotFound — FindByID untuk ID yang tidak ada
 func TestCachedRepo_FindByID_NotFound(t *testing.T) {
 	baseRepo := newMockProductRepoForCache()
-	cachedRepo := NewCachedProductRepository(baseRepo, nil)
+	cachedRepo := NewCachedProductRepository(baseRepo, nil, logger.Discard())
 
-	_, err := cachedRepo.FindByID("nonexistent")
+	_, err := cachedRepo.FindByID(context.Background(), "nonexistent")
 	if err == nil {
 		t.Fatal("Expected error for nonexistent product, got nil")
 	}
@@ -148,12 +209,12 @@ func TestCachedRepo_FindByID_NotFound(t *testing.T) {
 // TestCachedRepo_Update_Delegates — Update harus di-delegate ke base repo
 func TestCachedRepo_Update_Delegates(t *testing.T) {
 	baseRepo := newMockProductRepoForCache()
-	cachedRepo := NewCachedProductRepository(baseRepo, nil)
+	cachedRepo := NewCachedProductRepository(baseRepo, nil, logger.Discard())
 
 	baseRepo.products["prod-1"] = &domain.Product{ID: "prod-1", Name: "Wortel Biasa", Price: 500000}
 
 	updated := &domain.Product{ID: "prod-1", Name: "Wortel Organik", Price: 750000}
-	err := cachedRepo.Update(updated)
+	err := cachedRepo.Update(context.Background(), updated, "admin-1")
 	if err != nil {
 		t.Fatalf("Update failed: %v", err)
 	}
@@ -169,11 +230,11 @@ func TestCachedRepo_Update_Delegates(t *testing.T) {
 // TestCachedRepo_Delete_Delegates — Delete harus di-delegate ke base repo
 func TestCachedRepo_Delete_Delegates(t *testing.T) {
 	baseRepo := newMockProductRepoForCache()
-	cachedRepo := NewCachedProductRepository(baseRepo, nil)
+	cachedRepo := NewCachedProductRepository(baseRepo, nil, logger.Discard())
 
 	baseRepo.products["prod-1"] = &domain.Product{ID: "prod-1", Name: "Sawi Putih"}
 
-	err := cachedRepo.Delete("prod-1")
+	err := cachedRepo.Delete(context.Background(), "prod-1")
 	if err != nil {
 		t.Fatalf("Delete failed: %v", err)
 	}
@@ -189,14 +250,14 @@ func TestCachedRepo_Delete_Delegates(t *testing.T) {
 // TestCachedRepo_Search_BypassesCache — Search harus selalu langsung ke base repo
 func TestCachedRepo_Search_BypassesCache(t *testing.T) {
 	baseRepo := newMockProductRepoForCache()
-	cachedRepo := NewCachedProductRepository(baseRepo, nil)
+	cachedRepo := NewCachedProductRepository(baseRepo, nil, logger.Discard())
 
 	baseRepo.products["prod-1"] = &domain.Product{ID: "prod-1", Name: "Kangkung Segar", CategoryID: "cat-1"}
 	baseRepo.products["prod-2"] = &domain.Product{ID: "prod-2", Name: "Tomat Merah", CategoryID: "cat-2"}
 	baseRepo.products["prod-3"] = &domain.Product{ID: "prod-3", Name: "Kangkung Organik", CategoryID: "cat-1"}
 
 	// Search by keyword "Kangkung"
-	results, err := cachedRepo.Search("Kangkung", "", 10, 0)
+	results, err := cachedRepo.Search(context.Background(), domain.ProductSearchFilter{Keyword: "Kangkung", Limit: 10})
 	if err != nil {
 		t.Fatalf("Search failed: %v", err)
 	}
@@ -205,7 +266,7 @@ func TestCachedRepo_Search_BypassesCache(t *testing.T) {
 	}
 
 	// Search by keyword + categoryID
-	results, err = cachedRepo.Search("Kangkung", "cat-1", 10, 0)
+	results, err = cachedRepo.Search(context.Background(), domain.ProductSearchFilter{Keyword: "Kangkung", CategoryID: "cat-1", Limit: 10})
 	if err != nil {
 		t.Fatalf("Search with category failed: %v", err)
 	}
@@ -222,17 +283,17 @@ func TestCachedRepo_Search_BypassesCache(t *testing.T) {
 // TestCachedRepo_MultipleOperations_Flow — Test alur lengkap CRUD via cached repo
 func TestCachedRepo_MultipleOperations_Flow(t *testing.T) {
 	baseRepo := newMockProductRepoForCache()
-	cachedRepo := NewCachedProductRepository(baseRepo, nil)
+	cachedRepo := NewCachedProductRepository(baseRepo, nil, logger.Discard())
 
 	// 1. Create
 	p := &domain.Product{ID: "flow-1", Name: "Brokoli Premium", Price: 1500000, Stock: 50, CategoryID: "cat-daun"}
-	err := cachedRepo.Create(p)
+	err := cachedRepo.Create(context.Background(), p, "admin-1")
 	if err != nil {
 		t.Fatalf("Create failed: %v", err)
 	}
 
 	// 2. FindAll
-	all, err := cachedRepo.FindAll()
+	all, _, err := cachedRepo.FindAll(context.Background(), pagination.Params{Limit: pagination.DefaultLimit})
 	if err != nil {
 		t.Fatalf("FindAll failed: %v", err)
 	}
@@ -241,7 +302,7 @@ func TestCachedRepo_MultipleOperations_Flow(t *testing.T) {
 	}
 
 	// 3. FindByID
-	found, err := cachedRepo.FindByID("flow-1")
+	found, err := cachedRepo.FindByID(context.Background(), "flow-1")
 	if err != nil {
 		t.Fatalf("FindByID failed: %v", err)
 	}
@@ -251,25 +312,25 @@ func TestCachedRepo_MultipleOperations_Flow(t *testing.T) {
 
 	// 4. Update
 	found.Price = 1200000
-	err = cachedRepo.Update(found)
+	err = cachedRepo.Update(context.Background(), found, "admin-1")
 	if err != nil {
 		t.Fatalf("Update failed: %v", err)
 	}
 
 	// 5. Verify update
-	updated, _ := cachedRepo.FindByID("flow-1")
+	updated, _ := cachedRepo.FindByID(context.Background(), "flow-1")
 	if updated.Price != 1200000 {
 		t.Errorf("Expected price 1200000, got %v", updated.Price)
 	}
 
 	// 6. Delete
-	err = cachedRepo.Delete("flow-1")
+	err = cachedRepo.Delete(context.Background(), "flow-1")
 	if err != nil {
 		t.Fatalf("Delete failed: %v", err)
 	}
 
 	// 7. Verify deletion
-	allAfterDelete, _ := cachedRepo.FindAll()
+	allAfterDelete, _, _ := cachedRepo.FindAll(context.Background(), pagination.Params{Limit: pagination.DefaultLimit})
 	if len(allAfterDelete) != 0 {
 		t.Errorf("Expected 0 products after delete, got %d", len(allAfterDelete))
 	}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type productImageRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepository(db *gorm.DB) domain.ProductImageRepository {
+	return &productImageRepository{db: db}
+}
+
+// Gambar level produk ditampilkan lebih dulu, disusul gambar per varian
+const productImageOrder = "id_variant IS NOT NULL, id_variant, position asc"
+
+func (r *productImageRepository) FindByProductID(ctx context.Context, productID string) ([]domain.ProductImage, error) {
+	var images []domain.ProductImage
+	err := r.db.WithContext(ctx).Where("id_product = ?", productID).Order(productImageOrder).Find(&images).Error
+	return images, err
+}
+
+func (r *productImageRepository) FindByID(ctx context.Context, imageID string) (*domain.ProductImage, error) {
+	var image domain.ProductImage
+	if err := r.db.WithContext(ctx).Where("id_image = ?", imageID).First(&image).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("gambar tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &image, nil
+}
+
+// lockProduct mengunci baris produk agar perubahan galeri paralel tidak menghasilkan posisi ganda
+func lockProduct(tx *gorm.DB, productID string) error {
+	var product domain.Product
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id_product").
+		Where("id_product = ?", productID).First(&product).Error
+}
+
+// syncPrimaryImage menjadikan gambar produk pertama sebagai products.image_url agar klien lama
+// yang hanya membaca satu gambar tetap menampilkan gambar utama galeri
+func syncPrimaryImage(tx *gorm.DB, productID string, removedURL string) error {
+	var primary domain.ProductImage
+	err := tx.Where("id_product = ? AND id_variant IS NULL", productID).Order("position asc").First(&primary).Error
+	if err == nil {
+		return tx.Model(&domain.Product{}).Where("id_product = ?", productID).Update("image_url", primary.MediumURL).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if removedURL == "" {
+		return nil
+	}
+	// Galeri kosong: kosongkan image_url hanya jika masih menunjuk ke gambar yang baru dihapus
+	return tx.Model(&domain.Product{}).Where("id_product = ? AND image_url = ?", productID, removedURL).Update("image_url", "").Error
+}
+
+func (r *productImageRepository) Create(ctx context.Context, image *domain.ProductImage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, image.ProductID); err != nil {
+			return err
+		}
+
+		// Gambar baru selalu ditempatkan di akhir cakupannya (produk atau varian)
+		scope := tx.Model(&domain.ProductImage{}).Where("id_product = ?", image.ProductID)
+		if image.VariantID != nil {
+			scope = scope.Where("id_variant = ?", *image.VariantID)
+		} else {
+			scope = scope.Where("id_variant IS NULL")
+		}
+		var next int
+		if err := scope.Select("COALESCE(MAX(position) + 1, 0)").Scan(&next).Error; err != nil {
+			return err
+		}
+
+		image.ID = uuid.New().String()
+		image.Position = next
+		image.CreatedAt = time.Now()
+		if err := tx.Create(image).Error; err != nil {
+			return err
+		}
+		return syncPrimaryImage(tx, image.ProductID, "")
+	})
+}
+
+func (r *productImageRepository) Delete(ctx context.Context, image *domain.ProductImage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, image.ProductID); err != nil {
+			return err
+		}
+		if err := tx.Where("id_image = ?", image.ID).Delete(&domain.ProductImage{}).Error; err != nil {
+			return err
+		}
+		return syncPrimaryImage(tx, image.ProductID, image.MediumURL)
+	})
+}
+
+func (r *productImageRepository) Reorder(ctx context.Context, productID string, positions map[string]int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, productID); err != nil {
+			return err
+		}
+		for id, position := range positions {
+			if err := tx.Model(&domain.ProductImage{}).Where("id_image = ? AND id_product = ?", id, productID).
+				Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return syncPrimaryImage(tx, productID, "")
+	})
+}
+
+func (r *productImageRepository) CountByHash(ctx context.Context, hash string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.ProductImage{}).Where("hash = ?", hash).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type productOptionRepository struct {
+	db *gorm.DB
+}
+
+func NewProductOptionRepository(db *gorm.DB) domain.ProductOptionRepository {
+	return &productOptionRepository{db: db}
+}
+
+func (r *productOptionRepository) FindByProductID(ctx context.Context, productID string) ([]domain.ProductOption, error) {
+	var options []domain.ProductOption
+	err := r.db.WithContext(ctx).Preload("Values", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position asc")
+	}).Where("id_product = ?", productID).Order("position asc").Find(&options).Error
+	return options, err
+}
+
+func (r *productOptionRepository) ReplaceOptions(ctx context.Context, productID string, options []domain.ProductOption) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Kunci produk agar dua perubahan opsi paralel tidak saling menimpa
+		var product domain.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id_product").
+			Where("id_product = ?", productID).First(&product).Error; err != nil {
+			return err
+		}
+
+		var existing []domain.ProductOption
+		if err := tx.Preload("Values").Where("id_product = ?", productID).Find(&existing).Error; err != nil {
+			return err
+		}
+		existingByName := map[string]domain.ProductOption{}
+		for _, opt := range existing {
+			existingByName[strings.ToLower(opt.Name)] = opt
+		}
+
+		keptOptions := map[string]bool{}
+		keptValues := map[string]bool{}
+		for i := range options {
+			opt := &options[i]
+			if old, ok := existingByName[strings.ToLower(opt.Name)]; ok {
+				opt.ID = old.ID
+				opt.CreatedAt = old.CreatedAt
+				oldValues := map[string]string{}
+				for _, v := range old.Values {
+					oldValues[strings.ToLower(v.Value)] = v.ID
+				}
+				for j := range opt.Values {
+					if id, ok := oldValues[strings.ToLower(opt.Values[j].Value)]; ok {
+						opt.Values[j].ID = id
+					}
+				}
+			}
+			if opt.ID == "" {
+				opt.ID = uuid.New().String()
+				opt.CreatedAt = time.Now()
+			}
+			opt.ProductID = productID
+			opt.UpdatedAt = time.Now()
+			for j := range opt.Values {
+				if opt.Values[j].ID == "" {
+					opt.Values[j].ID = uuid.New().String()
+				}
+				opt.Values[j].OptionID = opt.ID
+				keptValues[opt.Values[j].ID] = true
+			}
+			keptOptions[opt.ID] = true
+		}
+
+		// Hapus nilai/opsi yang tidak lagi disebut beserta relasinya ke varian
+		var removedValues []string
+		var removedOptions []string
+		for _, opt := range existing {
+			if !keptOptions[opt.ID] {
+				removedOptions = append(removedOptions, opt.ID)
+			}
+			for _, v := range opt.Values {
+				if !keptValues[v.ID] {
+					removedValues = append(removedValues, v.ID)
+				}
+			}
+		}
+		if len(removedValues) > 0 {
+			if err := tx.Exec("DELETE FROM variant_option_values WHERE id_option_value IN ?", removedValues).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id_option_value IN ?", removedValues).Delete(&domain.ProductOptionValue{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(removedOptions) > 0 {
+			if err := tx.Where("id_option IN ?", removedOptions).Delete(&domain.ProductOption{}).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range options {
+			opt := options[i]
+			values := opt.Values
+			opt.Values = nil
+			if err := tx.Save(&opt).Error; err != nil {
+				return err
+			}
+			for j := range values {
+				if err := tx.Save(&values[j]).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -1,12 +1,64 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// productInStockCondition bernilai true jika stok dasar produk atau salah satu variannya masih ada (lihat Product.HasStock)
+const productInStockCondition = "(products.stock > 0 OR EXISTS (SELECT 1 FROM product_variants pv WHERE pv.id_product = products.id_product AND pv.stock > 0))"
+
+// productSearchVector harus identik dengan ekspresi index idx_products_search (lihat migrations/000005_search_indexes.up.sql)
+// agar Postgres memakai GIN index. Config 'simple' dipakai karena Postgres tidak punya stemmer Bahasa Indonesia.
+const productSearchVector = "(setweight(to_tsvector('simple', coalesce(products.name, '')), 'A') || setweight(to_tsvector('simple', coalesce(products.description, '')), 'C'))"
+
+// categorySearchVector mencocokkan keyword dengan nama kategori (tabel categories di-join pada searchQuery)
+const categorySearchVector = "to_tsvector('simple', coalesce(categories.name, ''))"
+
+// productRatingExpr adalah rata-rata rating ulasan produk (NULL jika belum ada ulasan)
+const productRatingExpr = "(SELECT AVG(rv.rating) FROM reviews rv WHERE rv.id_product = products.id_product)"
+
+// productSoldExpr menjumlahkan unit terjual dari pesanan yang sudah dibayar
+const productSoldExpr = `(SELECT COALESCE(SUM(oi.quantity), 0) FROM order_items oi
+	JOIN orders o ON o.id_order = oi.id_order
+	WHERE oi.id_product = products.id_product AND o.status IN ('PAID', 'PROCESSED', 'SHIPPED', 'DELIVERED'))`
+
+// productVisibleCondition menyaring produk yang belum/tidak lagi ACTIVE serta produk habis yang diminta supplier
+// untuk disembunyikan otomatis dari katalog publik
+const productVisibleCondition = "(products.status = '" + domain.ProductStatusActive + "' AND (products.auto_hide_out_of_stock = false OR " + productInStockCondition + "))"
+
+// variantAttributeCondition membangun filter "ada varian aktif yang memiliki SEMUA nilai opsi ini".
+// Nama opsi dan nilainya dibandingkan case-insensitive; urutan key di-sort agar query stabil.
+func variantAttributeCondition(attributes map[string]string) (string, []interface{}) {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	args := make([]interface{}, 0, len(names)*2)
+	sb.WriteString("EXISTS (SELECT 1 FROM product_variants pv WHERE pv.id_product = products.id_product AND pv.deleted_at IS NULL")
+	for _, name := range names {
+		sb.WriteString(` AND EXISTS (SELECT 1 FROM variant_option_values vov
+			JOIN product_option_values ov ON ov.id_option_value = vov.id_option_value
+			JOIN product_options o ON o.id_option = ov.id_option
+			WHERE vov.id_variant = pv.id_variant AND LOWER(o.name) = LOWER(?) AND LOWER(ov.value) = LOWER(?))`)
+		args = append(args, name, attributes[name])
+	}
+	sb.WriteString(")")
+	return sb.String(), args
+}
+
 type productRepository struct {
 	db *gorm.DB
 }
@@ -15,20 +67,48 @@ func NewProductRepository(db *gorm.DB) domain.ProductRepository {
 	return &productRepository{db: db}
 }
 
-func (r *productRepository) Create(product *domain.Product) error {
-	return r.db.Create(product).Error
+// Create menyimpan produk baru beserta catatan ledger INITIAL_STOCK dalam satu transaksi
+func (r *productRepository) Create(ctx context.Context, product *domain.Product, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if err := recordStockMovement(tx, domain.StockMovement{
+			ProductID: product.ID, Delta: product.Stock, Reason: "INITIAL_STOCK", UserID: optionalString(actorID),
+		}); err != nil {
+			return err
+		}
+		for _, v := range product.Variants {
+			variantID := v.ID
+			if err := recordStockMovement(tx, domain.StockMovement{
+				ProductID: product.ID, VariantID: &variantID, Delta: v.Stock, Reason: "INITIAL_STOCK", UserID: optionalString(actorID),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // FindAll automatically joins/preloads the relative Category
-func (r *productRepository) FindAll() ([]domain.Product, error) {
+func (r *productRepository) FindAll(ctx context.Context, page pagination.Params) ([]domain.Product, string, error) {
 	var products []domain.Product
-	err := r.db.Preload("Category").Preload("Variants").Find(&products).Error
-	return products, err
+	query := catalogRead(ctx, r.db).Preload("Category").Preload("Variants").Where(productVisibleCondition)
+	if err := pagination.Apply(query, page, "products.created_at", "products.id_product").Find(&products).Error; err != nil {
+		return nil, "", err
+	}
+	products, nextCursor := pagination.Trim(products, page, func(p domain.Product) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+	})
+	return products, nextCursor, nil
 }
 
-func (r *productRepository) FindByID(id string) (*domain.Product, error) {
+func (r *productRepository) FindByID(ctx context.Context, id string) (*domain.Product, error) {
 	var product domain.Product
-	err := r.db.Preload("Category").Preload("Supplier").Preload("Variants").Where("id_product = ?", id).First(&product).Error
+	err := r.db.WithContext(ctx).Preload("Category").Preload("Supplier").Preload("Variants.OptionValues").
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).Preload("Options.Values", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("id_variant IS NOT NULL, position asc") }).
+		Where("id_product = ?", id).First(&product).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("produk tidak ditemukan")
@@ -38,18 +118,34 @@ func (r *productRepository) FindByID(id string) (*domain.Product, error) {
 	return &product, nil
 }
 
-func (r *productRepository) Update(product *domain.Product) error {
-	return r.db.Save(product).Error
+// Update menyimpan perubahan produk. Jika stok berubah, selisihnya dicatat sebagai ADJUSTMENT di ledger.
+// Stok lama dibaca dengan FOR UPDATE agar delta tidak tertukar dengan checkout yang berjalan bersamaan.
+func (r *productRepository) Update(ctx context.Context, product *domain.Product, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current domain.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id_product", "stock").
+			Where("id_product = ?", product.ID).First(&current).Error; err != nil {
+			return err
+		}
+		// Varian dikelola lewat endpoint varian sendiri; Omit mencegah Save menimpa stok varian dengan data lama.
+		// low_stock_alerted_at hanya diubah lewat SetLowStockAlertedAt karena produk dari cache tidak membawanya.
+		if err := tx.Omit("Variants", "Options", "Images", "LowStockAlertedAt").Save(product).Error; err != nil {
+			return err
+		}
+		return recordStockMovement(tx, domain.StockMovement{
+			ProductID: product.ID, Delta: product.Stock - current.Stock, Reason: "ADJUSTMENT", UserID: optionalString(actorID),
+		})
+	})
 }
 
-func (r *productRepository) Delete(id string) error {
-	return r.db.Where("id_product = ?", id).Delete(&domain.Product{}).Error
+func (r *productRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id_product = ?", id).Delete(&domain.Product{}).Error
 }
 
 // Fitur Gamifikasi 40: Mengkalkulasi rata-rata skor performa toko/supplier
-func (r *productRepository) GetSupplierRating(supplierID string) float64 {
+func (r *productRepository) GetSupplierRating(ctx context.Context, supplierID string) float64 {
 	var avgRating float64
-	r.db.Table("reviews").
+	r.db.WithContext(ctx).Table("reviews").
 		Joins("JOIN products ON reviews.id_product = products.id_product").
 		Where("products.supplier_id = ?", supplierID).
 		Select("COALESCE(AVG(reviews.rating), 0)").
@@ -57,34 +153,358 @@ func (r *productRepository) GetSupplierRating(supplierID string) float64 {
 	return avgRating
 }
 
-// Search mencari produk berdasarkan keyword (nama) dan/atau filter kategori dengan Limit & Offset
-// Menggunakan ILIKE untuk pencarian case-insensitive di PostgreSQL.
-func (r *productRepository) Search(keyword string, categoryID string, limit int, offset int) ([]domain.Product, error) {
-	var products []domain.Product
-	query := r.db.Preload("Category").Preload("Variants")
+// GetReservedStock menghitung stok yang sedang ditahan oleh pesanan belum dibayar (StockReservation ACTIVE)
+func (r *productRepository) GetReservedStock(ctx context.Context, productID string, variantID *string) (int, error) {
+	return sumActiveReservations(r.db.WithContext(ctx), productID, variantID)
+}
+
+// searchQuery membangun query dasar pencarian katalog (filter saja, tanpa preload/urutan/paging)
+// agar Search, CountSearch, dan CategoryFacets selalu memakai kondisi yang sama. Dibaca dari read replica.
+func (r *productRepository) searchQuery(ctx context.Context, filter domain.ProductSearchFilter) *gorm.DB {
+	query := catalogRead(ctx, r.db).Model(&domain.Product{}).
+		Joins("LEFT JOIN categories ON categories.id_category = products.id_category AND categories.deleted_at IS NULL").
+		Where(productVisibleCondition)
+
+	if filter.Keyword != "" {
+		query = query.Where("("+productSearchVector+" @@ websearch_to_tsquery('simple', ?) OR "+categorySearchVector+" @@ websearch_to_tsquery('simple', ?))",
+			filter.Keyword, filter.Keyword)
+	}
+
+	// Filter kategori mencakup seluruh subkategori; CategoryID boleh berupa ID atau slug
+	if filter.CategoryID != "" {
+		query = query.Where("products.id_category IN ("+categoryTreeCTE+")", filter.CategoryID, filter.CategoryID)
+	}
+
+	if filter.SupplierID != "" {
+		query = query.Where("products.supplier_id = ?", filter.SupplierID)
+	}
+
+	if filter.MinPrice > 0 {
+		query = query.Where("products.price >= ?", filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		query = query.Where("products.price <= ?", filter.MaxPrice)
+	}
+
+	if filter.MinRating > 0 {
+		query = query.Where("COALESCE("+productRatingExpr+", 0) >= ?", filter.MinRating)
+	}
+
+	if filter.InStockOnly {
+		query = query.Where(productInStockCondition)
+	}
 
-	if keyword != "" {
-		query = query.Where("name ILIKE ?", "%"+keyword+"%")
+	if len(filter.Attributes) > 0 {
+		condition, args := variantAttributeCondition(filter.Attributes)
+		query = query.Where(condition, args...)
 	}
 
-	if categoryID != "" {
-		query = query.Where("id_category = ?", categoryID)
+	return query
+}
+
+// searchOrder menerjemahkan opsi sort ke ORDER BY. id_product menjadi tie-breaker agar paging stabil.
+func searchOrder(filter domain.ProductSearchFilter) clause.OrderBy {
+	sortBy := filter.Sort
+	if sortBy == "" || (sortBy == domain.SortRelevance && filter.Keyword == "") {
+		sortBy = domain.SortNewest
+		if filter.Keyword != "" {
+			sortBy = domain.SortRelevance
+		}
 	}
 
-	if limit > 0 {
-		query = query.Limit(limit)
+	var expr clause.Expr
+	switch sortBy {
+	case domain.SortRelevance:
+		expr = clause.Expr{
+			SQL:  "ts_rank(" + productSearchVector + " || setweight(" + categorySearchVector + ", 'B'), websearch_to_tsquery('simple', ?)) DESC",
+			Vars: []interface{}{filter.Keyword},
+		}
+	case domain.SortPriceAsc:
+		expr = clause.Expr{SQL: "products.price ASC"}
+	case domain.SortPriceDesc:
+		expr = clause.Expr{SQL: "products.price DESC"}
+	case domain.SortRating:
+		expr = clause.Expr{SQL: "COALESCE(" + productRatingExpr + ", 0) DESC"}
+	case domain.SortBestSelling:
+		expr = clause.Expr{SQL: productSoldExpr + " DESC"}
+	default:
+		expr = clause.Expr{SQL: "products.created_at DESC"}
+	}
+
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:  "?, products.id_product ASC",
+		Vars: []interface{}{expr},
+	}}
+}
+
+// Search mencari produk dengan full-text search, filter, dan urutan sesuai ProductSearchFilter
+func (r *productRepository) Search(ctx context.Context, filter domain.ProductSearchFilter) ([]domain.Product, error) {
+	var products []domain.Product
+	query := r.searchQuery(ctx, filter).
+		Select("products.*").
+		Preload("Category").Preload("Variants").
+		Order(searchOrder(filter))
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
 	}
-	if offset > 0 {
-		query = query.Offset(offset)
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
 	}
 
 	err := query.Find(&products).Error
 	return products, err
 }
 
+func (r *productRepository) CountSearch(ctx context.Context, filter domain.ProductSearchFilter) (int64, error) {
+	var total int64
+	err := r.searchQuery(ctx, filter).Count(&total).Error
+	return total, err
+}
+
+// CategoryFacets sengaja mengabaikan CategoryID agar frontend tetap bisa menampilkan kategori lain sebagai pilihan
+func (r *productRepository) CategoryFacets(ctx context.Context, filter domain.ProductSearchFilter) ([]domain.CategoryFacet, error) {
+	filter.CategoryID = ""
+	var facets []domain.CategoryFacet
+	err := r.searchQuery(ctx, filter).
+		Select("products.id_category AS id_category, COALESCE(categories.name, '') AS name, COUNT(*) AS count").
+		Group("products.id_category, categories.name").
+		Order("count DESC, name ASC").
+		Scan(&facets).Error
+	return facets, err
+}
+
 // FindBySupplierID mengembalikan produk milik supplier tertentu
-func (r *productRepository) FindBySupplierID(supplierID string) ([]domain.Product, error) {
+func (r *productRepository) FindBySupplierID(ctx context.Context, supplierID string) ([]domain.Product, error) {
+	var products []domain.Product
+	err := r.db.WithContext(ctx).Preload("Category").Preload("Variants").Where("supplier_id = ?", supplierID).Find(&products).Error
+	return products, err
+}
+
+func (r *productRepository) FindByIDs(ctx context.Context, ids []string) ([]domain.Product, error) {
 	var products []domain.Product
-	err := r.db.Preload("Category").Preload("Variants").Where("supplier_id = ?", supplierID).Find(&products).Error
+	err := r.db.WithContext(ctx).Preload("Variants").Where("id_product IN ?", ids).Find(&products).Error
 	return products, err
 }
+
+// FindByStatus mengembalikan produk dengan status tertentu beserta kategori dan supplier-nya
+func (r *productRepository) FindByStatus(ctx context.Context, status string, page pagination.Params) ([]domain.Product, string, error) {
+	var products []domain.Product
+	query := r.db.WithContext(ctx).Preload("Category").Preload("Supplier").Preload("Variants").Where("products.status = ?", status)
+	if err := pagination.Apply(query, page, "products.created_at", "products.id_product").Find(&products).Error; err != nil {
+		return nil, "", err
+	}
+	products, nextCursor := pagination.Trim(products, page, func(p domain.Product) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+	})
+	return products, nextCursor, nil
+}
+
+// UpdateStatus memakai Select agar perubahan status tidak ikut menimpa stok atau harga yang sedang berubah
+func (r *productRepository) UpdateStatus(ctx context.Context, product *domain.Product) error {
+	return r.db.WithContext(ctx).Model(&domain.Product{}).Where("id_product = ?", product.ID).
+		Select("status", "rejection_reason", "submitted_at", "reviewed_at", "reviewed_by", "updated_at").
+		Updates(product).Error
+}
+
+// SetLowStockAlertedAt memakai UpdateColumn agar updated_at produk tidak ikut berubah
+func (r *productRepository) SetLowStockAlertedAt(ctx context.Context, productID string, alertedAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.Product{}).Where("id_product = ?", productID).UpdateColumn("low_stock_alerted_at", alertedAt).Error
+}
+
+func (r *productRepository) TotalStock(ctx context.Context, productID string) (int, error) {
+	var total int
+	err := r.db.WithContext(ctx).Raw(`SELECT p.stock + COALESCE((SELECT SUM(v.stock) FROM product_variants v
+		WHERE v.id_product = p.id_product AND v.deleted_at IS NULL), 0)
+		FROM products p WHERE p.id_product = ? AND p.deleted_at IS NULL`, productID).Row().Scan(&total)
+	return total, err
+}
+
+func (r *productRepository) FindVariantsByProductID(ctx context.Context, productID string) ([]domain.ProductVariant, error) {
+	var variants []domain.ProductVariant
+	err := r.db.WithContext(ctx).Preload("OptionValues").Where("id_product = ?", productID).Order("created_at asc").Find(&variants).Error
+	return variants, err
+}
+
+func (r *productRepository) FindVariantByID(ctx context.Context, productID string, variantID string) (*domain.ProductVariant, error) {
+	var variant domain.ProductVariant
+	err := r.db.WithContext(ctx).Where("id_variant = ? AND id_product = ?", variantID, productID).First(&variant).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("varian produk tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (r *productRepository) SKUExists(ctx context.Context, sku string, excludeVariantID string) (bool, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&domain.ProductVariant{}).Where("sku_code = ?", sku)
+	if excludeVariantID != "" {
+		query = query.Where("id_variant <> ?", excludeVariantID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// ProductSKUExists memakai EXISTS dengan predikat yang sama dengan indeks parsial idx_products_supplier_sku
+// agar pengecekan tidak perlu memuat seluruh produk supplier
+func (r *productRepository) ProductSKUExists(ctx context.Context, supplierID string, sku string, excludeProductID string) (bool, error) {
+	var exists bool
+	err := r.db.WithContext(ctx).Raw(`SELECT EXISTS (
+		SELECT 1 FROM products
+		WHERE supplier_id = ? AND sku = ? AND sku <> '' AND deleted_at IS NULL AND id_product <> ?
+	)`, supplierID, sku, excludeProductID).Scan(&exists).Error
+	return exists, err
+}
+
+func (r *productRepository) CreateVariant(ctx context.Context, variant *domain.ProductVariant, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createVariant(tx, variant, actorID)
+	})
+}
+
+func (r *productRepository) UpdateVariant(ctx context.Context, variant *domain.ProductVariant, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateVariant(tx, variant, actorID)
+	})
+}
+
+func (r *productRepository) DeleteVariant(ctx context.Context, productID string, variantID string, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteVariant(tx, productID, variantID, actorID)
+	})
+}
+
+func (r *productRepository) ReplaceVariants(ctx context.Context, productID string, variants []domain.ProductVariant, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Kunci produk agar dua replace paralel tidak saling menimpa
+		var product domain.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id_product").
+			Where("id_product = ?", productID).First(&product).Error; err != nil {
+			return errors.New("produk tidak ditemukan")
+		}
+
+		var existing []domain.ProductVariant
+		if err := tx.Where("id_product = ?", productID).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		keep := map[string]bool{}
+		for _, v := range variants {
+			if v.ID != "" {
+				keep[v.ID] = true
+			}
+		}
+		for _, old := range existing {
+			if keep[old.ID] {
+				continue
+			}
+			// Varian dihapus lebih dulu agar SKU-nya bisa dipakai ulang oleh varian baru di transaksi yang sama
+			if err := deleteVariant(tx, productID, old.ID, actorID); err != nil {
+				return err
+			}
+		}
+
+		existingIDs := map[string]bool{}
+		for _, old := range existing {
+			existingIDs[old.ID] = true
+		}
+		for i := range variants {
+			variants[i].ProductID = productID
+			if existingIDs[variants[i].ID] {
+				if err := updateVariant(tx, &variants[i], actorID); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := createVariant(tx, &variants[i], actorID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *productRepository) ApplyGeneratedVariants(ctx context.Context, productID string, created []domain.ProductVariant, removedIDs []string, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Kunci produk agar generate paralel tidak membuat kombinasi yang sama dua kali
+		var product domain.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id_product").
+			Where("id_product = ?", productID).First(&product).Error; err != nil {
+			return errors.New("produk tidak ditemukan")
+		}
+		for i := range created {
+			created[i].ProductID = productID
+			if err := createVariant(tx, &created[i], actorID); err != nil {
+				return err
+			}
+		}
+		for _, id := range removedIDs {
+			if err := deleteVariant(tx, productID, id, actorID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deleteVariant mengunci varian, menolak penghapusan selama masih ada reservasi ACTIVE
+// (pesanan yang belum dibayar akan kehilangan stoknya), lalu mencatat sisa stok sebagai
+// ADJUSTMENT keluar agar saldo ledger varian berakhir di 0 sebelum baris varian dihapus
+func deleteVariant(tx *gorm.DB, productID string, variantID string, actorID string) error {
+	var current domain.ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id_variant", "stock").
+		Where("id_variant = ? AND id_product = ?", variantID, productID).First(&current).Error; err != nil {
+		return errors.New("varian produk tidak ditemukan")
+	}
+
+	reserved, err := sumActiveReservations(tx, productID, &variantID)
+	if err != nil {
+		return err
+	}
+	if reserved > 0 {
+		return fmt.Errorf("varian tidak dapat dihapus karena masih direservasi %d unit oleh pesanan yang belum dibayar", reserved)
+	}
+
+	if current.Stock != 0 {
+		if err := tx.Model(&domain.ProductVariant{}).Where("id_variant = ?", variantID).Update("stock", 0).Error; err != nil {
+			return err
+		}
+		if err := recordStockMovement(tx, domain.StockMovement{
+			ProductID: productID, VariantID: &variantID, Delta: -current.Stock, Reason: "ADJUSTMENT", UserID: optionalString(actorID),
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Delete(&domain.ProductVariant{}, "id_variant = ?", variantID).Error
+}
+
+// createVariant menyimpan varian baru beserta catatan ledger INITIAL_STOCK
+func createVariant(tx *gorm.DB, variant *domain.ProductVariant, actorID string) error {
+	if err := tx.Create(variant).Error; err != nil {
+		return err
+	}
+	variantID := variant.ID
+	return recordStockMovement(tx, domain.StockMovement{
+		ProductID: variant.ProductID, VariantID: &variantID, Delta: variant.Stock, Reason: "INITIAL_STOCK", UserID: optionalString(actorID),
+	})
+}
+
+// updateVariant mengunci stok lama varian lalu mencatat selisihnya sebagai ADJUSTMENT
+func updateVariant(tx *gorm.DB, variant *domain.ProductVariant, actorID string) error {
+	var current domain.ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id_variant", "stock", "created_at").
+		Where("id_variant = ? AND id_product = ?", variant.ID, variant.ProductID).First(&current).Error; err != nil {
+		return errors.New("varian produk tidak ditemukan")
+	}
+	variant.CreatedAt = current.CreatedAt
+	if err := tx.Save(variant).Error; err != nil {
+		return err
+	}
+	variantID := variant.ID
+	return recordStockMovement(tx, domain.StockMovement{
+		ProductID: variant.ProductID, VariantID: &variantID, Delta: variant.Stock - current.Stock, Reason: "ADJUSTMENT", UserID: optionalString(actorID),
+	})
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+func TestDeleteVariant_RejectedWhileReserved(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	execSeed(t, db,
+		`INSERT INTO stock_reservations (id_reservation, id_order, id_product, id_variant, quantity, status, expires_at, created_at, updated_at)
+			VALUES ('r1', 'o1', 'p1', 'v1', 2, 'ACTIVE', now() + interval '10 minutes', now(), now())`,
+	)
+	repo := NewProductRepository(db)
+
+	err := repo.DeleteVariant(context.Background(), "p1", "v1", "u1")
+	if err == nil || !strings.Contains(err.Error(), "direservasi") {
+		t.Fatalf("Expected delete rejected while an ACTIVE reservation exists, got %v", err)
+	}
+	if _, err := repo.FindVariantByID(context.Background(), "p1", "v1"); err != nil {
+		t.Errorf("Expected variant to remain after the rejected delete, got %v", err)
+	}
+
+	// Reservasi kedaluwarsa tidak lagi menahan stok sehingga tidak memblokir penghapusan
+	execSeed(t, db, `UPDATE stock_reservations SET expires_at = now() - interval '1 minute'`)
+	if err := repo.DeleteVariant(context.Background(), "p1", "v1", "u1"); err != nil {
+		t.Errorf("Expected delete allowed once the reservation expired, got %v", err)
+	}
+}
+
+func TestDeleteVariant_WritesAdjustmentForRemainingStock(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	repo := NewProductRepository(db)
+
+	if err := repo.DeleteVariant(context.Background(), "p1", "v1", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	movements := movementsOf(t, db, "p1", "v1")
+	if len(movements) != 1 {
+		t.Fatalf("Expected one ledger entry for the deleted variant, got %+v", movements)
+	}
+	m := movements[0]
+	if m.Reason != "ADJUSTMENT" || m.Delta != -4 || m.BalanceAfter != 0 || m.UserID == nil || *m.UserID != "u1" {
+		t.Errorf("Expected ADJUSTMENT -4 to balance 0 by u1, got %+v", m)
+	}
+
+	// Varian tanpa stok tidak menghasilkan catatan ledger kosong
+	if err := repo.DeleteVariant(context.Background(), "p1", "v2", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if movements := movementsOf(t, db, "p1", "v2"); len(movements) != 0 {
+		t.Errorf("Expected no ledger entry for a variant without stock, got %+v", movements)
+	}
+}
+
+func TestReplaceVariants_RemovedVariantsFollowDeleteRules(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	repo := NewProductRepository(db)
+	now := time.Now()
+	keep := []domain.ProductVariant{{ID: "v2", NameLabel: "1 Kg", Price: 18000, Stock: 0, SKUCode: "BYM-1000", CreatedAt: now, UpdatedAt: now}}
+
+	execSeed(t, db,
+		`INSERT INTO stock_reservations (id_reservation, id_order, id_product, id_variant, quantity, status, expires_at, created_at, updated_at)
+			VALUES ('r1', 'o1', 'p1', 'v1', 1, 'ACTIVE', now() + interval '10 minutes', now(), now())`,
+	)
+	if err := repo.ReplaceVariants(context.Background(), "p1", keep, "u1"); err == nil {
+		t.Fatal("Expected replace rejected while a dropped variant is still reserved")
+	}
+	if _, err := repo.FindVariantByID(context.Background(), "p1", "v1"); err != nil {
+		t.Errorf("Expected the whole replace rolled back, got %v", err)
+	}
+
+	execSeed(t, db, `UPDATE stock_reservations SET status = 'RELEASED'`)
+	if err := repo.ReplaceVariants(context.Background(), "p1", keep, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	movements := movementsOf(t, db, "p1", "v1")
+	if len(movements) != 1 || movements[0].Reason != "ADJUSTMENT" || movements[0].Delta != -4 {
+		t.Errorf("Expected ADJUSTMENT -4 for the dropped variant, got %+v", movements)
+	}
+}
+
+func TestApplyGeneratedVariants_RollsBackOnFailedRemoval(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	execSeed(t, db,
+		`INSERT INTO stock_reservations (id_reservation, id_order, id_product, id_variant, quantity, status, expires_at, created_at, updated_at)
+			VALUES ('r1', 'o1', 'p1', 'v2', 1, 'ACTIVE', now() + interval '10 minutes', now(), now())`,
+	)
+	repo := NewProductRepository(db)
+	now := time.Now()
+	created := []domain.ProductVariant{{ID: "v3", NameLabel: "500g", Price: 9000, Stock: 3, CreatedAt: now, UpdatedAt: now}}
+
+	// v1 bisa dihapus tetapi v2 masih direservasi: seluruh batch harus batal
+	if err := repo.ApplyGeneratedVariants(context.Background(), "p1", created, []string{"v1", "v2"}, "u1"); err == nil {
+		t.Fatal("Expected error when a stale variant is still reserved")
+	}
+	variants, err := repo.FindVariantsByProductID(context.Background(), "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 2 {
+		t.Errorf("Expected the original two variants after rollback, got %+v", variants)
+	}
+	if movements := movementsOf(t, db, "p1", "v3"); len(movements) != 0 {
+		t.Errorf("Expected no INITIAL_STOCK for the rolled back variant, got %+v", movements)
+	}
+}
+
+func TestTotalStock_SumsProductAndLiveVariants(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	repo := NewProductRepository(db)
+
+	total, err := repo.TotalStock(context.Background(), "p1")
+	if err != nil || total != 9 {
+		t.Fatalf("Expected 5 + 4 + 0 = 9, got %d (%v)", total, err)
+	}
+	if err := repo.DeleteVariant(context.Background(), "p1", "v1", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if total, err := repo.TotalStock(context.Background(), "p1"); err != nil || total != 5 {
+		t.Errorf("Expected deleted variants excluded (5), got %d (%v)", total, err)
+	}
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// ReplicaCatalog adalah nama resolver read replica katalog. main meneruskannya ke config.InitReplicas
+// saat mendaftarkan DB_REPLICA_HOSTS; tanpa replika, query yang memilihnya tetap berjalan di primary.
+const ReplicaCatalog = "catalog"
+
+type primaryReadKey struct{}
+
+// withPrimaryRead menandai ctx agar catalogRead membaca dari primary. Dipakai cache produk saat cache
+// miss: data yang disimpan selama cacheTTL tidak boleh berasal dari replika yang sedang tertinggal.
+func withPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadKey{}, true)
+}
+
+// catalogRead mengarahkan query baca katalog/listing publik ke read replica (jika DB_REPLICA_HOSTS diisi).
+// Hanya untuk data yang boleh sedikit tertinggal dari primary; query yang harus membaca hasil tulis
+// terbaru (stok saat checkout, produk setelah update) tetap memakai r.db langsung.
+func catalogRead(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if primary, _ := ctx.Value(primaryReadKey{}).(bool); primary {
+		return db.WithContext(ctx)
+	}
+	return db.WithContext(ctx).Clauses(dbresolver.Use(ReplicaCatalog))
+}
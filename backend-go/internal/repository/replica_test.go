@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+// usesReplica memeriksa apakah query memilih resolver replika lewat dbresolver.Use
+func usesReplica(db *gorm.DB) bool {
+	_, ok := db.Statement.Clauses["gorm:db_resolver:using"]
+	return ok
+}
+
+func TestCatalogRead_PrimaryOnCacheMiss(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !usesReplica(catalogRead(context.Background(), db)) {
+		t.Error("Query katalog biasa harus diarahkan ke read replica")
+	}
+	if usesReplica(catalogRead(withPrimaryRead(context.Background()), db)) {
+		t.Error("Query untuk mengisi cache harus membaca dari primary")
+	}
+}
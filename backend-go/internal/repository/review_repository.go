@@ -1,14 +1,17 @@
 package repository
 
 import (
+	"context"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 	"gorm.io/gorm"
 )
 
 type ReviewRepository interface {
-	Create(review *domain.Review) error
-	GetByProductID(productID string) ([]domain.Review, error)
-	GetAverageRating(productID string) (float64, error)
+	Create(ctx context.Context, review *domain.Review) error
+	GetByProductID(ctx context.Context, productID string, page pagination.Params) ([]domain.Review, string, error)
+	GetAverageRating(ctx context.Context, productID string) (float64, error)
+	CountByProductID(ctx context.Context, productID string) (int64, error)
 }
 
 type reviewRepository struct {
@@ -19,18 +22,30 @@ func NewReviewRepository(db *gorm.DB) ReviewRepository {
 	return &reviewRepository{db}
 }
 
-func (r *reviewRepository) Create(rev *domain.Review) error {
-	return r.db.Create(rev).Error
+func (r *reviewRepository) Create(ctx context.Context, rev *domain.Review) error {
+	return r.db.WithContext(ctx).Create(rev).Error
 }
 
-func (r *reviewRepository) GetByProductID(productID string) ([]domain.Review, error) {
+func (r *reviewRepository) GetByProductID(ctx context.Context, productID string, page pagination.Params) ([]domain.Review, string, error) {
 	var reviews []domain.Review
-	err := r.db.Preload("User").Where("id_product = ?", productID).Order("created_at DESC").Find(&reviews).Error
-	return reviews, err
+	query := catalogRead(ctx, r.db).Preload("User").Where("id_product = ?", productID)
+	if err := pagination.Apply(query, page, "created_at", "id_review").Find(&reviews).Error; err != nil {
+		return nil, "", err
+	}
+	reviews, nextCursor := pagination.Trim(reviews, page, func(rv domain.Review) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: rv.CreatedAt, ID: rv.ID}
+	})
+	return reviews, nextCursor, nil
 }
 
-func (r *reviewRepository) GetAverageRating(productID string) (float64, error) {
+func (r *reviewRepository) GetAverageRating(ctx context.Context, productID string) (float64, error) {
 	var avg float64
-	err := r.db.Model(&domain.Review{}).Where("id_product = ?", productID).Select("COALESCE(AVG(rating), 0)").Scan(&avg).Error
+	err := r.db.WithContext(ctx).Model(&domain.Review{}).Where("id_product = ?", productID).Select("COALESCE(AVG(rating), 0)").Scan(&avg).Error
 	return avg, err
 }
+
+func (r *reviewRepository) CountByProductID(ctx context.Context, productID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Review{}).Where("id_product = ?", productID).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// productSuggestPrefix berada di bawah namespace products: agar ikut terhapus saat katalog berubah
+	productSuggestPrefix = "products:suggest:"
+	suggestCacheTTL      = 10 * time.Minute
+)
+
+// cachedSearchSuggestionRepository membungkus SearchSuggestionRepository dengan Redis,
+// memakai client opsional yang sama dengan cachedProductRepository
+type cachedSearchSuggestionRepository struct {
+	base  domain.SearchSuggestionRepository
+	cache *redis.Client // Bisa nil jika Redis tidak tersedia
+}
+
+func NewCachedSearchSuggestionRepository(base domain.SearchSuggestionRepository, redisClient *redis.Client) domain.SearchSuggestionRepository {
+	return &cachedSearchSuggestionRepository{
+		base:  base,
+		cache: redisClient,
+	}
+}
+
+func (r *cachedSearchSuggestionRepository) Suggest(ctx context.Context, query string, limit int) (*domain.SearchSuggestions, error) {
+	if r.cache == nil {
+		return r.base.Suggest(ctx, query, limit)
+	}
+
+	cacheKey := fmt.Sprintf("%s%d:%s", productSuggestPrefix, limit, query)
+
+	cached, err := r.cache.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var suggestions domain.SearchSuggestions
+		if json.Unmarshal([]byte(cached), &suggestions) == nil {
+			metrics.CacheRequests.WithLabelValues("search_suggestion", metrics.ResultHit).Inc()
+			return &suggestions, nil
+		}
+	}
+	metrics.CacheRequests.WithLabelValues("search_suggestion", metrics.ResultMiss).Inc()
+
+	suggestions, err := r.base.Suggest(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	data, marshalErr := json.Marshal(suggestions)
+	if marshalErr == nil {
+		r.cache.Set(ctx, cacheKey, data, suggestCacheTTL)
+	}
+	return suggestions, nil
+}
+
+// RecordQuery tidak menghapus cache: pencarian populer cukup diperbarui saat TTL habis
+func (r *cachedSearchSuggestionRepository) RecordQuery(ctx context.Context, query string) error {
+	return r.base.RecordQuery(ctx, query)
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// suggestionWordSimilarity adalah ambang pg_trgm word_similarity untuk operator <%.
+// 0.4 cukup longgar untuk "brocoli" → "Brokoli Segar" tetapi tidak memunculkan nama yang tidak berhubungan.
+const suggestionWordSimilarity = "0.4"
+
+type searchSuggestionRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchSuggestionRepository(db *gorm.DB) domain.SearchSuggestionRepository {
+	return &searchSuggestionRepository{db: db}
+}
+
+// escapeLike meloloskan karakter wildcard LIKE agar input pengguna dicocokkan apa adanya
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Suggest mencocokkan awalan kata (ILIKE) atau kemiripan trigram (<%) lalu mengurutkan hasil
+// yang cocok awalan lebih dulu, kemudian berdasarkan word_similarity
+func (r *searchSuggestionRepository) Suggest(ctx context.Context, query string, limit int) (*domain.SearchSuggestions, error) {
+	result := &domain.SearchSuggestions{
+		Products:   []domain.ProductSuggestion{},
+		Categories: []domain.CategorySuggestion{},
+		Queries:    []string{},
+	}
+	prefix := escapeLike(query) + "%"
+	wordPrefix := "% " + prefix
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// SET LOCAL hanya berlaku di transaksi ini, koneksi pool lain tidak terpengaruh
+		if err := tx.Exec("SET LOCAL pg_trgm.word_similarity_threshold = " + suggestionWordSimilarity).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&domain.Product{}).
+			Select("products.id_product, products.name").
+			Where(productVisibleCondition).
+			Where("(products.name ILIKE ? OR products.name ILIKE ? OR ? <% products.name)", prefix, wordPrefix, query).
+			Order(suggestionOrder("products.name", prefix, query)).
+			Limit(limit).
+			Scan(&result.Products).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&domain.Category{}).
+			Select("id_category, name").
+			Where("(name ILIKE ? OR name ILIKE ? OR ? <% name)", prefix, wordPrefix, query).
+			Order(suggestionOrder("name", prefix, query)).
+			Limit(limit).
+			Scan(&result.Categories).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.SearchQuery{}).
+			Where("(query LIKE ? OR ? <% query)", prefix, query).
+			Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "(query LIKE ?) DESC, hits DESC, query ASC",
+				Vars: []interface{}{prefix},
+			}}).
+			Limit(limit).
+			Pluck("query", &result.Queries).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func suggestionOrder(column string, prefix string, query string) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:  "(" + column + " ILIKE ?) DESC, word_similarity(?, " + column + ") DESC, " + column + " ASC",
+		Vars: []interface{}{prefix, query},
+	}}
+}
+
+// RecordQuery melakukan upsert agar keyword yang sama cukup menambah hits
+func (r *searchSuggestionRepository) RecordQuery(ctx context.Context, query string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "query"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"hits":             gorm.Expr("search_queries.hits + 1"),
+			"last_searched_at": now,
+		}),
+	}).Create(&domain.SearchQuery{Query: query, Hits: 1, LastSearchedAt: now}).Error
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"gorm.io/gorm"
+)
+
+type stockMovementRepository struct {
+	db *gorm.DB
+}
+
+func NewStockMovementRepository(db *gorm.DB) domain.StockMovementRepository {
+	return &stockMovementRepository{db: db}
+}
+
+// FindByFilter mengembalikan riwayat pergerakan stok terbaru lebih dulu beserta total baris untuk paginasi
+func (r *stockMovementRepository) FindByFilter(ctx context.Context, filter domain.StockMovementFilter) ([]domain.StockMovement, int64, error) {
+	var movements []domain.StockMovement
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.StockMovement{})
+	if filter.SupplierID != "" {
+		query = query.Joins("JOIN products ON products.id_product = stock_movements.id_product").
+			Where("products.supplier_id = ?", filter.SupplierID)
+	}
+	if filter.ProductID != "" {
+		query = query.Where("stock_movements.id_product = ?", filter.ProductID)
+	}
+	if filter.VariantID != "" {
+		query = query.Where("stock_movements.id_variant = ?", filter.VariantID)
+	}
+	if filter.Reason != "" {
+		query = query.Where("stock_movements.reason = ?", filter.Reason)
+	}
+	if filter.From != nil {
+		query = query.Where("stock_movements.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("stock_movements.created_at <= ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	err := query.Select("stock_movements.*").Order("stock_movements.created_at desc").Find(&movements).Error
+	return movements, total, err
+}
+
+// CheckConsistency merekap ulang stok dari ledger (SUM delta) lalu membandingkannya dengan stok tersimpan.
+// Untuk produk tanpa varian, yang dibandingkan adalah products.stock; untuk varian, product_variants.stock.
+func (r *stockMovementRepository) CheckConsistency(ctx context.Context) ([]domain.StockDiscrepancy, error) {
+	var discrepancies []domain.StockDiscrepancy
+
+	var productRows []domain.StockDiscrepancy
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT p.id_product AS product_id, NULL AS variant_id, p.name AS name, p.stock AS current_stock,
+		       COALESCE(SUM(m.delta), 0) AS ledger_stock
+		FROM products p
+		LEFT JOIN stock_movements m ON m.id_product = p.id_product AND m.id_variant IS NULL
+		WHERE p.deleted_at IS NULL
+		GROUP BY p.id_product, p.name, p.stock
+		HAVING p.stock <> COALESCE(SUM(m.delta), 0)`).Scan(&productRows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var variantRows []domain.StockDiscrepancy
+	err = r.db.WithContext(ctx).Raw(`
+		SELECT v.id_product AS product_id, v.id_variant AS variant_id, v.name_label AS name, v.stock AS current_stock,
+		       COALESCE(SUM(m.delta), 0) AS ledger_stock
+		FROM product_variants v
+		LEFT JOIN stock_movements m ON m.id_variant = v.id_variant
+		WHERE v.deleted_at IS NULL
+		GROUP BY v.id_product, v.id_variant, v.name_label, v.stock
+		HAVING v.stock <> COALESCE(SUM(m.delta), 0)`).Scan(&variantRows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range append(productRows, variantRows...) {
+		row.Difference = row.CurrentStock - row.LedgerStock
+		discrepancies = append(discrepancies, row)
+	}
+	return discrepancies, nil
+}
+
+// BackfillOpeningBalances dipakai sekali untuk data lama yang dibuat sebelum ledger ada
+func (r *stockMovementRepository) BackfillOpeningBalances(ctx context.Context) (int, error) {
+	var created int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var products []domain.Product
+		if err := tx.Where("NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.id_product = products.id_product AND m.id_variant IS NULL)").
+			Find(&products).Error; err != nil {
+			return err
+		}
+		for _, p := range products {
+			if err := recordStockMovement(tx, domain.StockMovement{ProductID: p.ID, Delta: p.Stock, Reason: "OPENING_BALANCE"}); err != nil {
+				return err
+			}
+			created++
+		}
+
+		var variants []domain.ProductVariant
+		if err := tx.Where("NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.id_variant = product_variants.id_variant)").
+			Find(&variants).Error; err != nil {
+			return err
+		}
+		for _, v := range variants {
+			variantID := v.ID
+			if err := recordStockMovement(tx, domain.StockMovement{ProductID: v.ProductID, VariantID: &variantID, Delta: v.Stock, Reason: "OPENING_BALANCE"}); err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	return created, err
+}
+
+// recordStockMovement menulis satu baris ledger di dalam transaksi yang sama dengan perubahan stoknya.
+// BalanceAfter dibaca ulang dari baris produk/varian sehingga selalu mencerminkan hasil akhir transaksi.
+func recordStockMovement(tx *gorm.DB, m domain.StockMovement) error {
+	if m.Delta == 0 && m.Reason != "OPENING_BALANCE" && m.Reason != "INITIAL_STOCK" {
+		return nil
+	}
+
+	var balance int
+	if m.VariantID != nil {
+		if err := tx.Unscoped().Model(&domain.ProductVariant{}).Where("id_variant = ?", *m.VariantID).Select("stock").Row().Scan(&balance); err != nil {
+			return err
+		}
+	} else {
+		if err := tx.Unscoped().Model(&domain.Product{}).Where("id_product = ?", m.ProductID).Select("stock").Row().Scan(&balance); err != nil {
+			return err
+		}
+	}
+
+	m.ID = uuid.New().String()
+	m.BalanceAfter = balance
+	m.CreatedAt = time.Now()
+	return tx.Create(&m).Error
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
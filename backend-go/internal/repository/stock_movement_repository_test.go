@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"gorm.io/gorm"
+)
+
+func TestProductLedger_CreateAndUpdateWriteMovements(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	repo := NewProductRepository(db)
+	now := time.Now()
+
+	product := &domain.Product{
+		ID: "p2", Name: "Kangkung", Price: 4000, Stock: 7, CategoryID: "c1", SupplierID: "u1", CreatedAt: now, UpdatedAt: now,
+		Variants: []domain.ProductVariant{{ID: "v3", ProductID: "p2", NameLabel: "500g", Price: 7000, Stock: 3, SKUCode: "KKG-500", CreatedAt: now, UpdatedAt: now}},
+	}
+	if err := repo.Create(context.Background(), product, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if m := movementsOf(t, db, "p2", ""); len(m) != 1 || m[0].Reason != "INITIAL_STOCK" || m[0].Delta != 7 || m[0].BalanceAfter != 7 {
+		t.Errorf("Expected INITIAL_STOCK +7 for the product, got %+v", m)
+	}
+	if m := movementsOf(t, db, "p2", "v3"); len(m) != 1 || m[0].Reason != "INITIAL_STOCK" || m[0].Delta != 3 || m[0].BalanceAfter != 3 {
+		t.Errorf("Expected INITIAL_STOCK +3 for the variant, got %+v", m)
+	}
+
+	product.Variants = nil
+	product.Stock = 2
+	if err := repo.Update(context.Background(), product, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	// Update tanpa perubahan stok tidak boleh menambah baris ledger
+	product.Name = "Kangkung Segar"
+	if err := repo.Update(context.Background(), product, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	movements := movementsOf(t, db, "p2", "")
+	if len(movements) != 2 {
+		t.Fatalf("Expected INITIAL_STOCK followed by one ADJUSTMENT, got %+v", movements)
+	}
+	if m := movements[1]; m.Reason != "ADJUSTMENT" || m.Delta != -5 || m.BalanceAfter != 2 || m.UserID == nil || *m.UserID != "u1" {
+		t.Errorf("Expected ADJUSTMENT -5 to balance 2 by u1, got %+v", m)
+	}
+}
+
+func TestDecrementStock_RejectsShortStock(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	variantID := "v1"
+
+	// Permintaan 6 unit dari stok 4 ditolak utuh: stok tidak dipotong sebagian dan ledger tidak ditulis
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return decrementStock(tx, "p1", &variantID, 6, "o1")
+	})
+	if !errors.Is(err, errInsufficientStock) {
+		t.Fatalf("Expected errInsufficientStock, got %v", err)
+	}
+	if movements := movementsOf(t, db, "p1", "v1"); len(movements) != 0 {
+		t.Fatalf("Expected no SALE entry for a rejected decrement, got %+v", movements)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return decrementStock(tx, "p1", &variantID, 3, "o1")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	movements := movementsOf(t, db, "p1", "v1")
+	if len(movements) != 1 {
+		t.Fatalf("Expected one SALE entry, got %+v", movements)
+	}
+	m := movements[0]
+	if m.Reason != "SALE" || m.Delta != -3 || m.BalanceAfter != 1 || m.OrderID == nil || *m.OrderID != "o1" {
+		t.Errorf("Expected SALE -3 to balance 1 for order o1, got %+v", m)
+	}
+}
+
+func TestCancelOrder_LegacyOrderRestockIsRecorded(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	// Pesanan lama tanpa reservasi: stoknya sudah dipotong saat checkout sehingga pembatalan mengembalikannya
+	execSeed(t, db,
+		`INSERT INTO orders (id_order, id_user, total_amount, status, created_at, updated_at) VALUES ('o1', 'u1', 10000, 'PENDING', now(), now())`,
+		`INSERT INTO order_items (id_order_item, id_order, id_product, id_variant, quantity, price_at_purchase, created_at, updated_at)
+			VALUES ('oi1', 'o1', 'p1', 'v1', 2, 5000, now(), now())`,
+	)
+
+	if err := NewOrderRepository(db).CancelOrder(context.Background(), "o1", "CANCELLED"); err != nil {
+		t.Fatal(err)
+	}
+	movements := movementsOf(t, db, "p1", "v1")
+	if len(movements) != 1 {
+		t.Fatalf("Expected one restock entry, got %+v", movements)
+	}
+	m := movements[0]
+	if m.Reason != "ORDER_CANCELLED_RESTOCK" || m.Delta != 2 || m.BalanceAfter != 6 || m.OrderID == nil || *m.OrderID != "o1" {
+		t.Errorf("Expected ORDER_CANCELLED_RESTOCK +2 to balance 6 for order o1, got %+v", m)
+	}
+}
+
+func TestBackfillOpeningBalances_WritesOnceForUntrackedStock(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	repo := NewStockMovementRepository(db)
+
+	created, err := repo.BackfillOpeningBalances(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// p1, v1, dan v2 (stok 0 tetap dicatat sebagai saldo awal)
+	if created != 3 {
+		t.Errorf("Expected 3 opening balances, got %d", created)
+	}
+	if m := movementsOf(t, db, "p1", "v2"); len(m) != 1 || m[0].Reason != "OPENING_BALANCE" || m[0].Delta != 0 {
+		t.Errorf("Expected a zero OPENING_BALANCE for the empty variant, got %+v", m)
+	}
+
+	created, err = repo.BackfillOpeningBalances(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != 0 {
+		t.Errorf("Expected the second backfill to skip items that already have a ledger, got %d", created)
+	}
+}
+
+func TestCheckConsistency_ReportsDriftFromLedger(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	repo := NewStockMovementRepository(db)
+
+	// Tanpa ledger, p1 (5) dan v1 (4) berbeda dari rekap 0; v2 yang stoknya 0 tetap konsisten
+	discrepancies, err := repo.CheckConsistency(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 2 {
+		t.Fatalf("Expected 2 discrepancies before backfill, got %+v", discrepancies)
+	}
+
+	if _, err := repo.BackfillOpeningBalances(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if discrepancies, err := repo.CheckConsistency(context.Background()); err != nil || len(discrepancies) != 0 {
+		t.Fatalf("Expected no discrepancies after backfill, got %+v (%v)", discrepancies, err)
+	}
+
+	// Perubahan stok yang melewati ledger harus terdeteksi
+	execSeed(t, db, `UPDATE product_variants SET stock = 1 WHERE id_variant = 'v1'`)
+	discrepancies, err = repo.CheckConsistency(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 1 {
+		t.Fatalf("Expected one discrepancy, got %+v", discrepancies)
+	}
+	d := discrepancies[0]
+	if d.VariantID == nil || *d.VariantID != "v1" || d.CurrentStock != 1 || d.LedgerStock != 4 || d.Difference != -3 {
+		t.Errorf("Expected v1 current 1 ledger 4 difference -3, got %+v", d)
+	}
+}
+
+func TestFindByFilter_FiltersAndPaginatesNewestFirst(t *testing.T) {
+	db := openTestDB(t)
+	seedCatalog(t, db)
+	execSeed(t, db,
+		`INSERT INTO users (id_user, nama, email, password, role) VALUES ('u2', 'Supplier Lain', 's2@example.com', 'x', 'SUPPLIER')`,
+		`INSERT INTO products (id_product, name, price, stock, id_category, supplier_id, created_at, updated_at) VALUES ('p9', 'Wortel', 6000, 1, 'c1', 'u2', now(), now())`,
+		`INSERT INTO stock_movements (id_movement, id_product, id_variant, delta, reason, balance_after, created_at) VALUES
+			('m1', 'p1', NULL, 5, 'INITIAL_STOCK', 5, '2026-01-01 08:00:00+00'),
+			('m2', 'p1', 'v1', 4, 'INITIAL_STOCK', 4, '2026-01-02 08:00:00+00'),
+			('m3', 'p1', 'v1', -1, 'SALE', 3, '2026-01-03 08:00:00+00'),
+			('m4', 'p1', 'v1', 1, 'ADJUSTMENT', 4, '2026-01-04 08:00:00+00'),
+			('m5', 'p9', NULL, 1, 'INITIAL_STOCK', 1, '2026-01-05 08:00:00+00')`,
+	)
+	repo := NewStockMovementRepository(db)
+	ids := func(movements []domain.StockMovement) []string {
+		var out []string
+		for _, m := range movements {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+	from := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 3, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter domain.StockMovementFilter
+		want   []string
+		total  int64
+	}{
+		{"supplier", domain.StockMovementFilter{SupplierID: "u1"}, []string{"m4", "m3", "m2", "m1"}, 4},
+		{"variant", domain.StockMovementFilter{ProductID: "p1", VariantID: "v1"}, []string{"m4", "m3", "m2"}, 3},
+		{"reason", domain.StockMovementFilter{SupplierID: "u1", Reason: "INITIAL_STOCK"}, []string{"m2", "m1"}, 2},
+		{"date range", domain.StockMovementFilter{From: &from, To: &to}, []string{"m3", "m2"}, 2},
+		{"page", domain.StockMovementFilter{SupplierID: "u1", Limit: 2, Offset: 1}, []string{"m3", "m2"}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			movements, total, err := repo.FindByFilter(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ids(movements)
+			if total != tt.total || len(got) != len(tt.want) {
+				t.Fatalf("Expected %v (total %d), got %v (total %d)", tt.want, tt.total, got, total)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v (total %d), got %v (total %d)", tt.want, tt.total, got, total)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/migrations"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/migrate"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB membuka schema sementara berisi skema migrasi terbaru di database dari
+// TEST_DATABASE_URL; test dilewati jika tidak diisi
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL tidak diisi, test repository ke PostgreSQL dilewati")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Gagal terhubung ke database test: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Satu koneksi agar search_path berlaku untuk semua query
+	sqlDB.SetMaxOpenConns(1)
+	schema := fmt.Sprintf("repository_test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("SET search_path TO " + schema + ", public").Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	})
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("Migrasi database test gagal: %v", err)
+	}
+	return db
+}
+
+// execSeed menjalankan statement seed secara berurutan
+func execSeed(t *testing.T, db *gorm.DB, stmts ...string) {
+	t.Helper()
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Seed gagal (%s): %v", stmt, err)
+		}
+	}
+}
+
+// seedCatalog membuat satu supplier, kategori, dan produk p1 (stok 5) dengan varian v1 (stok 4) dan v2 (stok 0)
+func seedCatalog(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	execSeed(t, db,
+		`INSERT INTO users (id_user, nama, email, password, role) VALUES ('u1', 'Supplier', 's@example.com', 'x', 'SUPPLIER')`,
+		`INSERT INTO categories (id_category, name) VALUES ('c1', 'Sayur')`,
+		`INSERT INTO products (id_product, name, price, stock, id_category, supplier_id, created_at, updated_at) VALUES ('p1', 'Bayam', 5000, 5, 'c1', 'u1', now(), now())`,
+		`INSERT INTO product_variants (id_variant, id_product, name_label, price, stock, sku_code, created_at, updated_at) VALUES
+			('v1', 'p1', '250g', 5000, 4, 'BYM-250', now(), now()),
+			('v2', 'p1', '1 Kg', 18000, 0, 'BYM-1000', now(), now())`,
+	)
+}
+
+// movementsOf mengembalikan ledger varian (atau produk jika variantID kosong) urut waktu
+func movementsOf(t *testing.T, db *gorm.DB, productID string, variantID string) []domain.StockMovement {
+	t.Helper()
+	query := db.Where("id_product = ?", productID).Order("created_at ASC")
+	if variantID != "" {
+		query = query.Where("id_variant = ?", variantID)
+	} else {
+		query = query.Where("id_variant IS NULL")
+	}
+	var movements []domain.StockMovement
+	if err := query.Find(&movements).Error; err != nil {
+		t.Fatal(err)
+	}
+	return movements
+}
@@ -1,15 +1,24 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 	"gorm.io/gorm"
 )
 
 type WishlistRepository interface {
-	Add(wishlist *domain.Wishlist) error
-	Remove(userID, productID string) error
-	GetByUser(userID string) ([]domain.Wishlist, error)
-	CheckExists(userID, productID string) (bool, error)
+	Add(ctx context.Context, wishlist *domain.Wishlist) error
+	Remove(ctx context.Context, userID, productID string) error
+	GetByUser(ctx context.Context, userID string, page pagination.Params) ([]domain.Wishlist, string, error)
+	CheckExists(ctx context.Context, userID, productID string) (bool, error)
+	// FindSubscribers mengembalikan semua entri wishlist sebuah produk beserta data User-nya
+	FindSubscribers(ctx context.Context, productID string) ([]domain.Wishlist, error)
+	// FindNotifiedUserIDs mengembalikan pengguna yang sudah menerima notifikasi produk ini sejak waktu tertentu
+	FindNotifiedUserIDs(ctx context.Context, productID string, since time.Time) (map[string]bool, error)
+	RecordNotification(ctx context.Context, n *domain.WishlistNotification) error
 }
 
 type wishlistRepository struct {
@@ -20,22 +29,54 @@ func NewWishlistRepository(db *gorm.DB) WishlistRepository {
 	return &wishlistRepository{db}
 }
 
-func (r *wishlistRepository) Add(w *domain.Wishlist) error {
-	return r.db.Create(w).Error
+func (r *wishlistRepository) Add(ctx context.Context, w *domain.Wishlist) error {
+	return r.db.WithContext(ctx).Create(w).Error
 }
 
-func (r *wishlistRepository) Remove(userID, productID string) error {
-	return r.db.Where("id_user = ? AND id_product = ?", userID, productID).Delete(&domain.Wishlist{}).Error
+func (r *wishlistRepository) Remove(ctx context.Context, userID, productID string) error {
+	return r.db.WithContext(ctx).Where("id_user = ? AND id_product = ?", userID, productID).Delete(&domain.Wishlist{}).Error
 }
 
-func (r *wishlistRepository) GetByUser(userID string) ([]domain.Wishlist, error) {
+func (r *wishlistRepository) GetByUser(ctx context.Context, userID string, page pagination.Params) ([]domain.Wishlist, string, error) {
 	var list []domain.Wishlist
-	err := r.db.Preload("Product").Where("id_user = ?", userID).Find(&list).Error
-	return list, err
+	query := r.db.WithContext(ctx).Preload("Product").Where("id_user = ?", userID)
+	if err := pagination.Apply(query, page, "created_at", "id_wishlist").Find(&list).Error; err != nil {
+		return nil, "", err
+	}
+	list, nextCursor := pagination.Trim(list, page, func(w domain.Wishlist) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: w.CreatedAt, ID: w.ID}
+	})
+	return list, nextCursor, nil
 }
 
-func (r *wishlistRepository) CheckExists(userID, productID string) (bool, error) {
+func (r *wishlistRepository) CheckExists(ctx context.Context, userID, productID string) (bool, error) {
 	var count int64
-	err := r.db.Model(&domain.Wishlist{}).Where("id_user = ? AND id_product = ?", userID, productID).Count(&count).Error
+	err := r.db.WithContext(ctx).Model(&domain.Wishlist{}).Where("id_user = ? AND id_product = ?", userID, productID).Count(&count).Error
 	return count > 0, err
 }
+
+func (r *wishlistRepository) FindSubscribers(ctx context.Context, productID string) ([]domain.Wishlist, error) {
+	var list []domain.Wishlist
+	err := r.db.WithContext(ctx).Preload("User").Where("id_product = ?", productID).Find(&list).Error
+	return list, err
+}
+
+func (r *wishlistRepository) FindNotifiedUserIDs(ctx context.Context, productID string, since time.Time) (map[string]bool, error) {
+	var userIDs []string
+	err := r.db.WithContext(ctx).Model(&domain.WishlistNotification{}).
+		Where("id_product = ? AND sent_at >= ?", productID, since).
+		Distinct().Pluck("id_user", &userIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	notified := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		notified[id] = true
+	}
+	return notified, nil
+}
+
+func (r *wishlistRepository) RecordNotification(ctx context.Context, n *domain.WishlistNotification) error {
+	return r.db.WithContext(ctx).Create(n).Error
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+)
+
+// auditIgnoredFields tidak dicatat di diff: timestamp yang berubah setiap simpan, relasi yang diaudit
+// sebagai entitas sendiri, dan nilai yang dihitung run-time
+var auditIgnoredFields = map[string]bool{
+	"created_at": true, "updated_at": true,
+	"category": true, "supplier": true, "product": true,
+	"variants": true, "options": true, "images": true, "option_values": true,
+	"supplier_rating": true, "available_stock": true, "is_available": true,
+}
+
+// auditSnapshot mengubah entitas menjadi map JSON tanpa field yang diabaikan
+func auditSnapshot(v interface{}) map[string]interface{} {
+	snapshot := map[string]interface{}{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return snapshot
+	}
+	data, err := json.Marshal(v)
+	if err != nil || json.Unmarshal(data, &snapshot) != nil {
+		return map[string]interface{}{}
+	}
+	for key := range snapshot {
+		if auditIgnoredFields[key] {
+			delete(snapshot, key)
+		}
+	}
+	return snapshot
+}
+
+// auditDiff mengembalikan hanya field yang berbeda. Untuk pembuatan (before nil) seluruh nilai baru
+// dicatat, untuk penghapusan (after nil) seluruh nilai lama.
+func auditDiff(before interface{}, after interface{}) (map[string]interface{}, map[string]interface{}) {
+	oldValues, newValues := auditSnapshot(before), auditSnapshot(after)
+	if len(oldValues) == 0 || len(newValues) == 0 {
+		return oldValues, newValues
+	}
+	for key, oldValue := range oldValues {
+		if newValue, ok := newValues[key]; ok && reflect.DeepEqual(oldValue, newValue) {
+			delete(oldValues, key)
+			delete(newValues, key)
+		}
+	}
+	return oldValues, newValues
+}
+
+// newAuditLog menyiapkan AuditLog berisi pelaku, IP, user agent, dan request id dari ctx.
+// actorID yang sudah diotorisasi usecase diutamakan; jika kosong dipakai pelaku dari ctx.
+// OldValues/NewValues diisi "{}" agar selalu valid untuk kolom JSON.
+func newAuditLog(ctx context.Context, actorID string, action string, entity string, entityID string) *domain.AuditLog {
+	info := reqctx.From(ctx)
+	if actorID == "" {
+		actorID = info.ActorID
+	}
+	return &domain.AuditLog{
+		ID:        uuid.New().String(),
+		UserID:    actorID,
+		ActorRole: info.ActorRole,
+		RequestID: info.RequestID,
+		IPAddress: info.IP,
+		UserAgent: info.UserAgent,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		OldValues: "{}",
+		NewValues: "{}",
+		CreatedAt: time.Now(),
+	}
+}
+
+// auditJSON mengubah nilai bebas menjadi teks JSON untuk OldValues/NewValues
+func auditJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// insertAudit menyimpan AuditLog. Kegagalan hanya dicatat di log karena perubahan utamanya sudah tersimpan.
+func insertAudit(ctx context.Context, logger *slog.Logger, repo domain.AuditLogRepository, entry *domain.AuditLog) {
+	if repo == nil {
+		return
+	}
+	if err := repo.Insert(ctx, entry); err != nil {
+		logger.ErrorContext(ctx, "gagal mencatat audit log", "action", entry.Action, "entity", entry.Entity, "entity_id", entry.EntityID, "error", err)
+	}
+}
+
+// recordAudit menulis satu baris AuditLog berisi diff JSON. Tidak menulis apa pun jika tidak ada perubahan.
+func recordAudit(ctx context.Context, logger *slog.Logger, repo domain.AuditLogRepository, actorID string, action string, entity string, entityID string, parentID string, before interface{}, after interface{}) {
+	if repo == nil {
+		return
+	}
+	oldValues, newValues := auditDiff(before, after)
+	if len(oldValues) == 0 && len(newValues) == 0 {
+		return
+	}
+	entry := newAuditLog(ctx, actorID, action, entity, entityID)
+	entry.ParentID = parentID
+	entry.OldValues = auditJSON(oldValues)
+	entry.NewValues = auditJSON(newValues)
+	insertAudit(ctx, logger, repo, entry)
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+)
+
+// auditExportColumns adalah header CSV ekspor audit log
+var auditExportColumns = []string{
+	"seq", "id_audit_log", "created_at", "id_user", "actor_role", "request_id", "ip_address", "user_agent",
+	"action", "entity", "entity_id", "parent_id", "old_values", "new_values", "prev_hash", "hash",
+}
+
+// auditExportFlushEvery mengirim CSV ke klien bertahap agar ekspor besar tidak menumpuk di memori
+const auditExportFlushEvery = 500
+
+type auditLogUsecase struct {
+	auditLogRepo domain.AuditLogRepository
+}
+
+func NewAuditLogUsecase(auditLogRepo domain.AuditLogRepository) domain.AuditLogUsecase {
+	return &auditLogUsecase{auditLogRepo: auditLogRepo}
+}
+
+func validateAuditLogFilter(filter domain.AuditLogFilter) error {
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return errors.New("rentang waktu tidak valid: 'from' harus sebelum 'to'")
+	}
+	return nil
+}
+
+func (u *auditLogUsecase) List(ctx context.Context, filter domain.AuditLogFilter, page pagination.Params) ([]domain.AuditLog, string, error) {
+	if err := validateAuditLogFilter(filter); err != nil {
+		return nil, "", err
+	}
+	return u.auditLogRepo.FindAll(ctx, filter, page)
+}
+
+func (u *auditLogUsecase) Export(ctx context.Context, filter domain.AuditLogFilter, format string, w io.Writer) error {
+	if err := validateAuditLogFilter(filter); err != nil {
+		return err
+	}
+
+	switch format {
+	case domain.AuditExportNDJSON:
+		encoder := json.NewEncoder(w)
+		return u.auditLogRepo.Stream(ctx, filter, func(log *domain.AuditLog) error {
+			return encoder.Encode(log)
+		})
+	case domain.AuditExportCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(auditExportColumns); err != nil {
+			return err
+		}
+		count := 0
+		err := u.auditLogRepo.Stream(ctx, filter, func(log *domain.AuditLog) error {
+			count++
+			if count%auditExportFlushEvery == 0 {
+				writer.Flush()
+			}
+			return writer.Write([]string{
+				strconv.FormatInt(log.Seq, 10), log.ID, log.CreatedAt.UTC().Format(time.RFC3339Nano), log.UserID,
+				log.ActorRole, log.RequestID, log.IPAddress, log.UserAgent,
+				log.Action, log.Entity, log.EntityID, log.ParentID, log.OldValues, log.NewValues, log.PrevHash, log.Hash,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return errors.New("format ekspor tidak didukung, gunakan csv atau ndjson")
+	}
+}
+
+// VerifyChain menelusuri seluruh log urut Seq dan menghitung ulang hash setiap baris.
+// Baris tanpa hash di awal tabel dianggap data lama sebelum rantai diaktifkan.
+func (u *auditLogUsecase) VerifyChain(ctx context.Context) (*domain.AuditChainReport, error) {
+	report := &domain.AuditChainReport{Valid: true}
+	err := u.auditLogRepo.Stream(ctx, domain.AuditLogFilter{}, func(log *domain.AuditLog) error {
+		if !report.Valid {
+			return nil
+		}
+		broken := ""
+		switch {
+		case log.Hash == "" && report.Checked == 0:
+			report.Legacy++
+			return nil
+		case log.Hash == "":
+			broken = "baris tidak memiliki hash"
+		case log.PrevHash != report.LastHash:
+			broken = "prev_hash tidak cocok dengan baris sebelumnya (ada baris yang dihapus atau disisipkan)"
+		case log.ChainHash() != log.Hash:
+			broken = "isi baris tidak cocok dengan hash-nya (baris diubah)"
+		}
+		if broken != "" {
+			report.Valid = false
+			report.BrokenAtSeq = log.Seq
+			report.BrokenID = log.ID
+			report.Reason = broken
+			return nil
+		}
+		report.Checked++
+		report.LastSeq = log.Seq
+		report.LastHash = log.Hash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+)
+
+// MockAuditLogRepository meniru repository asli termasuk penyambungan rantai hash saat Insert
+type MockAuditLogRepository struct {
+	logs []domain.AuditLog
+}
+
+func (m *MockAuditLogRepository) Insert(ctx context.Context, log *domain.AuditLog) error {
+	log.Seq = int64(len(m.logs) + 1)
+	if len(m.logs) > 0 {
+		log.PrevHash = m.logs[len(m.logs)-1].Hash
+	}
+	log.Hash = log.ChainHash()
+	m.logs = append(m.logs, *log)
+	return nil
+}
+func (m *MockAuditLogRepository) FindByEntity(ctx context.Context, entity string, entityID string) ([]domain.AuditLog, error) {
+	var result []domain.AuditLog
+	for _, l := range m.logs {
+		if l.Entity == entity && l.EntityID == entityID {
+			result = append(result, l)
+		}
+	}
+	return result, nil
+}
+func (m *MockAuditLogRepository) FindByEntityWithChildren(ctx context.Context, entity string, entityID string) ([]domain.AuditLog, error) {
+	var result []domain.AuditLog
+	for _, l := range m.logs {
+		if (l.Entity == entity && l.EntityID == entityID) || l.ParentID == entityID {
+			result = append(result, l)
+		}
+	}
+	return result, nil
+}
+func (m *MockAuditLogRepository) matches(l domain.AuditLog, f domain.AuditLogFilter) bool {
+	return (f.UserID == "" || l.UserID == f.UserID) && (f.Action == "" || l.Action == f.Action) &&
+		(f.Entity == "" || l.Entity == f.Entity) && (f.EntityID == "" || l.EntityID == f.EntityID) &&
+		(f.From == nil || !l.CreatedAt.Before(*f.From)) && (f.To == nil || !l.CreatedAt.After(*f.To))
+}
+func (m *MockAuditLogRepository) FindAll(ctx context.Context, filter domain.AuditLogFilter, page pagination.Params) ([]domain.AuditLog, string, error) {
+	var result []domain.AuditLog
+	for i := len(m.logs) - 1; i >= 0; i-- {
+		if m.matches(m.logs[i], filter) {
+			result = append(result, m.logs[i])
+		}
+	}
+	result, next := pagination.Trim(result, page, func(l domain.AuditLog) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: l.CreatedAt, ID: l.ID}
+	})
+	return result, next, nil
+}
+func (m *MockAuditLogRepository) Stream(ctx context.Context, filter domain.AuditLogFilter, fn func(log *domain.AuditLog) error) error {
+	for i := range m.logs {
+		if m.matches(m.logs[i], filter) {
+			l := m.logs[i]
+			if err := fn(&l); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func seedAuditLogs(repo *MockAuditLogRepository) {
+	base := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	for i, action := range []string{"CREATE_PRODUCT", "UPDATE_PRODUCT", "PRODUCT_STATUS_ACTIVE", "UPDATE_PRODUCT"} {
+		_ = repo.Insert(context.Background(), &domain.AuditLog{
+			ID:        "log-" + string(rune('a'+i)),
+			UserID:    []string{"admin-1", "supplier-1"}[i%2],
+			Action:    action,
+			Entity:    "products",
+			EntityID:  "prod-1",
+			OldValues: `{}`,
+			NewValues: `{"price":5000}`,
+			CreatedAt: base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+}
+
+func TestAuditLog_ListFilters(t *testing.T) {
+	repo := &MockAuditLogRepository{}
+	seedAuditLogs(repo)
+	uc := NewAuditLogUsecase(repo)
+
+	logs, _, err := uc.List(context.Background(), domain.AuditLogFilter{Action: "UPDATE_PRODUCT"}, pagination.Params{Limit: 10})
+	if err != nil || len(logs) != 2 {
+		t.Fatalf("Filter action harus mengembalikan 2 log, didapat %d (%v)", len(logs), err)
+	}
+	if logs[0].ID != "log-d" {
+		t.Errorf("Log terbaru harus lebih dulu, didapat %s", logs[0].ID)
+	}
+
+	from := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	logs, _, _ = uc.List(context.Background(), domain.AuditLogFilter{From: &from, To: &to}, pagination.Params{Limit: 10})
+	if len(logs) != 2 {
+		t.Errorf("Filter rentang waktu harus mengembalikan 2 log, didapat %d", len(logs))
+	}
+	if _, _, err := uc.List(context.Background(), domain.AuditLogFilter{From: &to, To: &from}, pagination.Params{Limit: 10}); err == nil {
+		t.Error("Rentang waktu terbalik seharusnya ditolak")
+	}
+}
+
+func TestAuditLog_Export(t *testing.T) {
+	repo := &MockAuditLogRepository{}
+	seedAuditLogs(repo)
+	uc := NewAuditLogUsecase(repo)
+
+	var buf bytes.Buffer
+	if err := uc.Export(context.Background(), domain.AuditLogFilter{UserID: "admin-1"}, domain.AuditExportCSV, &buf); err != nil {
+		t.Fatalf("Export CSV gagal: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV tidak valid: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "seq" || records[1][1] != "log-a" || records[2][1] != "log-c" {
+		t.Errorf("CSV harus berisi header dan 2 log admin urut seq, didapat %v", records)
+	}
+
+	buf.Reset()
+	if err := uc.Export(context.Background(), domain.AuditLogFilter{}, domain.AuditExportNDJSON, &buf); err != nil {
+		t.Fatalf("Export NDJSON gagal: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("NDJSON harus berisi 4 baris, didapat %d", len(lines))
+	}
+	var first domain.AuditLog
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.ID != "log-a" || first.Hash == "" {
+		t.Errorf("Baris NDJSON tidak valid: %s (%v)", lines[0], err)
+	}
+
+	if err := uc.Export(context.Background(), domain.AuditLogFilter{}, "xml", &buf); err == nil {
+		t.Error("Format ekspor tidak dikenal seharusnya ditolak")
+	}
+}
+
+func TestAuditLog_VerifyChainDetectsTampering(t *testing.T) {
+	repo := &MockAuditLogRepository{}
+	seedAuditLogs(repo)
+	uc := NewAuditLogUsecase(repo)
+
+	report, err := uc.VerifyChain(context.Background())
+	if err != nil || !report.Valid || report.Checked != 4 || report.LastHash != repo.logs[3].Hash {
+		t.Fatalf("Rantai utuh harus valid, didapat %+v (%v)", report, err)
+	}
+
+	// Isi baris diubah langsung di database
+	repo.logs[1].NewValues = `{"price":1}`
+	report, _ = uc.VerifyChain(context.Background())
+	if report.Valid || report.BrokenID != "log-b" {
+		t.Errorf("Perubahan isi baris harus terdeteksi pada log-b, didapat %+v", report)
+	}
+
+	// Baris dihapus dari tengah rantai
+	repo = &MockAuditLogRepository{}
+	seedAuditLogs(repo)
+	repo.logs = append(repo.logs[:1], repo.logs[2:]...)
+	report, _ = NewAuditLogUsecase(repo).VerifyChain(context.Background())
+	if report.Valid || report.BrokenID != "log-c" {
+		t.Errorf("Penghapusan baris harus terdeteksi pada log-c, didapat %+v", report)
+	}
+
+	// Baris lama tanpa hash di awal tabel dihitung sebagai legacy
+	legacy := &MockAuditLogRepository{logs: []domain.AuditLog{{ID: "old-1", Seq: 1}}}
+	_ = legacy.Insert(context.Background(), &domain.AuditLog{ID: "new-1", Action: "CREATE_PRODUCT", CreatedAt: time.Now()})
+	legacy.logs[1].PrevHash = ""
+	legacy.logs[1].Hash = legacy.logs[1].ChainHash()
+	report, _ = NewAuditLogUsecase(legacy).VerifyChain(context.Background())
+	if !report.Valid || report.Legacy != 1 || report.Checked != 1 {
+		t.Errorf("Baris legacy harus dilewati, didapat %+v", report)
+	}
+}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -21,27 +22,35 @@ func NewCartUsecase(cRepo domain.CartRepository, pRepo domain.ProductRepository)
 	}
 }
 
-func (u *cartUsecase) AddToCart(userID string, req *domain.CartItem) error {
+func (u *cartUsecase) AddToCart(ctx context.Context, userID string, req *domain.CartItem) error {
 	// SQA Check 1: Pastikan produk yang dimasukkan katalognya ada valid
-	product, err := u.productRepo.FindByID(req.ProductID)
+	product, err := u.productRepo.FindByID(ctx, req.ProductID)
 	if err != nil {
 		return errors.New("produk tidak valid atau tidak ditemukan")
 	}
+	if !product.IsPublished() {
+		return errors.New("produk tidak valid atau tidak ditemukan")
+	}
 
 	// SQA Check 2: Hitung kuantitas yang SUDAH ada di keranjang untuk produk ini
 	// agar kita bisa memvalidasi total terhadap stok, bukan hanya yang baru ditambahkan.
-	existingCartItems, _ := u.cartRepo.FindByUserID(userID)
+	existingCartItems, _ := u.cartRepo.FindByUserID(ctx, userID)
 	var alreadyInCart int
 	for _, item := range existingCartItems {
-		if item.ProductID == req.ProductID {
+		if item.ProductID == req.ProductID && sameVariant(item.VariantID, req.VariantID) {
 			alreadyInCart = item.Quantity
 			break
 		}
 	}
 
-	if product.Stock < (alreadyInCart + req.Quantity) {
+	// SQA Check 3: Stok tersedia = stok fisik - reservasi pesanan lain yang belum dibayar
+	available, err := u.availableStock(ctx, product, req.VariantID)
+	if err != nil {
+		return err
+	}
+	if available < (alreadyInCart + req.Quantity) {
 		return fmt.Errorf("tidak bisa menambah %d unit. Stok tersisa: %d, sudah di keranjang: %d",
-			req.Quantity, product.Stock, alreadyInCart)
+			req.Quantity, available, alreadyInCart)
 	}
 
 	req.ID = uuid.New().String()
@@ -49,40 +58,77 @@ func (u *cartUsecase) AddToCart(userID string, req *domain.CartItem) error {
 	req.CreatedAt = time.Now()
 	req.UpdatedAt = time.Now()
 
-	return u.cartRepo.UpsertItem(req)
+	return u.cartRepo.UpsertItem(ctx, req)
 }
 
-func (u *cartUsecase) ViewCart(userID string) ([]domain.CartItem, error) {
-	return u.cartRepo.FindByUserID(userID)
+func (u *cartUsecase) ViewCart(ctx context.Context, userID string) ([]domain.CartItem, error) {
+	return u.cartRepo.FindByUserID(ctx, userID)
 }
 
-func (u *cartUsecase) UpdateQuantity(userID string, itemID string, quantity int) error {
+func (u *cartUsecase) UpdateQuantity(ctx context.Context, userID string, itemID string, quantity int) error {
 	if quantity <= 0 {
 		return errors.New("kuantitas harus lebih dari 0")
 	}
 
 	// 1. Ambil item di cart
-	cartItem, err := u.cartRepo.FindByID(itemID)
+	cartItem, err := u.cartRepo.FindByID(ctx, itemID)
 	if err != nil || cartItem.UserID != userID {
 		return errors.New("item pada keranjang tidak ditemukan atau bukan milik anda")
 	}
 
 	// 2. Ambil product utamanya untuk verifikasi stok sisa (SQA Check)
-	product, err := u.productRepo.FindByID(cartItem.ProductID)
+	product, err := u.productRepo.FindByID(ctx, cartItem.ProductID)
 	if err != nil {
 		return errors.New("produk bawaan tidak valid lagi")
 	}
+	if !product.IsPublished() {
+		return errors.New("produk sudah tidak tersedia")
+	}
 
-	if product.Stock < quantity {
+	available, err := u.availableStock(ctx, product, cartItem.VariantID)
+	if err != nil {
+		return err
+	}
+	if available < quantity {
 		return errors.New("kuantitas yang diminta melebihi stok gudang")
 	}
 
 	// 3. Update quantity jika lolos validasi
 	cartItem.Quantity = quantity
 	cartItem.UpdatedAt = time.Now()
-	return u.cartRepo.UpdateItem(cartItem)
+	return u.cartRepo.UpdateItem(ctx, cartItem)
+}
+
+func (u *cartUsecase) RemoveFromCart(ctx context.Context, userID string, itemID string) error {
+	return u.cartRepo.RemoveItem(ctx, itemID, userID)
 }
 
-func (u *cartUsecase) RemoveFromCart(userID string, itemID string) error {
-	return u.cartRepo.RemoveItem(itemID, userID)
+// availableStock mengembalikan stok produk/varian yang masih bisa dibeli (stok fisik dikurangi reservasi aktif)
+func (u *cartUsecase) availableStock(ctx context.Context, product *domain.Product, variantID *string) (int, error) {
+	stock := product.Stock
+	if variantID != nil {
+		found := false
+		for _, v := range product.Variants {
+			if v.ID == *variantID {
+				stock = v.Stock
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, errors.New("varian produk tidak ditemukan")
+		}
+	}
+	reserved, err := u.productRepo.GetReservedStock(ctx, product.ID, variantID)
+	if err != nil {
+		return 0, err
+	}
+	return stock - reserved, nil
+}
+
+func sameVariant(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
 }
@@ -1,38 +1,85 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 // --- Mock Repositories for Cart Tests ---
 
 type MockProductRepoForCart struct {
 	products map[string]*domain.Product
+	reserved map[string]int // Key: id_product, stok yang ditahan reservasi aktif
 }
 
-func (m *MockProductRepoForCart) Create(p *domain.Product) error   { return nil }
-func (m *MockProductRepoForCart) FindAll() ([]domain.Product, error) { return nil, nil }
-func (m *MockProductRepoForCart) FindByID(id string) (*domain.Product, error) {
+func (m *MockProductRepoForCart) Create(ctx context.Context, p *domain.Product, actorID string) error { return nil }
+func (m *MockProductRepoForCart) FindAll(ctx context.Context, page pagination.Params) ([]domain.Product, string, error) {
+	return nil, "", nil
+}
+func (m *MockProductRepoForCart) FindByID(ctx context.Context, id string) (*domain.Product, error) {
 	p, ok := m.products[id]
 	if !ok {
 		return nil, errors.New("produk tidak ditemukan")
 	}
 	return p, nil
 }
-func (m *MockProductRepoForCart) Update(p *domain.Product) error { return nil }
-func (m *MockProductRepoForCart) Delete(id string) error          { return nil }
-func (m *MockProductRepoForCart) Search(keyword string, categoryID string, limit int, offset int) ([]domain.Product, error) {
+func (m *MockProductRepoForCart) Update(ctx context.Context, p *domain.Product, actorID string) error { return nil }
+func (m *MockProductRepoForCart) Delete(ctx context.Context, id string) error          { return nil }
+func (m *MockProductRepoForCart) Search(ctx context.Context, filter domain.ProductSearchFilter) ([]domain.Product, error) {
 	return nil, nil
 }
-func (m *MockProductRepoForCart) FindBySupplierID(supplierID string) ([]domain.Product, error) {
+func (m *MockProductRepoForCart) CountSearch(ctx context.Context, filter domain.ProductSearchFilter) (int64, error) {
+	return 0, nil
+}
+func (m *MockProductRepoForCart) CategoryFacets(ctx context.Context, filter domain.ProductSearchFilter) ([]domain.CategoryFacet, error) {
 	return nil, nil
 }
-func (m *MockProductRepoForCart) GetSupplierRating(supplierID string) float64 { return 0 }
+func (m *MockProductRepoForCart) FindByIDs(ctx context.Context, ids []string) ([]domain.Product, error) { return nil, nil }
+func (m *MockProductRepoForCart) SetLowStockAlertedAt(ctx context.Context, productID string, alertedAt *time.Time) error {
+	return nil
+}
+func (m *MockProductRepoForCart) FindBySupplierID(ctx context.Context, supplierID string) ([]domain.Product, error) {
+	return nil, nil
+}
+func (m *MockProductRepoForCart) GetSupplierRating(ctx context.Context, supplierID string) float64 { return 0 }
+func (m *MockProductRepoForCart) GetReservedStock(ctx context.Context, productID string, variantID *string) (int, error) {
+	return m.reserved[productID], nil
+}
+func (m *MockProductRepoForCart) TotalStock(ctx context.Context, productID string) (int, error) {
+	return 0, nil
+}
+
+func (m *MockProductRepoForCart) FindVariantsByProductID(ctx context.Context, productID string) ([]domain.ProductVariant, error) {
+	return nil, nil
+}
+func (m *MockProductRepoForCart) FindVariantByID(ctx context.Context, productID string, variantID string) (*domain.ProductVariant, error) {
+	return nil, errors.New("not found")
+}
+func (m *MockProductRepoForCart) SKUExists(ctx context.Context, sku string, excludeVariantID string) (bool, error) { return false, nil }
+func (m *MockProductRepoForCart) ProductSKUExists(ctx context.Context, supplierID string, sku string, excludeProductID string) (bool, error) {
+	return false, nil
+}
+func (m *MockProductRepoForCart) CreateVariant(ctx context.Context, v *domain.ProductVariant, actorID string) error { return nil }
+func (m *MockProductRepoForCart) UpdateVariant(ctx context.Context, v *domain.ProductVariant, actorID string) error { return nil }
+func (m *MockProductRepoForCart) DeleteVariant(ctx context.Context, productID string, variantID string, actorID string) error       { return nil }
+func (m *MockProductRepoForCart) ApplyGeneratedVariants(ctx context.Context, productID string, created []domain.ProductVariant, removedIDs []string, actorID string) error {
+	return nil
+}
+func (m *MockProductRepoForCart) ReplaceVariants(ctx context.Context, productID string, variants []domain.ProductVariant, actorID string) error {
+	return nil
+}
+func (m *MockProductRepoForCart) FindByStatus(ctx context.Context, status string, page pagination.Params) ([]domain.Product, string, error) {
+	return nil, "", nil
+}
+func (m *MockProductRepoForCart) UpdateStatus(ctx context.Context, p *domain.Product) error {
+	return nil
+}
 
 type MockCartRepo struct {
 	items map[string]*domain.CartItem // Key: id_cart_item
@@ -42,15 +89,15 @@ func NewMockCartRepo() *MockCartRepo {
 	return &MockCartRepo{items: make(map[string]*domain.CartItem)}
 }
 
-func (m *MockCartRepo) UpsertItem(item *domain.CartItem) error {
+func (m *MockCartRepo) UpsertItem(ctx context.Context, item *domain.CartItem) error {
 	m.items[item.ID] = item
 	return nil
 }
-func (m *MockCartRepo) UpdateItem(item *domain.CartItem) error {
+func (m *MockCartRepo) UpdateItem(ctx context.Context, item *domain.CartItem) error {
 	m.items[item.ID] = item
 	return nil
 }
-func (m *MockCartRepo) FindByUserID(userID string) ([]domain.CartItem, error) {
+func (m *MockCartRepo) FindByUserID(ctx context.Context, userID string) ([]domain.CartItem, error) {
 	var result []domain.CartItem
 	for _, item := range m.items {
 		if item.UserID == userID {
@@ -59,7 +106,7 @@ func (m *MockCartRepo) FindByUserID(userID string) ([]domain.CartItem, error) {
 	}
 	return result, nil
 }
-func (m *MockCartRepo) DeleteByUserID(userID string) error {
+func (m *MockCartRepo) DeleteByUserID(ctx context.Context, userID string) error {
 	for id, item := range m.items {
 		if item.UserID == userID {
 			delete(m.items, id)
@@ -67,7 +114,7 @@ func (m *MockCartRepo) DeleteByUserID(userID string) error {
 	}
 	return nil
 }
-func (m *MockCartRepo) RemoveItem(itemID string, userID string) error {
+func (m *MockCartRepo) RemoveItem(ctx context.Context, itemID string, userID string) error {
 	item, ok := m.items[itemID]
 	if !ok || item.UserID != userID {
 		return errors.New("item not found")
@@ -75,7 +122,7 @@ func (m *MockCartRepo) RemoveItem(itemID string, userID string) error {
 	delete(m.items, itemID)
 	return nil
 }
-func (m *MockCartRepo) FindByID(itemID string) (*domain.CartItem, error) {
+func (m *MockCartRepo) FindByID(ctx context.Context, itemID string) (*domain.CartItem, error) {
 	item, ok := m.items[itemID]
 	if !ok {
 		return nil, errors.New("item not found")
@@ -95,7 +142,7 @@ func TestAddToCart_Success(t *testing.T) {
 	uc := NewCartUsecase(cartRepo, productRepo)
 
 	req := &domain.CartItem{ProductID: "prod-1", Quantity: 2}
-	err := uc.AddToCart("user-1", req)
+	err := uc.AddToCart(context.Background(), "user-1", req)
 
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
@@ -111,7 +158,7 @@ func TestAddToCart_ProductNotFound(t *testing.T) {
 	uc := NewCartUsecase(cartRepo, productRepo)
 
 	req := &domain.CartItem{ProductID: "nonexistent", Quantity: 1}
-	err := uc.AddToCart("user-1", req)
+	err := uc.AddToCart(context.Background(), "user-1", req)
 
 	if err == nil {
 		t.Fatal("Expected error for nonexistent product, got success")
@@ -129,11 +176,11 @@ func TestAddToCart_ExceedsStock(t *testing.T) {
 
 	// Add 2 units first
 	req1 := &domain.CartItem{ProductID: "prod-1", Quantity: 2}
-	_ = uc.AddToCart("user-1", req1)
+	_ = uc.AddToCart(context.Background(), "user-1", req1)
 
 	// SQA CHECK: Adding 2 more should fail because 2 (already) + 2 (new) = 4 > 3 (stock)
 	req2 := &domain.CartItem{ProductID: "prod-1", Quantity: 2}
-	err := uc.AddToCart("user-1", req2)
+	err := uc.AddToCart(context.Background(), "user-1", req2)
 
 	if err == nil {
 		t.Fatal("Expected error when exceeding stock (including existing cart items), got success")
@@ -158,7 +205,7 @@ func TestUpdateQuantity_Success(t *testing.T) {
 
 	uc := NewCartUsecase(cartRepo, productRepo)
 
-	err := uc.UpdateQuantity("user-1", "item-1", 5)
+	err := uc.UpdateQuantity(context.Background(), "user-1", "item-1", 5)
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
@@ -174,7 +221,7 @@ func TestUpdateQuantity_NotOwned(t *testing.T) {
 	uc := NewCartUsecase(cartRepo, productRepo)
 
 	// user-2 tries to update user-1's cart item
-	err := uc.UpdateQuantity("user-2", "item-1", 3)
+	err := uc.UpdateQuantity(context.Background(), "user-2", "item-1", 3)
 	if err == nil {
 		t.Fatal("Expected error when updating another user's cart item, got success")
 	}
@@ -189,7 +236,7 @@ func TestRemoveFromCart_Success(t *testing.T) {
 
 	uc := NewCartUsecase(cartRepo, productRepo)
 
-	err := uc.RemoveFromCart("user-1", "item-1")
+	err := uc.RemoveFromCart(context.Background(), "user-1", "item-1")
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
@@ -198,3 +245,24 @@ func TestRemoveFromCart_Success(t *testing.T) {
 		t.Errorf("Expected cart to be empty after removal, got %d items", len(cartRepo.items))
 	}
 }
+
+func TestAddToCart_ExcludesReservedStock(t *testing.T) {
+	productRepo := &MockProductRepoForCart{
+		products: map[string]*domain.Product{
+			"prod-1": {ID: "prod-1", Name: "Brokoli", Stock: 5, Price: 15000},
+		},
+		reserved: map[string]int{"prod-1": 4}, // 4 unit ditahan pesanan lain yang belum dibayar
+	}
+	cartRepo := NewMockCartRepo()
+	uc := NewCartUsecase(cartRepo, productRepo)
+
+	// SQA CHECK: stok fisik 5, tetapi hanya 1 yang tersedia
+	err := uc.AddToCart(context.Background(), "user-1", &domain.CartItem{ProductID: "prod-1", Quantity: 2})
+	if err == nil {
+		t.Fatal("Expected error when quantity exceeds available (unreserved) stock, got success")
+	}
+
+	if err := uc.AddToCart(context.Background(), "user-1", &domain.CartItem{ProductID: "prod-1", Quantity: 1}); err != nil {
+		t.Fatalf("Expected success for available stock, got error: %v", err)
+	}
+}
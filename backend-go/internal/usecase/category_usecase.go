@@ -1,8 +1,14 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
@@ -10,35 +16,180 @@ import (
 
 type categoryUsecase struct {
 	categoryRepo domain.CategoryRepository
+	auditLogRepo domain.AuditLogRepository
+	logger       *slog.Logger
 }
 
-func NewCategoryUsecase(repo domain.CategoryRepository) domain.CategoryUsecase {
+func NewCategoryUsecase(repo domain.CategoryRepository, auditLogRepo domain.AuditLogRepository, logger *slog.Logger) domain.CategoryUsecase {
 	return &categoryUsecase{
 		categoryRepo: repo,
+		auditLogRepo: auditLogRepo,
+		logger:       logger,
 	}
 }
 
-func (u *categoryUsecase) Create(category *domain.Category) error {
+// slugify mengubah "Sayur & Buah Segar" menjadi "sayur-buah-segar"
+func slugify(s string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			dash = false
+		} else if !dash && sb.Len() > 0 {
+			sb.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "-")
+}
+
+// resolveSlug memakai slug eksplisit (harus unik) atau membuat slug dari nama dengan akhiran -2, -3, ... jika bentrok
+func (u *categoryUsecase) resolveSlug(ctx context.Context, requested string, name string, excludeID string) (string, error) {
+	if requested != "" {
+		slug := slugify(requested)
+		if slug == "" {
+			return "", errors.New("slug tidak valid")
+		}
+		exists, err := u.categoryRepo.SlugExists(ctx, slug, excludeID)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return "", fmt.Errorf("slug %s sudah dipakai kategori lain", slug)
+		}
+		return slug, nil
+	}
+
+	base := slugify(name)
+	if base == "" {
+		base = "kategori"
+	}
+	slug := base
+	for i := 2; ; i++ {
+		exists, err := u.categoryRepo.SlugExists(ctx, slug, excludeID)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// validateParent memastikan induk ada dan tidak membuat siklus (induk bukan dirinya sendiri atau turunannya)
+func (u *categoryUsecase) validateParent(ctx context.Context, categoryID string, parentID string) error {
+	if _, err := u.categoryRepo.FindByID(ctx, parentID); err != nil {
+		return errors.New("kategori induk tidak ditemukan")
+	}
+	if categoryID == "" {
+		return nil
+	}
+
+	descendants, err := u.categoryRepo.FindDescendantIDs(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+	for _, id := range descendants {
+		if id == parentID {
+			return errors.New("kategori tidak boleh dipindahkan ke dalam dirinya sendiri atau subkategorinya")
+		}
+	}
+	return nil
+}
+
+func (u *categoryUsecase) Create(ctx context.Context, adminID string, category *domain.Category) error {
+	if category.ParentID != nil && *category.ParentID == "" {
+		category.ParentID = nil
+	}
+	if category.ParentID != nil {
+		if err := u.validateParent(ctx, "", *category.ParentID); err != nil {
+			return err
+		}
+	}
+
+	slug, err := u.resolveSlug(ctx, category.Slug, category.Name, "")
+	if err != nil {
+		return err
+	}
+
 	category.ID = uuid.New().String()
+	category.Slug = slug
 	category.CreatedAt = time.Now()
 	category.UpdatedAt = time.Now()
-	
-	return u.categoryRepo.Create(category)
+
+	if err := u.categoryRepo.Create(ctx, category); err != nil {
+		return err
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, adminID, "CREATE_CATEGORY", "categories", category.ID, "", nil, category)
+	return nil
 }
 
-func (u *categoryUsecase) FindAll() ([]domain.Category, error) {
-	return u.categoryRepo.FindAll()
+func (u *categoryUsecase) FindAll(ctx context.Context) ([]domain.Category, error) {
+	return u.categoryRepo.FindAll(ctx)
 }
 
-func (u *categoryUsecase) FindByID(id string) (*domain.Category, error) {
-	return u.categoryRepo.FindByID(id)
+func (u *categoryUsecase) Tree(ctx context.Context) ([]domain.Category, error) {
+	categories, err := u.categoryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(categories), nil
 }
 
-func (u *categoryUsecase) Update(id string, updateData *domain.Category) error {
-	existingCategory, err := u.categoryRepo.FindByID(id)
+// buildCategoryTree menyusun daftar datar menjadi pohon. Kategori yang induknya tidak ditemukan
+// diperlakukan sebagai akar agar tidak hilang dari tampilan.
+func buildCategoryTree(categories []domain.Category) []domain.Category {
+	known := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+
+	childrenOf := make(map[string][]domain.Category)
+	var roots []domain.Category
+	for _, c := range categories {
+		if c.ParentID != nil && known[*c.ParentID] {
+			childrenOf[*c.ParentID] = append(childrenOf[*c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var attach func(nodes []domain.Category) []domain.Category
+	attach = func(nodes []domain.Category) []domain.Category {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if nodes[i].SortOrder != nodes[j].SortOrder {
+				return nodes[i].SortOrder < nodes[j].SortOrder
+			}
+			return nodes[i].Name < nodes[j].Name
+		})
+		for i := range nodes {
+			if children, ok := childrenOf[nodes[i].ID]; ok {
+				nodes[i].Children = attach(children)
+			}
+		}
+		return nodes
+	}
+	return attach(roots)
+}
+
+func (u *categoryUsecase) FindByID(ctx context.Context, id string) (*domain.Category, error) {
+	return u.categoryRepo.FindByID(ctx, id)
+}
+
+func (u *categoryUsecase) FindBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	return u.categoryRepo.FindBySlug(ctx, slug)
+}
+
+// Update mengikuti pola field kosong = tidak diubah. ParentID "" memindahkan kategori ke akar;
+// SortOrder hanya diubah jika SortOrderInput diisi sehingga urutan 0 tetap bisa disimpan.
+func (u *categoryUsecase) Update(ctx context.Context, adminID string, id string, updateData *domain.Category) error {
+	existingCategory, err := u.categoryRepo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	before := *existingCategory
 
 	if updateData.Name != "" {
 		existingCategory.Name = updateData.Name
@@ -46,16 +197,86 @@ func (u *categoryUsecase) Update(id string, updateData *domain.Category) error {
 	if updateData.Description != "" {
 		existingCategory.Description = updateData.Description
 	}
+	if updateData.IconURL != "" {
+		existingCategory.IconURL = updateData.IconURL
+	}
+	if updateData.SortOrderInput != nil {
+		existingCategory.SortOrder = *updateData.SortOrderInput
+	}
+	if updateData.Slug != "" {
+		slug, err := u.resolveSlug(ctx, updateData.Slug, existingCategory.Name, id)
+		if err != nil {
+			return err
+		}
+		existingCategory.Slug = slug
+	}
+	if updateData.ParentID != nil {
+		if *updateData.ParentID == "" {
+			existingCategory.ParentID = nil
+		} else {
+			if err := u.validateParent(ctx, id, *updateData.ParentID); err != nil {
+				return err
+			}
+			parentID := *updateData.ParentID
+			existingCategory.ParentID = &parentID
+		}
+	}
 
 	existingCategory.UpdatedAt = time.Now()
-	return u.categoryRepo.Update(existingCategory)
+	if err := u.categoryRepo.Update(ctx, existingCategory); err != nil {
+		return err
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, adminID, "UPDATE_CATEGORY", "categories", id, "", &before, existingCategory)
+	return nil
 }
 
-func (u *categoryUsecase) Delete(id string) error {
-	_, err := u.categoryRepo.FindByID(id)
+// Delete ditolak selama kategori masih punya subkategori atau produk, agar tidak ada data yatim
+func (u *categoryUsecase) Delete(ctx context.Context, adminID string, id string) error {
+	existingCategory, err := u.categoryRepo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("kategori tidak ditemukan")
 	}
 
-	return u.categoryRepo.Delete(id)
+	children, err := u.categoryRepo.CountChildren(ctx, id)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return fmt.Errorf("kategori masih memiliki %d subkategori, pindahkan atau hapus terlebih dahulu", children)
+	}
+
+	products, err := u.categoryRepo.CountProducts(ctx, id)
+	if err != nil {
+		return err
+	}
+	if products > 0 {
+		return fmt.Errorf("kategori masih dipakai %d produk, pindahkan produk terlebih dahulu", products)
+	}
+
+	if err := u.categoryRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, adminID, "DELETE_CATEGORY", "categories", id, "", existingCategory, nil)
+	return nil
+}
+
+func (u *categoryUsecase) EnsureSlugs(ctx context.Context) error {
+	categories, err := u.categoryRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range categories {
+		if categories[i].Slug != "" {
+			continue
+		}
+		slug, err := u.resolveSlug(ctx, "", categories[i].Name, categories[i].ID)
+		if err != nil {
+			return err
+		}
+		categories[i].Slug = slug
+		if err := u.categoryRepo.Update(ctx, &categories[i]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
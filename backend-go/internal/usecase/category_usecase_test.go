@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestCategoryCreate_SlugGeneration(t *testing.T) {
+	repo := NewMockCategoryRepositoryForProduct()
+	uc := NewCategoryUsecase(repo, nil, logger.Discard())
+
+	first := &domain.Category{Name: "Sayur & Buah Segar"}
+	if err := uc.Create(context.Background(), "admin-1", first); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if first.Slug != "sayur-buah-segar" {
+		t.Errorf("Expected slug 'sayur-buah-segar', got '%s'", first.Slug)
+	}
+
+	// SQA CHECK: nama sama mendapat slug unik dengan akhiran
+	second := &domain.Category{Name: "Sayur & Buah Segar"}
+	_ = uc.Create(context.Background(), "admin-1", second)
+	if second.Slug != "sayur-buah-segar-2" {
+		t.Errorf("Expected slug 'sayur-buah-segar-2', got '%s'", second.Slug)
+	}
+
+	// Slug eksplisit yang bentrok ditolak
+	if err := uc.Create(context.Background(), "admin-1", &domain.Category{Name: "Lainnya", Slug: "sayur-buah-segar"}); err == nil {
+		t.Error("Expected error for duplicate explicit slug")
+	}
+}
+
+func TestCategoryTree_AndCycleProtection(t *testing.T) {
+	repo := NewMockCategoryRepositoryForProduct()
+	repo.categories["root"] = &domain.Category{ID: "root", Name: "Sayuran"}
+	repo.categories["leaf"] = &domain.Category{ID: "leaf", Name: "Sayuran Daun", ParentID: strPtr("root"), SortOrder: 2}
+	repo.categories["root2"] = &domain.Category{ID: "root2", Name: "Buah"}
+	repo.categories["umbi"] = &domain.Category{ID: "umbi", Name: "Umbi", ParentID: strPtr("root"), SortOrder: 1}
+	repo.categories["bayam"] = &domain.Category{ID: "bayam", Name: "Bayam", ParentID: strPtr("leaf")}
+	uc := NewCategoryUsecase(repo, nil, logger.Discard())
+
+	tree, err := uc.Tree(context.Background())
+	if err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	if len(tree) != 2 || tree[0].Name != "Buah" {
+		t.Fatalf("Expected 2 roots sorted by name (Buah first), got %+v", tree)
+	}
+	sayuran := tree[1]
+	if len(sayuran.Children) != 2 || sayuran.Children[0].ID != "umbi" {
+		t.Errorf("Expected children ordered by sort_order (umbi first), got %+v", sayuran.Children)
+	}
+	if len(sayuran.Children[1].Children) != 1 {
+		t.Errorf("Expected grandchild 'bayam' under 'leaf'")
+	}
+
+	// SQA CHECK: kategori tidak boleh dipindah ke bawah turunannya sendiri
+	if err := uc.Update(context.Background(), "admin-1", "root", &domain.Category{ParentID: strPtr("bayam")}); err == nil {
+		t.Error("Expected error when moving category under its own descendant")
+	}
+	// ParentID "" memindahkan kategori ke akar
+	if err := uc.Update(context.Background(), "admin-1", "leaf", &domain.Category{ParentID: strPtr("")}); err != nil {
+		t.Fatalf("Expected success moving to root, got error: %v", err)
+	}
+	if repo.categories["leaf"].ParentID != nil {
+		t.Error("Expected leaf to become a root category")
+	}
+}
+
+func TestCategoryUpdate_SortOrderCanBeResetToZero(t *testing.T) {
+	repo := NewMockCategoryRepositoryForProduct()
+	repo.categories["leaf"] = &domain.Category{ID: "leaf", Name: "Sayuran Daun", SortOrder: 3}
+	uc := NewCategoryUsecase(repo, nil, logger.Discard())
+
+	// Tanpa SortOrderInput urutan lama dipertahankan
+	if err := uc.Update(context.Background(), "admin-1", "leaf", &domain.Category{Name: "Daun"}); err != nil {
+		t.Fatalf("Update gagal: %v", err)
+	}
+	if repo.categories["leaf"].SortOrder != 3 {
+		t.Errorf("Expected sort_order 3 kept when not sent, got %d", repo.categories["leaf"].SortOrder)
+	}
+
+	zero := 0
+	if err := uc.Update(context.Background(), "admin-1", "leaf", &domain.Category{SortOrderInput: &zero}); err != nil {
+		t.Fatalf("Update gagal: %v", err)
+	}
+	if repo.categories["leaf"].SortOrder != 0 {
+		t.Errorf("Expected sort_order reset to 0, got %d", repo.categories["leaf"].SortOrder)
+	}
+}
+
+func TestCategoryDelete_BlockedByChildrenOrProducts(t *testing.T) {
+	repo := NewMockCategoryRepositoryForProduct()
+	repo.categories["root"] = &domain.Category{ID: "root", Name: "Sayuran"}
+	repo.categories["leaf"] = &domain.Category{ID: "leaf", Name: "Sayuran Daun", ParentID: strPtr("root")}
+	repo.productCounts["leaf"] = 3
+	uc := NewCategoryUsecase(repo, nil, logger.Discard())
+
+	if err := uc.Delete(context.Background(), "admin-1", "root"); err == nil {
+		t.Error("Expected error deleting category with children")
+	}
+	if err := uc.Delete(context.Background(), "admin-1", "leaf"); err == nil {
+		t.Error("Expected error deleting category with products")
+	}
+
+	repo.productCounts["leaf"] = 0
+	if err := uc.Delete(context.Background(), "admin-1", "leaf"); err != nil {
+		t.Errorf("Expected empty leaf category to be deletable, got %v", err)
+	}
+}
+
+func TestCategoryAudit_CreateUpdateDelete(t *testing.T) {
+	auditRepo := &MockAuditLogRepository{}
+	uc := NewCategoryUsecase(NewMockCategoryRepositoryForProduct(), auditRepo, logger.Discard())
+
+	category := &domain.Category{Name: "Sayur"}
+	if err := uc.Create(context.Background(), "admin-1", category); err != nil {
+		t.Fatalf("Create gagal: %v", err)
+	}
+	if err := uc.Update(context.Background(), "admin-1", category.ID, &domain.Category{Name: "Sayuran"}); err != nil {
+		t.Fatalf("Update gagal: %v", err)
+	}
+	if err := uc.Delete(context.Background(), "admin-1", category.ID); err != nil {
+		t.Fatalf("Delete gagal: %v", err)
+	}
+
+	want := []string{"CREATE_CATEGORY", "UPDATE_CATEGORY", "DELETE_CATEGORY"}
+	if len(auditRepo.logs) != len(want) {
+		t.Fatalf("Expected %d audit logs, got %+v", len(want), auditRepo.logs)
+	}
+	for i, action := range want {
+		if entry := auditRepo.logs[i]; entry.Action != action || entry.UserID != "admin-1" || entry.EntityID != category.ID {
+			t.Errorf("Log ke-%d tidak sesuai: %+v", i+1, entry)
+		}
+	}
+}
@@ -1,39 +1,90 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
 	"github.com/nuryanfa/e-commerse-sqa/internal/repository"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/storage"
 )
 
+// evidenceURLTTL adalah masa berlaku tautan bukti sengketa yang dikirim ke klien
+const evidenceURLTTL = 15 * time.Minute
+
 type DisputeUseCase interface {
-	OpenDispute(orderID, buyerID, reason, imageURL string) (*domain.Dispute, error)
-	GetDisputes(role, userID string) ([]domain.Dispute, error)
-	GetDisputeDetail(disputeID string) (*domain.Dispute, []domain.DisputeMessage, error)
-	AddReply(disputeID, senderID, message string) (*domain.DisputeMessage, error)
-	ResolveDispute(disputeID, adminID, decision, adminNote string) error
-	AssignReturnCourier(disputeID, courierID string) error
-	MarkReturnDelivered(disputeID, courierID string) error
+	// OpenDispute menerima isi file bukti (boleh kosong); file disimpan sebagai objek privat
+	OpenDispute(ctx context.Context, orderID, buyerID, reason string, evidence []byte) (*domain.Dispute, error)
+	GetDisputes(ctx context.Context, role, userID string, page pagination.Params) ([]domain.Dispute, string, error)
+	GetDisputeDetail(ctx context.Context, disputeID string) (*domain.Dispute, []domain.DisputeMessage, error)
+	AddReply(ctx context.Context, disputeID, senderID, message string) (*domain.DisputeMessage, error)
+	ResolveDispute(ctx context.Context, disputeID, adminID, decision, adminNote string) error
+	AssignReturnCourier(ctx context.Context, disputeID, courierID string) error
+	MarkReturnDelivered(ctx context.Context, disputeID, courierID string) error
+	InspectReturn(ctx context.Context, disputeID, supplierID, action string, items []domain.ReturnInspectionItem, note string) ([]domain.DisputeReturnItem, error)
 }
 
 type disputeUseCase struct {
 	disputeRepo repository.DisputeRepository
 	orderRepo   domain.OrderRepository
+	processor   domain.ImageProcessor
+	blobs       storage.BlobStore
+	logger      *slog.Logger
 }
 
-func NewDisputeUseCase(dr repository.DisputeRepository, or domain.OrderRepository) DisputeUseCase {
+func NewDisputeUseCase(dr repository.DisputeRepository, or domain.OrderRepository, processor domain.ImageProcessor, blobs storage.BlobStore, logger *slog.Logger) DisputeUseCase {
 	return &disputeUseCase{
 		disputeRepo: dr,
 		orderRepo:   or,
+		processor:   processor,
+		blobs:       blobs,
+		logger:      logger,
+	}
+}
+
+// storeEvidence memvalidasi dan me-re-encode bukti (EXIF seperti lokasi GPS pembeli ikut terbuang),
+// lalu menyimpannya di ruang privat. Yang disimpan di database adalah key, bukan URL.
+func (u *disputeUseCase) storeEvidence(ctx context.Context, evidence []byte) (string, error) {
+	processed, err := u.processor.Process(evidence)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range processed.Renditions {
+		if r.Size != domain.ImageSizeLarge {
+			continue
+		}
+		key := fmt.Sprintf("%sdisputes/%s.jpg", storage.PrivatePrefix, processed.Hash)
+		if err := u.blobs.Put(ctx, key, r.Data, "image/jpeg"); err != nil {
+			return "", err
+		}
+		return key, nil
+	}
+	return "", errors.New("gagal memproses gambar bukti")
+}
+
+// signEvidence mengganti key bukti privat dengan URL bertanda tangan yang kedaluwarsa.
+// Nilai lama berupa URL /uploads/... (sebelum migrasi storage) dibiarkan apa adanya.
+func (u *disputeUseCase) signEvidence(d *domain.Dispute) {
+	if d == nil || !storage.IsPrivate(d.ImageURL) {
+		return
+	}
+	signed, err := u.blobs.SignedURL(d.ImageURL, evidenceURLTTL)
+	if err != nil {
+		u.logger.Warn("gagal membuat URL bukti sengketa", "dispute_id", d.ID, "error", err)
+		d.ImageURL = ""
+		return
 	}
+	d.ImageURL = signed
 }
 
-func (u *disputeUseCase) OpenDispute(orderID, buyerID, reason, imageURL string) (*domain.Dispute, error) {
+func (u *disputeUseCase) OpenDispute(ctx context.Context, orderID, buyerID, reason string, evidence []byte) (*domain.Dispute, error) {
 	// 1. Validasi Order
-	order, err := u.orderRepo.FindByID(orderID)
+	order, err := u.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
 		return nil, errors.New("pesanan tidak ditemukan")
 	}
@@ -48,11 +99,18 @@ func (u *disputeUseCase) OpenDispute(orderID, buyerID, reason, imageURL string)
 	}
 
 	// 2. Cek apakah sudah ada sengketa
-	existing, _ := u.disputeRepo.GetDisputeByOrderID(orderID)
+	existing, _ := u.disputeRepo.GetDisputeByOrderID(ctx, orderID)
 	if existing != nil {
 		return nil, errors.New("pesanan ini sudah dalam masa sengketa aktif")
 	}
 
+	evidenceKey := ""
+	if len(evidence) > 0 {
+		if evidenceKey, err = u.storeEvidence(ctx, evidence); err != nil {
+			return nil, err
+		}
+	}
+
 	// 3. Buat Dispute
 	dispute := &domain.Dispute{
 		ID:        uuid.New().String(),
@@ -60,39 +118,50 @@ func (u *disputeUseCase) OpenDispute(orderID, buyerID, reason, imageURL string)
 		BuyerID:   buyerID,
 		Reason:    reason,
 		Status:    "OPEN",
-		ImageURL:  imageURL,
+		ImageURL:  evidenceKey,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	err = u.disputeRepo.CreateDispute(dispute)
+	err = u.disputeRepo.CreateDispute(ctx, dispute)
 	if err != nil {
 		return nil, err
 	}
 
 	// Mengunci status pesanan menjadi DISPUTED (opsional, tapi disarankan)
-	_ = u.orderRepo.UpdateStatus(orderID, "DISPUTED")
+	_ = u.orderRepo.UpdateStatus(ctx, orderID, "DISPUTED")
 
-	return dispute, nil
+	// Tanda tangani salinan agar entity yang tersimpan tetap memegang key, bukan URL sementara
+	result := *dispute
+	u.signEvidence(&result)
+	return &result, nil
 }
 
-func (u *disputeUseCase) GetDisputes(role, userID string) ([]domain.Dispute, error) {
-	return u.disputeRepo.GetDisputesByRole(role, userID)
+func (u *disputeUseCase) GetDisputes(ctx context.Context, role, userID string, page pagination.Params) ([]domain.Dispute, string, error) {
+	disputes, nextCursor, err := u.disputeRepo.GetDisputesByRole(ctx, role, userID, page)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range disputes {
+		u.signEvidence(&disputes[i])
+	}
+	return disputes, nextCursor, nil
 }
 
-func (u *disputeUseCase) GetDisputeDetail(disputeID string) (*domain.Dispute, []domain.DisputeMessage, error) {
-	dispute, err := u.disputeRepo.GetDisputeByID(disputeID)
+func (u *disputeUseCase) GetDisputeDetail(ctx context.Context, disputeID string) (*domain.Dispute, []domain.DisputeMessage, error) {
+	dispute, err := u.disputeRepo.GetDisputeByID(ctx, disputeID)
 	if err != nil {
 		return nil, nil, errors.New("sengketa tidak ditemukan")
 	}
 
-	messages, err := u.disputeRepo.GetMessagesByDisputeID(disputeID)
+	u.signEvidence(dispute)
+	messages, err := u.disputeRepo.GetMessagesByDisputeID(ctx, disputeID)
 	return dispute, messages, err
 }
 
-func (u *disputeUseCase) AddReply(disputeID, senderID, message string) (*domain.DisputeMessage, error) {
+func (u *disputeUseCase) AddReply(ctx context.Context, disputeID, senderID, message string) (*domain.DisputeMessage, error) {
 	// Pastikan sengketa eksis dan OPEN
-	dispute, err := u.disputeRepo.GetDisputeByID(disputeID)
+	dispute, err := u.disputeRepo.GetDisputeByID(ctx, disputeID)
 	if err != nil {
 		return nil, errors.New("sengketa tidak ditemukan")
 	}
@@ -109,24 +178,24 @@ func (u *disputeUseCase) AddReply(disputeID, senderID, message string) (*domain.
 		CreatedAt: time.Now(),
 	}
 
-	err = u.disputeRepo.AddMessage(msg)
+	err = u.disputeRepo.AddMessage(ctx, msg)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update waktu Dispute
-	_ = u.disputeRepo.UpdateDisputeStatus(disputeID, "OPEN", "") // hanya trigger updated_at di DB gorm
+	_ = u.disputeRepo.UpdateDisputeStatus(ctx, disputeID, "OPEN", "") // hanya trigger updated_at di DB gorm
 
 	return msg, nil
 }
 
-func (u *disputeUseCase) ResolveDispute(disputeID, adminID, decision, adminNote string) error {
+func (u *disputeUseCase) ResolveDispute(ctx context.Context, disputeID, adminID, decision, adminNote string) error {
 	// Decision harus valid
 	if decision != "REFUNDED" && decision != "REJECTED" && decision != "RESOLVED_PARTIAL" && decision != "APPROVED_FOR_RETURN" {
 		return errors.New("status putusan tidak valid")
 	}
 
-	dispute, err := u.disputeRepo.GetDisputeByID(disputeID)
+	dispute, err := u.disputeRepo.GetDisputeByID(ctx, disputeID)
 	if err != nil {
 		return errors.New("sengketa tidak ditemukan")
 	}
@@ -136,23 +205,23 @@ func (u *disputeUseCase) ResolveDispute(disputeID, adminID, decision, adminNote
 	}
 
 	// 1. Update Status Sengketa
-	err = u.disputeRepo.UpdateDisputeStatus(disputeID, decision, adminNote)
+	err = u.disputeRepo.UpdateDisputeStatus(ctx, disputeID, decision, adminNote)
 	if err != nil {
 		return err
 	}
 
 	// 2. Tindakan Lanjutan pada Order
 	if decision == "REFUNDED" {
-		_ = u.orderRepo.UpdateStatus(dispute.OrderID, "CANCELLED") // Barang batal, uang kembali
+		_ = u.orderRepo.UpdateStatus(ctx, dispute.OrderID, "CANCELLED") // Barang batal, uang kembali
 	} else if decision == "REJECTED" {
-		_ = u.orderRepo.UpdateStatus(dispute.OrderID, "DELIVERED") // Komplain ditolak admin, transaksi dianggap sah selesai
+		_ = u.orderRepo.UpdateStatus(ctx, dispute.OrderID, "DELIVERED") // Komplain ditolak admin, transaksi dianggap sah selesai
 	}
 
 	return nil
 }
 
-func (u *disputeUseCase) AssignReturnCourier(disputeID, courierID string) error {
-	dispute, err := u.disputeRepo.GetDisputeByID(disputeID)
+func (u *disputeUseCase) AssignReturnCourier(ctx context.Context, disputeID, courierID string) error {
+	dispute, err := u.disputeRepo.GetDisputeByID(ctx, disputeID)
 	if err != nil {
 		return errors.New("sengketa tidak ditemukan")
 	}
@@ -161,11 +230,11 @@ func (u *disputeUseCase) AssignReturnCourier(disputeID, courierID string) error
 		return errors.New("sengketa ini tidak sedang menantikan kurir untuk retur")
 	}
 
-	return u.disputeRepo.AssignCourier(disputeID, courierID)
+	return u.disputeRepo.AssignCourier(ctx, disputeID, courierID)
 }
 
-func (u *disputeUseCase) MarkReturnDelivered(disputeID, courierID string) error {
-	dispute, err := u.disputeRepo.GetDisputeByID(disputeID)
+func (u *disputeUseCase) MarkReturnDelivered(ctx context.Context, disputeID, courierID string) error {
+	dispute, err := u.disputeRepo.GetDisputeByID(ctx, disputeID)
 	if err != nil {
 		return errors.New("sengketa tidak ditemukan")
 	}
@@ -174,5 +243,118 @@ func (u *disputeUseCase) MarkReturnDelivered(disputeID, courierID string) error
 		return errors.New("akses ditolak atau status sengketa tidak sesuai")
 	}
 
-	return u.disputeRepo.UpdateDisputeStatus(disputeID, "RETURNED", "Barang Retur telah diserahkan kembali ke Supplier oleh Kurir")
+	// Barang sudah kembali di tangan supplier, menunggu inspeksi (restock / write-off).
+	// Sengketa dan pesanan diubah bersamaan agar inspeksi tidak berjalan di atas pesanan yang statusnya tertinggal.
+	return u.disputeRepo.MarkReturned(ctx, disputeID, dispute.OrderID, "Barang Retur telah diserahkan kembali ke Supplier oleh Kurir")
+}
+
+// InspectReturn dipanggil supplier setelah barang retur diterima (status RETURNED).
+// action RESTOCK: daftar item wajib diisi; hanya item yang disebut yang diinspeksi, masing-masing memakai
+// restock_quantity (sisanya dihapusbukukan). Item yang tidak disebut tetap menunggu inspeksi berikutnya.
+// action WRITE_OFF: seluruh item milik supplier yang belum diinspeksi dihapusbukukan tanpa menambah stok.
+func (u *disputeUseCase) InspectReturn(ctx context.Context, disputeID, supplierID, action string, items []domain.ReturnInspectionItem, note string) ([]domain.DisputeReturnItem, error) {
+	if action != "RESTOCK" && action != "WRITE_OFF" {
+		return nil, errors.New("aksi inspeksi tidak valid, gunakan RESTOCK atau WRITE_OFF")
+	}
+	if action == "WRITE_OFF" && len(items) > 0 {
+		return nil, errors.New("write-off berlaku untuk seluruh item, daftar item tidak diperlukan")
+	}
+	if action == "RESTOCK" && len(items) == 0 {
+		return nil, errors.New("restock memerlukan daftar item beserta restock_quantity masing-masing")
+	}
+
+	dispute, err := u.disputeRepo.GetDisputeByID(ctx, disputeID)
+	if err != nil {
+		return nil, errors.New("sengketa tidak ditemukan")
+	}
+	if dispute.Status != "RETURNED" {
+		return nil, errors.New("barang retur belum diterima, sengketa harus berstatus RETURNED")
+	}
+
+	order, err := u.orderRepo.FindByID(ctx, dispute.OrderID)
+	if err != nil {
+		return nil, errors.New("pesanan tidak ditemukan")
+	}
+
+	// Hanya item produk milik supplier ini yang boleh diinspeksi olehnya
+	ownedItems := map[string]domain.OrderItem{}
+	for _, item := range order.Items {
+		if item.Product != nil && item.Product.SupplierID == supplierID {
+			ownedItems[item.ID] = item
+		}
+	}
+	if len(ownedItems) == 0 {
+		return nil, errors.New("akses ditolak: pesanan ini tidak memuat produk dari toko anda")
+	}
+
+	requested := map[string]int{}
+	for _, in := range items {
+		orderItem, ok := ownedItems[in.OrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("item pesanan %s bukan milik toko anda", in.OrderItemID)
+		}
+		if _, dup := requested[in.OrderItemID]; dup {
+			return nil, fmt.Errorf("item pesanan %s disebut lebih dari sekali", in.OrderItemID)
+		}
+		if in.RestockQuantity < 0 || in.RestockQuantity > orderItem.Quantity {
+			return nil, fmt.Errorf("jumlah restock item %s harus antara 0 dan %d", in.OrderItemID, orderItem.Quantity)
+		}
+		requested[in.OrderItemID] = in.RestockQuantity
+	}
+
+	inspected := map[string]bool{}
+	for _, ri := range dispute.ReturnItems {
+		inspected[ri.OrderItemID] = true
+	}
+
+	now := time.Now()
+	var results []domain.DisputeReturnItem
+	for _, item := range order.Items {
+		if _, ok := ownedItems[item.ID]; !ok {
+			continue
+		}
+		if inspected[item.ID] {
+			if _, ok := requested[item.ID]; ok {
+				return nil, fmt.Errorf("item pesanan %s sudah pernah diinspeksi", item.ID)
+			}
+			continue
+		}
+
+		restock := 0
+		if action == "RESTOCK" {
+			qty, ok := requested[item.ID]
+			if !ok {
+				continue
+			}
+			restock = qty
+		}
+
+		results = append(results, domain.DisputeReturnItem{
+			ID:                 uuid.New().String(),
+			DisputeID:          disputeID,
+			OrderItemID:        item.ID,
+			ProductID:          item.ProductID,
+			VariantID:          item.VariantID,
+			ReturnedQuantity:   item.Quantity,
+			RestockedQuantity:  restock,
+			WrittenOffQuantity: item.Quantity - restock,
+			InspectedBy:        supplierID,
+			Note:               note,
+			CreatedAt:          now,
+		})
+	}
+	if len(results) == 0 {
+		return nil, errors.New("seluruh item retur milik toko anda sudah diinspeksi")
+	}
+
+	// Audit ditulis di transaksi yang sama dengan hasil inspeksi
+	audit := newAuditLog(ctx, supplierID, "SUPPLIER_INSPECT_RETURN", "disputes", disputeID)
+	audit.OldValues = auditJSON(map[string]interface{}{"status": dispute.Status})
+	audit.NewValues = auditJSON(map[string]interface{}{"action": action, "items": results})
+	audit.CreatedAt = now
+
+	if err := u.disputeRepo.InspectReturn(ctx, disputeID, results, audit); err != nil {
+		return nil, err
+	}
+	return results, nil
 }
@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+)
+
+// MockDisputeRepository implements repository.DisputeRepository for unit testing
+type MockDisputeRepository struct {
+	disputes  map[string]*domain.Dispute
+	Inspected []domain.DisputeReturnItem
+	Audits    []*domain.AuditLog
+	markErr   error
+}
+
+func (m *MockDisputeRepository) CreateDispute(ctx context.Context, d *domain.Dispute) error {
+	if m.disputes != nil {
+		m.disputes[d.ID] = d
+	}
+	return nil
+}
+func (m *MockDisputeRepository) GetDisputeByID(ctx context.Context, id string) (*domain.Dispute, error) {
+	if d, ok := m.disputes[id]; ok {
+		return d, nil
+	}
+	return nil, errors.New("not found")
+}
+func (m *MockDisputeRepository) GetDisputeByOrderID(ctx context.Context, orderID string) (*domain.Dispute, error) {
+	return nil, errors.New("not found")
+}
+func (m *MockDisputeRepository) GetDisputesByRole(ctx context.Context, role string, userID string, page pagination.Params) ([]domain.Dispute, string, error) {
+	return nil, "", nil
+}
+func (m *MockDisputeRepository) UpdateDisputeStatus(ctx context.Context, id string, status string, adminNote string) error {
+	return nil
+}
+func (m *MockDisputeRepository) AssignCourier(ctx context.Context, disputeID string, courierID string) error {
+	return nil
+}
+func (m *MockDisputeRepository) MarkReturned(ctx context.Context, disputeID string, orderID string, note string) error {
+	if m.markErr != nil {
+		return m.markErr
+	}
+	m.disputes[disputeID].Status = "RETURNED"
+	return nil
+}
+func (m *MockDisputeRepository) AddMessage(ctx context.Context, msg *domain.DisputeMessage) error {
+	return nil
+}
+func (m *MockDisputeRepository) GetMessagesByDisputeID(ctx context.Context, disputeID string) ([]domain.DisputeMessage, error) {
+	return nil, nil
+}
+func (m *MockDisputeRepository) InspectReturn(ctx context.Context, disputeID string, items []domain.DisputeReturnItem, audit *domain.AuditLog) error {
+	m.Inspected = append(m.Inspected, items...)
+	m.Audits = append(m.Audits, audit)
+	return nil
+}
+
+func newReturnedDisputeFixture() (*MockDisputeRepository, *MockOrderRepository) {
+	disputeRepo := &MockDisputeRepository{disputes: map[string]*domain.Dispute{
+		"disp-1": {ID: "disp-1", OrderID: "order-1", Status: "RETURNED"},
+	}}
+	orderRepo := &MockOrderRepository{Orders: map[string]*domain.Order{
+		"order-1": {ID: "order-1", Items: []domain.OrderItem{
+			{ID: "item-1", ProductID: "prod-1", Quantity: 3, Product: &domain.Product{ID: "prod-1", SupplierID: "supplier-1"}},
+			{ID: "item-2", ProductID: "prod-2", Quantity: 2, Product: &domain.Product{ID: "prod-2", SupplierID: "supplier-1"}},
+			{ID: "item-3", ProductID: "prod-3", Quantity: 1, Product: &domain.Product{ID: "prod-3", SupplierID: "supplier-2"}},
+		}},
+	}}
+	return disputeRepo, orderRepo
+}
+
+func TestInspectReturn_PartialRestock(t *testing.T) {
+	disputeRepo, orderRepo := newReturnedDisputeFixture()
+	uc := NewDisputeUseCase(disputeRepo, orderRepo, &MockImageProcessor{}, NewMockBlobStore(), logger.Discard())
+
+	results, err := uc.InspectReturn(context.Background(), "disp-1", "supplier-1", "RESTOCK", []domain.ReturnInspectionItem{
+		{OrderItemID: "item-1", RestockQuantity: 1},
+	}, "2 unit rusak")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Hanya item yang disebut yang diinspeksi; item-2 menunggu inspeksi berikutnya
+	if len(results) != 1 || results[0].OrderItemID != "item-1" {
+		t.Fatalf("Expected only item-1 inspected, got %+v", results)
+	}
+	if results[0].RestockedQuantity != 1 || results[0].WrittenOffQuantity != 2 {
+		t.Errorf("Expected item-1 restock 1 / write-off 2, got %d / %d", results[0].RestockedQuantity, results[0].WrittenOffQuantity)
+	}
+	if len(disputeRepo.Audits) != 1 || disputeRepo.Audits[0].Action != "SUPPLIER_INSPECT_RETURN" {
+		t.Error("Expected inspection to be written to the audit log")
+	}
+
+	// Sisa item dihapusbukukan lewat WRITE_OFF tanpa menyentuh item yang sudah diinspeksi
+	disputeRepo.disputes["disp-1"].ReturnItems = results
+	results, err = uc.InspectReturn(context.Background(), "disp-1", "supplier-1", "WRITE_OFF", nil, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 1 || results[0].OrderItemID != "item-2" || results[0].WrittenOffQuantity != 2 {
+		t.Errorf("Expected remaining item-2 written off, got %+v", results)
+	}
+}
+
+func TestInspectReturn_WriteOff(t *testing.T) {
+	disputeRepo, orderRepo := newReturnedDisputeFixture()
+	uc := NewDisputeUseCase(disputeRepo, orderRepo, &MockImageProcessor{}, NewMockBlobStore(), logger.Discard())
+
+	results, err := uc.InspectReturn(context.Background(), "disp-1", "supplier-1", "WRITE_OFF", nil, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, r := range results {
+		if r.RestockedQuantity != 0 || r.WrittenOffQuantity != r.ReturnedQuantity {
+			t.Errorf("Expected item %s fully written off, got restock %d", r.OrderItemID, r.RestockedQuantity)
+		}
+	}
+}
+
+func TestInspectReturn_Validation(t *testing.T) {
+	disputeRepo, orderRepo := newReturnedDisputeFixture()
+	uc := NewDisputeUseCase(disputeRepo, orderRepo, &MockImageProcessor{}, NewMockBlobStore(), logger.Discard())
+
+	cases := []struct {
+		name       string
+		supplierID string
+		items      []domain.ReturnInspectionItem
+	}{
+		{"item milik supplier lain", "supplier-1", []domain.ReturnInspectionItem{{OrderItemID: "item-3", RestockQuantity: 1}}},
+		{"restock melebihi kuantitas", "supplier-1", []domain.ReturnInspectionItem{{OrderItemID: "item-1", RestockQuantity: 4}}},
+		{"supplier tanpa item", "supplier-9", []domain.ReturnInspectionItem{{OrderItemID: "item-1", RestockQuantity: 1}}},
+		{"restock tanpa daftar item", "supplier-1", nil},
+	}
+	for _, tc := range cases {
+		if _, err := uc.InspectReturn(context.Background(), "disp-1", tc.supplierID, "RESTOCK", tc.items, ""); err == nil {
+			t.Errorf("%s: expected error, got nil", tc.name)
+		}
+	}
+
+	disputeRepo.disputes["disp-1"].Status = "RETURNING"
+	if _, err := uc.InspectReturn(context.Background(), "disp-1", "supplier-1", "RESTOCK", []domain.ReturnInspectionItem{{OrderItemID: "item-1", RestockQuantity: 1}}, ""); err == nil {
+		t.Error("Expected error when goods have not been returned yet")
+	}
+
+	if len(disputeRepo.Inspected) != 0 {
+		t.Error("Expected no stock changes for rejected inspections")
+	}
+}
+
+func TestMarkReturnDelivered_PropagatesStatusUpdateError(t *testing.T) {
+	courierID := "courier-1"
+	disputeRepo := &MockDisputeRepository{disputes: map[string]*domain.Dispute{
+		"disp-1": {ID: "disp-1", OrderID: "order-1", Status: "RETURNING", CourierID: &courierID},
+	}, markErr: errors.New("db down")}
+	uc := NewDisputeUseCase(disputeRepo, &MockOrderRepository{}, &MockImageProcessor{}, NewMockBlobStore(), logger.Discard())
+
+	if err := uc.MarkReturnDelivered(context.Background(), "disp-1", "courier-1"); err == nil {
+		t.Fatal("Expected the status update error to be returned")
+	}
+	if disputeRepo.disputes["disp-1"].Status != "RETURNING" {
+		t.Error("Expected dispute to stay RETURNING when the update fails")
+	}
+
+	disputeRepo.markErr = nil
+	if err := uc.MarkReturnDelivered(context.Background(), "disp-1", "courier-1"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if disputeRepo.disputes["disp-1"].Status != "RETURNED" {
+		t.Error("Expected dispute marked RETURNED")
+	}
+}
+
+func TestOpenDispute_EvidenceStoredPrivately(t *testing.T) {
+	disputeRepo := &MockDisputeRepository{disputes: map[string]*domain.Dispute{}}
+	orderRepo := &MockOrderRepository{Orders: map[string]*domain.Order{
+		"order-1": {ID: "order-1", UserID: "buyer-1", Status: "DELIVERED"},
+	}}
+	blobs := NewMockBlobStore()
+	uc := NewDisputeUseCase(disputeRepo, orderRepo, &MockImageProcessor{}, blobs, logger.Discard())
+
+	// SQA CHECK: file bukti yang bukan gambar ditolak sebelum sengketa dibuat
+	if _, err := uc.OpenDispute(context.Background(), "order-1", "buyer-1", "Barang busuk", []byte("<html>")); err == nil {
+		t.Fatal("Expected invalid evidence error, got success")
+	}
+	if len(disputeRepo.disputes) != 0 {
+		t.Fatal("Expected no dispute created for invalid evidence")
+	}
+
+	dispute, err := uc.OpenDispute(context.Background(), "order-1", "buyer-1", "Barang busuk", []byte("IMG-bukti"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	key := "private/disputes/hash-IMG-bukti.jpg"
+	if _, ok := blobs.files[key]; !ok {
+		t.Fatalf("Expected evidence stored under %s, got %v", key, blobs.files)
+	}
+	// Database menyimpan key; klien menerima URL bertanda tangan
+	if stored := disputeRepo.disputes[dispute.ID].ImageURL; stored != key {
+		t.Errorf("Expected key %s persisted, got %q", key, stored)
+	}
+	if dispute.ImageURL != "/uploads/"+key+"?signature=test" {
+		t.Errorf("Expected signed evidence URL, got %q", dispute.ImageURL)
+	}
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+// maxMovementPageSize membatasi jumlah baris ledger per halaman agar query supplier tetap ringan
+const maxMovementPageSize = 100
+
+type inventoryUsecase struct {
+	movementRepo domain.StockMovementRepository
+	productRepo  domain.ProductRepository
+	userRepo     domain.UserRepository
+	emailSvc     domain.EmailService
+	logger       *slog.Logger
+}
+
+func NewInventoryUsecase(mRepo domain.StockMovementRepository, pRepo domain.ProductRepository, uRepo domain.UserRepository, emailSvc domain.EmailService, logger *slog.Logger) domain.InventoryUsecase {
+	return &inventoryUsecase{
+		movementRepo: mRepo,
+		productRepo:  pRepo,
+		userRepo:     uRepo,
+		emailSvc:     emailSvc,
+		logger:       logger,
+	}
+}
+
+// GetSupplierMovements selalu membatasi query pada produk milik supplier yang sedang login
+func (u *inventoryUsecase) GetSupplierMovements(ctx context.Context, supplierID string, filter domain.StockMovementFilter) ([]domain.StockMovement, int64, error) {
+	filter.SupplierID = supplierID
+	if filter.Limit <= 0 || filter.Limit > maxMovementPageSize {
+		filter.Limit = maxMovementPageSize
+	}
+	return u.movementRepo.FindByFilter(ctx, filter)
+}
+
+func (u *inventoryUsecase) CheckConsistency(ctx context.Context) ([]domain.StockDiscrepancy, error) {
+	return u.movementRepo.CheckConsistency(ctx)
+}
+
+func (u *inventoryUsecase) BackfillOpeningBalances(ctx context.Context) (int, error) {
+	return u.movementRepo.BackfillOpeningBalances(ctx)
+}
+
+// CheckLowStock mengirim paling banyak satu notifikasi per produk sampai produk tersebut di-restock
+// di atas ambangnya lagi (LowStockAlertedAt direset). Notifikasi dikelompokkan per supplier.
+func (u *inventoryUsecase) CheckLowStock(ctx context.Context, productIDs []string) (int, error) {
+	if len(productIDs) == 0 {
+		return 0, nil
+	}
+
+	products, err := u.productRepo.FindByIDs(ctx, productIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	alertsBySupplier := map[string][]domain.LowStockItem{}
+	productsBySupplier := map[string][]string{}
+	for _, p := range products {
+		lowItems, err := u.lowStockItems(ctx, p)
+		if err != nil {
+			return 0, err
+		}
+		if len(lowItems) == 0 {
+			if p.LowStockAlertedAt != nil {
+				_ = u.productRepo.SetLowStockAlertedAt(ctx, p.ID, nil)
+			}
+			continue
+		}
+		if p.LowStockAlertedAt != nil || p.SupplierID == "" {
+			continue // Supplier sudah diberi tahu sebelumnya
+		}
+		alertsBySupplier[p.SupplierID] = append(alertsBySupplier[p.SupplierID], lowItems...)
+		productsBySupplier[p.SupplierID] = append(productsBySupplier[p.SupplierID], p.ID)
+	}
+
+	notified := 0
+	now := time.Now()
+	for supplierID, items := range alertsBySupplier {
+		supplier, err := u.userRepo.FindByID(ctx, supplierID)
+		if err != nil || supplier == nil {
+			u.logger.WarnContext(ctx, "supplier tidak ditemukan, notifikasi stok menipis dilewati", "supplier_id", supplierID)
+			continue
+		}
+		if u.emailSvc != nil {
+			if err := u.emailSvc.SendLowStockAlertEmail(supplier.Email, items); err != nil {
+				u.logger.ErrorContext(ctx, "gagal mengirim notifikasi stok menipis", "supplier_id", supplierID, "error", err)
+				continue
+			}
+		}
+		for _, productID := range productsBySupplier[supplierID] {
+			_ = u.productRepo.SetLowStockAlertedAt(ctx, productID, &now)
+		}
+		notified += len(items)
+	}
+	return notified, nil
+}
+
+// lowStockItems memakai stok tersedia (stok fisik dikurangi reservasi aktif) karena stok fisik
+// baru dipotong saat pembayaran. Untuk produk bervarian, yang diperiksa adalah stok tiap varian.
+func (u *inventoryUsecase) lowStockItems(ctx context.Context, p domain.Product) ([]domain.LowStockItem, error) {
+	var items []domain.LowStockItem
+	if len(p.Variants) == 0 {
+		reserved, err := u.productRepo.GetReservedStock(ctx, p.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		available := p.Stock - reserved
+		if available <= p.LowStockThreshold {
+			items = append(items, domain.LowStockItem{ProductID: p.ID, Name: p.Name, AvailableStock: available, Threshold: p.LowStockThreshold})
+		}
+		return items, nil
+	}
+
+	for _, v := range p.Variants {
+		variantID := v.ID
+		reserved, err := u.productRepo.GetReservedStock(ctx, p.ID, &variantID)
+		if err != nil {
+			return nil, err
+		}
+		available := v.Stock - reserved
+		if available <= p.LowStockThreshold {
+			items = append(items, domain.LowStockItem{
+				ProductID: p.ID, VariantID: &variantID, Name: p.Name + " - " + v.NameLabel,
+				AvailableStock: available, Threshold: p.LowStockThreshold,
+			})
+		}
+	}
+	return items, nil
+}
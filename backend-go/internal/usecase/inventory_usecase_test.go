@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+)
+
+// MockEmailService implements domain.EmailService and records low-stock alerts
+type MockEmailService struct {
+	LowStockAlerts map[string][]domain.LowStockItem
+	WishlistAlerts []string
+}
+
+func (m *MockEmailService) SendInvoiceEmail(customerEmail string, order *domain.Order) error {
+	return nil
+}
+func (m *MockEmailService) SendReviewReminderEmail(customerEmail string, order *domain.Order) error {
+	return nil
+}
+func (m *MockEmailService) SendWishlistAlertEmail(customerEmail string, job domain.WishlistAlertJob) error {
+	m.WishlistAlerts = append(m.WishlistAlerts, customerEmail)
+	return nil
+}
+func (m *MockEmailService) SendLowStockAlertEmail(supplierEmail string, items []domain.LowStockItem) error {
+	if m.LowStockAlerts == nil {
+		m.LowStockAlerts = map[string][]domain.LowStockItem{}
+	}
+	m.LowStockAlerts[supplierEmail] = append(m.LowStockAlerts[supplierEmail], items...)
+	return nil
+}
+
+func TestCheckLowStock_NotifiesSupplierOnce(t *testing.T) {
+	productRepo := NewMockProductRepository()
+	productRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Kangkung", Stock: 3, LowStockThreshold: 5, SupplierID: "sup-1"}
+	productRepo.products["p2"] = &domain.Product{ID: "p2", Name: "Bayam", Stock: 50, LowStockThreshold: 5, SupplierID: "sup-1"}
+
+	userRepo := NewMockUserRepository()
+	userRepo.users["tani@example.com"] = &domain.User{ID: "sup-1", Email: "tani@example.com"}
+	emailSvc := &MockEmailService{}
+
+	uc := NewInventoryUsecase(nil, productRepo, userRepo, emailSvc, logger.Discard())
+
+	notified, err := uc.CheckLowStock(context.Background(), []string{"p1", "p2"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if notified != 1 || len(emailSvc.LowStockAlerts["tani@example.com"]) != 1 {
+		t.Fatalf("Expected exactly one low-stock alert, got %d", notified)
+	}
+	if productRepo.products["p1"].LowStockAlertedAt == nil {
+		t.Error("Expected p1 to be marked as alerted")
+	}
+
+	// Checkout berikutnya tidak boleh mengirim notifikasi ulang untuk produk yang sama
+	notified, _ = uc.CheckLowStock(context.Background(), []string{"p1"})
+	if notified != 0 {
+		t.Errorf("Expected no repeated alert, got %d", notified)
+	}
+
+	// Setelah di-restock di atas ambang, penanda direset
+	productRepo.products["p1"].Stock = 20
+	_, _ = uc.CheckLowStock(context.Background(), []string{"p1"})
+	if productRepo.products["p1"].LowStockAlertedAt != nil {
+		t.Error("Expected alert marker to be cleared after restock")
+	}
+}
@@ -1,47 +1,89 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
+	"log/slog"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/midtrans/midtrans-go"
 	"github.com/midtrans/midtrans-go/snap"
+	"github.com/nuryanfa/e-commerse-sqa/config"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/metrics"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// legacyPendingOrderTTL adalah batas bayar pesanan lama tanpa expires_at (dibuat sebelum reservasi stok ada).
+// Tetap 24 jam seperti aturan lama agar pesanan tersebut tidak ikut kedaluwarsa lebih cepat saat
+// PAYMENT_EXPIRY diperpendek.
+const legacyPendingOrderTTL = 24 * time.Hour
+
 type orderUsecase struct {
 	orderRepo    domain.OrderRepository
 	cartRepo     domain.CartRepository
 	auditLogRepo domain.AuditLogRepository
 	emailSvc     domain.EmailService
 	userRepo     domain.UserRepository
+	inventoryUC  domain.InventoryUsecase
+	productCache domain.ProductCacheInvalidator
+	payment      config.PaymentConfig
+	logger       *slog.Logger
 }
 
-func NewOrderUsecase(oRepo domain.OrderRepository, cRepo domain.CartRepository, aRepo domain.AuditLogRepository, emailSvc domain.EmailService, uRepo domain.UserRepository) domain.OrderUsecase {
+func NewOrderUsecase(oRepo domain.OrderRepository, cRepo domain.CartRepository, aRepo domain.AuditLogRepository, emailSvc domain.EmailService, uRepo domain.UserRepository, invUC domain.InventoryUsecase, productCache domain.ProductCacheInvalidator, payment config.PaymentConfig, logger *slog.Logger) domain.OrderUsecase {
 	return &orderUsecase{
 		orderRepo:    oRepo,
 		cartRepo:     cRepo,
 		auditLogRepo: aRepo,
 		emailSvc:     emailSvc,
 		userRepo:     uRepo,
+		inventoryUC:  invUC,
+		productCache: productCache,
+		payment:      payment,
+		logger:       logger,
+	}
+}
+
+// invalidateProductCache dipanggil setelah stok fisik dipotong agar katalog tidak menampilkan stok lama
+func (u *orderUsecase) invalidateProductCache(ctx context.Context) {
+	if u.productCache != nil {
+		u.productCache.InvalidateProductCache(ctx)
 	}
 }
 
+// checkLowStockAsync memeriksa stok menipis di background agar respons checkout tidak tertahan
+func (u *orderUsecase) checkLowStockAsync(ctx context.Context, order *domain.Order) {
+	if u.inventoryUC == nil || order == nil {
+		return
+	}
+	productIDs := make([]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	// Goroutine hidup lebih lama dari request, jadi pembatalan request tidak ikut diteruskan
+	bgCtx := context.WithoutCancel(ctx)
+	go func() {
+		if _, err := u.inventoryUC.CheckLowStock(bgCtx, productIDs); err != nil {
+			u.logger.ErrorContext(bgCtx, "gagal memeriksa stok menipis", "order_id", order.ID, "error", err)
+		}
+	}()
+}
+
 // [B1] createSnapToken adalah private helper yang menyatukan logika inisialisasi Midtrans
 // yang sebelumnya terduplikasi identik di Checkout() dan InstantCheckout().
-// Mengembalikan *snap.Response dan error.
-func createSnapToken(orderID string, totalAmount float64) (*snap.Response, error) {
-	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
-	frontendURL := os.Getenv("APP_FRONTEND_URL")
-	if frontendURL == "" {
-		frontendURL = "http://localhost:5173"
-	}
+// Mengembalikan *snap.Response dan error. Panggilan ke Midtrans direkam sebagai span client tersendiri.
+func (u *orderUsecase) createSnapToken(ctx context.Context, orderID string, totalAmount float64, createdAt time.Time, expiresAt time.Time) (resp *snap.Response, err error) {
+	_, span := tracing.StartClient(ctx, "midtrans.snap.CreateTransaction",
+		attribute.String("order.id", orderID), attribute.Float64("order.total_amount", totalAmount))
+	defer func() { tracing.End(span, err) }()
 
 	var snapClient snap.Client
-	snapClient.New(serverKey, midtrans.Sandbox)
+	snapClient.New(u.payment.MidtransServerKey, midtrans.Sandbox)
 
 	req := &snap.Request{
 		TransactionDetails: midtrans.TransactionDetails{
@@ -51,20 +93,29 @@ func createSnapToken(orderID string, totalAmount float64) (*snap.Response, error
 		CreditCard: &snap.CreditCardDetails{
 			Secure: true,
 		},
+		// Samakan batas bayar Midtrans dengan masa berlaku reservasi stok
+		Expiry: &snap.ExpiryDetails{
+			StartTime: createdAt.Format("2006-01-02 15:04:05 -0700"),
+			Unit:      "minute",
+			Duration:  int64(expiresAt.Sub(createdAt).Round(time.Minute).Minutes()),
+		},
 		Callbacks: &snap.Callbacks{
-			Finish: frontendURL + "/orders/" + orderID,
+			Finish: u.payment.FrontendURL + "/orders/" + orderID,
 		},
 	}
 
 	resp, midErr := snapClient.CreateTransaction(req)
 	if midErr != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", midErr.StatusCode))
 		return nil, errors.New(midErr.Message)
 	}
 	return resp, nil
 }
 
-func (u *orderUsecase) Checkout(userID string, voucherCode string) (*domain.Order, error) {
-	cartItems, err := u.cartRepo.FindByUserID(userID)
+func (u *orderUsecase) Checkout(ctx context.Context, userID string, voucherCode string) (order *domain.Order, err error) {
+	defer func() { metrics.Checkouts.WithLabelValues("cart", metrics.Result(err)).Inc() }()
+
+	cartItems, err := u.cartRepo.FindByUserID(ctx, userID)
 	if err != nil {
 		return nil, errors.New("gagal memuat keranjang belanja")
 	}
@@ -72,25 +123,37 @@ func (u *orderUsecase) Checkout(userID string, voucherCode string) (*domain.Orde
 	if len(cartItems) == 0 {
 		return nil, errors.New("keranjang belanja anda kosong. tidak bisa checkout")
 	}
+	// Produk bisa diarsipkan atau dikembalikan ke review setelah masuk keranjang
+	for _, item := range cartItems {
+		if item.Product != nil && !item.Product.IsPublished() {
+			return nil, fmt.Errorf("produk %s sudah tidak tersedia, hapus dari keranjang untuk melanjutkan", item.Product.Name)
+		}
+	}
 
-	order, err := u.orderRepo.CheckoutTransaction(userID, cartItems, voucherCode)
+	// Span transaksi mengelompokkan statement GORM (termasuk SELECT ... FOR UPDATE) di dalamnya
+	txCtx, span := tracing.Start(ctx, "order.CheckoutTransaction", attribute.Int("cart.items", len(cartItems)))
+	order, err = u.orderRepo.CheckoutTransaction(txCtx, userID, cartItems, voucherCode, time.Now().Add(u.payment.Expiry))
+	tracing.End(span, err)
 	if err != nil {
 		return nil, errors.New("Checkout gagal: " + err.Error())
 	}
 
 	// [B1] Gunakan helper untuk menghindari duplikasi blok Midtrans
-	snapResp, snapErr := createSnapToken(order.ID, order.TotalAmount)
+	snapResp, snapErr := u.createSnapToken(ctx, order.ID, order.TotalAmount, order.CreatedAt, *order.ExpiresAt)
 	if snapErr == nil && snapResp != nil {
 		order.PaymentToken = &snapResp.Token
 		order.PaymentURL = &snapResp.RedirectURL
 	} else if snapErr != nil {
-		fmt.Printf("[MIDTRANS ERROR] Gagal generate Snap Token (Checkout): %v\n", snapErr)
+		u.logger.ErrorContext(ctx, "gagal membuat Snap Token Midtrans", "checkout", "cart", "order_id", order.ID, "error", snapErr)
 	}
 
+	u.checkLowStockAsync(ctx, order)
 	return order, nil
 }
 
-func (u *orderUsecase) InstantCheckout(userID string, productID string, variantID *string, quantity int, voucherCode string) (*domain.Order, error) {
+func (u *orderUsecase) InstantCheckout(ctx context.Context, userID string, productID string, variantID *string, quantity int, voucherCode string) (order *domain.Order, err error) {
+	defer func() { metrics.Checkouts.WithLabelValues("instant", metrics.Result(err)).Inc() }()
+
 	if quantity <= 0 {
 		return nil, errors.New("jumlah barang minimal 1")
 	}
@@ -101,29 +164,32 @@ func (u *orderUsecase) InstantCheckout(userID string, productID string, variantI
 		Quantity:  quantity,
 	}
 
-	order, err := u.orderRepo.InstantCheckoutTransaction(userID, item, voucherCode)
+	txCtx, span := tracing.Start(ctx, "order.InstantCheckoutTransaction", attribute.String("product.id", productID))
+	order, err = u.orderRepo.InstantCheckoutTransaction(txCtx, userID, item, voucherCode, time.Now().Add(u.payment.Expiry))
+	tracing.End(span, err)
 	if err != nil {
 		return nil, errors.New("Beli Langsung gagal: " + err.Error())
 	}
 
 	// [B1] Gunakan helper untuk menghindari duplikasi blok Midtrans
-	snapResp, snapErr := createSnapToken(order.ID, order.TotalAmount)
+	snapResp, snapErr := u.createSnapToken(ctx, order.ID, order.TotalAmount, order.CreatedAt, *order.ExpiresAt)
 	if snapErr == nil && snapResp != nil {
 		order.PaymentToken = &snapResp.Token
 		order.PaymentURL = &snapResp.RedirectURL
 	} else if snapErr != nil {
-		fmt.Printf("[MIDTRANS ERROR] Gagal generate Snap Token (InstantCheckout): %v\n", snapErr)
+		u.logger.ErrorContext(ctx, "gagal membuat Snap Token Midtrans", "checkout", "instant", "order_id", order.ID, "error", snapErr)
 	}
 
+	u.checkLowStockAsync(ctx, order)
 	return order, nil
 }
 
-func (u *orderUsecase) GetMyOrders(userID string) ([]domain.Order, error) {
-	return u.orderRepo.FindByUserID(userID)
+func (u *orderUsecase) GetMyOrders(ctx context.Context, userID string, page pagination.Params) ([]domain.Order, string, error) {
+	return u.orderRepo.FindByUserID(ctx, userID, page)
 }
 
-func (u *orderUsecase) GetOrderDetail(userID string, orderID string) (*domain.Order, error) {
-	order, err := u.orderRepo.FindByID(orderID)
+func (u *orderUsecase) GetOrderDetail(ctx context.Context, userID string, orderID string) (*domain.Order, error) {
+	order, err := u.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
@@ -135,8 +201,8 @@ func (u *orderUsecase) GetOrderDetail(userID string, orderID string) (*domain.Or
 	return order, nil
 }
 
-func (u *orderUsecase) PayOrder(orderID string) error {
-	order, err := u.orderRepo.FindByID(orderID)
+func (u *orderUsecase) PayOrder(ctx context.Context, orderID string) error {
+	order, err := u.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
 		return err
 	}
@@ -145,20 +211,69 @@ func (u *orderUsecase) PayOrder(orderID string) error {
 		return errors.New("pesanan ini sudah dibayar")
 	}
 
-	return u.orderRepo.UpdateStatus(orderID, "PAID")
+	status, err := u.orderRepo.ConfirmPayment(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if status == domain.OrderStatusPaid {
+		u.invalidateProductCache(ctx)
+	}
+	if status == domain.OrderStatusPaymentReview {
+		return errors.New("pembayaran diterima, tetapi stok pesanan sudah habis karena batas bayar terlewati. Pesanan menunggu peninjauan admin")
+	}
+	return nil
+}
+
+// --- Admin Methods ---
+
+// ResolvePaymentReview menindaklanjuti pesanan PAYMENT_REVIEW.
+// APPROVE mengonfirmasi ulang pembayaran setelah stok ditambah (stok dipotong seperti pembayaran biasa);
+// jika stok masih kurang, pesanan tetap PAYMENT_REVIEW dan error dikembalikan.
+// REFUND menutup pesanan sebagai REFUNDED tanpa menyentuh stok; dana dikembalikan di luar sistem.
+func (u *orderUsecase) ResolvePaymentReview(ctx context.Context, adminID string, orderID string, action string) (*domain.Order, error) {
+	if action != domain.PaymentReviewApprove && action != domain.PaymentReviewRefund {
+		return nil, errors.New("aksi tidak valid, gunakan APPROVE atau REFUND")
+	}
+
+	order, err := u.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order == nil {
+		return nil, errors.New("pesanan tidak ditemukan")
+	}
+	if order.Status != domain.OrderStatusPaymentReview {
+		return nil, errors.New("pesanan tidak sedang menunggu peninjauan pembayaran")
+	}
+
+	newStatus := domain.OrderStatusRefunded
+	if action == domain.PaymentReviewApprove {
+		newStatus, err = u.orderRepo.ConfirmPayment(ctx, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if newStatus != domain.OrderStatusPaid {
+			return nil, errors.New("stok pesanan masih belum mencukupi, tambah stok terlebih dahulu atau lakukan refund")
+		}
+		u.invalidateProductCache(ctx)
+	} else if err := u.orderRepo.CancelOrder(ctx, orderID, newStatus); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, u.logger, u.auditLogRepo, adminID, "ADMIN_RESOLVE_PAYMENT_REVIEW", "orders", orderID, "",
+		map[string]string{"status": order.Status}, map[string]string{"status": newStatus, "action": action})
+	order.Status = newStatus
+	return order, nil
 }
 
 // --- Courier Methods ---
 
 // GetPaidOrders mengembalikan pesanan siap kirim (status PROCESSED)
-func (u *orderUsecase) GetPaidOrders() ([]domain.Order, error) {
+func (u *orderUsecase) GetPaidOrders(ctx context.Context) ([]domain.Order, error) {
 	// Peringatan: Meskipun nama func GetPaidOrders(), kita akan mengubah implementasinya ke "PROCESSED" sesuai arahan Stage 9 agar tidak merusak Interface.
-	return u.orderRepo.FindProcessedOrders()
+	return u.orderRepo.FindProcessedOrders(ctx)
 }
 
 // AssignAndShip mengambil pesanan PAID dan set menjadi SHIPPED oleh kurir
-func (u *orderUsecase) AssignAndShip(orderID string, courierID string) error {
-	order, err := u.orderRepo.FindByID(orderID)
+func (u *orderUsecase) AssignAndShip(ctx context.Context, orderID string, courierID string) error {
+	order, err := u.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
 		return err
 	}
@@ -171,12 +286,12 @@ func (u *orderUsecase) AssignAndShip(orderID string, courierID string) error {
 		return errors.New("pesanan ini sudah diambil kurir lain")
 	}
 
-	return u.orderRepo.AssignCourier(orderID, courierID)
+	return u.orderRepo.AssignCourier(ctx, orderID, courierID)
 }
 
 // MarkDelivered menandai pesanan sebagai DELIVERED oleh kurir
-func (u *orderUsecase) MarkDelivered(orderID string, courierID string) error {
-	order, err := u.orderRepo.FindByID(orderID)
+func (u *orderUsecase) MarkDelivered(ctx context.Context, orderID string, courierID string) error {
+	order, err := u.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
 		return err
 	}
@@ -190,24 +305,24 @@ func (u *orderUsecase) MarkDelivered(orderID string, courierID string) error {
 	}
 
 	// [B5] Variabel `now` dan dead code `_ = now` dihapus. DeliveredAt di-set oleh repository.
-	return u.orderRepo.UpdateStatus(orderID, "DELIVERED")
+	return u.orderRepo.UpdateStatus(ctx, orderID, "DELIVERED")
 }
 
 // GetCourierOrders mengembalikan pesanan yang sedang di-handle kurir
-func (u *orderUsecase) GetCourierOrders(courierID string) ([]domain.Order, error) {
-	return u.orderRepo.FindByCourierID(courierID)
+func (u *orderUsecase) GetCourierOrders(ctx context.Context, courierID string) ([]domain.Order, error) {
+	return u.orderRepo.FindByCourierID(ctx, courierID)
 }
 
 // --- Supplier Methods ---
 
 // GetSupplierOrders mengembalikan pesanan yang berisi produk milik supplier
-func (u *orderUsecase) GetSupplierOrders(supplierID string) ([]domain.Order, error) {
-	return u.orderRepo.FindByProductSupplier(supplierID)
+func (u *orderUsecase) GetSupplierOrders(ctx context.Context, supplierID string, page pagination.Params) ([]domain.Order, string, error) {
+	return u.orderRepo.FindByProductSupplier(ctx, supplierID, page)
 }
 
 // ProcessSupplierOrder mengubah pesanan PAID menjadi PROCESSED oleh Supplier
-func (u *orderUsecase) ProcessSupplierOrder(supplierID string, orderID string) error {
-	order, err := u.orderRepo.FindByID(orderID)
+func (u *orderUsecase) ProcessSupplierOrder(ctx context.Context, supplierID string, orderID string) error {
+	order, err := u.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
 		return err
 	}
@@ -229,37 +344,28 @@ func (u *orderUsecase) ProcessSupplierOrder(supplierID string, orderID string) e
 		return errors.New("pesanan harus berstatus PAID untuk bisa mulai diproses")
 	}
 
-	err = u.orderRepo.UpdateStatus(orderID, "PROCESSED")
-	if err == nil && u.auditLogRepo != nil {
-		_ = u.auditLogRepo.Insert(&domain.AuditLog{
-			ID:        uuid.New().String(),
-			UserID:    supplierID,
-			Action:    "SUPPLIER_PROCESS_ORDER",
-			Entity:    "orders",
-			EntityID:  orderID,
-			OldValues: "PAID",
-			NewValues: "PROCESSED",
-			CreatedAt: time.Now(),
-		})
+	err = u.orderRepo.UpdateStatus(ctx, orderID, "PROCESSED")
+	if err == nil {
+		recordAudit(ctx, u.logger, u.auditLogRepo, supplierID, "SUPPLIER_PROCESS_ORDER", "orders", orderID, "",
+			map[string]string{"status": "PAID"}, map[string]string{"status": "PROCESSED"})
 	}
 	return err
 }
 
 // BatchProcessSupplierOrders mengubah banyak pesanan PAID menjadi PROCESSED serentak.
 // [B4] Menggunakan FindByIDs (satu query SQL IN) alih-alih N+1 loop FindByID.
-func (u *orderUsecase) BatchProcessSupplierOrders(supplierID string, orderIDs []string) error {
+func (u *orderUsecase) BatchProcessSupplierOrders(ctx context.Context, supplierID string, orderIDs []string) error {
 	if len(orderIDs) == 0 {
 		return errors.New("daftar pesanan kosong")
 	}
 
 	// [B4] Ambil semua pesanan sekaligus dengan satu query, bukan N kali FindByID di dalam loop
-	orders, err := u.orderRepo.FindByIDs(orderIDs)
+	orders, err := u.orderRepo.FindByIDs(ctx, orderIDs)
 	if err != nil {
 		return errors.New("gagal mengambil data pesanan: " + err.Error())
 	}
 
 	validOrderIDs := []string{}
-	now := time.Now()
 
 	for _, order := range orders {
 		// Validasi: pesanan harus berstatus PAID dan mengandung produk milik supplier ini
@@ -273,19 +379,6 @@ func (u *orderUsecase) BatchProcessSupplierOrders(supplierID string, orderIDs []
 
 		if isOwnedBySupplier && order.Status == "PAID" {
 			validOrderIDs = append(validOrderIDs, order.ID)
-
-			if u.auditLogRepo != nil {
-				_ = u.auditLogRepo.Insert(&domain.AuditLog{
-					ID:        uuid.New().String(),
-					UserID:    supplierID,
-					Action:    "SUPPLIER_BATCH_PROCESS_ORDER",
-					Entity:    "orders",
-					EntityID:  order.ID,
-					OldValues: "PAID",
-					NewValues: "PROCESSED",
-					CreatedAt: now,
-				})
-			}
 		}
 	}
 
@@ -293,12 +386,19 @@ func (u *orderUsecase) BatchProcessSupplierOrders(supplierID string, orderIDs []
 		return errors.New("tidak ada pesanan yang valid untuk diproses (status harus PAID dan milik toko Anda)")
 	}
 
-	return u.orderRepo.BatchUpdateStatus(validOrderIDs, "PROCESSED")
+	if err := u.orderRepo.BatchUpdateStatus(ctx, validOrderIDs, "PROCESSED"); err != nil {
+		return err
+	}
+	for _, orderID := range validOrderIDs {
+		recordAudit(ctx, u.logger, u.auditLogRepo, supplierID, "SUPPLIER_BATCH_PROCESS_ORDER", "orders", orderID, "",
+			map[string]string{"status": "PAID"}, map[string]string{"status": "PROCESSED"})
+	}
+	return nil
 }
 
 // --- Webhook Logik ---
 
-func (u *orderUsecase) ProcessPaymentWebhook(payload map[string]interface{}) error {
+func (u *orderUsecase) ProcessPaymentWebhook(ctx context.Context, payload map[string]interface{}) error {
 	orderID, ok := payload["order_id"].(string)
 	if !ok {
 		return errors.New("order_id tidak ditemukan atau invalid")
@@ -324,7 +424,6 @@ func (u *orderUsecase) ProcessPaymentWebhook(payload map[string]interface{}) err
 		newStatus = "PAID"
 	case "deny", "cancel", "expire":
 		newStatus = "CANCELLED"
-		// TODO: PENTING: Restorasi / Kembalikan Stok jika Dibatalkan (Akan dikerjakan di Ticket 31)
 	case "pending":
 		newStatus = "PENDING"
 	default:
@@ -333,26 +432,47 @@ func (u *orderUsecase) ProcessPaymentWebhook(payload map[string]interface{}) err
 	}
 
 	if newStatus != "" {
-		err := u.orderRepo.UpdateStatus(orderID, newStatus)
-		if err == nil && u.auditLogRepo != nil {
-			_ = u.auditLogRepo.Insert(&domain.AuditLog{
-				ID:        uuid.New().String(),
-				UserID:    "SYSTEM/MIDTRANS",
-				Action:    "WEBHOOK_PAYMENT_UPDATE",
-				Entity:    "orders",
-				EntityID:  orderID,
-				NewValues: newStatus,
-				CreatedAt: time.Now(),
-			})
+		var err error
+		switch newStatus {
+		case "PAID":
+			// Konsumsi reservasi: stok fisik dipotong, atau PAYMENT_REVIEW jika stok sudah habis
+			var confirmed string
+			confirmed, err = u.orderRepo.ConfirmPayment(ctx, orderID)
+			if err == nil && confirmed == domain.OrderStatusPaid {
+				u.invalidateProductCache(ctx)
+			}
+			if err == nil && confirmed != newStatus {
+				newStatus = confirmed
+				u.logger.WarnContext(ctx, "pembayaran terlambat dan stok sudah habis, pesanan perlu ditinjau admin",
+					"order_id", orderID, "status", newStatus)
+			}
+		case "CANCELLED":
+			err = u.orderRepo.CancelOrder(ctx, orderID, newStatus) // Lepas reservasi / kembalikan stok
+		default:
+			// PENDING: pesanan baru memang PENDING; status tidak diubah agar notifikasi yang datang
+			// terlambat tidak mengembalikan pesanan yang sudah dibayar ke PENDING
+		}
+		if errors.Is(err, domain.ErrOrderNotPayable) || errors.Is(err, domain.ErrOrderNotCancellable) {
+			// Notifikasi ganda/terlambat untuk pesanan yang sudah berjalan: abaikan tanpa efek samping
+			// dan jawab sukses agar Midtrans berhenti mengirim ulang
+			u.logger.WarnContext(ctx, "notifikasi pembayaran diabaikan karena status pesanan sudah berubah",
+				"order_id", orderID, "transaction_status", transactionStatus, "error", err)
+			return nil
+		}
+		if err == nil {
+			systemCtx := reqctx.WithActor(ctx, domain.SystemActorMidtrans, domain.AuditActorRoleSystem)
+			recordAudit(systemCtx, u.logger, u.auditLogRepo, "", "WEBHOOK_PAYMENT_UPDATE", "orders", orderID, "", nil,
+				map[string]string{"status": newStatus, "transaction_status": transactionStatus})
 		}
 
 		// [Fitur 39] Jika Pembayaran Berhasil (PAID), Luncurkan Goroutine Background Worker untuk Notifikasi Invoice!
 		if err == nil && newStatus == "PAID" && u.emailSvc != nil && u.userRepo != nil {
+			bgCtx := context.WithoutCancel(ctx)
 			go func() {
 				// Jalankan di *background thread*, biarkan HTTP Response (Midtrans) segera kembali dalam 10ms!
-				orderData, _ := u.orderRepo.FindByID(orderID)
+				orderData, _ := u.orderRepo.FindByID(bgCtx, orderID)
 				if orderData != nil {
-					userData, _ := u.userRepo.FindByID(orderData.UserID)
+					userData, _ := u.userRepo.FindByID(bgCtx, orderData.UserID)
 					if userData != nil {
 						_ = u.emailSvc.SendInvoiceEmail(userData.Email, orderData)
 					}
@@ -366,9 +486,14 @@ func (u *orderUsecase) ProcessPaymentWebhook(payload map[string]interface{}) err
 	return nil
 }
 
-// ProcessCancelExpiredJobs diakses oleh cronjob untuk mencari order yang terlambat 24 jam
-func (u *orderUsecase) ProcessCancelExpiredJobs() (int, error) {
-	// Batas waktu: pesanan dibuat lebih dari 24 jam yang lalu
-	cutoffTime := time.Now().Add(-24 * time.Hour)
-	return u.orderRepo.CancelExpiredOrders(cutoffTime)
+// ProcessCancelExpiredJobs diakses oleh scheduler untuk meng-EXPIRED-kan pesanan yang batas bayarnya lewat
+// dan melepas reservasi stoknya. Pesanan lama tanpa expires_at memakai legacyPendingOrderTTL.
+func (u *orderUsecase) ProcessCancelExpiredJobs(ctx context.Context) (int, error) {
+	now := time.Now()
+	return u.orderRepo.CancelExpiredOrders(ctx, now, now.Add(-legacyPendingOrderTTL))
+}
+
+// NextReservationExpiry dipakai scheduler untuk tidur tepat sampai reservasi berikutnya kedaluwarsa
+func (u *orderUsecase) NextReservationExpiry(ctx context.Context) (*time.Time, error) {
+	return u.orderRepo.FindNextReservationExpiry(ctx)
 }
@@ -1,12 +1,17 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/nuryanfa/e-commerse-sqa/config"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 // --- MOCKS ---
@@ -14,17 +19,23 @@ import (
 type MockCartRepository struct {
 	items []domain.CartItem
 }
-func (m *MockCartRepository) UpsertItem(item *domain.CartItem) error { return nil }
-func (m *MockCartRepository) UpdateItem(item *domain.CartItem) error { return nil }
-func (m *MockCartRepository) FindByUserID(userID string) ([]domain.CartItem, error) { return m.items, nil }
-func (m *MockCartRepository) DeleteByUserID(userID string) error { return nil }
-func (m *MockCartRepository) RemoveItem(itemID string, userID string) error { return nil }
-func (m *MockCartRepository) FindByID(itemID string) (*domain.CartItem, error) { return nil, nil }
+func (m *MockCartRepository) UpsertItem(ctx context.Context, item *domain.CartItem) error { return nil }
+func (m *MockCartRepository) UpdateItem(ctx context.Context, item *domain.CartItem) error { return nil }
+func (m *MockCartRepository) FindByUserID(ctx context.Context, userID string) ([]domain.CartItem, error) { return m.items, nil }
+func (m *MockCartRepository) DeleteByUserID(ctx context.Context, userID string) error { return nil }
+func (m *MockCartRepository) RemoveItem(ctx context.Context, itemID string, userID string) error { return nil }
+func (m *MockCartRepository) FindByID(ctx context.Context, itemID string) (*domain.CartItem, error) { return nil, nil }
 
 type MockOrderRepository struct {
-	Checkouts []*domain.Order
+	Checkouts       []*domain.Order
+	ConfirmedOrders []string
+	CanceledOrders  map[string]string
+	ExpireNow       time.Time
+	LegacyCutoff    time.Time
+	Orders          map[string]*domain.Order
+	SoldOutOrders   map[string]bool // Pembayaran terlambat yang stoknya sudah habis
 }
-func (m *MockOrderRepository) CheckoutTransaction(userID string, cartItems []domain.CartItem, voucherCode string) (*domain.Order, error) {
+func (m *MockOrderRepository) CheckoutTransaction(ctx context.Context, userID string, cartItems []domain.CartItem, voucherCode string, expiresAt time.Time) (*domain.Order, error) {
 	// Simulate Transaction logic for Test
 	if len(cartItems) == 0 {
 		return nil, errors.New("cart empty")
@@ -34,34 +45,61 @@ func (m *MockOrderRepository) CheckoutTransaction(userID string, cartItems []dom
 		UserID:      userID,
 		TotalAmount: 1000,
 		Status:      "PENDING",
+		ExpiresAt:   &expiresAt,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 	m.Checkouts = append(m.Checkouts, order)
 	return order, nil
 }
-func (m *MockOrderRepository) FindByUserID(userID string) ([]domain.Order, error) { return nil, nil }
-func (m *MockOrderRepository) FindByID(orderID string) (*domain.Order, error) { return nil, nil }
-func (m *MockOrderRepository) FindByIDs(orderIDs []string) ([]domain.Order, error) { return nil, nil }
-func (m *MockOrderRepository) UpdateStatus(orderID string, status string) error { return nil }
-func (m *MockOrderRepository) FindPaidOrders() ([]domain.Order, error)          { return nil, nil }
-func (m *MockOrderRepository) FindProcessedOrders() ([]domain.Order, error)     { return nil, nil }
-func (m *MockOrderRepository) AssignCourier(orderID string, courierID string) error { return nil }
-func (m *MockOrderRepository) FindByCourierID(courierID string) ([]domain.Order, error) {
-	return nil, nil
+func (m *MockOrderRepository) FindByUserID(ctx context.Context, userID string, page pagination.Params) ([]domain.Order, string, error) {
+	return nil, "", nil
+}
+func (m *MockOrderRepository) FindByID(ctx context.Context, orderID string) (*domain.Order, error) {
+	return m.Orders[orderID], nil
 }
-func (m *MockOrderRepository) FindByProductSupplier(supplierID string) ([]domain.Order, error) {
+func (m *MockOrderRepository) FindByIDs(ctx context.Context, orderIDs []string) ([]domain.Order, error) { return nil, nil }
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, orderID string, status string) error { return nil }
+func (m *MockOrderRepository) FindPaidOrders(ctx context.Context) ([]domain.Order, error)          { return nil, nil }
+func (m *MockOrderRepository) FindProcessedOrders(ctx context.Context) ([]domain.Order, error)     { return nil, nil }
+func (m *MockOrderRepository) AssignCourier(ctx context.Context, orderID string, courierID string) error { return nil }
+func (m *MockOrderRepository) FindByCourierID(ctx context.Context, courierID string) ([]domain.Order, error) {
 	return nil, nil
 }
-func (m *MockOrderRepository) CancelExpiredOrders(cutoffTime time.Time) (int, error) {
+func (m *MockOrderRepository) FindByProductSupplier(ctx context.Context, supplierID string, page pagination.Params) ([]domain.Order, string, error) {
+	return nil, "", nil
+}
+func (m *MockOrderRepository) ConfirmPayment(ctx context.Context, orderID string) (string, error) {
+	if order, ok := m.Orders[orderID]; ok && order.Status != "PENDING" && order.Status != "EXPIRED" && order.Status != domain.OrderStatusPaymentReview {
+		return "", domain.ErrOrderNotPayable
+	}
+	m.ConfirmedOrders = append(m.ConfirmedOrders, orderID)
+	if m.SoldOutOrders[orderID] {
+		return domain.OrderStatusPaymentReview, nil
+	}
+	return domain.OrderStatusPaid, nil
+}
+func (m *MockOrderRepository) CancelOrder(ctx context.Context, orderID string, status string) error {
+	if m.CanceledOrders == nil {
+		m.CanceledOrders = map[string]string{}
+	}
+	m.CanceledOrders[orderID] = status
+	return nil
+}
+func (m *MockOrderRepository) CancelExpiredOrders(ctx context.Context, now time.Time, legacyCutoff time.Time) (int, error) {
+	m.ExpireNow = now
+	m.LegacyCutoff = legacyCutoff
 	return 0, nil
 }
-func (m *MockOrderRepository) BatchUpdateStatus(orderIDs []string, status string) error {
+func (m *MockOrderRepository) FindNextReservationExpiry(ctx context.Context) (*time.Time, error) {
+	return nil, nil
+}
+func (m *MockOrderRepository) BatchUpdateStatus(ctx context.Context, orderIDs []string, status string) error {
 	return nil
 }
 
-func (m *MockOrderRepository) InstantCheckoutTransaction(userID string, item domain.CartItem, voucherCode string) (*domain.Order, error) {
-	return &domain.Order{ID: "mock-instant-id", TotalAmount: float64(item.Quantity * 1000)}, nil
+func (m *MockOrderRepository) InstantCheckoutTransaction(ctx context.Context, userID string, item domain.CartItem, voucherCode string, expiresAt time.Time) (*domain.Order, error) {
+	return &domain.Order{ID: "mock-instant-id", TotalAmount: float64(item.Quantity * 1000), ExpiresAt: &expiresAt}, nil
 }
 
 // --- TESTS ---
@@ -74,9 +112,9 @@ func TestCheckout_Success(t *testing.T) {
 	}
 	mockOrderRepo := &MockOrderRepository{}
 	
-	usecase := NewOrderUsecase(mockOrderRepo, mockCartRepo, nil, nil, nil)
+	usecase := NewOrderUsecase(mockOrderRepo, mockCartRepo, nil, nil, nil, nil, nil, config.Defaults().Payment, logger.Discard())
 
-	order, err := usecase.Checkout("user-1", "")
+	order, err := usecase.Checkout(context.Background(), "user-1", "")
 
 	if err != nil {
 		t.Fatalf("Expected successful checkout, got error: %v", err)
@@ -97,9 +135,9 @@ func TestCheckout_EmptyCart(t *testing.T) {
 	}
 	mockOrderRepo := &MockOrderRepository{}
 	
-	usecase := NewOrderUsecase(mockOrderRepo, mockCartRepo, nil, nil, nil)
+	usecase := NewOrderUsecase(mockOrderRepo, mockCartRepo, nil, nil, nil, nil, nil, config.Defaults().Payment, logger.Discard())
 
-	_, err := usecase.Checkout("user-1", "")
+	_, err := usecase.Checkout(context.Background(), "user-1", "")
 
 	if err == nil {
 		t.Fatalf("Expected error for empty cart, got success")
@@ -109,3 +147,184 @@ func TestCheckout_EmptyCart(t *testing.T) {
 		t.Errorf("Expected 'keranjang belanja anda kosong' error, got %v", err)
 	}
 }
+
+func TestCheckout_SetsPaymentExpiry(t *testing.T) {
+	mockCartRepo := &MockCartRepository{
+		items: []domain.CartItem{
+			{ID: "item-1", UserID: "user-1", ProductID: "prod-1", Quantity: 1},
+		},
+	}
+	mockOrderRepo := &MockOrderRepository{}
+
+	payment := config.Defaults().Payment
+	payment.Expiry = 15 * time.Minute
+	usecase := NewOrderUsecase(mockOrderRepo, mockCartRepo, nil, nil, nil, nil, nil, payment, logger.Discard())
+
+	before := time.Now()
+	order, err := usecase.Checkout(context.Background(), "user-1", "")
+	if err != nil {
+		t.Fatalf("Expected successful checkout, got error: %v", err)
+	}
+
+	// Reservasi stok harus berlaku sepanjang batas bayar dari konfigurasi
+	if order.ExpiresAt == nil || order.ExpiresAt.Before(before.Add(15*time.Minute)) || order.ExpiresAt.After(time.Now().Add(15*time.Minute)) {
+		t.Errorf("Expected expires_at about 15 minutes from now, got %v", order.ExpiresAt)
+	}
+}
+
+func TestProcessPaymentWebhook_ReservationLifecycle(t *testing.T) {
+	mockOrderRepo := &MockOrderRepository{}
+	usecase := NewOrderUsecase(mockOrderRepo, &MockCartRepository{}, nil, nil, nil, nil, nil, config.Defaults().Payment, logger.Discard())
+
+	// settlement -> reservasi dikonsumsi (stok fisik dipotong)
+	if err := usecase.ProcessPaymentWebhook(context.Background(), map[string]interface{}{"order_id": "order-paid", "transaction_status": "settlement"}); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if len(mockOrderRepo.ConfirmedOrders) != 1 || mockOrderRepo.ConfirmedOrders[0] != "order-paid" {
+		t.Errorf("Expected order-paid to be confirmed, got %v", mockOrderRepo.ConfirmedOrders)
+	}
+
+	// expire -> reservasi dilepas
+	if err := usecase.ProcessPaymentWebhook(context.Background(), map[string]interface{}{"order_id": "order-expired", "transaction_status": "expire"}); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if mockOrderRepo.CanceledOrders["order-expired"] != "CANCELLED" {
+		t.Errorf("Expected order-expired to be cancelled with released reservations, got %v", mockOrderRepo.CanceledOrders)
+	}
+}
+
+func TestPayOrder_LatePaymentWithoutStockNeedsReview(t *testing.T) {
+	mockOrderRepo := &MockOrderRepository{
+		Orders:        map[string]*domain.Order{"order-late": {ID: "order-late", Status: "EXPIRED"}},
+		SoldOutOrders: map[string]bool{"order-late": true},
+	}
+	usecase := NewOrderUsecase(mockOrderRepo, &MockCartRepository{}, nil, nil, nil, nil, nil, config.Defaults().Payment, logger.Discard())
+
+	err := usecase.PayOrder(context.Background(), "order-late")
+	if err == nil || !strings.Contains(err.Error(), "peninjauan") {
+		t.Errorf("Pembayaran terlambat tanpa stok harus dilaporkan menunggu peninjauan, didapat %v", err)
+	}
+
+	// Webhook tetap sukses (tidak di-retry Midtrans), tetapi status audit mengikuti hasil konfirmasi
+	if err := usecase.ProcessPaymentWebhook(context.Background(), map[string]interface{}{"order_id": "order-late", "transaction_status": "settlement"}); err != nil {
+		t.Fatalf("Expected webhook success, got error: %v", err)
+	}
+}
+
+func TestProcessCancelExpiredJobs_LegacyOrdersKeep24Hours(t *testing.T) {
+	mockOrderRepo := &MockOrderRepository{}
+	payment := config.Defaults().Payment
+	payment.Expiry = 15 * time.Minute
+	usecase := NewOrderUsecase(mockOrderRepo, &MockCartRepository{}, nil, nil, nil, nil, nil, payment, logger.Discard())
+
+	if _, err := usecase.ProcessCancelExpiredJobs(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := mockOrderRepo.ExpireNow.Sub(mockOrderRepo.LegacyCutoff); got != 24*time.Hour {
+		t.Errorf("Pesanan lama tanpa expires_at harus tetap memakai batas 24 jam, didapat %v", got)
+	}
+}
+
+type mockProductCache struct{ invalidations int }
+
+func (m *mockProductCache) InvalidateProductCache(ctx context.Context) { m.invalidations++ }
+
+func TestPayOrder_InvalidatesProductCache(t *testing.T) {
+	mockOrderRepo := &MockOrderRepository{
+		Orders:        map[string]*domain.Order{"order-1": {ID: "order-1", Status: "PENDING"}, "order-late": {ID: "order-late", Status: "EXPIRED"}},
+		SoldOutOrders: map[string]bool{"order-late": true},
+	}
+	cache := &mockProductCache{}
+	usecase := NewOrderUsecase(mockOrderRepo, &MockCartRepository{}, nil, nil, nil, nil, cache, config.Defaults().Payment, logger.Discard())
+
+	if err := usecase.PayOrder(context.Background(), "order-1"); err != nil {
+		t.Fatalf("Expected payment success, got %v", err)
+	}
+	if cache.invalidations != 1 {
+		t.Errorf("Expected product cache invalidated after stock was consumed, got %d invalidations", cache.invalidations)
+	}
+
+	// Pesanan yang masuk peninjauan tidak memotong stok, jadi cache tidak perlu dihapus
+	_ = usecase.PayOrder(context.Background(), "order-late")
+	if cache.invalidations != 1 {
+		t.Errorf("Expected no invalidation for PAYMENT_REVIEW, got %d invalidations", cache.invalidations)
+	}
+}
+
+func TestProcessPaymentWebhook_DuplicateSettlementIsNoOp(t *testing.T) {
+	mockOrderRepo := &MockOrderRepository{
+		Orders: map[string]*domain.Order{"order-1": {ID: "order-1", Status: "PROCESSED"}},
+	}
+	auditRepo := &MockAuditLogRepository{}
+	cache := &mockProductCache{}
+	usecase := NewOrderUsecase(mockOrderRepo, &MockCartRepository{}, auditRepo, nil, nil, nil, cache, config.Defaults().Payment, logger.Discard())
+
+	// Midtrans mengirim ulang settlement: jawab sukses tanpa audit, invalidasi cache, atau perubahan status
+	for _, status := range []string{"settlement", "pending"} {
+		if err := usecase.ProcessPaymentWebhook(context.Background(), map[string]interface{}{"order_id": "order-1", "transaction_status": status}); err != nil {
+			t.Fatalf("Expected %s retry acknowledged, got %v", status, err)
+		}
+	}
+	if len(mockOrderRepo.ConfirmedOrders) != 0 || cache.invalidations != 0 {
+		t.Errorf("Expected no confirmation side effects, got %v confirmations and %d invalidations", mockOrderRepo.ConfirmedOrders, cache.invalidations)
+	}
+	for _, entry := range auditRepo.logs {
+		if entry.Action == "WEBHOOK_PAYMENT_UPDATE" && strings.Contains(entry.NewValues, "PAID") {
+			t.Errorf("Expected no PAID audit for an ignored webhook, got %+v", entry)
+		}
+	}
+}
+
+func TestResolvePaymentReview(t *testing.T) {
+	mockOrderRepo := &MockOrderRepository{
+		Orders: map[string]*domain.Order{
+			"order-sold-out":  {ID: "order-sold-out", Status: domain.OrderStatusPaymentReview},
+			"order-restocked": {ID: "order-restocked", Status: domain.OrderStatusPaymentReview},
+			"order-refund":    {ID: "order-refund", Status: domain.OrderStatusPaymentReview},
+			"order-paid":      {ID: "order-paid", Status: "PAID"},
+		},
+		SoldOutOrders: map[string]bool{"order-sold-out": true},
+	}
+	auditRepo := &MockAuditLogRepository{}
+	cache := &mockProductCache{}
+	usecase := NewOrderUsecase(mockOrderRepo, &MockCartRepository{}, auditRepo, nil, nil, nil, cache, config.Defaults().Payment, logger.Discard())
+	ctx := context.Background()
+
+	if _, err := usecase.ResolvePaymentReview(ctx, "admin-1", "order-restocked", "SHIP"); err == nil {
+		t.Error("Expected unknown action rejected")
+	}
+	if _, err := usecase.ResolvePaymentReview(ctx, "admin-1", "order-paid", domain.PaymentReviewRefund); err == nil {
+		t.Error("Expected orders outside PAYMENT_REVIEW rejected")
+	}
+
+	// Stok belum ditambah: pesanan tetap menunggu peninjauan
+	if _, err := usecase.ResolvePaymentReview(ctx, "admin-1", "order-sold-out", domain.PaymentReviewApprove); err == nil {
+		t.Error("Expected approve rejected while stock is still short")
+	}
+
+	order, err := usecase.ResolvePaymentReview(ctx, "admin-1", "order-restocked", domain.PaymentReviewApprove)
+	if err != nil || order.Status != domain.OrderStatusPaid {
+		t.Fatalf("Expected approve to confirm payment, got %+v (%v)", order, err)
+	}
+	if cache.invalidations != 1 {
+		t.Errorf("Expected product cache invalidated after approve, got %d", cache.invalidations)
+	}
+
+	order, err = usecase.ResolvePaymentReview(ctx, "admin-1", "order-refund", domain.PaymentReviewRefund)
+	if err != nil || order.Status != domain.OrderStatusRefunded {
+		t.Fatalf("Expected refund to close the order, got %+v (%v)", order, err)
+	}
+	if mockOrderRepo.CanceledOrders["order-refund"] != domain.OrderStatusRefunded {
+		t.Errorf("Expected order-refund cancelled as REFUNDED, got %v", mockOrderRepo.CanceledOrders)
+	}
+
+	if len(auditRepo.logs) != 2 {
+		t.Fatalf("Expected 2 audit logs, got %+v", auditRepo.logs)
+	}
+	for _, entry := range auditRepo.logs {
+		if entry.Action != "ADMIN_RESOLVE_PAYMENT_REVIEW" || entry.UserID != "admin-1" {
+			t.Errorf("Unexpected audit entry %+v", entry)
+		}
+	}
+}
+
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+)
+
+func newAuditTestSetup() (domain.ProductUsecase, *MockAuditLogRepository) {
+	categoryRepo := NewMockCategoryRepositoryForProduct()
+	categoryRepo.categories["cat-1"] = &domain.Category{ID: "cat-1", Name: "Sayur"}
+	auditRepo := &MockAuditLogRepository{}
+	return NewProductUsecase(NewMockProductRepository(), categoryRepo, auditRepo, nil, nil, logger.Discard()), auditRepo
+}
+
+func TestProductAudit_UpdateRecordsOnlyChangedFields(t *testing.T) {
+	uc, auditRepo := newAuditTestSetup()
+
+	product := &domain.Product{Name: "Bayam", CategoryID: "cat-1", Price: 5000, Stock: 10}
+	if err := uc.Create(context.Background(), "admin-1", product); err != nil {
+		t.Fatalf("Create gagal: %v", err)
+	}
+	if len(auditRepo.logs) != 1 || auditRepo.logs[0].Action != "CREATE_PRODUCT" {
+		t.Fatalf("Pembuatan produk harus dicatat, didapat %+v", auditRepo.logs)
+	}
+
+	if err := uc.Update(context.Background(), "admin-1", product.ID, &domain.Product{Name: "Bayam", CategoryID: "cat-1", Price: 4500, Stock: 10}); err != nil {
+		t.Fatalf("Update gagal: %v", err)
+	}
+	if len(auditRepo.logs) != 2 {
+		t.Fatalf("Update harga harus menambah satu log, didapat %d", len(auditRepo.logs))
+	}
+	entry := auditRepo.logs[1]
+	var oldValues, newValues map[string]interface{}
+	_ = json.Unmarshal([]byte(entry.OldValues), &oldValues)
+	_ = json.Unmarshal([]byte(entry.NewValues), &newValues)
+	if entry.Action != "UPDATE_PRODUCT" || entry.UserID != "admin-1" {
+		t.Errorf("Log update tidak sesuai: %+v", entry)
+	}
+	if len(newValues) != 1 || newValues["price"] != 4500.0 || oldValues["price"] != 5000.0 {
+		t.Errorf("Diff harus hanya berisi harga, didapat old=%v new=%v", oldValues, newValues)
+	}
+
+	// Update tanpa perubahan tidak menulis log
+	if err := uc.Update(context.Background(), "admin-1", product.ID, &domain.Product{Name: "Bayam", CategoryID: "cat-1", Price: 4500, Stock: 10}); err != nil {
+		t.Fatalf("Update gagal: %v", err)
+	}
+	if len(auditRepo.logs) != 2 {
+		t.Errorf("Update tanpa perubahan tidak boleh dicatat, didapat %d log", len(auditRepo.logs))
+	}
+
+	if err := uc.Delete(context.Background(), "admin-1", product.ID); err != nil {
+		t.Fatalf("Delete gagal: %v", err)
+	}
+	last := auditRepo.logs[len(auditRepo.logs)-1]
+	if last.Action != "DELETE_PRODUCT" || last.NewValues != "{}" {
+		t.Errorf("Penghapusan harus mencatat nilai lama saja, didapat %+v", last)
+	}
+}
+
+func TestProductAudit_PriceHistory(t *testing.T) {
+	uc, _ := newAuditTestSetup()
+
+	product := &domain.Product{Name: "Beras", CategoryID: "cat-1", Price: 60000, Stock: 10}
+	if err := uc.Create(context.Background(), "admin-1", product); err != nil {
+		t.Fatalf("Create gagal: %v", err)
+	}
+	variant := &domain.ProductVariant{NameLabel: "5kg", Price: 70000, Stock: 5}
+	if err := uc.CreateVariant(context.Background(), "admin", "admin-1", product.ID, variant); err != nil {
+		t.Fatalf("CreateVariant gagal: %v", err)
+	}
+	if err := uc.Update(context.Background(), "admin-1", product.ID, &domain.Product{Name: "Beras", CategoryID: "cat-1", Price: 55000, Stock: 10}); err != nil {
+		t.Fatalf("Update gagal: %v", err)
+	}
+	if err := uc.UpdateVariant(context.Background(), "admin", "admin-1", product.ID, variant.ID, &domain.ProductVariant{NameLabel: "5kg", Price: 65000, Stock: 5}); err != nil {
+		t.Fatalf("UpdateVariant gagal: %v", err)
+	}
+
+	history, err := uc.PriceHistory(context.Background(), product.ID)
+	if err != nil {
+		t.Fatalf("PriceHistory gagal: %v", err)
+	}
+	if history.CurrentPrice != 55000 || len(history.Series) != 2 {
+		t.Fatalf("Riwayat harus berisi deret produk dan varian, didapat %+v", history)
+	}
+
+	base := history.Series[0]
+	if base.VariantID != nil || len(base.Points) != 2 || base.Points[0].Price != 60000 || base.Points[1].Price != 55000 {
+		t.Errorf("Deret produk tidak sesuai: %+v", base)
+	}
+	variantSeries := history.Series[1]
+	if variantSeries.VariantID == nil || *variantSeries.VariantID != variant.ID || variantSeries.Label != "5kg" {
+		t.Fatalf("Deret varian tidak sesuai: %+v", variantSeries)
+	}
+	if len(variantSeries.Points) != 2 || variantSeries.Points[0].Price != 70000 || variantSeries.Points[1].Price != 65000 {
+		t.Errorf("Titik harga varian tidak sesuai: %+v", variantSeries.Points)
+	}
+
+	if _, err := uc.PriceHistory(context.Background(), "unknown"); err == nil {
+		t.Error("Riwayat harga produk yang tidak ada seharusnya error")
+	}
+}
+
+func TestProductAudit_CarriesRequestContext(t *testing.T) {
+	uc, auditRepo := newAuditTestSetup()
+
+	ctx := reqctx.With(context.Background(), reqctx.Info{RequestID: "req-42", IP: "10.1.2.3", UserAgent: "curl/8.5"})
+	ctx = reqctx.WithActor(ctx, "supplier-1", "supplier")
+	product := &domain.Product{Name: "Kangkung", CategoryID: "cat-1", Price: 3000, Stock: 4}
+	if err := uc.CreateBySupplier(ctx, "supplier-1", product); err != nil {
+		t.Fatalf("CreateBySupplier gagal: %v", err)
+	}
+
+	entry := auditRepo.logs[0]
+	if entry.UserID != "supplier-1" || entry.ActorRole != "supplier" || entry.RequestID != "req-42" ||
+		entry.IPAddress != "10.1.2.3" || entry.UserAgent != "curl/8.5" {
+		t.Errorf("Audit log harus membawa konteks request, didapat %+v", entry)
+	}
+	if entry.Hash != entry.ChainHash() {
+		t.Error("Hash harus mencakup konteks request")
+	}
+}
@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/storage"
+)
+
+// maxImagesPerProduct membatasi jumlah gambar galeri (produk + seluruh varian)
+const maxImagesPerProduct = 15
+
+type productImageUsecase struct {
+	imageRepo    domain.ProductImageRepository
+	productRepo  domain.ProductRepository
+	processor    domain.ImageProcessor
+	blobs        storage.BlobStore
+	auditLogRepo domain.AuditLogRepository
+	productCache domain.ProductCacheInvalidator
+	logger       *slog.Logger
+}
+
+func NewProductImageUsecase(iRepo domain.ProductImageRepository, pRepo domain.ProductRepository, processor domain.ImageProcessor, blobs storage.BlobStore, auditLogRepo domain.AuditLogRepository, productCache domain.ProductCacheInvalidator, logger *slog.Logger) domain.ProductImageUsecase {
+	return &productImageUsecase{
+		imageRepo:    iRepo,
+		productRepo:  pRepo,
+		processor:    processor,
+		blobs:        blobs,
+		auditLogRepo: auditLogRepo,
+		productCache: productCache,
+		logger:       logger,
+	}
+}
+
+// invalidateProductCache dipanggil setelah galeri berubah: repository gambar ikut menulis
+// products.image_url dan detail produk yang di-cache memuat daftar gambar
+func (u *productImageUsecase) invalidateProductCache(ctx context.Context) {
+	if u.productCache != nil {
+		u.productCache.InvalidateProductCache(ctx)
+	}
+}
+
+// imageKey menghasilkan key rendisi dari hash isi, misalnya products/ab12…_thumb.jpg
+func imageKey(hash string, size string) string {
+	return fmt.Sprintf("products/%s_%s.jpg", hash, size)
+}
+
+func (u *productImageUsecase) List(ctx context.Context, role string, actorID string, productID string) ([]domain.ProductImage, error) {
+	if _, err := authorizeProductRead(ctx, u.productRepo, role, actorID, productID); err != nil {
+		return nil, err
+	}
+	return u.imageRepo.FindByProductID(ctx, productID)
+}
+
+// Upload memproses file (validasi magic bytes, re-encode, resize, buang EXIF), menyimpan ketiga
+// rendisinya, lalu menambahkan gambar di akhir galeri produk atau varian
+func (u *productImageUsecase) Upload(ctx context.Context, role string, actorID string, productID string, variantID string, data []byte, altText string) (*domain.ProductImage, error) {
+	product, err := authorizeProductAccess(ctx, u.productRepo, role, actorID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &domain.ProductImage{ProductID: productID, AltText: strings.TrimSpace(altText)}
+	if variantID != "" {
+		found := false
+		for _, v := range product.Variants {
+			if v.ID == variantID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("varian tidak ditemukan pada produk ini")
+		}
+		image.VariantID = &variantID
+	}
+
+	existing, err := u.imageRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxImagesPerProduct {
+		return nil, fmt.Errorf("maksimal %d gambar per produk", maxImagesPerProduct)
+	}
+
+	processed, err := u.processor.Process(data)
+	if err != nil {
+		return nil, err
+	}
+	image.Hash = processed.Hash
+	for _, r := range processed.Renditions {
+		key := imageKey(processed.Hash, r.Size)
+		// Key berbasis hash isi: jika sudah ada, isinya pasti identik dan tidak perlu diunggah ulang
+		exists, err := u.blobs.Exists(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			if err := u.blobs.Put(ctx, key, r.Data, "image/jpeg"); err != nil {
+				return nil, err
+			}
+		}
+		url := u.blobs.URL(key)
+		switch r.Size {
+		case domain.ImageSizeThumb:
+			image.ThumbURL = url
+		case domain.ImageSizeMedium:
+			image.MediumURL = url
+		case domain.ImageSizeLarge:
+			image.LargeURL = url
+			image.Width, image.Height = r.Width, r.Height
+		}
+	}
+
+	if err := u.imageRepo.Create(ctx, image); err != nil {
+		return nil, err
+	}
+	u.invalidateProductCache(ctx)
+	recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "ADD_PRODUCT_IMAGE", "product_images", image.ID, productID, nil, image)
+	if err := requireReReview(ctx, u.logger, u.productRepo, u.auditLogRepo, role, actorID, productID); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// Reorder menerima seluruh ID gambar produk dalam urutan baru. Posisi dihitung ulang per cakupan,
+// jadi gambar varian hanya berpindah relatif terhadap gambar lain pada varian yang sama.
+func (u *productImageUsecase) Reorder(ctx context.Context, role string, actorID string, productID string, imageIDs []string) ([]domain.ProductImage, error) {
+	if _, err := authorizeProductAccess(ctx, u.productRepo, role, actorID, productID); err != nil {
+		return nil, err
+	}
+
+	existing, err := u.imageRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]domain.ProductImage{}
+	for _, img := range existing {
+		byID[img.ID] = img
+	}
+	if len(imageIDs) != len(existing) {
+		return nil, errors.New("urutan harus memuat seluruh gambar produk tepat satu kali")
+	}
+
+	oldPositions := map[string]interface{}{}
+	for _, img := range existing {
+		oldPositions[img.ID] = img.Position
+	}
+	positions := map[string]int{}
+	nextInScope := map[string]int{}
+	for _, id := range imageIDs {
+		img, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("gambar %s tidak ditemukan pada produk ini", id)
+		}
+		if _, dup := positions[id]; dup {
+			return nil, errors.New("urutan harus memuat seluruh gambar produk tepat satu kali")
+		}
+		scope := ""
+		if img.VariantID != nil {
+			scope = *img.VariantID
+		}
+		positions[id] = nextInScope[scope]
+		nextInScope[scope]++
+	}
+
+	if err := u.imageRepo.Reorder(ctx, productID, positions); err != nil {
+		return nil, err
+	}
+	u.invalidateProductCache(ctx)
+	newPositions := map[string]interface{}{}
+	for id, pos := range positions {
+		newPositions[id] = pos
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "REORDER_PRODUCT_IMAGES", "products", productID, "", oldPositions, newPositions)
+	if fmt.Sprint(oldPositions) != fmt.Sprint(newPositions) {
+		if err := requireReReview(ctx, u.logger, u.productRepo, u.auditLogRepo, role, actorID, productID); err != nil {
+			return nil, err
+		}
+	}
+	return u.imageRepo.FindByProductID(ctx, productID)
+}
+
+func (u *productImageUsecase) Delete(ctx context.Context, role string, actorID string, productID string, imageID string) error {
+	if _, err := authorizeProductAccess(ctx, u.productRepo, role, actorID, productID); err != nil {
+		return err
+	}
+	image, err := u.imageRepo.FindByID(ctx, imageID)
+	if err != nil || image.ProductID != productID {
+		return errors.New("gambar tidak ditemukan")
+	}
+	if err := u.imageRepo.Delete(ctx, image); err != nil {
+		return err
+	}
+	u.invalidateProductCache(ctx)
+	recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "DELETE_PRODUCT_IMAGE", "product_images", image.ID, productID, image, nil)
+	if err := requireReReview(ctx, u.logger, u.productRepo, u.auditLogRepo, role, actorID, productID); err != nil {
+		return err
+	}
+
+	// File berbasis hash bisa dipakai bersama oleh produk lain; hapus hanya jika tidak dirujuk lagi
+	remaining, err := u.imageRepo.CountByHash(ctx, image.Hash)
+	if err != nil || remaining > 0 {
+		return nil
+	}
+	for _, size := range []string{domain.ImageSizeThumb, domain.ImageSizeMedium, domain.ImageSizeLarge} {
+		if err := u.blobs.Delete(ctx, imageKey(image.Hash, size)); err != nil {
+			u.logger.WarnContext(ctx, "gagal menghapus file gambar", "key", imageKey(image.Hash, size), "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/storage"
+)
+
+// --- Mock Product Image Repository ---
+
+type MockProductImageRepository struct {
+	images map[string]*domain.ProductImage // Key: id_image
+}
+
+func NewMockProductImageRepository() *MockProductImageRepository {
+	return &MockProductImageRepository{images: make(map[string]*domain.ProductImage)}
+}
+
+func (m *MockProductImageRepository) FindByProductID(ctx context.Context, productID string) ([]domain.ProductImage, error) {
+	var result []domain.ProductImage
+	for _, img := range m.images {
+		if img.ProductID == productID {
+			result = append(result, *img)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].VariantID == nil) != (result[j].VariantID == nil) {
+			return result[i].VariantID == nil
+		}
+		return result[i].Position < result[j].Position
+	})
+	return result, nil
+}
+func (m *MockProductImageRepository) FindByID(ctx context.Context, imageID string) (*domain.ProductImage, error) {
+	img, ok := m.images[imageID]
+	if !ok {
+		return nil, errors.New("gambar tidak ditemukan")
+	}
+	return img, nil
+}
+func (m *MockProductImageRepository) Create(ctx context.Context, image *domain.ProductImage) error {
+	next := 0
+	for _, img := range m.images {
+		sameScope := (img.VariantID == nil && image.VariantID == nil) ||
+			(img.VariantID != nil && image.VariantID != nil && *img.VariantID == *image.VariantID)
+		if img.ProductID == image.ProductID && sameScope && img.Position >= next {
+			next = img.Position + 1
+		}
+	}
+	image.ID = uuid.New().String()
+	image.Position = next
+	m.images[image.ID] = image
+	return nil
+}
+func (m *MockProductImageRepository) Delete(ctx context.Context, image *domain.ProductImage) error {
+	delete(m.images, image.ID)
+	return nil
+}
+func (m *MockProductImageRepository) Reorder(ctx context.Context, productID string, positions map[string]int) error {
+	for id, pos := range positions {
+		if img, ok := m.images[id]; ok && img.ProductID == productID {
+			img.Position = pos
+		}
+	}
+	return nil
+}
+func (m *MockProductImageRepository) CountByHash(ctx context.Context, hash string) (int64, error) {
+	var count int64
+	for _, img := range m.images {
+		if img.Hash == hash {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// --- Mock Image Processor & Blob Store ---
+
+type MockImageProcessor struct{}
+
+// Process meniru validasi magic bytes sederhana: hanya data berawalan "IMG" yang dianggap gambar
+func (p *MockImageProcessor) Process(data []byte) (*domain.ProcessedImage, error) {
+	if len(data) < 3 || string(data[:3]) != "IMG" {
+		return nil, errors.New("format gambar tidak didukung")
+	}
+	result := &domain.ProcessedImage{Hash: "hash-" + string(data)}
+	for _, size := range []string{domain.ImageSizeThumb, domain.ImageSizeMedium, domain.ImageSizeLarge} {
+		result.Renditions = append(result.Renditions, domain.ImageRendition{Size: size, Width: 100, Height: 100, Data: data})
+	}
+	return result, nil
+}
+
+// MockBlobStore adalah storage.BlobStore in-memory
+type MockBlobStore struct {
+	files map[string][]byte
+}
+
+func NewMockBlobStore() *MockBlobStore {
+	return &MockBlobStore{files: map[string][]byte{}}
+}
+
+func (s *MockBlobStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	s.files[key] = data
+	return nil
+}
+func (s *MockBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, ok := s.files[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return data, nil
+}
+func (s *MockBlobStore) Delete(ctx context.Context, key string) error {
+	delete(s.files, key)
+	return nil
+}
+func (s *MockBlobStore) Exists(ctx context.Context, key string) (bool, error) {
+	_, ok := s.files[key]
+	return ok, nil
+}
+func (s *MockBlobStore) URL(key string) string {
+	return "/uploads/" + key
+}
+func (s *MockBlobStore) SignedURL(key string, ttl time.Duration) (string, error) {
+	return "/uploads/" + key + "?signature=test", nil
+}
+
+type imageTestSetup struct {
+	imageRepo *MockProductImageRepository
+	storage   *MockBlobStore
+	cache     *mockProductCache
+}
+
+func newImageTestSetup() (*imageTestSetup, domain.ProductImageUsecase) {
+	productRepo := NewMockProductRepository()
+	productRepo.products["prod-1"] = &domain.Product{
+		ID: "prod-1", Name: "Cabai Rawit", SupplierID: "supplier-1", Price: 10000,
+		Variants: []domain.ProductVariant{{ID: "var-1", ProductID: "prod-1", NameLabel: "250g", Price: 10000}},
+	}
+	setup := &imageTestSetup{imageRepo: NewMockProductImageRepository(), storage: NewMockBlobStore(), cache: &mockProductCache{}}
+	return setup, NewProductImageUsecase(setup.imageRepo, productRepo, &MockImageProcessor{}, setup.storage, nil, setup.cache, logger.Discard())
+}
+
+func TestUploadProductImage(t *testing.T) {
+	setup, uc := newImageTestSetup()
+
+	// SQA CHECK: supplier lain tidak boleh menambah gambar
+	if _, err := uc.Upload(context.Background(), "supplier", "supplier-2", "prod-1", "", []byte("IMG-a"), ""); err == nil {
+		t.Fatal("Expected ownership error, got success")
+	}
+
+	// SQA CHECK: file yang bukan gambar ditolak sebelum disimpan
+	if _, err := uc.Upload(context.Background(), "supplier", "supplier-1", "prod-1", "", []byte("<html>"), ""); err == nil {
+		t.Fatal("Expected invalid image error, got success")
+	}
+	if len(setup.storage.files) != 0 {
+		t.Errorf("Expected no files stored for rejected upload, got %d", len(setup.storage.files))
+	}
+
+	// SQA CHECK: varian harus milik produk yang sama
+	if _, err := uc.Upload(context.Background(), "supplier", "supplier-1", "prod-1", "var-x", []byte("IMG-a"), ""); err == nil {
+		t.Fatal("Expected unknown variant error, got success")
+	}
+
+	first, err := uc.Upload(context.Background(), "supplier", "supplier-1", "prod-1", "", []byte("IMG-a"), " Tampak depan ")
+	if err != nil {
+		t.Fatalf("Expected upload success, got %v", err)
+	}
+	if first.ThumbURL != "/uploads/products/hash-IMG-a_thumb.jpg" || first.LargeURL != "/uploads/products/hash-IMG-a_large.jpg" {
+		t.Errorf("Expected content-hash URLs, got %s / %s", first.ThumbURL, first.LargeURL)
+	}
+	if first.AltText != "Tampak depan" {
+		t.Errorf("Expected trimmed alt text, got %q", first.AltText)
+	}
+
+	second, _ := uc.Upload(context.Background(), "supplier", "supplier-1", "prod-1", "", []byte("IMG-b"), "")
+	variantImg, err := uc.Upload(context.Background(), "admin", "admin-1", "prod-1", "var-1", []byte("IMG-c"), "")
+	if err != nil {
+		t.Fatalf("Expected admin variant upload success, got %v", err)
+	}
+	if second.Position != 1 || variantImg.Position != 0 {
+		t.Errorf("Expected positions per scope (1, 0), got (%d, %d)", second.Position, variantImg.Position)
+	}
+}
+
+func TestReorderAndDeleteProductImages(t *testing.T) {
+	setup, uc := newImageTestSetup()
+	a, _ := uc.Upload(context.Background(), "supplier", "supplier-1", "prod-1", "", []byte("IMG-a"), "")
+	b, _ := uc.Upload(context.Background(), "supplier", "supplier-1", "prod-1", "", []byte("IMG-b"), "")
+	v, _ := uc.Upload(context.Background(), "supplier", "supplier-1", "prod-1", "var-1", []byte("IMG-c"), "")
+
+	// SQA CHECK: urutan harus mencakup seluruh gambar
+	if _, err := uc.Reorder(context.Background(), "supplier", "supplier-1", "prod-1", []string{b.ID, a.ID}); err == nil {
+		t.Fatal("Expected error for incomplete order, got success")
+	}
+	if _, err := uc.Reorder(context.Background(), "supplier", "supplier-1", "prod-1", []string{b.ID, b.ID, v.ID}); err == nil {
+		t.Fatal("Expected error for duplicate ID, got success")
+	}
+
+	images, err := uc.Reorder(context.Background(), "supplier", "supplier-1", "prod-1", []string{v.ID, b.ID, a.ID})
+	if err != nil {
+		t.Fatalf("Expected reorder success, got %v", err)
+	}
+	if images[0].ID != b.ID || images[1].ID != a.ID {
+		t.Errorf("Expected product images ordered b, a; got %s, %s", images[0].ID, images[1].ID)
+	}
+	if setup.imageRepo.images[v.ID].Position != 0 {
+		t.Errorf("Expected variant image position to be counted within its own scope, got %d", setup.imageRepo.images[v.ID].Position)
+	}
+
+	// File hanya dihapus jika hash tidak lagi dirujuk gambar lain
+	dup, _ := uc.Upload(context.Background(), "admin", "admin-1", "prod-1", "", []byte("IMG-a"), "")
+	if err := uc.Delete(context.Background(), "supplier", "supplier-1", "prod-1", a.ID); err != nil {
+		t.Fatalf("Expected delete success, got %v", err)
+	}
+	if _, ok := setup.storage.files["products/hash-IMG-a_medium.jpg"]; !ok {
+		t.Error("Expected shared file to be kept while another image still uses it")
+	}
+	uc.Delete(context.Background(), "admin", "admin-1", "prod-1", dup.ID)
+	if _, ok := setup.storage.files["products/hash-IMG-a_medium.jpg"]; ok {
+		t.Error("Expected file to be removed once no image references it")
+	}
+
+	// SQA CHECK: gambar produk lain tidak bisa dihapus lewat produk ini
+	if err := uc.Delete(context.Background(), "supplier", "supplier-1", "prod-1", "unknown"); err == nil {
+		t.Error("Expected not found error, got success")
+	}
+
+	// image_url produk ikut berubah, jadi setiap upload/reorder/hapus yang berhasil membuang cache produk
+	// (4 upload + 1 reorder + 2 hapus)
+	if setup.cache.invalidations != 7 {
+		t.Errorf("Expected 7 product cache invalidations, got %d", setup.cache.invalidations)
+	}
+}
+
+func TestProductImageEditsRequireReReview(t *testing.T) {
+	productRepo := NewMockProductRepository()
+	productRepo.products["prod-1"] = &domain.Product{ID: "prod-1", Name: "Cabai Rawit", SupplierID: "supplier-1", Price: 10000, Status: domain.ProductStatusPendingReview}
+	uc := NewProductImageUsecase(NewMockProductImageRepository(), productRepo, &MockImageProcessor{}, NewMockBlobStore(), nil, nil, logger.Discard())
+
+	// SQA CHECK: galeri produk yang belum tayang tidak terlihat publik
+	if _, err := uc.List(context.Background(), "", "", "prod-1"); err == nil {
+		t.Fatal("Expected unpublished gallery to be hidden from public, got success")
+	}
+
+	productRepo.products["prod-1"].Status = domain.ProductStatusActive
+	if _, err := uc.Upload(context.Background(), "supplier", "supplier-1", "prod-1", "", []byte("IMG-a"), ""); err != nil {
+		t.Fatalf("Expected upload success, got %v", err)
+	}
+	if productRepo.products["prod-1"].Status != domain.ProductStatusPendingReview {
+		t.Errorf("Expected supplier upload on a live product to require re-review, got %s", productRepo.products["prod-1"].Status)
+	}
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/xuri/excelize/v2"
+)
+
+// importSheetName adalah nama sheet pada file XLSX hasil ekspor
+const importSheetName = "Produk"
+
+// importNumericColumns ditulis sebagai angka pada XLSX agar bisa langsung diolah di spreadsheet
+var importNumericColumns = map[string]bool{
+	"price": true, "stock": true, "low_stock_threshold": true, "variant_price": true, "variant_stock": true,
+}
+
+// importRow adalah satu baris data file impor, dipetakan per nama kolom
+type importRow struct {
+	Line   int
+	Values map[string]string
+}
+
+func (r importRow) get(column string) string {
+	return r.Values[column]
+}
+
+// detectImportFormat menentukan format dari ekstensi file lalu mencocokkannya dengan isi file
+func detectImportFormat(fileName string, data []byte) (string, error) {
+	isZip := bytes.HasPrefix(data, []byte("PK\x03\x04"))
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".xlsx":
+		if !isZip {
+			return "", errors.New("file .xlsx rusak atau bukan spreadsheet Excel")
+		}
+		return domain.ImportFormatXLSX, nil
+	case ".csv":
+		if isZip {
+			return "", errors.New("file .csv berisi data biner, simpan ulang sebagai CSV")
+		}
+		return domain.ImportFormatCSV, nil
+	default:
+		return "", errors.New("format file tidak didukung, gunakan .csv atau .xlsx")
+	}
+}
+
+// readImportRows membaca file lalu memetakan setiap baris ke nama kolom header.
+// Baris kosong dilewati; kolom yang tidak dikenal diabaikan.
+func readImportRows(format string, data []byte) ([]importRow, error) {
+	var records [][]string
+	var err error
+	if format == domain.ImportFormatXLSX {
+		records, err = readXLSXRecords(data)
+	} else {
+		records, err = readCSVRecords(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("file kosong, baris header tidak ditemukan")
+	}
+
+	header := make([]string, len(records[0]))
+	seen := map[string]bool{}
+	for i, h := range records[0] {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" && seen[h] {
+			return nil, fmt.Errorf("kolom %s muncul lebih dari sekali di header", h)
+		}
+		seen[h] = true
+		header[i] = h
+	}
+	if !seen["product_sku"] && !seen["id_product"] {
+		return nil, errors.New("header wajib memuat kolom product_sku (unduh template lewat endpoint ekspor)")
+	}
+
+	rows := make([]importRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		row := importRow{Line: i + 2, Values: map[string]string{}}
+		empty := true
+		for j, value := range record {
+			if j >= len(header) || header[j] == "" {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			if value != "" {
+				empty = false
+			}
+			row.Values[header[j]] = value
+		}
+		if !empty {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func readCSVRecords(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM dari Excel
+	reader := csv.NewReader(bytes.NewReader(data))
+	// Excel dengan locale Indonesia menyimpan CSV memakai pemisah titik koma
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("file CSV tidak valid: %v", err)
+	}
+	return records, nil
+}
+
+func readXLSXRecords(data []byte) ([][]string, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("file XLSX tidak valid: %v", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("file XLSX tidak memiliki sheet")
+	}
+	// Hanya sheet pertama yang dibaca
+	records, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("file XLSX tidak valid: %v", err)
+	}
+	return records, nil
+}
+
+// writeImportRows menulis header domain.ImportColumns diikuti records dalam format yang diminta
+func writeImportRows(format string, records [][]string) ([]byte, error) {
+	if format == domain.ImportFormatXLSX {
+		return writeXLSXRecords(records)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(domain.ImportColumns); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeXLSXRecords(records [][]string) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", importSheetName); err != nil {
+		return nil, err
+	}
+
+	header := make([]interface{}, len(domain.ImportColumns))
+	for i, col := range domain.ImportColumns {
+		header[i] = col
+	}
+	if err := f.SetSheetRow(importSheetName, "A1", &header); err != nil {
+		return nil, err
+	}
+	for i, record := range records {
+		row := make([]interface{}, len(record))
+		for j, value := range record {
+			row[j] = value
+			if importNumericColumns[domain.ImportColumns[j]] {
+				if n, err := strconv.ParseFloat(value, 64); err == nil {
+					row[j] = n
+				}
+			}
+		}
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.SetSheetRow(importSheetName, cell, &row); err != nil {
+			return nil, err
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
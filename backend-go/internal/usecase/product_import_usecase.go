@@ -0,0 +1,592 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+)
+
+// maxImportRows membatasi jumlah baris data per file impor
+const maxImportRows = 5000
+
+// importProductColumns adalah kolom milik produk; sisanya milik varian
+var importProductColumns = []string{
+	"id_product", "product_sku", "name", "description", "id_category", "price", "stock",
+	"low_stock_threshold", "auto_hide_out_of_stock", "image_url",
+}
+
+type productImportUsecase struct {
+	jobRepo        domain.ImportJobRepository
+	productRepo    domain.ProductRepository
+	categoryRepo   domain.CategoryRepository
+	productUsecase domain.ProductUsecase // Penyimpanan lewat usecase agar validasi, ledger stok, dan notifikasi wishlist tetap berlaku
+	queue          domain.ImportQueue
+}
+
+func NewProductImportUsecase(jRepo domain.ImportJobRepository, pRepo domain.ProductRepository, cRepo domain.CategoryRepository, puc domain.ProductUsecase, queue domain.ImportQueue) domain.ProductImportUsecase {
+	return &productImportUsecase{
+		jobRepo:        jRepo,
+		productRepo:    pRepo,
+		categoryRepo:   cRepo,
+		productUsecase: puc,
+		queue:          queue,
+	}
+}
+
+// StartImport menolak file yang tidak bisa dibaca sama sekali (format/header salah) secara langsung,
+// sedangkan validasi per baris dijalankan worker dan hasilnya dicatat di job
+func (u *productImportUsecase) StartImport(ctx context.Context, supplierID string, fileName string, data []byte, dryRun bool) (*domain.ImportJob, error) {
+	if len(data) == 0 {
+		return nil, errors.New("file impor kosong")
+	}
+	if len(data) > domain.MaxImportFileBytes {
+		return nil, fmt.Errorf("ukuran file impor maksimal %d MB", domain.MaxImportFileBytes>>20)
+	}
+	format, err := detectImportFormat(fileName, data)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := readImportRows(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("file tidak memiliki baris data")
+	}
+	if len(rows) > maxImportRows {
+		return nil, fmt.Errorf("maksimal %d baris per file, pecah file menjadi beberapa bagian", maxImportRows)
+	}
+
+	job := &domain.ImportJob{
+		ID:         uuid.New().String(),
+		SupplierID: supplierID,
+		Status:     domain.ImportStatusPending,
+		DryRun:     dryRun,
+		FileName:   filepath.Base(fileName),
+		Format:     format,
+		TotalRows:  len(rows),
+		Errors:     []domain.ImportRowError{},
+		CreatedAt:  time.Now(),
+	}
+	if err := u.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if !u.queue.Enqueue(domain.ImportTask{JobID: job.ID, Data: data, Request: reqctx.From(ctx)}) {
+		u.finishJob(ctx, job, domain.ImportStatusFailed, "antrian impor penuh")
+		return nil, errors.New("antrian impor sedang penuh, silakan coba beberapa saat lagi")
+	}
+	return job, nil
+}
+
+func (u *productImportUsecase) GetJob(ctx context.Context, supplierID string, jobID string) (*domain.ImportJob, error) {
+	job, err := u.jobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.SupplierID != supplierID {
+		return nil, errors.New("job impor tidak ditemukan")
+	}
+	return job, nil
+}
+
+func (u *productImportUsecase) finishJob(ctx context.Context, job *domain.ImportJob, status string, message string) error {
+	now := time.Now()
+	job.Status = status
+	job.Message = message
+	job.ErrorCount = len(job.Errors)
+	job.FinishedAt = &now
+	return u.jobRepo.Update(ctx, job)
+}
+
+func (u *productImportUsecase) FailInterruptedJobs(ctx context.Context) (int64, error) {
+	return u.jobRepo.FailUnfinished(ctx, "server dimulai ulang sebelum impor selesai, silakan unggah ulang file", time.Now())
+}
+
+func (u *productImportUsecase) ProcessImport(ctx context.Context, task domain.ImportTask) error {
+	ctx = reqctx.With(ctx, task.Request)
+	job, err := u.jobRepo.FindByID(ctx, task.JobID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	job.Status = domain.ImportStatusRunning
+	job.StartedAt = &now
+	if err := u.jobRepo.Update(ctx, job); err != nil {
+		return err
+	}
+
+	rows, err := readImportRows(job.Format, task.Data)
+	if err == nil {
+		err = u.importRows(ctx, job, rows)
+	}
+	if err != nil {
+		if updateErr := u.finishJob(ctx, job, domain.ImportStatusFailed, err.Error()); updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	sort.SliceStable(job.Errors, func(i, j int) bool { return job.Errors[i].Row < job.Errors[j].Row })
+	return u.finishJob(ctx, job, domain.ImportStatusCompleted, "")
+}
+
+// importVariantPlan adalah varian hasil validasi; target nil berarti varian baru
+type importVariantPlan struct {
+	line    int
+	target  *domain.ProductVariant
+	variant domain.ProductVariant
+}
+
+// importProductPlan adalah produk hasil validasi satu kelompok baris; target nil berarti produk baru
+type importProductPlan struct {
+	line     int
+	target   *domain.Product
+	product  domain.Product
+	variants []importVariantPlan
+}
+
+// importRowGroup mengumpulkan baris milik produk yang sama (id_product, atau product_sku jika kosong)
+type importRowGroup struct {
+	rows []importRow
+}
+
+func groupImportRows(rows []importRow) ([]*importRowGroup, []domain.ImportRowError) {
+	var groups []*importRowGroup
+	var errs []domain.ImportRowError
+	byKey := map[string]*importRowGroup{}
+	for _, row := range rows {
+		key := ""
+		if id := row.get("id_product"); id != "" {
+			key = "id:" + id
+		} else if sku := row.get("product_sku"); sku != "" {
+			key = "sku:" + sku
+		} else {
+			errs = append(errs, domain.ImportRowError{Row: row.Line, Column: "product_sku", Message: "product_sku wajib diisi"})
+			continue
+		}
+		group, ok := byKey[key]
+		if !ok {
+			group = &importRowGroup{}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		group.rows = append(group.rows, row)
+	}
+	return groups, errs
+}
+
+// importState menyimpan data yang dipakai bersama seluruh kelompok baris dalam satu file
+type importState struct {
+	supplierID  string
+	byID        map[string]*domain.Product
+	bySKU       map[string]*domain.Product
+	claimed     map[string]int // id_product -> baris pertama yang memakainya
+	productSKUs map[string]int // SKU produk akhir -> baris pertama
+	variantSKUs map[string]int // variant_sku -> baris pertama
+	categories  map[string]bool
+}
+
+// importRows memvalidasi setiap kelompok baris lalu menyimpannya (kecuali dry run). Kelompok yang
+// memiliki kesalahan dilewati seluruhnya; kelompok lain tetap diproses.
+func (u *productImportUsecase) importRows(ctx context.Context, job *domain.ImportJob, rows []importRow) error {
+	existing, err := u.productRepo.FindBySupplierID(ctx, job.SupplierID)
+	if err != nil {
+		return err
+	}
+	state := &importState{
+		supplierID:  job.SupplierID,
+		byID:        map[string]*domain.Product{},
+		bySKU:       map[string]*domain.Product{},
+		claimed:     map[string]int{},
+		productSKUs: map[string]int{},
+		variantSKUs: map[string]int{},
+		categories:  map[string]bool{},
+	}
+	for i := range existing {
+		p := &existing[i]
+		state.byID[p.ID] = p
+		if p.SKU != "" {
+			state.bySKU[p.SKU] = p
+		}
+	}
+
+	groups, errs := groupImportRows(rows)
+	job.Errors = append(job.Errors, errs...)
+	for _, group := range groups {
+		plan, errs := u.planProduct(ctx, state, group)
+		if len(errs) > 0 {
+			job.Errors = append(job.Errors, errs...)
+			continue
+		}
+
+		changed := plan.target == nil || productChanged(plan.target, &plan.product)
+		for _, v := range plan.variants {
+			if v.target == nil || variantChanged(v.target, &v.variant) {
+				changed = true
+			}
+		}
+		if !job.DryRun && changed {
+			if err := u.applyPlan(ctx, state.supplierID, plan); err != nil {
+				job.Errors = append(job.Errors, domain.ImportRowError{Row: plan.line, Message: err.Error()})
+				continue
+			}
+		}
+		switch {
+		case plan.target == nil:
+			job.CreatedCount++
+		case changed:
+			job.UpdatedCount++
+		default:
+			job.UnchangedCount++
+		}
+	}
+	return nil
+}
+
+// planProduct menggabungkan kolom produk dari seluruh baris kelompok, mencocokkan produk dan varian
+// yang sudah ada, lalu memvalidasi nilai akhirnya
+func (u *productImportUsecase) planProduct(ctx context.Context, state *importState, group *importRowGroup) (*importProductPlan, []domain.ImportRowError) {
+	var errs []domain.ImportRowError
+	addErr := func(line int, column string, format string, args ...interface{}) {
+		errs = append(errs, domain.ImportRowError{Row: line, Column: column, Message: fmt.Sprintf(format, args...)})
+	}
+
+	firstLine := group.rows[0].Line
+	values := map[string]string{}
+	lineOf := map[string]int{}
+	for _, row := range group.rows {
+		for _, col := range importProductColumns {
+			v := row.get(col)
+			if v == "" {
+				continue
+			}
+			if prev, ok := values[col]; ok {
+				if prev != v {
+					addErr(row.Line, col, "nilai berbeda dengan baris %d untuk produk yang sama", lineOf[col])
+				}
+				continue
+			}
+			values[col] = v
+			lineOf[col] = row.Line
+		}
+	}
+	set := func(col string) bool { _, ok := values[col]; return ok }
+
+	plan := &importProductPlan{line: firstLine}
+	if id := values["id_product"]; id != "" {
+		plan.target = state.byID[id]
+		if plan.target == nil {
+			addErr(lineOf["id_product"], "id_product", "produk tidak ditemukan atau bukan milik anda")
+			return nil, errs
+		}
+	} else {
+		plan.target = state.bySKU[values["product_sku"]]
+	}
+	if plan.target != nil {
+		if prev, ok := state.claimed[plan.target.ID]; ok {
+			addErr(firstLine, "", "produk yang sama sudah diimpor di baris %d", prev)
+			return nil, errs
+		}
+		state.claimed[plan.target.ID] = firstLine
+		plan.product = *plan.target
+	}
+
+	p := &plan.product
+	if sku := values["product_sku"]; sku != "" {
+		if other := state.bySKU[sku]; other != nil && (plan.target == nil || other.ID != plan.target.ID) {
+			addErr(lineOf["product_sku"], "product_sku", "SKU produk %s sudah dipakai produk lain", sku)
+		}
+		p.SKU = sku
+	}
+	if p.SKU != "" {
+		if prev, ok := state.productSKUs[p.SKU]; ok {
+			addErr(firstLine, "product_sku", "SKU produk %s sudah dipakai di baris %d", p.SKU, prev)
+		}
+		state.productSKUs[p.SKU] = firstLine
+	}
+
+	isNew := plan.target == nil
+	if set("name") {
+		if len([]rune(values["name"])) < 3 {
+			addErr(lineOf["name"], "name", "nama produk minimal 3 karakter")
+		}
+		p.Name = values["name"]
+	} else if isNew {
+		addErr(firstLine, "name", "name wajib diisi untuk produk baru")
+	}
+	if set("description") {
+		p.Description = values["description"]
+	}
+	if set("image_url") {
+		p.ImageURL = values["image_url"]
+	}
+	if set("id_category") {
+		if !u.categoryExists(ctx, state, values["id_category"]) {
+			addErr(lineOf["id_category"], "id_category", "kategori %s tidak ditemukan", values["id_category"])
+		}
+		p.CategoryID = values["id_category"]
+	} else if isNew {
+		addErr(firstLine, "id_category", "id_category wajib diisi untuk produk baru")
+	}
+	if set("price") {
+		price, err := parseImportFloat(values["price"])
+		if err != nil || price <= 0 {
+			addErr(lineOf["price"], "price", "harga harus berupa angka lebih dari 0")
+		}
+		p.Price = price
+	} else if isNew {
+		addErr(firstLine, "price", "price wajib diisi untuk produk baru")
+	}
+	if set("stock") {
+		stock, err := parseImportInt(values["stock"])
+		if err != nil || stock < 0 {
+			addErr(lineOf["stock"], "stock", "stok harus berupa bilangan bulat minimal 0")
+		}
+		p.Stock = stock
+	}
+	if set("low_stock_threshold") {
+		threshold, err := parseImportInt(values["low_stock_threshold"])
+		if err != nil || threshold < 1 {
+			addErr(lineOf["low_stock_threshold"], "low_stock_threshold", "ambang stok harus berupa bilangan bulat lebih dari 0")
+		}
+		p.LowStockThreshold = threshold
+	}
+	if set("auto_hide_out_of_stock") {
+		hide, err := parseImportBool(values["auto_hide_out_of_stock"])
+		if err != nil {
+			addErr(lineOf["auto_hide_out_of_stock"], "auto_hide_out_of_stock", "%v", err)
+		}
+		p.AutoHideOutOfStock = hide
+	}
+
+	matched := map[string]int{}
+	for _, row := range group.rows {
+		variant, ok := u.planVariant(ctx, state, plan.target, row, matched, addErr)
+		if ok {
+			plan.variants = append(plan.variants, variant)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return plan, nil
+}
+
+// planVariant mencocokkan baris dengan varian produk berdasarkan variant_sku, atau variant_name untuk
+// varian lama yang belum punya SKU. Baris tanpa kolom varian hanya berisi data produk.
+func (u *productImportUsecase) planVariant(ctx context.Context, state *importState, target *domain.Product, row importRow, matched map[string]int, addErr func(int, string, string, ...interface{})) (importVariantPlan, bool) {
+	sku, name := row.get("variant_sku"), row.get("variant_name")
+	priceStr, stockStr := row.get("variant_price"), row.get("variant_stock")
+	if sku == "" && name == "" && priceStr == "" && stockStr == "" {
+		return importVariantPlan{}, false
+	}
+
+	plan := importVariantPlan{line: row.Line}
+	if sku != "" {
+		if prev, ok := state.variantSKUs[sku]; ok {
+			addErr(row.Line, "variant_sku", "variant_sku %s duplikat dengan baris %d", sku, prev)
+		}
+		state.variantSKUs[sku] = row.Line
+	}
+	if target != nil {
+		for i := range target.Variants {
+			v := &target.Variants[i]
+			if sku != "" && v.SKUCode == sku {
+				plan.target = v
+				break
+			}
+		}
+		if plan.target == nil && name != "" {
+			for i := range target.Variants {
+				v := &target.Variants[i]
+				if v.SKUCode == "" && v.NameLabel == name {
+					plan.target = v
+					break
+				}
+			}
+		}
+	}
+	if plan.target != nil {
+		if prev, ok := matched[plan.target.ID]; ok {
+			addErr(row.Line, "variant_sku", "varian yang sama sudah disebut di baris %d", prev)
+		}
+		matched[plan.target.ID] = row.Line
+		plan.variant = *plan.target
+	}
+
+	v := &plan.variant
+	if name != "" {
+		v.NameLabel = name
+	} else if plan.target == nil {
+		addErr(row.Line, "variant_name", "variant_name wajib diisi untuk varian baru")
+	}
+	if priceStr != "" {
+		price, err := parseImportFloat(priceStr)
+		if err != nil || price <= 0 {
+			addErr(row.Line, "variant_price", "harga varian harus berupa angka lebih dari 0")
+		}
+		v.Price = price
+	} else if plan.target == nil {
+		addErr(row.Line, "variant_price", "variant_price wajib diisi untuk varian baru")
+	}
+	if stockStr != "" {
+		stock, err := parseImportInt(stockStr)
+		if err != nil || stock < 0 {
+			addErr(row.Line, "variant_stock", "stok varian harus berupa bilangan bulat minimal 0")
+		}
+		v.Stock = stock
+	}
+	if sku != "" {
+		excludeID := ""
+		if plan.target != nil {
+			excludeID = plan.target.ID
+		}
+		exists, err := u.productRepo.SKUExists(ctx, sku, excludeID)
+		if err != nil {
+			addErr(row.Line, "variant_sku", "gagal memeriksa SKU: %v", err)
+		} else if exists {
+			addErr(row.Line, "variant_sku", "SKU %s sudah dipakai varian lain", sku)
+		}
+		v.SKUCode = sku
+	}
+	return plan, true
+}
+
+func (u *productImportUsecase) categoryExists(ctx context.Context, state *importState, categoryID string) bool {
+	if ok, cached := state.categories[categoryID]; cached {
+		return ok
+	}
+	_, err := u.categoryRepo.FindByID(ctx, categoryID)
+	state.categories[categoryID] = err == nil
+	return err == nil
+}
+
+// applyPlan menyimpan satu produk beserta variannya. Setiap langkah memakai ProductUsecase sehingga
+// kegagalan di tengah (misalnya SKU direbut request lain) hanya menyisakan langkah yang sudah berhasil.
+func (u *productImportUsecase) applyPlan(ctx context.Context, supplierID string, plan *importProductPlan) error {
+	if plan.target == nil {
+		product := plan.product
+		for _, v := range plan.variants {
+			product.Variants = append(product.Variants, v.variant)
+		}
+		return u.productUsecase.CreateBySupplier(ctx, supplierID, &product)
+	}
+
+	if productChanged(plan.target, &plan.product) {
+		update := plan.product
+		// plan.product berangkat dari produk tersimpan, jadi nilainya selalu lengkap
+		hide := update.AutoHideOutOfStock
+		update.AutoHideOutOfStockInput = &hide
+		if err := u.productUsecase.UpdateBySupplier(ctx, supplierID, plan.target.ID, &update); err != nil {
+			return err
+		}
+	}
+	for _, v := range plan.variants {
+		variant := v.variant
+		var err error
+		switch {
+		case v.target == nil:
+			err = u.productUsecase.CreateVariant(ctx, "supplier", supplierID, plan.target.ID, &variant)
+		case variantChanged(v.target, &variant):
+			err = u.productUsecase.UpdateVariant(ctx, "supplier", supplierID, plan.target.ID, v.target.ID, &variant)
+		}
+		if err != nil {
+			return fmt.Errorf("baris %d: %w", v.line, err)
+		}
+	}
+	return nil
+}
+
+func productChanged(old *domain.Product, p *domain.Product) bool {
+	return old.SKU != p.SKU || old.Name != p.Name || old.Description != p.Description ||
+		old.CategoryID != p.CategoryID || old.Price != p.Price || old.Stock != p.Stock ||
+		old.LowStockThreshold != p.LowStockThreshold || old.AutoHideOutOfStock != p.AutoHideOutOfStock ||
+		old.ImageURL != p.ImageURL
+}
+
+func variantChanged(old *domain.ProductVariant, v *domain.ProductVariant) bool {
+	return old.SKUCode != v.SKUCode || old.NameLabel != v.NameLabel || old.Price != v.Price || old.Stock != v.Stock
+}
+
+func parseImportFloat(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(s, " ", ""), 64)
+}
+
+// parseImportInt juga menerima "10.0" karena spreadsheet kadang menyimpan bilangan bulat sebagai desimal
+func parseImportInt(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != float64(int(f)) {
+		return 0, errors.New("bukan bilangan bulat")
+	}
+	return int(f), nil
+}
+
+func parseImportBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "1", "ya", "yes":
+		return true, nil
+	case "false", "0", "tidak", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("nilai %s tidak valid, gunakan true atau false", s)
+}
+
+// Export menulis seluruh produk supplier, satu baris per varian (produk tanpa varian satu baris).
+// Kolom produk diulang di setiap baris varian sehingga file tetap valid setelah diurutkan ulang.
+func (u *productImportUsecase) Export(ctx context.Context, supplierID string, format string) ([]byte, error) {
+	format = strings.ToLower(format)
+	if format == "" {
+		format = domain.ImportFormatCSV
+	}
+	if format != domain.ImportFormatCSV && format != domain.ImportFormatXLSX {
+		return nil, errors.New("format ekspor tidak didukung, gunakan csv atau xlsx")
+	}
+
+	products, err := u.productRepo.FindBySupplierID(ctx, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(products, func(i, j int) bool {
+		if products[i].CreatedAt.Equal(products[j].CreatedAt) {
+			return products[i].ID < products[j].ID
+		}
+		return products[i].CreatedAt.Before(products[j].CreatedAt)
+	})
+
+	var records [][]string
+	for _, p := range products {
+		base := []string{
+			p.ID, p.SKU, p.Name, p.Description, p.CategoryID, formatImportFloat(p.Price), strconv.Itoa(p.Stock),
+			strconv.Itoa(p.LowStockThreshold), strconv.FormatBool(p.AutoHideOutOfStock), p.ImageURL,
+		}
+		if len(p.Variants) == 0 {
+			records = append(records, append(base, "", "", "", ""))
+			continue
+		}
+		variants := append([]domain.ProductVariant{}, p.Variants...)
+		sort.SliceStable(variants, func(i, j int) bool { return variants[i].CreatedAt.Before(variants[j].CreatedAt) })
+		for _, v := range variants {
+			record := append(append([]string{}, base...), v.SKUCode, v.NameLabel, formatImportFloat(v.Price), strconv.Itoa(v.Stock))
+			records = append(records, record)
+		}
+	}
+	return writeImportRows(format, records)
+}
+
+func formatImportFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
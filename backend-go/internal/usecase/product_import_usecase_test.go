@@ -0,0 +1,313 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+)
+
+// --- Mock Import Job Repository ---
+type MockImportJobRepository struct {
+	jobs map[string]*domain.ImportJob
+}
+
+func (m *MockImportJobRepository) Create(ctx context.Context, job *domain.ImportJob) error {
+	m.jobs[job.ID] = job
+	return nil
+}
+func (m *MockImportJobRepository) Update(ctx context.Context, job *domain.ImportJob) error {
+	m.jobs[job.ID] = job
+	return nil
+}
+func (m *MockImportJobRepository) FailUnfinished(ctx context.Context, message string, finishedAt time.Time) (int64, error) {
+	var failed int64
+	for _, job := range m.jobs {
+		if job.Status == domain.ImportStatusPending || job.Status == domain.ImportStatusRunning {
+			job.Status = domain.ImportStatusFailed
+			job.Message = message
+			job.FinishedAt = &finishedAt
+			failed++
+		}
+	}
+	return failed, nil
+}
+func (m *MockImportJobRepository) FindByID(ctx context.Context, jobID string) (*domain.ImportJob, error) {
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return nil, errors.New("job impor tidak ditemukan")
+	}
+	return job, nil
+}
+
+// --- Mock Import Queue: menyimpan task agar test bisa menjalankannya secara sinkron ---
+type MockImportQueue struct {
+	tasks []domain.ImportTask
+	full  bool
+}
+
+func (m *MockImportQueue) Enqueue(task domain.ImportTask) bool {
+	if m.full {
+		return false
+	}
+	m.tasks = append(m.tasks, task)
+	return true
+}
+
+type importTestSetup struct {
+	uc          domain.ProductImportUsecase
+	productRepo *MockProductRepository
+	jobRepo     *MockImportJobRepository
+	queue       *MockImportQueue
+}
+
+func newImportTestSetup() *importTestSetup {
+	productRepo := NewMockProductRepository()
+	categoryRepo := NewMockCategoryRepositoryForProduct()
+	categoryRepo.categories["cat-1"] = &domain.Category{ID: "cat-1", Name: "Sayur"}
+	jobRepo := &MockImportJobRepository{jobs: map[string]*domain.ImportJob{}}
+	queue := &MockImportQueue{}
+	puc := NewProductUsecase(productRepo, categoryRepo, nil, nil, nil, logger.Discard())
+	return &importTestSetup{
+		uc:          NewProductImportUsecase(jobRepo, productRepo, categoryRepo, puc, queue),
+		productRepo: productRepo,
+		jobRepo:     jobRepo,
+		queue:       queue,
+	}
+}
+
+// run mengunggah file lalu menjalankan task antrian seperti yang dilakukan worker
+func (s *importTestSetup) run(t *testing.T, fileName string, data []byte, dryRun bool) *domain.ImportJob {
+	t.Helper()
+	job, err := s.uc.StartImport(context.Background(), "supplier-1", fileName, data, dryRun)
+	if err != nil {
+		t.Fatalf("StartImport gagal: %v", err)
+	}
+	if job.Status != domain.ImportStatusPending {
+		t.Fatalf("Status awal job harus PENDING, didapat %s", job.Status)
+	}
+	task := s.queue.tasks[len(s.queue.tasks)-1]
+	if err := s.uc.ProcessImport(context.Background(), task); err != nil {
+		t.Fatalf("ProcessImport gagal: %v", err)
+	}
+	job, err = s.uc.GetJob(context.Background(), "supplier-1", job.ID)
+	if err != nil {
+		t.Fatalf("GetJob gagal: %v", err)
+	}
+	return job
+}
+
+func (s *importTestSetup) productBySKU(sku string) *domain.Product {
+	for _, p := range s.productRepo.products {
+		if p.SKU == sku && p.SupplierID == "supplier-1" {
+			return p
+		}
+	}
+	return nil
+}
+
+const importHeader = "product_sku,name,description,id_category,price,stock,low_stock_threshold,auto_hide_out_of_stock,image_url,variant_sku,variant_name,variant_price,variant_stock\n"
+
+func TestStartImport_RejectsUnreadableFiles(t *testing.T) {
+	s := newImportTestSetup()
+	cases := []struct {
+		name     string
+		fileName string
+		data     string
+	}{
+		{"ekstensi tidak didukung", "produk.xls", importHeader + "SKU-1,Bayam,,cat-1,5000,10,,,,,,,\n"},
+		{"xlsx palsu", "produk.xlsx", importHeader},
+		{"tanpa kolom product_sku", "produk.csv", "name,price\nBayam,5000\n"},
+		{"tanpa baris data", "produk.csv", importHeader},
+		{"file kosong", "produk.csv", ""},
+	}
+	for _, tc := range cases {
+		if _, err := s.uc.StartImport(context.Background(), "supplier-1", tc.fileName, []byte(tc.data), false); err == nil {
+			t.Errorf("%s: seharusnya ditolak", tc.name)
+		}
+	}
+	if len(s.queue.tasks) != 0 {
+		t.Errorf("File yang ditolak tidak boleh masuk antrian, didapat %d task", len(s.queue.tasks))
+	}
+
+	s.queue.full = true
+	if _, err := s.uc.StartImport(context.Background(), "supplier-1", "produk.csv", []byte(importHeader+"SKU-1,Bayam,,cat-1,5000,10,,,,,,,\n"), false); err == nil {
+		t.Error("Antrian penuh seharusnya mengembalikan error")
+	}
+	for _, job := range s.jobRepo.jobs {
+		if job.Status != domain.ImportStatusFailed {
+			t.Errorf("Job yang gagal diantrikan harus FAILED, didapat %s", job.Status)
+		}
+	}
+}
+
+func TestProcessImport_DryRunReportsRowErrorsWithoutWriting(t *testing.T) {
+	s := newImportTestSetup()
+	csv := importHeader +
+		"SKU-1,Bayam Hijau,Segar,cat-1,5000,10,,,,BYM-250,250g,5000,4\n" +
+		"SKU-1,,,,,,,,,BYM-500,500g,9000,6\n" +
+		"SKU-2,Wortel,,cat-x,-1,10,,,,,,,\n" + // kategori & harga salah
+		",Tanpa SKU,,cat-1,1000,1,,,,,,,\n" +
+		"SKU-3,Kol,,cat-1,3000,abc,,,,BYM-250,1 pcs,3000,1\n" // stok bukan angka & variant_sku duplikat
+
+	job := s.run(t, "produk.csv", []byte(csv), true)
+
+	if job.Status != domain.ImportStatusCompleted || !job.DryRun {
+		t.Fatalf("Job dry run harus COMPLETED, didapat %s (dry_run=%v)", job.Status, job.DryRun)
+	}
+	if job.TotalRows != 5 || job.CreatedCount != 1 {
+		t.Errorf("Diharapkan 5 baris dan 1 produk akan dibuat, didapat %d baris, %d dibuat", job.TotalRows, job.CreatedCount)
+	}
+	if len(s.productRepo.products) != 0 {
+		t.Fatalf("Dry run tidak boleh menyimpan produk, tersimpan %d", len(s.productRepo.products))
+	}
+
+	expected := map[string]bool{
+		"4:id_category": true, "4:price": true, "5:product_sku": true, "6:stock": true, "6:variant_sku": true,
+	}
+	got := map[string]bool{}
+	for _, e := range job.Errors {
+		got[fmt.Sprintf("%d:%s", e.Row, e.Column)] = true
+	}
+	for key := range expected {
+		if !got[key] {
+			t.Errorf("Error baris %s tidak dilaporkan, didapat %+v", key, job.Errors)
+		}
+	}
+	if job.ErrorCount != len(job.Errors) {
+		t.Errorf("ErrorCount %d tidak sama dengan jumlah errors %d", job.ErrorCount, len(job.Errors))
+	}
+	for i := 1; i < len(job.Errors); i++ {
+		if job.Errors[i-1].Row > job.Errors[i].Row {
+			t.Fatalf("Errors harus urut per baris: %+v", job.Errors)
+		}
+	}
+}
+
+func TestProcessImport_UpsertBySKU(t *testing.T) {
+	s := newImportTestSetup()
+	s.productRepo.products["p-1"] = &domain.Product{
+		ID: "p-1", SKU: "SKU-1", Name: "Bayam", CategoryID: "cat-1", SupplierID: "supplier-1",
+		Price: 5000, Stock: 10, LowStockThreshold: 5, CreatedAt: time.Now(),
+		Variants: []domain.ProductVariant{
+			{ID: "v-1", ProductID: "p-1", NameLabel: "250g", Price: 5000, Stock: 4, SKUCode: "BYM-250"},
+			{ID: "v-2", ProductID: "p-1", NameLabel: "500g", Price: 9000, Stock: 2}, // Varian lama tanpa SKU
+		},
+	}
+
+	csv := importHeader +
+		"SKU-1,,,,5500,,,,,BYM-250,,5500,8\n" + // update harga produk & varian lewat SKU
+		"SKU-1,,,,,,,,,BYM-500,500g,9500,2\n" + // varian lama tanpa SKU dicocokkan lewat nama
+		"SKU-1,,,,,,,,,BYM-1KG,1 Kg,17000,1\n" + // varian baru
+		"SKU-2,Wortel,,cat-1,8000,20,,true,,,,,\n" // produk baru
+	job := s.run(t, "produk.csv", []byte(csv), false)
+
+	if len(job.Errors) != 0 {
+		t.Fatalf("Tidak diharapkan error, didapat %+v", job.Errors)
+	}
+	if job.CreatedCount != 1 || job.UpdatedCount != 1 {
+		t.Errorf("Diharapkan 1 dibuat & 1 diperbarui, didapat %d & %d", job.CreatedCount, job.UpdatedCount)
+	}
+
+	bayam := s.productRepo.products["p-1"]
+	if bayam.Price != 5500 || bayam.Stock != 10 || bayam.Name != "Bayam" {
+		t.Errorf("Kolom kosong harus mempertahankan nilai lama, didapat %+v", bayam)
+	}
+	if len(bayam.Variants) != 3 {
+		t.Fatalf("Diharapkan 3 varian (tidak ada duplikat), didapat %d", len(bayam.Variants))
+	}
+	for _, v := range bayam.Variants {
+		switch v.NameLabel {
+		case "250g":
+			if v.Price != 5500 || v.Stock != 8 {
+				t.Errorf("Varian 250g tidak diperbarui: %+v", v)
+			}
+		case "500g":
+			if v.ID != "v-2" || v.SKUCode != "BYM-500" || v.Price != 9500 {
+				t.Errorf("Varian 500g harus diperbarui dan diberi SKU: %+v", v)
+			}
+		case "1 Kg":
+			if v.SKUCode != "BYM-1KG" || v.ID == "" {
+				t.Errorf("Varian baru tidak dibuat dengan benar: %+v", v)
+			}
+		}
+	}
+
+	wortel := s.productBySKU("SKU-2")
+	if wortel == nil || wortel.Price != 8000 || !wortel.AutoHideOutOfStock {
+		t.Fatalf("Produk baru SKU-2 tidak dibuat dengan benar: %+v", wortel)
+	}
+
+	// Impor ulang file yang sama tidak boleh membuat duplikat
+	job = s.run(t, "produk.csv", []byte(csv), false)
+	if job.CreatedCount != 0 || job.UpdatedCount != 0 || job.UnchangedCount != 2 || len(job.Errors) != 0 {
+		t.Errorf("Impor ulang harus idempoten, didapat %+v", job)
+	}
+	if len(s.productRepo.products) != 2 {
+		t.Errorf("Diharapkan tetap 2 produk, didapat %d", len(s.productRepo.products))
+	}
+
+	// Job milik supplier lain tidak boleh terbaca
+	if _, err := s.uc.GetJob(context.Background(), "supplier-2", job.ID); err == nil {
+		t.Error("Supplier lain seharusnya tidak bisa membaca job")
+	}
+}
+
+func TestExport_RoundTrip(t *testing.T) {
+	for _, format := range []string{domain.ImportFormatCSV, domain.ImportFormatXLSX} {
+		s := newImportTestSetup()
+		s.productRepo.products["p-1"] = &domain.Product{
+			ID: "p-1", Name: "Bayam", Description: "Bayam, segar \"organik\"", CategoryID: "cat-1", SupplierID: "supplier-1",
+			Price: 5000.5, Stock: 10, LowStockThreshold: 5, CreatedAt: time.Now(),
+			Variants: []domain.ProductVariant{{ID: "v-1", ProductID: "p-1", NameLabel: "250g", Price: 5000, Stock: 4, SKUCode: "BYM-250"}},
+		}
+		s.productRepo.products["p-2"] = &domain.Product{
+			ID: "p-2", SKU: "SKU-2", Name: "Wortel", CategoryID: "cat-1", SupplierID: "supplier-1",
+			Price: 8000, Stock: 0, LowStockThreshold: 3, AutoHideOutOfStock: true, CreatedAt: time.Now().Add(time.Minute),
+		}
+		s.productRepo.products["p-3"] = &domain.Product{ID: "p-3", SKU: "X", Name: "Milik supplier lain", SupplierID: "supplier-2", Price: 1}
+
+		data, err := s.uc.Export(context.Background(), "supplier-1", format)
+		if err != nil {
+			t.Fatalf("%s: Export gagal: %v", format, err)
+		}
+
+		// File ekspor yang diimpor kembali tanpa perubahan tidak mengubah apa pun
+		job := s.run(t, "produk."+format, data, false)
+		if len(job.Errors) != 0 || job.TotalRows != 2 || job.UnchangedCount != 2 || job.CreatedCount+job.UpdatedCount != 0 {
+			t.Errorf("%s: round trip harus tanpa perubahan, didapat %+v", format, job)
+		}
+	}
+
+	s := newImportTestSetup()
+	if _, err := s.uc.Export(context.Background(), "supplier-1", "pdf"); err == nil {
+		t.Error("Format ekspor tidak dikenal seharusnya ditolak")
+	}
+}
+
+func TestFailInterruptedJobs(t *testing.T) {
+	s := newImportTestSetup()
+	s.jobRepo.jobs["pending"] = &domain.ImportJob{ID: "pending", Status: domain.ImportStatusPending}
+	s.jobRepo.jobs["running"] = &domain.ImportJob{ID: "running", Status: domain.ImportStatusRunning}
+	s.jobRepo.jobs["done"] = &domain.ImportJob{ID: "done", Status: domain.ImportStatusCompleted}
+
+	failed, err := s.uc.FailInterruptedJobs(context.Background())
+	if err != nil {
+		t.Fatalf("FailInterruptedJobs gagal: %v", err)
+	}
+	if failed != 2 {
+		t.Errorf("Expected 2 interrupted jobs, got %d", failed)
+	}
+	for _, id := range []string{"pending", "running"} {
+		if job := s.jobRepo.jobs[id]; job.Status != domain.ImportStatusFailed || job.Message == "" || job.FinishedAt == nil {
+			t.Errorf("Job %s harus FAILED dengan alasan, didapat %+v", id, job)
+		}
+	}
+	if s.jobRepo.jobs["done"].Status != domain.ImportStatusCompleted {
+		t.Error("Job yang sudah selesai tidak boleh diubah")
+	}
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+)
+
+// maxRejectionReasonLength membatasi panjang alasan penolakan yang ditampilkan ke supplier
+const maxRejectionReasonLength = 1000
+
+// productContent merangkum kolom listing yang wajib direview ulang jika diubah setelah tayang
+func productContent(p *domain.Product) string {
+	return strings.Join([]string{p.Name, p.Description, p.CategoryID, p.ImageURL}, "\x00")
+}
+
+// variantContent merangkum label varian yang tampil di listing; perubahan harga atau stok saja
+// tidak memicu review ulang
+func variantContent(variants []domain.ProductVariant) string {
+	labels := make([]string, 0, len(variants))
+	for _, v := range variants {
+		labels = append(labels, v.NameLabel)
+	}
+	sort.Strings(labels)
+	return strings.Join(labels, "\x00")
+}
+
+// requireReReview mengembalikan produk yang sudah tayang ke antrian review setelah supplier mengubah
+// varian, opsi, atau galerinya, sama seperti aturan di UpdateBySupplier. Perubahan oleh admin tidak
+// perlu direview ulang.
+func requireReReview(ctx context.Context, logger *slog.Logger, productRepo domain.ProductRepository, auditLogRepo domain.AuditLogRepository, role string, actorID string, productID string) error {
+	if role == "admin" {
+		return nil
+	}
+	product, err := productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if !product.IsPublished() {
+		return nil
+	}
+	before := *product
+	now := time.Now()
+	product.Status = domain.ProductStatusPendingReview
+	product.SubmittedAt = &now
+	product.UpdatedAt = now
+	if err := productRepo.UpdateStatus(ctx, product); err != nil {
+		return err
+	}
+	recordAudit(ctx, logger, auditLogRepo, actorID, "PRODUCT_STATUS_"+domain.ProductStatusPendingReview, "products", productID, "", &before, product)
+	return nil
+}
+
+func (u *productUsecase) ReviewQueue(ctx context.Context, page pagination.Params) ([]domain.Product, string, error) {
+	return u.productRepo.FindByStatus(ctx, domain.ProductStatusPendingReview, page)
+}
+
+func (u *productUsecase) Approve(ctx context.Context, adminID string, productID string) (*domain.Product, error) {
+	return u.changeStatus(ctx, adminID, productID, domain.ProductStatusActive, func(p *domain.Product, now time.Time) {
+		p.RejectionReason = ""
+		p.ReviewedAt = &now
+		p.ReviewedBy = adminID
+	})
+}
+
+func (u *productUsecase) Reject(ctx context.Context, adminID string, productID string, reason string) (*domain.Product, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("alasan penolakan wajib diisi")
+	}
+	if len([]rune(reason)) > maxRejectionReasonLength {
+		return nil, fmt.Errorf("alasan penolakan maksimal %d karakter", maxRejectionReasonLength)
+	}
+	return u.changeStatus(ctx, adminID, productID, domain.ProductStatusRejected, func(p *domain.Product, now time.Time) {
+		p.RejectionReason = reason
+		p.ReviewedAt = &now
+		p.ReviewedBy = adminID
+	})
+}
+
+// SubmitForReview mengajukan draft, produk yang ditolak, atau produk arsip ke antrian review
+func (u *productUsecase) SubmitForReview(ctx context.Context, supplierID string, productID string) (*domain.Product, error) {
+	if _, err := authorizeProductAccess(ctx, u.productRepo, "supplier", supplierID, productID); err != nil {
+		return nil, err
+	}
+	return u.changeStatus(ctx, supplierID, productID, domain.ProductStatusPendingReview, func(p *domain.Product, now time.Time) {
+		p.SubmittedAt = &now
+	})
+}
+
+// Archive menarik produk dari katalog. Supplier hanya untuk produk miliknya, admin untuk semua produk.
+func (u *productUsecase) Archive(ctx context.Context, role string, actorID string, productID string) (*domain.Product, error) {
+	if _, err := authorizeProductAccess(ctx, u.productRepo, role, actorID, productID); err != nil {
+		return nil, err
+	}
+	return u.changeStatus(ctx, actorID, productID, domain.ProductStatusArchived, nil)
+}
+
+// changeStatus memvalidasi perpindahan status, menyimpan kolom moderasi, lalu mencatatnya di audit log
+func (u *productUsecase) changeStatus(ctx context.Context, actorID string, productID string, status string, apply func(p *domain.Product, now time.Time)) (*domain.Product, error) {
+	product, err := u.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return nil, errors.New("produk tidak ditemukan")
+	}
+	if product.Status == "" {
+		product.Status = domain.ProductStatusActive
+	}
+	oldStatus := product.Status
+	before := *product
+	if !product.CanTransitionTo(status) {
+		return nil, fmt.Errorf("status produk tidak bisa diubah dari %s ke %s", oldStatus, status)
+	}
+
+	now := time.Now()
+	product.Status = status
+	if apply != nil {
+		apply(product, now)
+	}
+	product.UpdatedAt = now
+	if err := u.productRepo.UpdateStatus(ctx, product); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "PRODUCT_STATUS_"+status, "products", productID, "", &before, product)
+	return product, nil
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+)
+
+func newModerationTestSetup() (domain.ProductUsecase, *MockProductRepository) {
+	productRepo := NewMockProductRepository()
+	categoryRepo := NewMockCategoryRepositoryForProduct()
+	categoryRepo.categories["cat-1"] = &domain.Category{ID: "cat-1", Name: "Sayur"}
+	return NewProductUsecase(productRepo, categoryRepo, nil, nil, nil, logger.Discard()), productRepo
+}
+
+func TestModeration_SupplierProductNeedsApproval(t *testing.T) {
+	uc, repo := newModerationTestSetup()
+
+	product := &domain.Product{Name: "Bayam", CategoryID: "cat-1", Price: 5000, Stock: 10}
+	if err := uc.CreateBySupplier(context.Background(), "supplier-1", product); err != nil {
+		t.Fatalf("CreateBySupplier gagal: %v", err)
+	}
+	if product.Status != domain.ProductStatusPendingReview || product.SubmittedAt == nil {
+		t.Fatalf("Produk supplier harus PENDING_REVIEW, didapat %s", product.Status)
+	}
+	if product.IsPublished() {
+		t.Error("Produk yang belum direview tidak boleh tayang")
+	}
+
+	queue, _, _ := uc.ReviewQueue(context.Background(), pagination.Params{Limit: pagination.DefaultLimit})
+	if len(queue) != 1 || queue[0].ID != product.ID {
+		t.Fatalf("Produk harus masuk antrian review, didapat %d produk", len(queue))
+	}
+
+	if _, err := uc.Reject(context.Background(), "admin-1", product.ID, "  "); err == nil {
+		t.Error("Penolakan tanpa alasan seharusnya ditolak")
+	}
+	rejected, err := uc.Reject(context.Background(), "admin-1", product.ID, "Foto produk buram")
+	if err != nil {
+		t.Fatalf("Reject gagal: %v", err)
+	}
+	if rejected.Status != domain.ProductStatusRejected || rejected.RejectionReason != "Foto produk buram" || rejected.ReviewedBy != "admin-1" {
+		t.Errorf("Status penolakan tidak tersimpan dengan benar: %+v", rejected)
+	}
+	if _, err := uc.Approve(context.Background(), "admin-1", product.ID); err == nil {
+		t.Error("Produk REJECTED harus diajukan ulang sebelum bisa disetujui")
+	}
+
+	if _, err := uc.SubmitForReview(context.Background(), "supplier-2", product.ID); err == nil {
+		t.Error("Supplier lain tidak boleh mengajukan produk ini")
+	}
+	if _, err := uc.SubmitForReview(context.Background(), "supplier-1", product.ID); err != nil {
+		t.Fatalf("SubmitForReview gagal: %v", err)
+	}
+	approved, err := uc.Approve(context.Background(), "admin-1", product.ID)
+	if err != nil {
+		t.Fatalf("Approve gagal: %v", err)
+	}
+	if approved.Status != domain.ProductStatusActive || approved.RejectionReason != "" || !approved.IsPublished() {
+		t.Errorf("Produk yang disetujui harus ACTIVE tanpa alasan penolakan: %+v", approved)
+	}
+
+	// Perubahan stok/harga tidak memicu review ulang
+	if err := uc.UpdateBySupplier(context.Background(), "supplier-1", product.ID, &domain.Product{Stock: 3, Price: 5500}); err != nil {
+		t.Fatalf("UpdateBySupplier gagal: %v", err)
+	}
+	if repo.products[product.ID].Status != domain.ProductStatusActive {
+		t.Errorf("Update stok/harga tidak boleh mengubah status, didapat %s", repo.products[product.ID].Status)
+	}
+	// Perubahan isi listing harus direview ulang
+	if err := uc.UpdateBySupplier(context.Background(), "supplier-1", product.ID, &domain.Product{Name: "Bayam Organik", Stock: 3}); err != nil {
+		t.Fatalf("UpdateBySupplier gagal: %v", err)
+	}
+	if repo.products[product.ID].Status != domain.ProductStatusPendingReview {
+		t.Errorf("Perubahan nama produk tayang harus kembali ke PENDING_REVIEW, didapat %s", repo.products[product.ID].Status)
+	}
+}
+
+func TestModeration_DraftAndArchive(t *testing.T) {
+	uc, _ := newModerationTestSetup()
+
+	draft := &domain.Product{Name: "Wortel", CategoryID: "cat-1", Price: 8000, Stock: 5, Status: domain.ProductStatusDraft}
+	if err := uc.CreateBySupplier(context.Background(), "supplier-1", draft); err != nil {
+		t.Fatalf("CreateBySupplier gagal: %v", err)
+	}
+	if draft.Status != domain.ProductStatusDraft || draft.SubmittedAt != nil {
+		t.Fatalf("Produk harus tersimpan sebagai DRAFT, didapat %s", draft.Status)
+	}
+	if _, err := uc.Approve(context.Background(), "admin-1", draft.ID); err == nil {
+		t.Error("Draft tidak boleh langsung disetujui sebelum diajukan")
+	}
+
+	forced := &domain.Product{Name: "Kol", CategoryID: "cat-1", Price: 3000, Stock: 5, Status: domain.ProductStatusActive}
+	if err := uc.CreateBySupplier(context.Background(), "supplier-1", forced); err == nil {
+		t.Error("Supplier tidak boleh membuat produk langsung ACTIVE")
+	}
+
+	if _, err := uc.Archive(context.Background(), "supplier", "supplier-2", draft.ID); err == nil {
+		t.Error("Supplier lain tidak boleh mengarsipkan produk ini")
+	}
+	archived, err := uc.Archive(context.Background(), "supplier", "supplier-1", draft.ID)
+	if err != nil || archived.Status != domain.ProductStatusArchived {
+		t.Fatalf("Archive gagal: %v", err)
+	}
+	if _, err := uc.Archive(context.Background(), "admin", "admin-1", draft.ID); err == nil {
+		t.Error("Produk yang sudah diarsipkan tidak bisa diarsipkan lagi")
+	}
+
+	// Produk yang dibuat admin langsung tayang
+	adminProduct := &domain.Product{Name: "Tomat", CategoryID: "cat-1", Price: 7000, Stock: 5, Status: domain.ProductStatusDraft}
+	if err := uc.Create(context.Background(), "admin-1", adminProduct); err != nil {
+		t.Fatalf("Create gagal: %v", err)
+	}
+	if adminProduct.Status != domain.ProductStatusActive {
+		t.Errorf("Produk admin harus ACTIVE, didapat %s", adminProduct.Status)
+	}
+}
+
+func TestModeration_UnpublishedProductDetailsHiddenFromPublic(t *testing.T) {
+	uc, repo := newModerationTestSetup()
+	repo.products["p1"] = &domain.Product{ID: "p1", Name: "Bayam", SupplierID: "supplier-1", Status: domain.ProductStatusPendingReview,
+		Variants: []domain.ProductVariant{{ID: "v1", ProductID: "p1", NameLabel: "Ikat", Price: 3000}}}
+
+	if _, err := uc.ListVariants(context.Background(), "", "", "p1"); err == nil || err.Error() != "produk tidak ditemukan" {
+		t.Errorf("Varian produk yang belum tayang harus tersembunyi dari publik, didapat %v", err)
+	}
+	if _, err := uc.ListVariants(context.Background(), "supplier", "supplier-2", "p1"); err == nil {
+		t.Error("Supplier lain tidak boleh melihat varian produk yang belum tayang")
+	}
+	if variants, err := uc.ListVariants(context.Background(), "supplier", "supplier-1", "p1"); err != nil || len(variants) != 1 {
+		t.Errorf("Pemilik produk harus tetap bisa melihat variannya, didapat %d varian, err %v", len(variants), err)
+	}
+	if _, err := uc.ListVariants(context.Background(), "admin", "admin-1", "p1"); err != nil {
+		t.Errorf("Admin harus bisa melihat varian produk di antrian review, didapat %v", err)
+	}
+}
+
+func TestModeration_VariantEditsRequireReReview(t *testing.T) {
+	uc, repo := newModerationTestSetup()
+	repo.products["p1"] = &domain.Product{ID: "p1", Name: "Bayam", SupplierID: "supplier-1", Status: domain.ProductStatusActive,
+		Variants: []domain.ProductVariant{{ID: "v1", ProductID: "p1", NameLabel: "Ikat", Price: 3000, Stock: 5}}}
+
+	// Perubahan harga/stok varian tidak memicu review ulang
+	if err := uc.UpdateVariant(context.Background(), "supplier", "supplier-1", "p1", "v1", &domain.ProductVariant{NameLabel: "Ikat", Price: 3500, Stock: 8}); err != nil {
+		t.Fatalf("UpdateVariant gagal: %v", err)
+	}
+	if repo.products["p1"].Status != domain.ProductStatusActive {
+		t.Fatalf("Update harga/stok varian tidak boleh mengubah status, didapat %s", repo.products["p1"].Status)
+	}
+
+	// Varian baru dari admin tidak perlu direview ulang
+	if err := uc.CreateVariant(context.Background(), "admin", "admin-1", "p1", &domain.ProductVariant{NameLabel: "Karung", Price: 90000}); err != nil {
+		t.Fatalf("CreateVariant admin gagal: %v", err)
+	}
+	if repo.products["p1"].Status != domain.ProductStatusActive {
+		t.Fatalf("Perubahan oleh admin tidak boleh memicu review ulang, didapat %s", repo.products["p1"].Status)
+	}
+
+	if err := uc.CreateVariant(context.Background(), "supplier", "supplier-1", "p1", &domain.ProductVariant{NameLabel: "500g", Price: 6000}); err != nil {
+		t.Fatalf("CreateVariant gagal: %v", err)
+	}
+	if repo.products["p1"].Status != domain.ProductStatusPendingReview || repo.products["p1"].SubmittedAt == nil {
+		t.Errorf("Varian baru dari supplier harus mengembalikan produk ke PENDING_REVIEW, didapat %s", repo.products["p1"].Status)
+	}
+}
@@ -0,0 +1,265 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+// maxVariantCombinations membatasi ukuran matriks agar satu produk tidak meledak menjadi ribuan varian
+const maxVariantCombinations = 100
+
+type productOptionUsecase struct {
+	optionRepo   domain.ProductOptionRepository
+	productRepo  domain.ProductRepository
+	auditLogRepo domain.AuditLogRepository
+	productCache domain.ProductCacheInvalidator
+	logger       *slog.Logger
+}
+
+func NewProductOptionUsecase(oRepo domain.ProductOptionRepository, pRepo domain.ProductRepository, auditLogRepo domain.AuditLogRepository, productCache domain.ProductCacheInvalidator, logger *slog.Logger) domain.ProductOptionUsecase {
+	return &productOptionUsecase{
+		optionRepo:   oRepo,
+		productRepo:  pRepo,
+		auditLogRepo: auditLogRepo,
+		productCache: productCache,
+		logger:       logger,
+	}
+}
+
+// optionAuditSnapshot meringkas grup opsi menjadi nama → nilai agar diff audit tidak berisi ID yang dibuat ulang
+func optionAuditSnapshot(options []domain.ProductOption) map[string]interface{} {
+	summary := map[string]interface{}{}
+	for _, opt := range options {
+		values := make([]string, 0, len(opt.Values))
+		for _, v := range opt.Values {
+			values = append(values, v.Value)
+		}
+		summary[opt.Name] = map[string]interface{}{"type": opt.Type, "unit": opt.Unit, "values": values}
+	}
+	return summary
+}
+
+func (u *productOptionUsecase) GetOptions(ctx context.Context, role string, actorID string, productID string) ([]domain.ProductOption, error) {
+	if _, err := authorizeProductRead(ctx, u.productRepo, role, actorID, productID); err != nil {
+		return nil, err
+	}
+	return u.optionRepo.FindByProductID(ctx, productID)
+}
+
+func validateOption(opt *domain.ProductOption) error {
+	opt.Name = strings.TrimSpace(opt.Name)
+	opt.Type = strings.ToUpper(strings.TrimSpace(opt.Type))
+	if opt.Type == "" {
+		opt.Type = "TEXT"
+	}
+	if opt.Name == "" {
+		return errors.New("nama opsi wajib diisi")
+	}
+	if opt.Type != "TEXT" && opt.Type != "NUMBER" {
+		return fmt.Errorf("tipe opsi %s tidak valid, gunakan TEXT atau NUMBER", opt.Name)
+	}
+	if len(opt.Values) == 0 {
+		return fmt.Errorf("opsi %s minimal memiliki satu nilai", opt.Name)
+	}
+
+	seen := map[string]bool{}
+	for i := range opt.Values {
+		v := &opt.Values[i]
+		v.Value = strings.TrimSpace(v.Value)
+		if v.Value == "" {
+			return fmt.Errorf("nilai opsi %s tidak boleh kosong", opt.Name)
+		}
+		if opt.Type == "NUMBER" {
+			if _, err := strconv.ParseFloat(v.Value, 64); err != nil {
+				return fmt.Errorf("nilai %s pada opsi %s harus berupa angka", v.Value, opt.Name)
+			}
+		}
+		key := strings.ToLower(v.Value)
+		if seen[key] {
+			return fmt.Errorf("nilai %s duplikat pada opsi %s", v.Value, opt.Name)
+		}
+		seen[key] = true
+		v.Position = i
+	}
+	return nil
+}
+
+// SetOptions mengganti seluruh grup opsi produk. Urutan di request menjadi urutan tampilan.
+func (u *productOptionUsecase) SetOptions(ctx context.Context, role string, actorID string, productID string, options []domain.ProductOption) ([]domain.ProductOption, error) {
+	if _, err := authorizeProductAccess(ctx, u.productRepo, role, actorID, productID); err != nil {
+		return nil, err
+	}
+
+	seenNames := map[string]bool{}
+	combinations := 1
+	for i := range options {
+		if err := validateOption(&options[i]); err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(options[i].Name)
+		if seenNames[key] {
+			return nil, fmt.Errorf("opsi %s disebut lebih dari sekali", options[i].Name)
+		}
+		seenNames[key] = true
+		options[i].Position = i
+		combinations *= len(options[i].Values)
+	}
+	if combinations > maxVariantCombinations {
+		return nil, fmt.Errorf("kombinasi opsi terlalu banyak (%d), maksimal %d varian", combinations, maxVariantCombinations)
+	}
+
+	before, err := u.optionRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.optionRepo.ReplaceOptions(ctx, productID, options); err != nil {
+		return nil, err
+	}
+	// Opsi ikut di-preload bersama varian pada detail produk yang di-cache
+	if u.productCache != nil {
+		u.productCache.InvalidateProductCache(ctx)
+	}
+	saved, err := u.optionRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "SET_PRODUCT_OPTIONS", "products", productID, "", optionAuditSnapshot(before), optionAuditSnapshot(saved))
+	if fmt.Sprint(optionAuditSnapshot(before)) != fmt.Sprint(optionAuditSnapshot(saved)) {
+		if err := requireReReview(ctx, u.logger, u.productRepo, u.auditLogRepo, role, actorID, productID); err != nil {
+			return nil, err
+		}
+	}
+	return saved, nil
+}
+
+// combinationKey membuat kunci unik kombinasi dari ID nilai opsi (tidak bergantung urutan)
+func combinationKey(values []domain.ProductOptionValue) string {
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		ids = append(ids, v.ID)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, "|")
+}
+
+// cartesian menghasilkan seluruh kombinasi nilai (satu nilai dari setiap opsi)
+func cartesian(options []domain.ProductOption) [][]domain.ProductOptionValue {
+	result := [][]domain.ProductOptionValue{{}}
+	for _, opt := range options {
+		var next [][]domain.ProductOptionValue
+		for _, combo := range result {
+			for _, v := range opt.Values {
+				extended := append(append([]domain.ProductOptionValue{}, combo...), v)
+				next = append(next, extended)
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// GenerateVariants membuat varian untuk setiap kombinasi nilai opsi yang belum punya varian.
+// Varian yang sudah ada (kombinasi sama) dibiarkan apa adanya agar harga/stoknya tidak tertimpa.
+func (u *productOptionUsecase) GenerateVariants(ctx context.Context, role string, actorID string, productID string, req domain.GenerateVariantsRequest) (*domain.GenerateVariantsResult, error) {
+	if _, err := authorizeProductAccess(ctx, u.productRepo, role, actorID, productID); err != nil {
+		return nil, err
+	}
+	if req.Price <= 0 {
+		return nil, errors.New("harga varian harus lebih dari 0")
+	}
+	if req.Stock < 0 {
+		return nil, errors.New("stok varian tidak boleh negatif")
+	}
+
+	options, err := u.optionRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) == 0 {
+		return nil, errors.New("produk belum memiliki opsi, atur opsi terlebih dahulu")
+	}
+
+	existing, err := u.productRepo.FindVariantsByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	existingByKey := map[string]bool{}
+	for _, v := range existing {
+		if len(v.OptionValues) > 0 {
+			existingByKey[combinationKey(v.OptionValues)] = true
+		}
+	}
+
+	result := &domain.GenerateVariantsResult{}
+	wanted := map[string]bool{}
+	var created []domain.ProductVariant
+	now := time.Now()
+	for _, combo := range cartesian(options) {
+		key := combinationKey(combo)
+		wanted[key] = true
+		if existingByKey[key] {
+			result.Kept++
+			continue
+		}
+
+		labels := make([]string, 0, len(combo))
+		for i, v := range combo {
+			label := v.Value
+			if options[i].Unit != "" {
+				label += options[i].Unit
+			}
+			labels = append(labels, label)
+		}
+
+		created = append(created, domain.ProductVariant{
+			ID:           uuid.New().String(),
+			ProductID:    productID,
+			NameLabel:    strings.Join(labels, " / "),
+			Price:        req.Price,
+			Stock:        req.Stock,
+			OptionValues: combo,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+	}
+
+	var stale []domain.ProductVariant
+	var staleIDs []string
+	if req.RemoveStale {
+		for _, v := range existing {
+			if len(v.OptionValues) == len(options) && wanted[combinationKey(v.OptionValues)] {
+				continue
+			}
+			stale = append(stale, v)
+			staleIDs = append(staleIDs, v.ID)
+		}
+	}
+
+	// Semua varian baru dan penghapusan varian usang disimpan dalam satu transaksi
+	if err := u.productRepo.ApplyGeneratedVariants(ctx, productID, created, staleIDs, actorID); err != nil {
+		return nil, err
+	}
+	for i := range created {
+		recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "CREATE_VARIANT", "product_variants", created[i].ID, productID, nil, &created[i])
+	}
+	for i := range stale {
+		recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "DELETE_VARIANT", "product_variants", stale[i].ID, productID, &stale[i], nil)
+	}
+	result.Created = created
+	result.Removed = len(stale)
+	if len(result.Created) > 0 || result.Removed > 0 {
+		if err := requireReReview(ctx, u.logger, u.productRepo, u.auditLogRepo, role, actorID, productID); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+)
+
+// --- Mock Product Option Repository ---
+
+type MockProductOptionRepository struct {
+	options map[string][]domain.ProductOption // Key: id_product
+}
+
+func NewMockProductOptionRepository() *MockProductOptionRepository {
+	return &MockProductOptionRepository{options: make(map[string][]domain.ProductOption)}
+}
+
+func (m *MockProductOptionRepository) FindByProductID(ctx context.Context, productID string) ([]domain.ProductOption, error) {
+	return m.options[productID], nil
+}
+
+// ReplaceOptions mempertahankan ID opsi/nilai yang namanya sama, seperti repository asli
+func (m *MockProductOptionRepository) ReplaceOptions(ctx context.Context, productID string, options []domain.ProductOption) error {
+	existingIDs := map[string]string{}
+	for _, opt := range m.options[productID] {
+		existingIDs[strings.ToLower(opt.Name)] = opt.ID
+		for _, v := range opt.Values {
+			existingIDs[strings.ToLower(opt.Name)+"="+strings.ToLower(v.Value)] = v.ID
+		}
+	}
+	idFor := func(key string) string {
+		if id, ok := existingIDs[key]; ok {
+			return id
+		}
+		return uuid.New().String()
+	}
+
+	for i := range options {
+		optKey := strings.ToLower(options[i].Name)
+		options[i].ID = idFor(optKey)
+		options[i].ProductID = productID
+		for j := range options[i].Values {
+			options[i].Values[j].ID = idFor(optKey + "=" + strings.ToLower(options[i].Values[j].Value))
+			options[i].Values[j].OptionID = options[i].ID
+		}
+	}
+	m.options[productID] = options
+	return nil
+}
+
+func newOptionTestSetup() (*MockProductRepository, domain.ProductOptionUsecase) {
+	productRepo := NewMockProductRepository()
+	productRepo.products["prod-1"] = &domain.Product{ID: "prod-1", Name: "Cabai Rawit", SupplierID: "supplier-1", Price: 10000}
+	return productRepo, NewProductOptionUsecase(NewMockProductOptionRepository(), productRepo, nil, nil, logger.Discard())
+}
+
+func TestSetOptions_Validation(t *testing.T) {
+	_, uc := newOptionTestSetup()
+
+	// SQA CHECK: supplier lain tidak boleh mengubah opsi produk
+	_, err := uc.SetOptions(context.Background(), "supplier", "supplier-2", "prod-1", []domain.ProductOption{
+		{Name: "Grade", Values: []domain.ProductOptionValue{{Value: "A"}}},
+	})
+	if err == nil {
+		t.Fatal("Expected ownership error, got success")
+	}
+
+	// SQA CHECK: opsi bertipe NUMBER harus berisi angka
+	_, err = uc.SetOptions(context.Background(), "supplier", "supplier-1", "prod-1", []domain.ProductOption{
+		{Name: "Ukuran", Type: "NUMBER", Values: []domain.ProductOptionValue{{Value: "besar"}}},
+	})
+	if err == nil {
+		t.Fatal("Expected error for non-numeric NUMBER value, got success")
+	}
+
+	// SQA CHECK: nilai duplikat (case-insensitive) ditolak
+	_, err = uc.SetOptions(context.Background(), "supplier", "supplier-1", "prod-1", []domain.ProductOption{
+		{Name: "Grade", Values: []domain.ProductOptionValue{{Value: "A"}, {Value: "a"}}},
+	})
+	if err == nil {
+		t.Fatal("Expected error for duplicate option value, got success")
+	}
+
+	options, err := uc.SetOptions(context.Background(), "admin", "admin-1", "prod-1", []domain.ProductOption{
+		{Name: " Ukuran ", Type: "number", Unit: "g", Values: []domain.ProductOptionValue{{Value: "250"}, {Value: "500"}}},
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if options[0].Name != "Ukuran" || options[0].Type != "NUMBER" {
+		t.Errorf("Expected normalized option 'Ukuran'/NUMBER, got '%s'/%s", options[0].Name, options[0].Type)
+	}
+}
+
+func TestGenerateVariants_Matrix(t *testing.T) {
+	productRepo, uc := newOptionTestSetup()
+
+	_, err := uc.SetOptions(context.Background(), "supplier", "supplier-1", "prod-1", []domain.ProductOption{
+		{Name: "Ukuran", Type: "NUMBER", Unit: "g", Values: []domain.ProductOptionValue{{Value: "250"}, {Value: "500"}}},
+		{Name: "Grade", Values: []domain.ProductOptionValue{{Value: "A"}, {Value: "B"}}},
+	})
+	if err != nil {
+		t.Fatalf("SetOptions failed: %v", err)
+	}
+
+	result, err := uc.GenerateVariants(context.Background(), "supplier", "supplier-1", "prod-1", domain.GenerateVariantsRequest{Price: 8000, Stock: 5})
+	if err != nil {
+		t.Fatalf("GenerateVariants failed: %v", err)
+	}
+	if len(result.Created) != 4 {
+		t.Fatalf("Expected 4 variants (2x2), got %d", len(result.Created))
+	}
+	if result.Created[0].NameLabel != "250g / A" {
+		t.Errorf("Expected label '250g / A', got '%s'", result.Created[0].NameLabel)
+	}
+
+	// SQA CHECK: generate ulang tidak menduplikasi varian yang sudah ada
+	result, err = uc.GenerateVariants(context.Background(), "supplier", "supplier-1", "prod-1", domain.GenerateVariantsRequest{Price: 8000})
+	if err != nil {
+		t.Fatalf("Second GenerateVariants failed: %v", err)
+	}
+	if len(result.Created) != 0 || result.Kept != 4 {
+		t.Errorf("Expected 0 created / 4 kept, got %d / %d", len(result.Created), result.Kept)
+	}
+
+	// Hapus nilai "B" → 2 kombinasi menjadi usang dan dihapus saat remove_stale
+	_, err = uc.SetOptions(context.Background(), "supplier", "supplier-1", "prod-1", []domain.ProductOption{
+		{Name: "Ukuran", Type: "NUMBER", Unit: "g", Values: []domain.ProductOptionValue{{Value: "250"}, {Value: "500"}}},
+		{Name: "Grade", Values: []domain.ProductOptionValue{{Value: "A"}}},
+	})
+	if err != nil {
+		t.Fatalf("SetOptions failed: %v", err)
+	}
+	result, err = uc.GenerateVariants(context.Background(), "supplier", "supplier-1", "prod-1", domain.GenerateVariantsRequest{Price: 8000, RemoveStale: true})
+	if err != nil {
+		t.Fatalf("GenerateVariants with remove_stale failed: %v", err)
+	}
+	if len(result.Created) != 0 || result.Kept != 2 || result.Removed != 2 {
+		t.Errorf("Expected 0 created / 2 kept / 2 removed, got %d / %d / %d", len(result.Created), result.Kept, result.Removed)
+	}
+	if len(productRepo.products["prod-1"].Variants) != 2 {
+		t.Errorf("Expected 2 variants after regenerate, got %d", len(productRepo.products["prod-1"].Variants))
+	}
+}
+
+func TestSetOptions_InvalidatesProductCache(t *testing.T) {
+	productRepo := NewMockProductRepository()
+	productRepo.products["prod-1"] = &domain.Product{ID: "prod-1", Name: "Cabai Rawit", SupplierID: "supplier-1", Price: 10000}
+	cache := &mockProductCache{}
+	uc := NewProductOptionUsecase(NewMockProductOptionRepository(), productRepo, nil, cache, logger.Discard())
+
+	_, err := uc.SetOptions(context.Background(), "supplier", "supplier-1", "prod-1", []domain.ProductOption{
+		{Name: "Grade", Values: []domain.ProductOptionValue{{Value: "A"}}},
+	})
+	if err != nil {
+		t.Fatalf("SetOptions failed: %v", err)
+	}
+	if cache.invalidations != 1 {
+		t.Errorf("Expected the cached product detail invalidated once, got %d", cache.invalidations)
+	}
+}
+
+// SQA CHECK: varian usang yang gagal dihapus membatalkan seluruh generate, termasuk varian baru
+func TestGenerateVariants_AllOrNothing(t *testing.T) {
+	productRepo, uc := newOptionTestSetup()
+	_, err := uc.SetOptions(context.Background(), "supplier", "supplier-1", "prod-1", []domain.ProductOption{
+		{Name: "Grade", Values: []domain.ProductOptionValue{{Value: "A"}}},
+	})
+	if err != nil {
+		t.Fatalf("SetOptions failed: %v", err)
+	}
+	productRepo.products["prod-1"].Variants = []domain.ProductVariant{{ID: "legacy", ProductID: "prod-1", NameLabel: "Curah", Price: 9000, Stock: 2}}
+	productRepo.deleteErrs = map[string]error{"legacy": errors.New("varian masih direservasi")}
+
+	_, err = uc.GenerateVariants(context.Background(), "supplier", "supplier-1", "prod-1", domain.GenerateVariantsRequest{Price: 8000, Stock: 5, RemoveStale: true})
+	if err == nil {
+		t.Fatal("Expected error when a stale variant cannot be removed")
+	}
+	variants := productRepo.products["prod-1"].Variants
+	if len(variants) != 1 || variants[0].ID != "legacy" {
+		t.Errorf("Expected no variant created when the batch fails, got %+v", variants)
+	}
+}
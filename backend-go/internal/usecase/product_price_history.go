@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+// auditPrice membaca field "price" dari kolom JSON audit log
+func auditPrice(values string) (float64, bool) {
+	var parsed map[string]interface{}
+	if values == "" || json.Unmarshal([]byte(values), &parsed) != nil {
+		return 0, false
+	}
+	price, ok := parsed["price"].(float64)
+	return price, ok
+}
+
+// PriceHistory menyusun deret waktu harga dari audit log. Setiap titik adalah harga yang berlaku
+// sejak waktu tersebut; deret tanpa riwayat berisi satu titik harga saat ini.
+func (u *productUsecase) PriceHistory(ctx context.Context, productID string) (*domain.PriceHistory, error) {
+	product, err := u.productRepo.FindByID(ctx, productID)
+	if err != nil || !product.IsPublished() {
+		return nil, errors.New("produk tidak ditemukan")
+	}
+	variants, err := u.productRepo.FindVariantsByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []domain.AuditLog
+	if u.auditLogRepo != nil {
+		logs, err = u.auditLogRepo.FindByEntityWithChildren(ctx, "products", productID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	base := &domain.PriceSeries{Label: product.Name}
+	variantSeries := map[string]*domain.PriceSeries{}
+	series := []*domain.PriceSeries{base}
+	for i := range variants {
+		id := variants[i].ID
+		s := &domain.PriceSeries{VariantID: &id, Label: variants[i].NameLabel}
+		variantSeries[id] = s
+		series = append(series, s)
+	}
+
+	for _, entry := range logs {
+		var target *domain.PriceSeries
+		switch entry.Entity {
+		case "products":
+			target = base
+		case "product_variants":
+			target = variantSeries[entry.EntityID] // varian yang sudah dihapus tidak ditampilkan
+		}
+		if target == nil {
+			continue
+		}
+		newPrice, ok := auditPrice(entry.NewValues)
+		if !ok {
+			continue
+		}
+		// Riwayat yang dimulai dari perubahan (misalnya data sebelum audit dicatat) diberi titik awal harga lama
+		if len(target.Points) == 0 {
+			if oldPrice, hasOld := auditPrice(entry.OldValues); hasOld && entry.CreatedAt.After(product.CreatedAt) {
+				target.Points = append(target.Points, domain.PricePoint{At: product.CreatedAt, Price: oldPrice})
+			}
+		}
+		target.Points = append(target.Points, domain.PricePoint{At: entry.CreatedAt, Price: newPrice})
+	}
+
+	history := &domain.PriceHistory{ProductID: productID, CurrentPrice: product.Price, Series: []domain.PriceSeries{}}
+	for i, s := range series {
+		if len(s.Points) == 0 {
+			current := product.Price
+			if i > 0 {
+				current = variants[i-1].Price
+			}
+			s.Points = []domain.PricePoint{{At: product.CreatedAt, Price: current}}
+		}
+		history.Series = append(history.Series, *s)
+	}
+	return history, nil
+}
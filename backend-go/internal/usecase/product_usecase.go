@@ -1,62 +1,190 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 type productUsecase struct {
 	productRepo  domain.ProductRepository
 	categoryRepo domain.CategoryRepository
 	auditLogRepo domain.AuditLogRepository
+	alertQueue   domain.WishlistAlertQueue
+	queryRepo    domain.SearchSuggestionRepository // Opsional: mencatat keyword populer untuk autocomplete
+	logger       *slog.Logger
 }
 
-func NewProductUsecase(pRepo domain.ProductRepository, cRepo domain.CategoryRepository, aRepo domain.AuditLogRepository) domain.ProductUsecase {
+func NewProductUsecase(pRepo domain.ProductRepository, cRepo domain.CategoryRepository, aRepo domain.AuditLogRepository, alertQueue domain.WishlistAlertQueue, queryRepo domain.SearchSuggestionRepository, logger *slog.Logger) domain.ProductUsecase {
 	return &productUsecase{
 		productRepo:  pRepo,
 		categoryRepo: cRepo,
 		auditLogRepo: aRepo,
+		alertQueue:   alertQueue,
+		queryRepo:    queryRepo,
+		logger:       logger,
 	}
 }
 
-func (u *productUsecase) Create(product *domain.Product) error {
-	_, err := u.categoryRepo.FindByID(product.CategoryID)
+// stockSnapshot membaca total stok produk + varian dari primary sebelum perubahan. Produk dari cache
+// bisa tertinggal dan tidak mencerminkan stok varian, sehingga tidak dipakai sebagai stok lama.
+// Mengembalikan -1 jika gagal agar notifikasi back-in-stock dilewati, bukan dikirim dari data yang tidak pasti.
+func (u *productUsecase) stockSnapshot(ctx context.Context, productID string) int {
+	if u.alertQueue == nil {
+		return -1
+	}
+	total, err := u.productRepo.TotalStock(ctx, productID)
+	if err != nil {
+		u.logger.WarnContext(ctx, "gagal membaca stok untuk notifikasi wishlist", "product_id", productID, "error", err)
+		return -1
+	}
+	return total
+}
+
+// enqueueWishlistAlert mengantrikan notifikasi wishlist jika total stok (produk dan varian) naik dari nol
+// atau harga turun. oldStock berasal dari stockSnapshot sebelum perubahan disimpan.
+func (u *productUsecase) enqueueWishlistAlert(ctx context.Context, product *domain.Product, oldStock int, oldPrice float64) {
+	if u.alertQueue == nil || !product.IsPublished() {
+		return
+	}
+	backInStock := false
+	if oldStock == 0 {
+		newStock, err := u.productRepo.TotalStock(ctx, product.ID)
+		if err != nil {
+			u.logger.WarnContext(ctx, "gagal membaca stok untuk notifikasi wishlist", "product_id", product.ID, "error", err)
+		}
+		backInStock = err == nil && newStock > 0
+	}
+	priceDropped := product.Price < oldPrice
+	if !backInStock && !priceDropped {
+		return
+	}
+
+	u.alertQueue.Enqueue(domain.WishlistAlertJob{
+		ProductID:    product.ID,
+		ProductName:  product.Name,
+		BackInStock:  backInStock,
+		PriceDropped: priceDropped,
+		OldPrice:     oldPrice,
+		NewPrice:     product.Price,
+		CreatedAt:    time.Now(),
+	})
+}
+
+// enqueueVariantRestockAlert dipanggil setelah perubahan varian; status produk dibaca ulang karena
+// perubahan varian oleh supplier bisa mengembalikan produk ke antrian review
+func (u *productUsecase) enqueueVariantRestockAlert(ctx context.Context, productID string, oldStock int) {
+	if u.alertQueue == nil || oldStock != 0 {
+		return
+	}
+	product, err := u.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return
+	}
+	u.enqueueWishlistAlert(ctx, product, oldStock, product.Price)
+}
+
+func (u *productUsecase) Create(ctx context.Context, adminID string, product *domain.Product) error {
+	_, err := u.categoryRepo.FindByID(ctx, product.CategoryID)
 	if err != nil {
 		return errors.New("invalid category_id: kategori tidak ditemukan")
 	}
 
+	product.SKU = strings.TrimSpace(product.SKU)
+	if err := u.ensureUniqueProductSKU(ctx, product.SupplierID, product.SKU, ""); err != nil {
+		return err
+	}
+
+	// Produk yang dibuat admin tidak perlu direview
+	product.Status = domain.ProductStatusActive
 	product.ID = uuid.New().String()
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
+	if err := u.prepareNewVariants(ctx, product); err != nil {
+		return err
+	}
+
+	if err := u.productRepo.Create(ctx, product, adminID); err != nil {
+		return err
+	}
+	u.auditCreatedProduct(ctx, adminID, product)
+	return nil
+}
 
-	return u.productRepo.Create(product)
+func (u *productUsecase) FindAll(ctx context.Context, page pagination.Params) ([]domain.Product, string, error) {
+	products, nextCursor, err := u.productRepo.FindAll(ctx, page)
+	markAvailability(products)
+	return products, nextCursor, err
 }
 
-func (u *productUsecase) FindAll() ([]domain.Product, error) {
-	return u.productRepo.FindAll()
+// markAvailability mengisi IsAvailable berdasarkan stok fisik untuk daftar katalog.
+// Halaman detail (FindByID) memakai AvailableStock yang sudah dikurangi reservasi.
+func markAvailability(products []domain.Product) {
+	for i := range products {
+		products[i].IsAvailable = products[i].HasStock()
+		for j := range products[i].Variants {
+			products[i].Variants[j].IsAvailable = products[i].Variants[j].Stock > 0
+		}
+	}
 }
 
-func (u *productUsecase) FindByID(id string) (*domain.Product, error) {
-	product, err := u.productRepo.FindByID(id)
+func (u *productUsecase) FindByID(ctx context.Context, id string) (*domain.Product, error) {
+	product, err := u.productRepo.FindByID(ctx, id)
 	if err == nil && product != nil && product.SupplierID != "" {
 		// [Fitur 40] Gamifikasi: Sisipkan agregasi skor Bintang (*Rating*) dari Repositori
-		product.SupplierRating = u.productRepo.GetSupplierRating(product.SupplierID)
+		product.SupplierRating = u.productRepo.GetSupplierRating(ctx, product.SupplierID)
+	}
+	if err == nil && product != nil {
+		// Stok yang ditampilkan ke pembeli tidak termasuk stok yang sedang ditahan pesanan belum dibayar
+		reserved, err := u.productRepo.GetReservedStock(ctx, product.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		available := product.Stock - reserved
+		product.AvailableStock = &available
+		product.IsAvailable = available > 0
+		for i := range product.Variants {
+			variantID := product.Variants[i].ID
+			variantReserved, err := u.productRepo.GetReservedStock(ctx, product.ID, &variantID)
+			if err != nil {
+				return nil, err
+			}
+			variantAvailable := product.Variants[i].Stock - variantReserved
+			product.Variants[i].AvailableStock = &variantAvailable
+			product.Variants[i].IsAvailable = variantAvailable > 0
+			if variantAvailable > 0 {
+				product.IsAvailable = true
+			}
+		}
 	}
 	return product, err
 }
 
-func (u *productUsecase) Update(id string, updateData *domain.Product) error {
-	existingProduct, err := u.productRepo.FindByID(id)
+func (u *productUsecase) Update(ctx context.Context, adminID string, id string, updateData *domain.Product) error {
+	existingProduct, err := u.productRepo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
+	oldStock, oldPrice := u.stockSnapshot(ctx, existingProduct.ID), existingProduct.Price
+	before := *existingProduct
+
 	if updateData.Name != "" {
 		existingProduct.Name = updateData.Name
 	}
+	if sku := strings.TrimSpace(updateData.SKU); sku != "" && sku != existingProduct.SKU {
+		if err := u.ensureUniqueProductSKU(ctx, existingProduct.SupplierID, sku, existingProduct.ID); err != nil {
+			return err
+		}
+		existingProduct.SKU = sku
+	}
 	if updateData.Description != "" {
 		existingProduct.Description = updateData.Description
 	}
@@ -67,9 +195,15 @@ func (u *productUsecase) Update(id string, updateData *domain.Product) error {
 		existingProduct.Price = updateData.Price
 	}
 	existingProduct.Stock = updateData.Stock
+	if updateData.AutoHideOutOfStockInput != nil {
+		existingProduct.AutoHideOutOfStock = *updateData.AutoHideOutOfStockInput
+	}
+	if updateData.LowStockThreshold > 0 {
+		existingProduct.LowStockThreshold = updateData.LowStockThreshold
+	}
 
 	if updateData.CategoryID != "" && updateData.CategoryID != existingProduct.CategoryID {
-		_, err := u.categoryRepo.FindByID(updateData.CategoryID)
+		_, err := u.categoryRepo.FindByID(ctx, updateData.CategoryID)
 		if err != nil {
 			return errors.New("invalid category_id")
 		}
@@ -77,43 +211,143 @@ func (u *productUsecase) Update(id string, updateData *domain.Product) error {
 	}
 
 	existingProduct.UpdatedAt = time.Now()
-	return u.productRepo.Update(existingProduct)
+	if err := u.productRepo.Update(ctx, existingProduct, adminID); err != nil {
+		return err
+	}
+	if existingProduct.Stock > existingProduct.LowStockThreshold {
+		// Sudah di-restock, notifikasi boleh dikirim lagi nanti. Kolom ini tidak ikut Update karena
+		// produk dari cache tidak membawa low_stock_alerted_at.
+		if err := u.productRepo.SetLowStockAlertedAt(ctx, existingProduct.ID, nil); err != nil {
+			u.logger.WarnContext(ctx, "gagal mereset penanda stok menipis", "product_id", existingProduct.ID, "error", err)
+		}
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, adminID, "UPDATE_PRODUCT", "products", existingProduct.ID, "", &before, existingProduct)
+
+	u.enqueueWishlistAlert(ctx, existingProduct, oldStock, oldPrice)
+	return nil
 }
 
-func (u *productUsecase) Delete(id string) error {
-	_, err := u.productRepo.FindByID(id)
+func (u *productUsecase) Delete(ctx context.Context, adminID string, id string) error {
+	existingProduct, err := u.productRepo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("produk tidak ditemukan")
 	}
-	return u.productRepo.Delete(id)
+	if err := u.productRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, adminID, "DELETE_PRODUCT", "products", id, "", existingProduct, nil)
+	return nil
+}
+
+// auditCreatedProduct mencatat produk baru beserta varian awalnya (titik pertama riwayat harga)
+func (u *productUsecase) auditCreatedProduct(ctx context.Context, actorID string, product *domain.Product) {
+	recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "CREATE_PRODUCT", "products", product.ID, "", nil, product)
+	for i := range product.Variants {
+		recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "CREATE_VARIANT", "product_variants", product.Variants[i].ID, product.ID, nil, &product.Variants[i])
+	}
 }
 
-func (u *productUsecase) Search(keyword string, categoryID string, limit int, offset int) ([]domain.Product, error) {
-	return u.productRepo.Search(keyword, categoryID, limit, offset)
+// validSearchSorts adalah opsi sort yang diterima dari query string
+var validSearchSorts = map[string]bool{
+	domain.SortRelevance:   true,
+	domain.SortNewest:      true,
+	domain.SortPriceAsc:    true,
+	domain.SortPriceDesc:   true,
+	domain.SortRating:      true,
+	domain.SortBestSelling: true,
 }
 
-func (u *productUsecase) FindBySupplierID(supplierID string) ([]domain.Product, error) {
-	return u.productRepo.FindBySupplierID(supplierID)
+// Search mengembalikan satu halaman hasil pencarian beserta total untuk paginasi dan facet kategori
+func (u *productUsecase) Search(ctx context.Context, filter domain.ProductSearchFilter) (*domain.ProductSearchResult, error) {
+	filter.Keyword = strings.TrimSpace(filter.Keyword)
+	if filter.Sort != "" && !validSearchSorts[filter.Sort] {
+		return nil, fmt.Errorf("%w: sort %s tidak valid", domain.ErrInvalidSearchFilter, filter.Sort)
+	}
+	if filter.MinPrice < 0 || filter.MaxPrice < 0 {
+		return nil, fmt.Errorf("%w: rentang harga tidak boleh negatif", domain.ErrInvalidSearchFilter)
+	}
+	if filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		return nil, fmt.Errorf("%w: min_price tidak boleh lebih besar dari max_price", domain.ErrInvalidSearchFilter)
+	}
+	if filter.MinRating < 0 || filter.MinRating > 5 {
+		return nil, fmt.Errorf("%w: min_rating harus di antara 0 dan 5", domain.ErrInvalidSearchFilter)
+	}
+
+	products, err := u.productRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := u.productRepo.CountSearch(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	facets, err := u.productRepo.CategoryFacets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hanya keyword yang menghasilkan produk (di halaman pertama) yang dicatat,
+	// supaya salah ketik tidak ikut muncul sebagai saran pencarian populer
+	if u.queryRepo != nil && filter.Offset == 0 && total > 0 {
+		if query := normalizeSearchQuery(filter.Keyword); query != "" {
+			if err := u.queryRepo.RecordQuery(ctx, query); err != nil {
+				u.logger.WarnContext(ctx, "gagal mencatat keyword pencarian", "query", query, "error", err)
+			}
+		}
+	}
+
+	markAvailability(products)
+	return &domain.ProductSearchResult{Products: products, Total: total, Facets: facets}, nil
+}
+
+func (u *productUsecase) FindBySupplierID(ctx context.Context, supplierID string) ([]domain.Product, error) {
+	products, err := u.productRepo.FindBySupplierID(ctx, supplierID)
+	markAvailability(products)
+	return products, err
 }
 
 // CreateBySupplier membuat produk dengan SupplierID otomatis di-set
-func (u *productUsecase) CreateBySupplier(supplierID string, product *domain.Product) error {
-	_, err := u.categoryRepo.FindByID(product.CategoryID)
+func (u *productUsecase) CreateBySupplier(ctx context.Context, supplierID string, product *domain.Product) error {
+	_, err := u.categoryRepo.FindByID(ctx, product.CategoryID)
 	if err != nil {
 		return errors.New("invalid category_id: kategori tidak ditemukan")
 	}
 
+	product.SKU = strings.TrimSpace(product.SKU)
+	if err := u.ensureUniqueProductSKU(ctx, supplierID, product.SKU, ""); err != nil {
+		return err
+	}
+
+	// Produk supplier selalu melewati review admin; supplier hanya boleh memilih menyimpannya sebagai draft
+	switch product.Status {
+	case "", domain.ProductStatusPendingReview:
+		product.Status = domain.ProductStatusPendingReview
+		now := time.Now()
+		product.SubmittedAt = &now
+	case domain.ProductStatusDraft:
+	default:
+		return errors.New("status produk baru hanya boleh DRAFT atau PENDING_REVIEW")
+	}
+	product.RejectionReason, product.ReviewedAt, product.ReviewedBy = "", nil, ""
+
 	product.ID = uuid.New().String()
 	product.SupplierID = supplierID
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
+	if err := u.prepareNewVariants(ctx, product); err != nil {
+		return err
+	}
 
-	return u.productRepo.Create(product)
+	if err := u.productRepo.Create(ctx, product, supplierID); err != nil {
+		return err
+	}
+	u.auditCreatedProduct(ctx, supplierID, product)
+	return nil
 }
 
 // UpdateBySupplier update produk milik supplier (ownership check)
-func (u *productUsecase) UpdateBySupplier(supplierID string, productID string, updateData *domain.Product) error {
-	existingProduct, err := u.productRepo.FindByID(productID)
+func (u *productUsecase) UpdateBySupplier(ctx context.Context, supplierID string, productID string, updateData *domain.Product) error {
+	existingProduct, err := u.productRepo.FindByID(ctx, productID)
 	if err != nil {
 		return err
 	}
@@ -123,9 +357,19 @@ func (u *productUsecase) UpdateBySupplier(supplierID string, productID string, u
 		return errors.New("akses ditolak: produk ini bukan milik anda")
 	}
 
+	oldStock, oldPrice := u.stockSnapshot(ctx, existingProduct.ID), existingProduct.Price
+	before := *existingProduct
+	oldContent := productContent(existingProduct)
+
 	if updateData.Name != "" {
 		existingProduct.Name = updateData.Name
 	}
+	if sku := strings.TrimSpace(updateData.SKU); sku != "" && sku != existingProduct.SKU {
+		if err := u.ensureUniqueProductSKU(ctx, existingProduct.SupplierID, sku, existingProduct.ID); err != nil {
+			return err
+		}
+		existingProduct.SKU = sku
+	}
 	if updateData.Description != "" {
 		existingProduct.Description = updateData.Description
 	}
@@ -136,22 +380,48 @@ func (u *productUsecase) UpdateBySupplier(supplierID string, productID string, u
 		existingProduct.Price = updateData.Price
 	}
 	existingProduct.Stock = updateData.Stock
+	if updateData.AutoHideOutOfStockInput != nil {
+		existingProduct.AutoHideOutOfStock = *updateData.AutoHideOutOfStockInput
+	}
+	if updateData.LowStockThreshold > 0 {
+		existingProduct.LowStockThreshold = updateData.LowStockThreshold
+	}
 
 	if updateData.CategoryID != "" && updateData.CategoryID != existingProduct.CategoryID {
-		_, err := u.categoryRepo.FindByID(updateData.CategoryID)
+		_, err := u.categoryRepo.FindByID(ctx, updateData.CategoryID)
 		if err != nil {
 			return errors.New("invalid category_id")
 		}
 		existingProduct.CategoryID = updateData.CategoryID
 	}
 
+	// Perubahan isi listing yang sudah tayang harus direview ulang; perubahan harga/stok tidak
+	if existingProduct.IsPublished() && productContent(existingProduct) != oldContent {
+		now := time.Now()
+		existingProduct.Status = domain.ProductStatusPendingReview
+		existingProduct.SubmittedAt = &now
+	}
+
 	existingProduct.UpdatedAt = time.Now()
-	return u.productRepo.Update(existingProduct)
+	if err := u.productRepo.Update(ctx, existingProduct, supplierID); err != nil {
+		return err
+	}
+	if existingProduct.Stock > existingProduct.LowStockThreshold {
+		// Sudah di-restock, notifikasi boleh dikirim lagi nanti. Kolom ini tidak ikut Update karena
+		// produk dari cache tidak membawa low_stock_alerted_at.
+		if err := u.productRepo.SetLowStockAlertedAt(ctx, existingProduct.ID, nil); err != nil {
+			u.logger.WarnContext(ctx, "gagal mereset penanda stok menipis", "product_id", existingProduct.ID, "error", err)
+		}
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, supplierID, "UPDATE_PRODUCT", "products", existingProduct.ID, "", &before, existingProduct)
+
+	u.enqueueWishlistAlert(ctx, existingProduct, oldStock, oldPrice)
+	return nil
 }
 
 // DeleteBySupplier hapus produk milik supplier (ownership check)
-func (u *productUsecase) DeleteBySupplier(supplierID string, productID string) error {
-	existingProduct, err := u.productRepo.FindByID(productID)
+func (u *productUsecase) DeleteBySupplier(ctx context.Context, supplierID string, productID string) error {
+	existingProduct, err := u.productRepo.FindByID(ctx, productID)
 	if err != nil {
 		return errors.New("produk tidak ditemukan")
 	}
@@ -160,5 +430,283 @@ func (u *productUsecase) DeleteBySupplier(supplierID string, productID string) e
 		return errors.New("akses ditolak: produk ini bukan milik anda")
 	}
 
-	return u.productRepo.Delete(productID)
+	if err := u.productRepo.Delete(ctx, productID); err != nil {
+		return err
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, supplierID, "DELETE_PRODUCT", "products", productID, "", existingProduct, nil)
+	return nil
+}
+
+// --- Manajemen Varian ---
+
+// authorizeProduct memuat produk dan memastikan supplier hanya mengelola varian produk miliknya
+func (u *productUsecase) authorizeProduct(ctx context.Context, role string, actorID string, productID string) (*domain.Product, error) {
+	return authorizeProductAccess(ctx, u.productRepo, role, actorID, productID)
+}
+
+// authorizeProductAccess dipakai bersama oleh usecase varian dan opsi produk
+func authorizeProductAccess(ctx context.Context, productRepo domain.ProductRepository, role string, actorID string, productID string) (*domain.Product, error) {
+	product, err := productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return nil, errors.New("produk tidak ditemukan")
+	}
+	if role != "admin" && product.SupplierID != actorID {
+		return nil, errors.New("akses ditolak: produk ini bukan milik anda")
+	}
+	return product, nil
+}
+
+// authorizeProductRead dipakai endpoint baca varian, opsi, dan galeri. Rute publik (tanpa role)
+// hanya melihat produk yang tayang, supplier hanya produk miliknya, admin seluruh produk.
+func authorizeProductRead(ctx context.Context, productRepo domain.ProductRepository, role string, actorID string, productID string) (*domain.Product, error) {
+	if role != "" {
+		return authorizeProductAccess(ctx, productRepo, role, actorID, productID)
+	}
+	product, err := productRepo.FindByID(ctx, productID)
+	if err != nil || !product.IsPublished() {
+		return nil, errors.New("produk tidak ditemukan")
+	}
+	return product, nil
+}
+
+func validateVariant(v *domain.ProductVariant) error {
+	v.NameLabel = strings.TrimSpace(v.NameLabel)
+	v.SKUCode = strings.TrimSpace(v.SKUCode)
+	if v.NameLabel == "" {
+		return errors.New("name_label varian wajib diisi")
+	}
+	if v.Price <= 0 {
+		return errors.New("harga varian harus lebih dari 0")
+	}
+	if v.Stock < 0 {
+		return errors.New("stok varian tidak boleh negatif")
+	}
+	return nil
+}
+
+func (u *productUsecase) ensureUniqueSKU(ctx context.Context, sku string, excludeVariantID string) error {
+	if sku == "" {
+		return nil
+	}
+	exists, err := u.productRepo.SKUExists(ctx, sku, excludeVariantID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("SKU %s sudah dipakai varian lain", sku)
+	}
+	return nil
+}
+
+// ensureUniqueProductSKU memastikan SKU produk belum dipakai produk lain milik supplier yang sama
+func (u *productUsecase) ensureUniqueProductSKU(ctx context.Context, supplierID string, sku string, excludeProductID string) error {
+	if sku == "" {
+		return nil
+	}
+	exists, err := u.productRepo.ProductSKUExists(ctx, supplierID, sku, excludeProductID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("SKU produk %s sudah dipakai produk lain", sku)
+	}
+	return nil
+}
+
+// prepareNewVariants memvalidasi dan memberi ID pada varian yang dikirim bersama produk baru
+func (u *productUsecase) prepareNewVariants(ctx context.Context, product *domain.Product) error {
+	seenSKU := map[string]bool{}
+	for i := range product.Variants {
+		v := &product.Variants[i]
+		if err := validateVariant(v); err != nil {
+			return err
+		}
+		if v.SKUCode != "" {
+			if seenSKU[v.SKUCode] {
+				return fmt.Errorf("SKU %s duplikat di dalam permintaan", v.SKUCode)
+			}
+			seenSKU[v.SKUCode] = true
+			if err := u.ensureUniqueSKU(ctx, v.SKUCode, ""); err != nil {
+				return err
+			}
+		}
+		v.ID = uuid.New().String()
+		v.ProductID = product.ID
+		v.CreatedAt = product.CreatedAt
+		v.UpdatedAt = product.CreatedAt
+	}
+	return nil
+}
+
+func (u *productUsecase) ListVariants(ctx context.Context, role string, actorID string, productID string) ([]domain.ProductVariant, error) {
+	if _, err := authorizeProductRead(ctx, u.productRepo, role, actorID, productID); err != nil {
+		return nil, err
+	}
+	return u.productRepo.FindVariantsByProductID(ctx, productID)
+}
+
+func (u *productUsecase) CreateVariant(ctx context.Context, role string, actorID string, productID string, variant *domain.ProductVariant) error {
+	if _, err := u.authorizeProduct(ctx, role, actorID, productID); err != nil {
+		return err
+	}
+	if err := validateVariant(variant); err != nil {
+		return err
+	}
+	if err := u.ensureUniqueSKU(ctx, variant.SKUCode, ""); err != nil {
+		return err
+	}
+
+	oldStock := u.stockSnapshot(ctx, productID)
+	variant.ID = uuid.New().String()
+	variant.ProductID = productID
+	variant.CreatedAt = time.Now()
+	variant.UpdatedAt = time.Now()
+	if err := u.productRepo.CreateVariant(ctx, variant, actorID); err != nil {
+		return err
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "CREATE_VARIANT", "product_variants", variant.ID, productID, nil, variant)
+	if err := requireReReview(ctx, u.logger, u.productRepo, u.auditLogRepo, role, actorID, productID); err != nil {
+		return err
+	}
+	u.enqueueVariantRestockAlert(ctx, productID, oldStock)
+	return nil
+}
+
+func (u *productUsecase) UpdateVariant(ctx context.Context, role string, actorID string, productID string, variantID string, variant *domain.ProductVariant) error {
+	if _, err := u.authorizeProduct(ctx, role, actorID, productID); err != nil {
+		return err
+	}
+	existing, err := u.productRepo.FindVariantByID(ctx, productID, variantID)
+	if err != nil {
+		return err
+	}
+	before := *existing
+	if err := validateVariant(variant); err != nil {
+		return err
+	}
+	if err := u.ensureUniqueSKU(ctx, variant.SKUCode, variantID); err != nil {
+		return err
+	}
+
+	oldStock := u.stockSnapshot(ctx, productID)
+	variant.ID = variantID
+	variant.ProductID = productID
+	variant.UpdatedAt = time.Now()
+	if err := u.productRepo.UpdateVariant(ctx, variant, actorID); err != nil {
+		return err
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "UPDATE_VARIANT", "product_variants", variantID, productID, &before, variant)
+	if variant.NameLabel != before.NameLabel {
+		if err := requireReReview(ctx, u.logger, u.productRepo, u.auditLogRepo, role, actorID, productID); err != nil {
+			return err
+		}
+	}
+	u.enqueueVariantRestockAlert(ctx, productID, oldStock)
+	return nil
+}
+
+func (u *productUsecase) DeleteVariant(ctx context.Context, role string, actorID string, productID string, variantID string) error {
+	if _, err := u.authorizeProduct(ctx, role, actorID, productID); err != nil {
+		return err
+	}
+	existing, err := u.productRepo.FindVariantByID(ctx, productID, variantID)
+	if err != nil {
+		return err
+	}
+	before := *existing
+	if err := u.productRepo.DeleteVariant(ctx, productID, variantID, actorID); err != nil {
+		return err
+	}
+	recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "DELETE_VARIANT", "product_variants", variantID, productID, &before, nil)
+	return requireReReview(ctx, u.logger, u.productRepo, u.auditLogRepo, role, actorID, productID)
+}
+
+// ReplaceVariants menjalankan bulk replace. Varian dengan id_variant milik produk ini diperbarui,
+// varian tanpa id_variant dibuat baru, dan varian lama yang tidak disebut dihapus.
+func (u *productUsecase) ReplaceVariants(ctx context.Context, role string, actorID string, productID string, variants []domain.ProductVariant) ([]domain.ProductVariant, error) {
+	product, err := u.authorizeProduct(ctx, role, actorID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := u.productRepo.FindVariantsByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	currentIDs := map[string]bool{}
+	currentSKUs := map[string]bool{}
+	for _, v := range current {
+		currentIDs[v.ID] = true
+		if v.SKUCode != "" {
+			currentSKUs[v.SKUCode] = true
+		}
+	}
+
+	now := time.Now()
+	seenIDs := map[string]bool{}
+	seenSKU := map[string]bool{}
+	for i := range variants {
+		v := &variants[i]
+		if err := validateVariant(v); err != nil {
+			return nil, fmt.Errorf("varian ke-%d: %w", i+1, err)
+		}
+
+		if v.ID != "" {
+			if !currentIDs[v.ID] {
+				return nil, fmt.Errorf("varian %s bukan milik produk ini", v.ID)
+			}
+			if seenIDs[v.ID] {
+				return nil, fmt.Errorf("varian %s disebut lebih dari sekali", v.ID)
+			}
+			seenIDs[v.ID] = true
+		} else {
+			v.ID = uuid.New().String()
+			v.CreatedAt = now
+		}
+
+		if v.SKUCode != "" {
+			if seenSKU[v.SKUCode] {
+				return nil, fmt.Errorf("SKU %s duplikat di dalam permintaan", v.SKUCode)
+			}
+			seenSKU[v.SKUCode] = true
+			// SKU milik varian produk ini sendiri boleh dipakai ulang karena ikut diganti dalam transaksi yang sama
+			if !currentSKUs[v.SKUCode] {
+				if err := u.ensureUniqueSKU(ctx, v.SKUCode, ""); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		v.ProductID = product.ID
+		v.UpdatedAt = now
+	}
+
+	oldStock := u.stockSnapshot(ctx, productID)
+	if err := u.productRepo.ReplaceVariants(ctx, productID, variants, actorID); err != nil {
+		return nil, err
+	}
+
+	byID := map[string]domain.ProductVariant{}
+	for _, v := range current {
+		byID[v.ID] = v
+	}
+	for i := range variants {
+		if old, ok := byID[variants[i].ID]; ok {
+			recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "UPDATE_VARIANT", "product_variants", old.ID, productID, &old, &variants[i])
+		} else {
+			recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "CREATE_VARIANT", "product_variants", variants[i].ID, productID, nil, &variants[i])
+		}
+	}
+	for id, old := range byID {
+		if !seenIDs[id] {
+			recordAudit(ctx, u.logger, u.auditLogRepo, actorID, "DELETE_VARIANT", "product_variants", id, productID, &old, nil)
+		}
+	}
+	if variantContent(current) != variantContent(variants) {
+		if err := requireReReview(ctx, u.logger, u.productRepo, u.auditLogRepo, role, actorID, productID); err != nil {
+			return nil, err
+		}
+	}
+	u.enqueueVariantRestockAlert(ctx, productID, oldStock)
+	return variants, nil
 }
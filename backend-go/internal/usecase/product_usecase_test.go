@@ -1,62 +1,120 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 // --- Mock Product Repository ---
 type MockProductRepository struct {
-	products map[string]*domain.Product
+	products   map[string]*domain.Product
+	deleteErrs map[string]error // Key: id_variant, mensimulasikan penghapusan varian yang ditolak repository
 }
 
 func NewMockProductRepository() *MockProductRepository {
 	return &MockProductRepository{products: make(map[string]*domain.Product)}
 }
 
-func (m *MockProductRepository) Create(p *domain.Product) error {
+func (m *MockProductRepository) Create(ctx context.Context, p *domain.Product, actorID string) error {
 	m.products[p.ID] = p
 	return nil
 }
-func (m *MockProductRepository) FindAll() ([]domain.Product, error) {
+func (m *MockProductRepository) FindAll(ctx context.Context, page pagination.Params) ([]domain.Product, string, error) {
 	var result []domain.Product
 	for _, p := range m.products {
 		result = append(result, *p)
 	}
-	return result, nil
+	return result, "", nil
 }
-func (m *MockProductRepository) FindByID(id string) (*domain.Product, error) {
+func (m *MockProductRepository) FindByID(ctx context.Context, id string) (*domain.Product, error) {
 	p, ok := m.products[id]
 	if !ok {
 		return nil, errors.New("produk tidak ditemukan")
 	}
 	return p, nil
 }
-func (m *MockProductRepository) Update(p *domain.Product) error {
+func (m *MockProductRepository) Update(ctx context.Context, p *domain.Product, actorID string) error {
 	m.products[p.ID] = p
 	return nil
 }
-func (m *MockProductRepository) Delete(id string) error {
+func (m *MockProductRepository) Delete(ctx context.Context, id string) error {
 	delete(m.products, id)
 	return nil
 }
-func (m *MockProductRepository) Search(keyword string, categoryID string, limit int, offset int) ([]domain.Product, error) {
+func (m *MockProductRepository) Search(ctx context.Context, filter domain.ProductSearchFilter) ([]domain.Product, error) {
 	var result []domain.Product
 	for _, p := range m.products {
+		keyword := filter.Keyword
 		matchKeyword := keyword == "" ||
 			(len(p.Name) >= len(keyword) && p.Name[:len(keyword)] == keyword)
-		matchCategory := categoryID == "" || p.CategoryID == categoryID
-		if matchKeyword && matchCategory {
+		matchCategory := filter.CategoryID == "" || p.CategoryID == filter.CategoryID
+		matchStock := !filter.InStockOnly || p.HasStock()
+		matchPrice := (filter.MinPrice == 0 || p.Price >= filter.MinPrice) && (filter.MaxPrice == 0 || p.Price <= filter.MaxPrice)
+		if matchKeyword && matchCategory && matchStock && matchPrice {
 			result = append(result, *p)
 		}
 	}
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
 	return result, nil
 }
-func (m *MockProductRepository) GetSupplierRating(supplierID string) float64 { return 0 }
-func (m *MockProductRepository) FindBySupplierID(supplierID string) ([]domain.Product, error) {
+func (m *MockProductRepository) CountSearch(ctx context.Context, filter domain.ProductSearchFilter) (int64, error) {
+	filter.Limit = 0
+	result, _ := m.Search(context.Background(), filter)
+	return int64(len(result)), nil
+}
+func (m *MockProductRepository) CategoryFacets(ctx context.Context, filter domain.ProductSearchFilter) ([]domain.CategoryFacet, error) {
+	filter.CategoryID = ""
+	filter.Limit = 0
+	result, _ := m.Search(context.Background(), filter)
+	counts := map[string]int64{}
+	for _, p := range result {
+		counts[p.CategoryID]++
+	}
+	var facets []domain.CategoryFacet
+	for id, count := range counts {
+		facets = append(facets, domain.CategoryFacet{CategoryID: id, Count: count})
+	}
+	return facets, nil
+}
+func (m *MockProductRepository) FindByIDs(ctx context.Context, ids []string) ([]domain.Product, error) {
+	var result []domain.Product
+	for _, id := range ids {
+		if p, ok := m.products[id]; ok {
+			result = append(result, *p)
+		}
+	}
+	return result, nil
+}
+func (m *MockProductRepository) SetLowStockAlertedAt(ctx context.Context, productID string, alertedAt *time.Time) error {
+	if p, ok := m.products[productID]; ok {
+		p.LowStockAlertedAt = alertedAt
+	}
+	return nil
+}
+func (m *MockProductRepository) GetSupplierRating(ctx context.Context, supplierID string) float64 { return 0 }
+func (m *MockProductRepository) GetReservedStock(ctx context.Context, productID string, variantID *string) (int, error) {
+	return 0, nil
+}
+func (m *MockProductRepository) TotalStock(ctx context.Context, productID string) (int, error) {
+	p, ok := m.products[productID]
+	if !ok {
+		return 0, errors.New("produk tidak ditemukan")
+	}
+	total := p.Stock
+	for _, v := range p.Variants {
+		total += v.Stock
+	}
+	return total, nil
+}
+func (m *MockProductRepository) FindBySupplierID(ctx context.Context, supplierID string) ([]domain.Product, error) {
 	var result []domain.Product
 	for _, p := range m.products {
 		if p.SupplierID == supplierID {
@@ -66,31 +124,175 @@ func (m *MockProductRepository) FindBySupplierID(supplierID string) ([]domain.Pr
 	return result, nil
 }
 
+func (m *MockProductRepository) allVariants() []*domain.ProductVariant {
+	var result []*domain.ProductVariant
+	for _, p := range m.products {
+		for i := range p.Variants {
+			result = append(result, &p.Variants[i])
+		}
+	}
+	return result
+}
+func (m *MockProductRepository) FindVariantsByProductID(ctx context.Context, productID string) ([]domain.ProductVariant, error) {
+	if p, ok := m.products[productID]; ok {
+		return append([]domain.ProductVariant{}, p.Variants...), nil
+	}
+	return nil, nil
+}
+func (m *MockProductRepository) FindVariantByID(ctx context.Context, productID string, variantID string) (*domain.ProductVariant, error) {
+	for _, v := range m.allVariants() {
+		if v.ID == variantID && v.ProductID == productID {
+			return v, nil
+		}
+	}
+	return nil, errors.New("varian produk tidak ditemukan")
+}
+func (m *MockProductRepository) SKUExists(ctx context.Context, sku string, excludeVariantID string) (bool, error) {
+	for _, v := range m.allVariants() {
+		if v.SKUCode == sku && v.ID != excludeVariantID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (m *MockProductRepository) ProductSKUExists(ctx context.Context, supplierID string, sku string, excludeProductID string) (bool, error) {
+	for _, p := range m.products {
+		if p.SupplierID == supplierID && p.SKU == sku && p.ID != excludeProductID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (m *MockProductRepository) CreateVariant(ctx context.Context, v *domain.ProductVariant, actorID string) error {
+	p := m.products[v.ProductID]
+	p.Variants = append(p.Variants, *v)
+	return nil
+}
+func (m *MockProductRepository) UpdateVariant(ctx context.Context, v *domain.ProductVariant, actorID string) error {
+	for _, existing := range m.allVariants() {
+		if existing.ID == v.ID {
+			*existing = *v
+		}
+	}
+	return nil
+}
+func (m *MockProductRepository) DeleteVariant(ctx context.Context, productID string, variantID string, actorID string) error {
+	p := m.products[productID]
+	for i, v := range p.Variants {
+		if v.ID == variantID {
+			p.Variants = append(p.Variants[:i], p.Variants[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("varian produk tidak ditemukan")
+}
+func (m *MockProductRepository) ReplaceVariants(ctx context.Context, productID string, variants []domain.ProductVariant, actorID string) error {
+	m.products[productID].Variants = variants
+	return nil
+}
+// ApplyGeneratedVariants meniru transaksi repository asli: jika salah satu varian usang gagal
+// dihapus (deleteErrs), tidak ada perubahan yang tersimpan
+func (m *MockProductRepository) ApplyGeneratedVariants(ctx context.Context, productID string, created []domain.ProductVariant, removedIDs []string, actorID string) error {
+	for _, id := range removedIDs {
+		if err := m.deleteErrs[id]; err != nil {
+			return err
+		}
+	}
+	p := m.products[productID]
+	p.Variants = append(p.Variants, created...)
+	for _, id := range removedIDs {
+		if err := m.DeleteVariant(ctx, productID, id, actorID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (m *MockProductRepository) FindByStatus(ctx context.Context, status string, page pagination.Params) ([]domain.Product, string, error) {
+	var result []domain.Product
+	for _, p := range m.products {
+		if p.Status == status {
+			result = append(result, *p)
+		}
+	}
+	return result, "", nil
+}
+func (m *MockProductRepository) UpdateStatus(ctx context.Context, p *domain.Product) error {
+	m.products[p.ID] = p
+	return nil
+}
+
 // --- Mock Category Repository ---
 type MockCategoryRepositoryForProduct struct {
-	categories map[string]*domain.Category
+	categories    map[string]*domain.Category
+	productCounts map[string]int64 // Key: id_category
 }
 
 func NewMockCategoryRepositoryForProduct() *MockCategoryRepositoryForProduct {
-	return &MockCategoryRepositoryForProduct{categories: make(map[string]*domain.Category)}
+	return &MockCategoryRepositoryForProduct{categories: make(map[string]*domain.Category), productCounts: make(map[string]int64)}
 }
 
-func (m *MockCategoryRepositoryForProduct) Create(c *domain.Category) error {
+func (m *MockCategoryRepositoryForProduct) Create(ctx context.Context, c *domain.Category) error {
 	m.categories[c.ID] = c
 	return nil
 }
-func (m *MockCategoryRepositoryForProduct) FindAll() ([]domain.Category, error) {
-	return nil, nil
+func (m *MockCategoryRepositoryForProduct) FindAll(ctx context.Context) ([]domain.Category, error) {
+	var result []domain.Category
+	for _, c := range m.categories {
+		result = append(result, *c)
+	}
+	return result, nil
 }
-func (m *MockCategoryRepositoryForProduct) FindByID(id string) (*domain.Category, error) {
+func (m *MockCategoryRepositoryForProduct) FindByID(ctx context.Context, id string) (*domain.Category, error) {
 	c, ok := m.categories[id]
 	if !ok {
 		return nil, errors.New("kategori tidak ditemukan")
 	}
 	return c, nil
 }
-func (m *MockCategoryRepositoryForProduct) Update(c *domain.Category) error { return nil }
-func (m *MockCategoryRepositoryForProduct) Delete(id string) error            { return nil }
+func (m *MockCategoryRepositoryForProduct) FindBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	for _, c := range m.categories {
+		if c.Slug == slug {
+			return c, nil
+		}
+	}
+	return nil, errors.New("kategori tidak ditemukan")
+}
+func (m *MockCategoryRepositoryForProduct) FindDescendantIDs(ctx context.Context, id string) ([]string, error) {
+	ids := []string{id}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range m.categories {
+			if c.ParentID != nil && *c.ParentID == ids[i] {
+				ids = append(ids, c.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+func (m *MockCategoryRepositoryForProduct) SlugExists(ctx context.Context, slug string, excludeID string) (bool, error) {
+	for _, c := range m.categories {
+		if c.Slug == slug && c.ID != excludeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (m *MockCategoryRepositoryForProduct) CountChildren(ctx context.Context, id string) (int64, error) {
+	var count int64
+	for _, c := range m.categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			count++
+		}
+	}
+	return count, nil
+}
+func (m *MockCategoryRepositoryForProduct) CountProducts(ctx context.Context, id string) (int64, error) {
+	return m.productCounts[id], nil
+}
+func (m *MockCategoryRepositoryForProduct) Update(ctx context.Context, c *domain.Category) error {
+	m.categories[c.ID] = c
+	return nil
+}
+func (m *MockCategoryRepositoryForProduct) Delete(ctx context.Context, id string) error            { return nil }
 
 // --- Product CRUD Tests ---
 
@@ -99,7 +301,7 @@ func TestProductCreate_Success(t *testing.T) {
 	mockCategoryRepo := NewMockCategoryRepositoryForProduct()
 	mockCategoryRepo.categories["cat-1"] = &domain.Category{ID: "cat-1", Name: "Elektronik"}
 
-	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil)
+	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil, nil, nil, logger.Discard())
 
 	product := &domain.Product{
 		Name:        "Kangkung Segar",
@@ -109,7 +311,7 @@ func TestProductCreate_Success(t *testing.T) {
 		CategoryID:  "cat-1",
 	}
 
-	err := uc.Create(product)
+	err := uc.Create(context.Background(), "admin-1", product)
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
@@ -122,7 +324,7 @@ func TestProductCreate_InvalidCategory(t *testing.T) {
 	mockProductRepo := NewMockProductRepository()
 	mockCategoryRepo := NewMockCategoryRepositoryForProduct()
 
-	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil)
+	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil, nil, nil, logger.Discard())
 
 	product := &domain.Product{
 		Name:       "Laptop",
@@ -131,7 +333,7 @@ func TestProductCreate_InvalidCategory(t *testing.T) {
 		CategoryID: "non-existent-cat",
 	}
 
-	err := uc.Create(product)
+	err := uc.Create(context.Background(), "admin-1", product)
 	if err == nil {
 		t.Fatal("Expected error for invalid category, got success")
 	}
@@ -154,7 +356,7 @@ func TestProductUpdate_Success(t *testing.T) {
 		CreatedAt:  time.Now(),
 	}
 
-	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil)
+	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil, nil, nil, logger.Discard())
 
 	updateData := &domain.Product{
 		Name:  "Laptop Baru",
@@ -162,7 +364,7 @@ func TestProductUpdate_Success(t *testing.T) {
 		Stock: 8,
 	}
 
-	err := uc.Update("prod-1", updateData)
+	err := uc.Update(context.Background(), "admin-1", "prod-1", updateData)
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
@@ -180,9 +382,9 @@ func TestProductUpdate_NotFound(t *testing.T) {
 	mockProductRepo := NewMockProductRepository()
 	mockCategoryRepo := NewMockCategoryRepositoryForProduct()
 
-	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil)
+	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil, nil, nil, logger.Discard())
 
-	err := uc.Update("nonexistent", &domain.Product{Name: "X"})
+	err := uc.Update(context.Background(), "admin-1", "nonexistent", &domain.Product{Name: "X"})
 	if err == nil {
 		t.Fatal("Expected error for updating nonexistent product, got success")
 	}
@@ -192,9 +394,9 @@ func TestProductDelete_NotFound(t *testing.T) {
 	mockProductRepo := NewMockProductRepository()
 	mockCategoryRepo := NewMockCategoryRepositoryForProduct()
 
-	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil)
+	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil, nil, nil, logger.Discard())
 
-	err := uc.Delete("nonexistent")
+	err := uc.Delete(context.Background(), "admin-1", "nonexistent")
 	if err == nil {
 		t.Fatal("Expected error for deleting nonexistent product, got success")
 	}
@@ -213,14 +415,14 @@ func TestProductSearch_ByKeyword(t *testing.T) {
 	mockProductRepo.products["p2"] = &domain.Product{ID: "p2", Name: "Wortel Organik", CategoryID: "cat-2", Price: 12000}
 	mockProductRepo.products["p3"] = &domain.Product{ID: "p3", Name: "Kangkung Organik", CategoryID: "cat-1", Price: 7000}
 
-	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil)
+	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil, nil, nil, logger.Discard())
 
-	results, err := uc.Search("Kangkung", "", 10, 0)
+	results, err := uc.Search(context.Background(), domain.ProductSearchFilter{Keyword: "Kangkung", Limit: 10})
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
-	if len(results) != 2 {
-		t.Errorf("Expected 2 results for 'Kangkung', got %d", len(results))
+	if len(results.Products) != 2 || results.Total != 2 {
+		t.Errorf("Expected 2 results for 'Kangkung', got %d (total %d)", len(results.Products), results.Total)
 	}
 }
 
@@ -232,14 +434,14 @@ func TestProductSearch_ByKeywordAndCategory(t *testing.T) {
 	mockProductRepo.products["p2"] = &domain.Product{ID: "p2", Name: "Bayam Hijau", CategoryID: "cat-2"}
 	mockProductRepo.products["p3"] = &domain.Product{ID: "p3", Name: "Wortel", CategoryID: "cat-1"}
 
-	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil)
+	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil, nil, nil, logger.Discard())
 
-	results, err := uc.Search("Kangkung", "cat-1", 10, 0)
+	results, err := uc.Search(context.Background(), domain.ProductSearchFilter{Keyword: "Kangkung", CategoryID: "cat-1", Limit: 10})
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
-	if len(results) != 1 {
-		t.Errorf("Expected 1 result for 'Kangkung' in cat-1, got %d", len(results))
+	if len(results.Products) != 1 {
+		t.Errorf("Expected 1 result for 'Kangkung' in cat-1, got %d", len(results.Products))
 	}
 }
 
@@ -250,13 +452,173 @@ func TestProductSearch_EmptyKeyword_ReturnsAll(t *testing.T) {
 	mockProductRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Kangkung"}
 	mockProductRepo.products["p2"] = &domain.Product{ID: "p2", Name: "Wortel"}
 
-	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil)
+	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil, nil, nil, logger.Discard())
+
+	results, err := uc.Search(context.Background(), domain.ProductSearchFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if len(results.Products) != 2 {
+		t.Errorf("Expected 2 results for empty search, got %d", len(results.Products))
+	}
+}
+
+func TestProductSearch_InStockOnlyAndAvailability(t *testing.T) {
+	mockProductRepo := NewMockProductRepository()
+	mockCategoryRepo := NewMockCategoryRepositoryForProduct()
+	mockProductRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Kangkung", Stock: 0}
+	mockProductRepo.products["p2"] = &domain.Product{ID: "p2", Name: "Bayam", Stock: 0,
+		Variants: []domain.ProductVariant{{ID: "v1", NameLabel: "250g", Stock: 3}}}
+
+	uc := NewProductUsecase(mockProductRepo, mockCategoryRepo, nil, nil, nil, logger.Discard())
+
+	all, _ := uc.Search(context.Background(), domain.ProductSearchFilter{})
+	for _, p := range all.Products {
+		if p.ID == "p1" && p.IsAvailable {
+			t.Error("Expected out-of-stock product to be marked unavailable")
+		}
+		if p.ID == "p2" && !p.IsAvailable {
+			t.Error("Expected product with stocked variant to be available")
+		}
+	}
+
+	inStock, _ := uc.Search(context.Background(), domain.ProductSearchFilter{InStockOnly: true})
+	if len(inStock.Products) != 1 || inStock.Products[0].ID != "p2" {
+		t.Errorf("Expected only p2 when filtering in-stock, got %d results", len(inStock.Products))
+	}
+}
+
+func TestProductSearch_TotalFacetsAndValidation(t *testing.T) {
+	mockProductRepo := NewMockProductRepository()
+	mockProductRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Kangkung Segar", CategoryID: "cat-1", Price: 5000}
+	mockProductRepo.products["p2"] = &domain.Product{ID: "p2", Name: "Kangkung Organik", CategoryID: "cat-1", Price: 7000}
+	mockProductRepo.products["p3"] = &domain.Product{ID: "p3", Name: "Kangkung Hidroponik", CategoryID: "cat-2", Price: 9000}
 
-	results, err := uc.Search("", "", 10, 0)
+	uc := NewProductUsecase(mockProductRepo, NewMockCategoryRepositoryForProduct(), nil, nil, nil, logger.Discard())
+
+	// SQA CHECK: total harus menghitung seluruh hasil, bukan hanya halaman saat ini
+	result, err := uc.Search(context.Background(), domain.ProductSearchFilter{Keyword: "Kangkung", CategoryID: "cat-1", Limit: 1})
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
-	if len(results) != 2 {
-		t.Errorf("Expected 2 results for empty search, got %d", len(results))
+	if len(result.Products) != 1 || result.Total != 2 {
+		t.Errorf("Expected 1 product on page with total 2, got %d (total %d)", len(result.Products), result.Total)
+	}
+	// Facet mengabaikan filter kategori agar kategori lain tetap bisa dipilih
+	if len(result.Facets) != 2 {
+		t.Errorf("Expected facets for 2 categories, got %d", len(result.Facets))
+	}
+
+	result, _ = uc.Search(context.Background(), domain.ProductSearchFilter{MinPrice: 6000, MaxPrice: 8000})
+	if result.Total != 1 {
+		t.Errorf("Expected 1 product in price range, got %d", result.Total)
+	}
+
+	if _, err := uc.Search(context.Background(), domain.ProductSearchFilter{Sort: "cheapest"}); !errors.Is(err, domain.ErrInvalidSearchFilter) {
+		t.Errorf("Expected ErrInvalidSearchFilter for invalid sort option, got %v", err)
+	}
+	if _, err := uc.Search(context.Background(), domain.ProductSearchFilter{MinPrice: 9000, MaxPrice: 1000}); !errors.Is(err, domain.ErrInvalidSearchFilter) {
+		t.Errorf("Expected ErrInvalidSearchFilter when min_price > max_price, got %v", err)
+	}
+}
+
+// --- Variant Management Tests ---
+
+func TestCreateVariant_OwnershipAndSKUUniqueness(t *testing.T) {
+	mockProductRepo := NewMockProductRepository()
+	mockProductRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Kangkung", SupplierID: "sup-1",
+		Variants: []domain.ProductVariant{{ID: "v1", ProductID: "p1", NameLabel: "250g", Price: 5000, SKUCode: "KK-250"}}}
+	uc := NewProductUsecase(mockProductRepo, NewMockCategoryRepositoryForProduct(), nil, nil, nil, logger.Discard())
+
+	err := uc.CreateVariant(context.Background(), "supplier", "sup-2", "p1", &domain.ProductVariant{NameLabel: "1 Kg", Price: 18000, Stock: 5})
+	if err == nil {
+		t.Error("Expected ownership error for another supplier's product")
+	}
+
+	err = uc.CreateVariant(context.Background(), "supplier", "sup-1", "p1", &domain.ProductVariant{NameLabel: "500g", Price: 9000, Stock: 5, SKUCode: "KK-250"})
+	if err == nil {
+		t.Error("Expected duplicate SKU to be rejected")
+	}
+
+	err = uc.CreateVariant(context.Background(), "supplier", "sup-1", "p1", &domain.ProductVariant{NameLabel: "500g", Price: 0, Stock: 5})
+	if err == nil {
+		t.Error("Expected zero price to be rejected")
+	}
+
+	// Admin boleh mengelola varian produk supplier mana pun
+	err = uc.CreateVariant(context.Background(), "admin", "admin-1", "p1", &domain.ProductVariant{NameLabel: "1 Kg", Price: 18000, Stock: 5, SKUCode: "KK-1000"})
+	if err != nil {
+		t.Fatalf("Expected admin to create variant, got %v", err)
+	}
+	if len(mockProductRepo.products["p1"].Variants) != 2 {
+		t.Errorf("Expected 2 variants, got %d", len(mockProductRepo.products["p1"].Variants))
+	}
+}
+
+func TestReplaceVariants_Validation(t *testing.T) {
+	mockProductRepo := NewMockProductRepository()
+	mockProductRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Kangkung", SupplierID: "sup-1",
+		Variants: []domain.ProductVariant{{ID: "v1", ProductID: "p1", NameLabel: "250g", Price: 5000, SKUCode: "KK-250"}}}
+	mockProductRepo.products["p2"] = &domain.Product{ID: "p2", Name: "Bayam", SupplierID: "sup-1",
+		Variants: []domain.ProductVariant{{ID: "v9", ProductID: "p2", NameLabel: "Ikat", Price: 3000, SKUCode: "BY-1"}}}
+	uc := NewProductUsecase(mockProductRepo, NewMockCategoryRepositoryForProduct(), nil, nil, nil, logger.Discard())
+
+	_, err := uc.ReplaceVariants(context.Background(), "supplier", "sup-1", "p1", []domain.ProductVariant{
+		{NameLabel: "A", Price: 1000, SKUCode: "X"}, {NameLabel: "B", Price: 1000, SKUCode: "X"},
+	})
+	if err == nil {
+		t.Error("Expected duplicate SKU inside the request to be rejected")
+	}
+
+	_, err = uc.ReplaceVariants(context.Background(), "supplier", "sup-1", "p1", []domain.ProductVariant{{ID: "v9", NameLabel: "A", Price: 1000}})
+	if err == nil {
+		t.Error("Expected variant of another product to be rejected")
+	}
+
+	_, err = uc.ReplaceVariants(context.Background(), "supplier", "sup-1", "p1", []domain.ProductVariant{{NameLabel: "A", Price: 1000, SKUCode: "BY-1"}})
+	if err == nil {
+		t.Error("Expected SKU used by another product to be rejected")
+	}
+
+	// SKU milik varian lama produk ini sendiri boleh dipakai ulang
+	variants, err := uc.ReplaceVariants(context.Background(), "supplier", "sup-1", "p1", []domain.ProductVariant{
+		{NameLabel: "250g Baru", Price: 5500, Stock: 3, SKUCode: "KK-250"},
+		{NameLabel: "1 Kg", Price: 18000, Stock: 2},
+	})
+	if err != nil {
+		t.Fatalf("Expected replace to succeed, got %v", err)
+	}
+	if len(variants) != 2 || variants[0].ID == "" || variants[0].ProductID != "p1" {
+		t.Errorf("Expected 2 new variants with IDs assigned, got %+v", variants)
+	}
+}
+
+func TestUpdateBySupplier_KeepsAutoHideWhenOmitted(t *testing.T) {
+	mockProductRepo := NewMockProductRepository()
+	alertedAt := time.Now()
+	mockProductRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Kangkung", SupplierID: "sup-1", Price: 5000, Stock: 2,
+		LowStockThreshold: 5, AutoHideOutOfStock: true, LowStockAlertedAt: &alertedAt}
+	uc := NewProductUsecase(mockProductRepo, NewMockCategoryRepositoryForProduct(), nil, nil, nil, logger.Discard())
+
+	// SQA CHECK: update tanpa auto_hide_out_of_stock tidak boleh mematikan pengaturan yang sudah ada
+	if err := uc.UpdateBySupplier(context.Background(), "sup-1", "p1", &domain.Product{Stock: 3}); err != nil {
+		t.Fatalf("UpdateBySupplier gagal: %v", err)
+	}
+	if !mockProductRepo.products["p1"].AutoHideOutOfStock {
+		t.Error("Expected auto_hide_out_of_stock to stay true when omitted")
+	}
+	if mockProductRepo.products["p1"].LowStockAlertedAt == nil {
+		t.Error("Expected low stock marker to stay while stock is still below the threshold")
+	}
+
+	hide := false
+	if err := uc.UpdateBySupplier(context.Background(), "sup-1", "p1", &domain.Product{Stock: 20, AutoHideOutOfStockInput: &hide}); err != nil {
+		t.Fatalf("UpdateBySupplier gagal: %v", err)
+	}
+	if mockProductRepo.products["p1"].AutoHideOutOfStock {
+		t.Error("Expected explicit auto_hide_out_of_stock=false to be applied")
+	}
+	if mockProductRepo.products["p1"].LowStockAlertedAt != nil {
+		t.Error("Expected restock above the threshold to reset the low stock marker")
 	}
 }
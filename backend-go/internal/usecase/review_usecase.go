@@ -1,16 +1,20 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
 	"github.com/nuryanfa/e-commerse-sqa/internal/repository"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 type ReviewUsecase interface {
-	AddReview(review *domain.Review) error
-	GetProductReviews(productID string) ([]domain.Review, error)
-	GetProductAverageRating(productID string) (float64, error)
+	AddReview(ctx context.Context, review *domain.Review) error
+	GetProductReviews(ctx context.Context, productID string, page pagination.Params) ([]domain.Review, string, error)
+	GetProductAverageRating(ctx context.Context, productID string) (float64, error)
+	// CountProductReviews menghitung seluruh ulasan produk, bukan hanya halaman yang sedang dimuat
+	CountProductReviews(ctx context.Context, productID string) (int64, error)
 }
 
 type reviewUsecase struct {
@@ -22,9 +26,9 @@ func NewReviewUsecase(rr repository.ReviewRepository, pr domain.ProductRepositor
 	return &reviewUsecase{reviewRepo: rr, productRepo: pr}
 }
 
-func (u *reviewUsecase) AddReview(review *domain.Review) error {
+func (u *reviewUsecase) AddReview(ctx context.Context, review *domain.Review) error {
 	// Verify product exists
-	_, err := u.productRepo.FindByID(review.ProductID)
+	_, err := u.productRepo.FindByID(ctx, review.ProductID)
 	if err != nil {
 		return errors.New("produk tidak ditemukan")
 	}
@@ -33,13 +37,17 @@ func (u *reviewUsecase) AddReview(review *domain.Review) error {
 		return errors.New("rating harus antara 1 sampai 5")
 	}
 
-	return u.reviewRepo.Create(review)
+	return u.reviewRepo.Create(ctx, review)
 }
 
-func (u *reviewUsecase) GetProductReviews(productID string) ([]domain.Review, error) {
-	return u.reviewRepo.GetByProductID(productID)
+func (u *reviewUsecase) GetProductReviews(ctx context.Context, productID string, page pagination.Params) ([]domain.Review, string, error) {
+	return u.reviewRepo.GetByProductID(ctx, productID, page)
 }
 
-func (u *reviewUsecase) GetProductAverageRating(productID string) (float64, error) {
-	return u.reviewRepo.GetAverageRating(productID)
+func (u *reviewUsecase) GetProductAverageRating(ctx context.Context, productID string) (float64, error) {
+	return u.reviewRepo.GetAverageRating(ctx, productID)
+}
+
+func (u *reviewUsecase) CountProductReviews(ctx context.Context, productID string) (int64, error) {
+	return u.reviewRepo.CountByProductID(ctx, productID)
 }
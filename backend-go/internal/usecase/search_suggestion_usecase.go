@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+)
+
+const (
+	minSuggestQueryLength = 2   // Satu huruf terlalu umum untuk menghasilkan saran yang berguna
+	maxSuggestQueryLength = 100 // Input lebih panjang dipotong
+	suggestionLimit       = 5   // Jumlah saran per kelompok (produk, kategori, pencarian populer)
+)
+
+// normalizeSearchQuery menyeragamkan keyword agar "Brokoli  Segar" dan "brokoli segar" dianggap sama
+func normalizeSearchQuery(query string) string {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if utf8.RuneCountInString(query) > maxSuggestQueryLength {
+		query = string([]rune(query)[:maxSuggestQueryLength])
+	}
+	return query
+}
+
+type searchSuggestionUsecase struct {
+	suggestionRepo domain.SearchSuggestionRepository
+}
+
+func NewSearchSuggestionUsecase(sRepo domain.SearchSuggestionRepository) domain.SearchSuggestionUsecase {
+	return &searchSuggestionUsecase{suggestionRepo: sRepo}
+}
+
+func (u *searchSuggestionUsecase) Suggest(ctx context.Context, query string) (*domain.SearchSuggestions, error) {
+	query = normalizeSearchQuery(query)
+	if utf8.RuneCountInString(query) < minSuggestQueryLength {
+		return &domain.SearchSuggestions{
+			Products:   []domain.ProductSuggestion{},
+			Categories: []domain.CategorySuggestion{},
+			Queries:    []string{},
+		}, nil
+	}
+	return u.suggestionRepo.Suggest(ctx, query, suggestionLimit)
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+)
+
+// --- Mock Search Suggestion Repository ---
+
+type MockSearchSuggestionRepository struct {
+	suggestCalls []string
+	recorded     map[string]int64
+}
+
+func NewMockSearchSuggestionRepository() *MockSearchSuggestionRepository {
+	return &MockSearchSuggestionRepository{recorded: make(map[string]int64)}
+}
+
+func (m *MockSearchSuggestionRepository) Suggest(ctx context.Context, query string, limit int) (*domain.SearchSuggestions, error) {
+	m.suggestCalls = append(m.suggestCalls, query)
+	return &domain.SearchSuggestions{Queries: []string{query}}, nil
+}
+
+func (m *MockSearchSuggestionRepository) RecordQuery(ctx context.Context, query string) error {
+	m.recorded[query]++
+	return nil
+}
+
+func TestSuggest_NormalizesAndSkipsShortQueries(t *testing.T) {
+	repo := NewMockSearchSuggestionRepository()
+	uc := NewSearchSuggestionUsecase(repo)
+
+	result, err := uc.Suggest(context.Background(), "  b ")
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if len(repo.suggestCalls) != 0 || result.Products == nil {
+		t.Error("Expected empty (non-nil) suggestions without querying repository for 1-char input")
+	}
+
+	if _, err := uc.Suggest(context.Background(), "  Brocoli   SEGAR "); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if len(repo.suggestCalls) != 1 || repo.suggestCalls[0] != "brocoli segar" {
+		t.Errorf("Expected normalized query 'brocoli segar', got %v", repo.suggestCalls)
+	}
+}
+
+func TestProductSearch_RecordsOnlyQueriesWithResults(t *testing.T) {
+	productRepo := NewMockProductRepository()
+	productRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Brokoli Segar", CategoryID: "cat-1"}
+	queryRepo := NewMockSearchSuggestionRepository()
+	uc := NewProductUsecase(productRepo, NewMockCategoryRepositoryForProduct(), nil, nil, queryRepo, logger.Discard())
+
+	_, _ = uc.Search(context.Background(), domain.ProductSearchFilter{Keyword: "Brokoli"})
+	_, _ = uc.Search(context.Background(), domain.ProductSearchFilter{Keyword: "Brokoli", Offset: 20}) // Halaman berikutnya tidak dihitung ulang
+	_, _ = uc.Search(context.Background(), domain.ProductSearchFilter{Keyword: "Brocoli"})             // Salah ketik tanpa hasil
+
+	if queryRepo.recorded["brokoli"] != 1 {
+		t.Errorf("Expected 'brokoli' recorded once, got %d", queryRepo.recorded["brokoli"])
+	}
+	if _, ok := queryRepo.recorded["brocoli"]; ok {
+		t.Error("Expected query without results not to be recorded")
+	}
+}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"time"
@@ -15,19 +16,21 @@ import (
 // Prinsip Clean Architecture: interface milik domain, bukan usecase layer.
 type userUsecase struct {
 	userRepo domain.UserRepository
+	tokens   *jwt.Manager
 }
 
 // NewUserUsecase creates a new usecase instance
-func NewUserUsecase(repo domain.UserRepository) domain.UserUsecase {
+func NewUserUsecase(repo domain.UserRepository, tokens *jwt.Manager) domain.UserUsecase {
 	return &userUsecase{
 		userRepo: repo,
+		tokens:   tokens,
 	}
 }
 
-func (u *userUsecase) Register(user *domain.User) error {
+func (u *userUsecase) Register(ctx context.Context, user *domain.User) error {
 	user.Email = strings.ToLower(user.Email)
 	// 1. Check if email already exists
-	existingUser, _ := u.userRepo.FindByEmail(user.Email)
+	existingUser, _ := u.userRepo.FindByEmail(ctx, user.Email)
 	if existingUser != nil {
 		return errors.New("email sudah terdaftar")
 	}
@@ -48,13 +51,13 @@ func (u *userUsecase) Register(user *domain.User) error {
 	user.UpdatedAt = time.Now()
 
 	// 4. Save to database
-	return u.userRepo.Create(user)
+	return u.userRepo.Create(ctx, user)
 }
 
-func (u *userUsecase) Login(email, pass string) (string, string, error) {
+func (u *userUsecase) Login(ctx context.Context, email, pass string) (string, string, error) {
 	email = strings.ToLower(email)
 	// 1. Find user by email
-	user, err := u.userRepo.FindByEmail(email)
+	user, err := u.userRepo.FindByEmail(ctx, email)
 	if err != nil {
 		return "", "", errors.New("email atau password salah")
 	}
@@ -65,12 +68,12 @@ func (u *userUsecase) Login(email, pass string) (string, string, error) {
 	}
 
 	// 3. Generate JWT Token
-	token, err := jwt.GenerateToken(user.ID, user.Role)
+	token, err := u.tokens.GenerateToken(user.ID, user.Role)
 	return token, user.Role, err
 }
 
-func (u *userUsecase) UpdateProfile(userID, name, phone, address string) error {
-	user, err := u.userRepo.FindByID(userID)
+func (u *userUsecase) UpdateProfile(ctx context.Context, userID, name, phone, address string) error {
+	user, err := u.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return errors.New("pengguna tidak ditemukan")
 	}
@@ -81,5 +84,5 @@ func (u *userUsecase) UpdateProfile(userID, name, phone, address string) error {
 	user.UpdatedAt = time.Now()
 
 	// We can add Update to UserRepository.
-	return u.userRepo.Update(user)
+	return u.userRepo.Update(ctx, user)
 }
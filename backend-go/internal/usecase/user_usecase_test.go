@@ -1,11 +1,13 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/jwt"
 	"github.com/nuryanfa/e-commerse-sqa/pkg/password"
 )
 
@@ -20,7 +22,7 @@ func NewMockUserRepository() *MockUserRepository {
 	}
 }
 
-func (m *MockUserRepository) Create(u *domain.User) error {
+func (m *MockUserRepository) Create(ctx context.Context, u *domain.User) error {
 	if u.Email == "error@example.com" {
 		return errors.New("db error")
 	}
@@ -28,14 +30,14 @@ func (m *MockUserRepository) Create(u *domain.User) error {
 	return nil
 }
 
-func (m *MockUserRepository) FindByEmail(email string) (*domain.User, error) {
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
 	if u, exists := m.users[email]; exists {
 		return u, nil
 	}
 	return nil, errors.New("not found")
 }
 
-func (m *MockUserRepository) FindByID(id string) (*domain.User, error) {
+func (m *MockUserRepository) FindByID(ctx context.Context, id string) (*domain.User, error) {
 	for _, u := range m.users {
 		if u.ID == id {
 			return u, nil
@@ -44,7 +46,7 @@ func (m *MockUserRepository) FindByID(id string) (*domain.User, error) {
 	return nil, errors.New("not found")
 }
 
-func (m *MockUserRepository) Update(u *domain.User) error {
+func (m *MockUserRepository) Update(ctx context.Context, u *domain.User) error {
 	if u.Email == "error@example.com" {
 		return errors.New("db error")
 	}
@@ -56,7 +58,7 @@ func (m *MockUserRepository) Update(u *domain.User) error {
 
 func TestRegister_Success(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	usecase := NewUserUsecase(mockRepo)
+	usecase := NewUserUsecase(mockRepo, jwt.NewManager("test-secret", time.Hour))
 
 	req := &domain.User{
 		Nama:     "Tester",
@@ -64,14 +66,14 @@ func TestRegister_Success(t *testing.T) {
 		Password: "password123",
 	}
 
-	err := usecase.Register(req)
+	err := usecase.Register(context.Background(), req)
 	
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
 	// Verify User saved
-	savedUser, err := mockRepo.FindByEmail("test@example.com")
+	savedUser, err := mockRepo.FindByEmail(context.Background(), "test@example.com")
 	if err != nil || savedUser == nil {
 		t.Fatalf("Expected user to be saved, got err: %v", err)
 	}
@@ -107,7 +109,7 @@ func TestRegister_DuplicateEmail(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 
-	usecase := NewUserUsecase(mockRepo)
+	usecase := NewUserUsecase(mockRepo, jwt.NewManager("test-secret", time.Hour))
 
 	req := &domain.User{
 		Nama:     "Tester 2",
@@ -115,7 +117,7 @@ func TestRegister_DuplicateEmail(t *testing.T) {
 		Password: "password123",
 	}
 
-	err := usecase.Register(req)
+	err := usecase.Register(context.Background(), req)
 
 	if err == nil {
 		t.Fatalf("Expected error for duplicate email, got nil")
@@ -139,9 +141,9 @@ func TestLogin_WrongPassword(t *testing.T) {
 		Role:      "pembeli",
 	}
 
-	usecase := NewUserUsecase(mockRepo)
+	usecase := NewUserUsecase(mockRepo, jwt.NewManager("test-secret", time.Hour))
 
-	_, _, err := usecase.Login("test@example.com", "wrongpass")
+	_, _, err := usecase.Login(context.Background(), "test@example.com", "wrongpass")
 
 	if err == nil {
 		t.Fatalf("Expected login to fail, got success")
@@ -1,57 +1,132 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"log/slog"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
 	"github.com/nuryanfa/e-commerse-sqa/internal/repository"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
 )
 
 type WishlistUsecase interface {
-	ToggleWishlist(userID, productID string) (bool, error)
-	GetMyWishlist(userID string) ([]domain.Wishlist, error)
-	CheckIsWishlisted(userID, productID string) (bool, error)
+	ToggleWishlist(ctx context.Context, userID, productID string) (bool, error)
+	GetMyWishlist(ctx context.Context, userID string, page pagination.Params) ([]domain.Wishlist, string, error)
+	CheckIsWishlisted(ctx context.Context, userID, productID string) (bool, error)
+	// ProcessWishlistAlert dipanggil worker antrian untuk mengirim notifikasi restock / turun harga
+	ProcessWishlistAlert(ctx context.Context, job domain.WishlistAlertJob) (int, error)
 }
 
+// wishlistAlertThrottle: satu pengguna maksimal menerima satu notifikasi per produk dalam jendela ini
+const wishlistAlertThrottle = 24 * time.Hour
+
 type wishlistUsecase struct {
 	wishlistRepo repository.WishlistRepository
 	productRepo  domain.ProductRepository
+	emailSvc     domain.EmailService
+	logger       *slog.Logger
 }
 
-func NewWishlistUsecase(wr repository.WishlistRepository, pr domain.ProductRepository) WishlistUsecase {
-	return &wishlistUsecase{wishlistRepo: wr, productRepo: pr}
+func NewWishlistUsecase(wr repository.WishlistRepository, pr domain.ProductRepository, emailSvc domain.EmailService, logger *slog.Logger) WishlistUsecase {
+	return &wishlistUsecase{wishlistRepo: wr, productRepo: pr, emailSvc: emailSvc, logger: logger}
 }
 
 // Returns true if added, false if removed
-func (u *wishlistUsecase) ToggleWishlist(userID, productID string) (bool, error) {
+func (u *wishlistUsecase) ToggleWishlist(ctx context.Context, userID, productID string) (bool, error) {
 	// Verify product exists
-	_, err := u.productRepo.FindByID(productID)
+	product, err := u.productRepo.FindByID(ctx, productID)
 	if err != nil {
 		return false, errors.New("produk tidak ditemukan")
 	}
 
-	exists, err := u.wishlistRepo.CheckExists(userID, productID)
+	exists, err := u.wishlistRepo.CheckExists(ctx, userID, productID)
 	if err != nil {
 		return false, err
 	}
 
 	if exists {
 		// Remove
-		return false, u.wishlistRepo.Remove(userID, productID)
+		return false, u.wishlistRepo.Remove(ctx, userID, productID)
 	} else {
-		// Add
+		// Add — produk yang belum/tidak lagi tayang tetap bisa dihapus dari wishlist, tapi tidak bisa ditambahkan
+		if !product.IsPublished() {
+			return false, errors.New("produk tidak ditemukan")
+		}
 		w := &domain.Wishlist{
-			UserID:    userID,
-			ProductID: productID,
+			ID:         uuid.New().String(),
+			UserID:     userID,
+			ProductID:  productID,
+			PriceAtAdd: product.Price,
+			CreatedAt:  time.Now(),
 		}
-		return true, u.wishlistRepo.Add(w)
+		return true, u.wishlistRepo.Add(ctx, w)
 	}
 }
 
-func (u *wishlistUsecase) GetMyWishlist(userID string) ([]domain.Wishlist, error) {
-	return u.wishlistRepo.GetByUser(userID)
+func (u *wishlistUsecase) GetMyWishlist(ctx context.Context, userID string, page pagination.Params) ([]domain.Wishlist, string, error) {
+	return u.wishlistRepo.GetByUser(ctx, userID, page)
+}
+
+func (u *wishlistUsecase) CheckIsWishlisted(ctx context.Context, userID, productID string) (bool, error) {
+	return u.wishlistRepo.CheckExists(ctx, userID, productID)
 }
 
-func (u *wishlistUsecase) CheckIsWishlisted(userID, productID string) (bool, error) {
-	return u.wishlistRepo.CheckExists(userID, productID)
+func (u *wishlistUsecase) ProcessWishlistAlert(ctx context.Context, job domain.WishlistAlertJob) (int, error) {
+	if !job.BackInStock && !job.PriceDropped {
+		return 0, nil
+	}
+
+	subscribers, err := u.wishlistRepo.FindSubscribers(ctx, job.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	if len(subscribers) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	alreadyNotified, err := u.wishlistRepo.FindNotifiedUserIDs(ctx, job.ProductID, now.Add(-wishlistAlertThrottle))
+	if err != nil {
+		return 0, err
+	}
+
+	notifType := "BACK_IN_STOCK"
+	if !job.BackInStock {
+		notifType = "PRICE_DROP"
+	}
+
+	sent := 0
+	for _, w := range subscribers {
+		if alreadyNotified[w.UserID] || w.User == nil {
+			continue
+		}
+		// Turun harga hanya relevan jika harga baru di bawah harga saat produk di-wishlist.
+		// Entri lama (PriceAtAdd = 0) tetap diberi tahu.
+		if !job.BackInStock && w.PriceAtAdd > 0 && job.NewPrice >= w.PriceAtAdd {
+			continue
+		}
+
+		if u.emailSvc != nil {
+			if err := u.emailSvc.SendWishlistAlertEmail(w.User.Email, job); err != nil {
+				u.logger.ErrorContext(ctx, "gagal mengirim notifikasi wishlist", "user_id", w.UserID, "product_id", job.ProductID, "error", err)
+				continue
+			}
+		}
+
+		_ = u.wishlistRepo.RecordNotification(ctx, &domain.WishlistNotification{
+			ID:        uuid.New().String(),
+			UserID:    w.UserID,
+			ProductID: job.ProductID,
+			Type:      notifType,
+			OldPrice:  job.OldPrice,
+			NewPrice:  job.NewPrice,
+			SentAt:    now,
+		})
+		alreadyNotified[w.UserID] = true
+		sent++
+	}
+	return sent, nil
 }
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/logger"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/pagination"
+)
+
+// MockWishlistRepository implements repository.WishlistRepository for unit testing
+type MockWishlistRepository struct {
+	entries       []domain.Wishlist
+	notifications []domain.WishlistNotification
+}
+
+func (m *MockWishlistRepository) Add(ctx context.Context, w *domain.Wishlist) error {
+	m.entries = append(m.entries, *w)
+	return nil
+}
+func (m *MockWishlistRepository) Remove(ctx context.Context, userID, productID string) error {
+	return nil
+}
+func (m *MockWishlistRepository) GetByUser(ctx context.Context, userID string, page pagination.Params) ([]domain.Wishlist, string, error) {
+	return nil, "", nil
+}
+func (m *MockWishlistRepository) CheckExists(ctx context.Context, userID, productID string) (bool, error) {
+	for _, w := range m.entries {
+		if w.UserID == userID && w.ProductID == productID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (m *MockWishlistRepository) FindSubscribers(ctx context.Context, productID string) ([]domain.Wishlist, error) {
+	var result []domain.Wishlist
+	for _, w := range m.entries {
+		if w.ProductID == productID {
+			result = append(result, w)
+		}
+	}
+	return result, nil
+}
+func (m *MockWishlistRepository) FindNotifiedUserIDs(ctx context.Context, productID string, since time.Time) (map[string]bool, error) {
+	notified := map[string]bool{}
+	for _, n := range m.notifications {
+		if n.ProductID == productID && !n.SentAt.Before(since) {
+			notified[n.UserID] = true
+		}
+	}
+	return notified, nil
+}
+func (m *MockWishlistRepository) RecordNotification(ctx context.Context, n *domain.WishlistNotification) error {
+	m.notifications = append(m.notifications, *n)
+	return nil
+}
+
+// mockAlertQueue menampung job tanpa worker agar bisa diperiksa langsung
+type mockAlertQueue struct {
+	jobs []domain.WishlistAlertJob
+}
+
+func (q *mockAlertQueue) Enqueue(job domain.WishlistAlertJob) { q.jobs = append(q.jobs, job) }
+
+func TestToggleWishlist_RecordsPriceAtAdd(t *testing.T) {
+	productRepo := NewMockProductRepository()
+	productRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Kangkung", Price: 5000}
+	wishlistRepo := &MockWishlistRepository{}
+
+	uc := NewWishlistUsecase(wishlistRepo, productRepo, nil, logger.Discard())
+	added, err := uc.ToggleWishlist(context.Background(), "user-1", "p1")
+	if err != nil || !added {
+		t.Fatalf("Expected product to be added, got added=%v err=%v", added, err)
+	}
+	if wishlistRepo.entries[0].PriceAtAdd != 5000 {
+		t.Errorf("Expected price_at_add 5000, got %.0f", wishlistRepo.entries[0].PriceAtAdd)
+	}
+}
+
+func TestUpdateProduct_EnqueuesBackInStockAndPriceDrop(t *testing.T) {
+	productRepo := NewMockProductRepository()
+	productRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Kangkung", Price: 5000, Stock: 0}
+	queue := &mockAlertQueue{}
+
+	uc := NewProductUsecase(productRepo, NewMockCategoryRepositoryForProduct(), nil, queue, nil, logger.Discard())
+	if err := uc.Update(context.Background(), "admin-1", "p1", &domain.Product{Price: 4000, Stock: 10}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(queue.jobs) != 1 || !queue.jobs[0].BackInStock || !queue.jobs[0].PriceDropped {
+		t.Fatalf("Expected one back-in-stock + price-drop job, got %+v", queue.jobs)
+	}
+
+	// Kenaikan harga tanpa perubahan stok dari nol tidak memicu notifikasi
+	_ = uc.Update(context.Background(), "admin-1", "p1", &domain.Product{Price: 6000, Stock: 8})
+	if len(queue.jobs) != 1 {
+		t.Errorf("Expected no new job for a price increase, got %d jobs", len(queue.jobs))
+	}
+}
+
+func TestProcessWishlistAlert_ThrottlesPerUserPerDay(t *testing.T) {
+	wishlistRepo := &MockWishlistRepository{entries: []domain.Wishlist{
+		{UserID: "u1", ProductID: "p1", PriceAtAdd: 5000, User: &domain.User{ID: "u1", Email: "u1@example.com"}},
+		{UserID: "u2", ProductID: "p1", PriceAtAdd: 3000, User: &domain.User{ID: "u2", Email: "u2@example.com"}},
+	}}
+	emailSvc := &MockEmailService{}
+	uc := NewWishlistUsecase(wishlistRepo, NewMockProductRepository(), emailSvc, logger.Discard())
+
+	job := domain.WishlistAlertJob{ProductID: "p1", ProductName: "Kangkung", PriceDropped: true, OldPrice: 5000, NewPrice: 4000}
+	sent, err := uc.ProcessWishlistAlert(context.Background(), job)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// u2 menambahkan saat harga 3000, jadi 4000 bukan penurunan baginya
+	if sent != 1 || emailSvc.WishlistAlerts[0] != "u1@example.com" {
+		t.Fatalf("Expected only u1 to be notified, got %v", emailSvc.WishlistAlerts)
+	}
+
+	job.BackInStock = true
+	sent, _ = uc.ProcessWishlistAlert(context.Background(), job)
+	if sent != 1 || emailSvc.WishlistAlerts[1] != "u2@example.com" {
+		t.Errorf("Expected only u2 (not yet notified today) to get the restock alert, got %v", emailSvc.WishlistAlerts)
+	}
+}
+
+// SQA CHECK: produk bervarian kembali tersedia saat stok salah satu varian naik dari nol,
+// walaupun stok dasar produk tetap 0
+func TestUpdateVariant_EnqueuesBackInStockFromVariantTotals(t *testing.T) {
+	productRepo := NewMockProductRepository()
+	productRepo.products["p1"] = &domain.Product{ID: "p1", Name: "Cabai", Price: 5000, SupplierID: "supplier-1", Status: domain.ProductStatusActive,
+		Variants: []domain.ProductVariant{{ID: "v1", ProductID: "p1", NameLabel: "250g", Price: 5000, Stock: 0}}}
+	queue := &mockAlertQueue{}
+	uc := NewProductUsecase(productRepo, NewMockCategoryRepositoryForProduct(), nil, queue, nil, logger.Discard())
+
+	err := uc.UpdateVariant(context.Background(), "supplier", "supplier-1", "p1", "v1", &domain.ProductVariant{NameLabel: "250g", Price: 5000, Stock: 7})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(queue.jobs) != 1 || !queue.jobs[0].BackInStock {
+		t.Fatalf("Expected one back-in-stock job after the variant restock, got %+v", queue.jobs)
+	}
+
+	// Produk dengan stok varian tersisa tidak dianggap kembali tersedia saat stok dasarnya diisi
+	if err := uc.Update(context.Background(), "admin-1", "p1", &domain.Product{Stock: 3}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(queue.jobs) != 1 {
+		t.Errorf("Expected no new job while variants already had stock, got %+v", queue.jobs)
+	}
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/metrics"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ProductImportProcessor adalah bagian dari ProductImportUsecase yang dibutuhkan worker
+type ProductImportProcessor interface {
+	ProcessImport(ctx context.Context, task domain.ImportTask) error
+}
+
+// ProductImportWorker menjalankan impor produk massal satu per satu di background agar file
+// berisi ratusan SKU tidak menahan request supplier
+type ProductImportWorker struct {
+	processor ProductImportProcessor
+	tasks     chan domain.ImportTask
+	logger    *slog.Logger
+}
+
+func NewProductImportWorker(queueSize int, logger *slog.Logger) *ProductImportWorker {
+	return &ProductImportWorker{
+		tasks:  make(chan domain.ImportTask, queueSize),
+		logger: logger.With("job", "product_import"),
+	}
+}
+
+// SetProcessor dipanggil dari main setelah usecase dibuat (usecase sendiri membutuhkan worker sebagai antrian)
+func (w *ProductImportWorker) SetProcessor(processor ProductImportProcessor) {
+	w.processor = processor
+}
+
+func (w *ProductImportWorker) Enqueue(task domain.ImportTask) bool {
+	select {
+	case w.tasks <- task:
+		return true
+	default:
+		w.logger.Warn("antrian impor produk penuh, job ditolak", "import_job_id", task.JobID)
+		return false
+	}
+}
+
+// Run memproses task sampai ctx dibatalkan (dipanggil sebagai goroutine dari main)
+func (w *ProductImportWorker) Run(ctx context.Context) {
+	w.logger.Info("worker aktif")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case task := <-w.tasks:
+			start := time.Now()
+			jobCtx, span := tracing.Start(ctx, "job.product_import", attribute.String("import_job.id", task.JobID))
+			err := w.processor.ProcessImport(jobCtx, task)
+			metrics.ObserveJob("product_import", start, err)
+			if err != nil {
+				w.logger.ErrorContext(jobCtx, "impor produk gagal", "import_job_id", task.JobID, "error", err)
+			} else {
+				w.logger.InfoContext(jobCtx, "impor produk selesai", "import_job_id", task.JobID, "duration_ms", time.Since(start).Milliseconds())
+			}
+			tracing.End(span, err)
+		}
+	}
+}
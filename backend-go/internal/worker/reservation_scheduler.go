@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/metrics"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ReservationScheduler melepas StockReservation tepat saat masa berlakunya habis.
+// Alih-alih polling per jam, scheduler tidur sampai reservasi ACTIVE terdekat kedaluwarsa,
+// dibatasi maxWait agar reservasi yang baru dibuat tetap terpantau.
+type ReservationScheduler struct {
+	orderUsecase domain.OrderUsecase
+	maxWait      time.Duration
+	logger       *slog.Logger
+}
+
+func NewReservationScheduler(uc domain.OrderUsecase, maxWait time.Duration, logger *slog.Logger) *ReservationScheduler {
+	return &ReservationScheduler{
+		orderUsecase: uc,
+		maxWait:      maxWait,
+		logger:       logger.With("job", "reservation_expiry"),
+	}
+}
+
+// Run memblokir sampai ctx dibatalkan (dipanggil sebagai goroutine dari main)
+func (s *ReservationScheduler) Run(ctx context.Context) {
+	s.logger.Info("scheduler aktif", "max_wait", s.maxWait.String())
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		jobCtx, span := tracing.Start(ctx, "job.reservation_expiry")
+		canceled, err := s.orderUsecase.ProcessCancelExpiredJobs(jobCtx)
+		span.SetAttributes(attribute.Int("job.canceled_orders", canceled))
+		metrics.ObserveJob("reservation_expiry", start, err)
+		if err != nil {
+			s.logger.ErrorContext(jobCtx, "gagal melepas reservasi stok kedaluwarsa", "error", err)
+		} else if canceled > 0 {
+			s.logger.InfoContext(jobCtx, "pesanan kedaluwarsa dibatalkan, reservasi stok dilepas", "canceled", canceled)
+		}
+		tracing.End(span, err)
+
+		timer.Reset(s.nextWait(ctx))
+	}
+}
+
+// nextWait menghitung durasi tidur sampai reservasi berikutnya kedaluwarsa
+func (s *ReservationScheduler) nextWait(ctx context.Context) time.Duration {
+	next, err := s.orderUsecase.NextReservationExpiry(ctx)
+	if err != nil || next == nil {
+		return s.maxWait
+	}
+
+	wait := time.Until(*next)
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > s.maxWait {
+		wait = s.maxWait
+	}
+	return wait
+}
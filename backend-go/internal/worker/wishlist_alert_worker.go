@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/metrics"
+	"github.com/nuryanfa/e-commerse-sqa/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WishlistAlertProcessor adalah bagian dari WishlistUsecase yang dibutuhkan worker
+type WishlistAlertProcessor interface {
+	ProcessWishlistAlert(ctx context.Context, job domain.WishlistAlertJob) (int, error)
+}
+
+// WishlistAlertWorker adalah antrian in-memory untuk notifikasi wishlist.
+// Enqueue tidak pernah memblokir request: jika antrian penuh, job dibuang dan dicatat di log.
+type WishlistAlertWorker struct {
+	processor WishlistAlertProcessor
+	jobs      chan domain.WishlistAlertJob
+	logger    *slog.Logger
+}
+
+func NewWishlistAlertWorker(processor WishlistAlertProcessor, queueSize int, logger *slog.Logger) *WishlistAlertWorker {
+	return &WishlistAlertWorker{
+		processor: processor,
+		jobs:      make(chan domain.WishlistAlertJob, queueSize),
+		logger:    logger.With("job", "wishlist_alert"),
+	}
+}
+
+func (w *WishlistAlertWorker) Enqueue(job domain.WishlistAlertJob) {
+	select {
+	case w.jobs <- job:
+	default:
+		w.logger.Warn("antrian notifikasi wishlist penuh, job dibuang", "product_id", job.ProductID)
+	}
+}
+
+// Run memproses job satu per satu sampai ctx dibatalkan (dipanggil sebagai goroutine dari main)
+func (w *WishlistAlertWorker) Run(ctx context.Context) {
+	w.logger.Info("worker aktif")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-w.jobs:
+			start := time.Now()
+			jobCtx, span := tracing.Start(ctx, "job.wishlist_alert", attribute.String("product.id", job.ProductID))
+			sent, err := w.processor.ProcessWishlistAlert(jobCtx, job)
+			metrics.ObserveJob("wishlist_alert", start, err)
+			if err != nil {
+				w.logger.ErrorContext(jobCtx, "gagal memproses notifikasi wishlist", "product_id", job.ProductID, "error", err)
+			} else if sent > 0 {
+				w.logger.InfoContext(jobCtx, "notifikasi wishlist terkirim", "product_id", job.ProductID, "sent", sent)
+			}
+			tracing.End(span, err)
+		}
+	}
+}
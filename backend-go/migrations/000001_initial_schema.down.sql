@@ -0,0 +1,14 @@
+-- Menghapus seluruh skema awal (semua data hilang). CASCADE ikut menghapus foreign key.
+DROP TABLE IF EXISTS "dispute_messages" CASCADE;
+DROP TABLE IF EXISTS "disputes" CASCADE;
+DROP TABLE IF EXISTS "audit_logs" CASCADE;
+DROP TABLE IF EXISTS "vouchers" CASCADE;
+DROP TABLE IF EXISTS "wishlists" CASCADE;
+DROP TABLE IF EXISTS "reviews" CASCADE;
+DROP TABLE IF EXISTS "order_items" CASCADE;
+DROP TABLE IF EXISTS "orders" CASCADE;
+DROP TABLE IF EXISTS "cart_items" CASCADE;
+DROP TABLE IF EXISTS "product_variants" CASCADE;
+DROP TABLE IF EXISTS "products" CASCADE;
+DROP TABLE IF EXISTS "categories" CASCADE;
+DROP TABLE IF EXISTS "users" CASCADE;
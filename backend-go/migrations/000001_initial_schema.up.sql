@@ -0,0 +1,198 @@
+-- Skema awal: sama persis dengan hasil AutoMigrate terakhir sebelum migrasi berversi diperkenalkan.
+-- Semua objek memakai IF NOT EXISTS agar database lama yang dibuat AutoMigrate dapat diadopsi
+-- tanpa kehilangan data (jalankan `go run ./cmd/migrate up` sekali). Kolom, tabel, dan indeks yang
+-- ditambahkan sesudahnya ada di migrasi berikutnya.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id_user" text,
+    "nama" text,
+    "email" varchar(255) NOT NULL,
+    "password" varchar(255) NOT NULL,
+    "role" varchar(50) NOT NULL,
+    "phone" varchar(20),
+    "address" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id_user"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "categories" (
+    "id_category" text,
+    "name" text,
+    "description" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id_category")
+);
+CREATE INDEX IF NOT EXISTS "idx_categories_deleted_at" ON "categories" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "products" (
+    "id_product" text,
+    "name" text,
+    "description" text,
+    "price" decimal,
+    "stock" bigint,
+    "id_category" text,
+    "supplier_id" text,
+    "image_url" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id_product"),
+    CONSTRAINT "fk_products_category" FOREIGN KEY ("id_category") REFERENCES "categories"("id_category"),
+    CONSTRAINT "fk_products_supplier" FOREIGN KEY ("supplier_id") REFERENCES "users"("id_user")
+);
+CREATE INDEX IF NOT EXISTS "idx_products_deleted_at" ON "products" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_products_supplier_id" ON "products" ("supplier_id");
+CREATE INDEX IF NOT EXISTS "idx_products_category_id" ON "products" ("id_category");
+CREATE INDEX IF NOT EXISTS "idx_products_name" ON "products" ("name");
+
+CREATE TABLE IF NOT EXISTS "product_variants" (
+    "id_variant" text,
+    "id_product" text,
+    "name_label" text,
+    "price" decimal,
+    "stock" bigint,
+    "sku_code" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id_variant"),
+    CONSTRAINT "fk_products_variants" FOREIGN KEY ("id_product") REFERENCES "products"("id_product")
+);
+
+CREATE TABLE IF NOT EXISTS "cart_items" (
+    "id_cart_item" text,
+    "id_user" text,
+    "id_product" text,
+    "id_variant" text,
+    "quantity" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id_cart_item"),
+    CONSTRAINT "fk_cart_items_variant" FOREIGN KEY ("id_variant") REFERENCES "product_variants"("id_variant"),
+    CONSTRAINT "fk_cart_items_product" FOREIGN KEY ("id_product") REFERENCES "products"("id_product")
+);
+
+CREATE TABLE IF NOT EXISTS "orders" (
+    "id_order" text,
+    "id_user" text,
+    "total_amount" decimal,
+    "status" text,
+    "courier_id" text,
+    "discount_amount" decimal DEFAULT 0,
+    "voucher_code" text,
+    "payment_token" text,
+    "payment_url" text,
+    "shipped_at" timestamptz,
+    "delivered_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id_order")
+);
+CREATE INDEX IF NOT EXISTS "idx_orders_deleted_at" ON "orders" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_orders_courier_id" ON "orders" ("courier_id");
+CREATE INDEX IF NOT EXISTS "idx_orders_status" ON "orders" ("status");
+CREATE INDEX IF NOT EXISTS "idx_orders_user_id" ON "orders" ("id_user");
+
+CREATE TABLE IF NOT EXISTS "order_items" (
+    "id_order_item" text,
+    "id_order" text,
+    "id_product" text,
+    "id_variant" text,
+    "quantity" bigint,
+    "price_at_purchase" decimal,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id_order_item"),
+    CONSTRAINT "fk_order_items_variant" FOREIGN KEY ("id_variant") REFERENCES "product_variants"("id_variant"),
+    CONSTRAINT "fk_orders_items" FOREIGN KEY ("id_order") REFERENCES "orders"("id_order"),
+    CONSTRAINT "fk_order_items_product" FOREIGN KEY ("id_product") REFERENCES "products"("id_product")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_items_product_id" ON "order_items" ("id_product");
+CREATE INDEX IF NOT EXISTS "idx_order_items_order_id" ON "order_items" ("id_order");
+
+CREATE TABLE IF NOT EXISTS "reviews" (
+    "id_review" text,
+    "id_product" text,
+    "id_user" text,
+    "rating" bigint,
+    "comment" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id_review"),
+    CONSTRAINT "fk_reviews_product" FOREIGN KEY ("id_product") REFERENCES "products"("id_product"),
+    CONSTRAINT "fk_reviews_user" FOREIGN KEY ("id_user") REFERENCES "users"("id_user")
+);
+
+CREATE TABLE IF NOT EXISTS "wishlists" (
+    "id_wishlist" text,
+    "id_user" text,
+    "id_product" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id_wishlist"),
+    CONSTRAINT "fk_wishlists_user" FOREIGN KEY ("id_user") REFERENCES "users"("id_user"),
+    CONSTRAINT "fk_wishlists_product" FOREIGN KEY ("id_product") REFERENCES "products"("id_product")
+);
+
+CREATE TABLE IF NOT EXISTS "vouchers" (
+    "id_voucher" text,
+    "code" text NOT NULL,
+    "discount_amount" decimal NOT NULL,
+    "min_purchase" decimal,
+    "expiry_date" timestamptz,
+    "usage_limit" bigint,
+    "used_count" bigint DEFAULT 0,
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id_voucher"),
+    CONSTRAINT "uni_vouchers_code" UNIQUE ("code")
+);
+
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+    "id_audit_log" text,
+    "id_user" text,
+    "action" text,
+    "entity" text,
+    "entity_id" text,
+    "old_values" json,
+    "new_values" json,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id_audit_log")
+);
+
+CREATE TABLE IF NOT EXISTS "disputes" (
+    "id_dispute" text,
+    "id_order" text,
+    "id_buyer" text,
+    "courier_id" text,
+    "reason" text,
+    "status" text,
+    "image_url" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "admin_note" text,
+    PRIMARY KEY ("id_dispute"),
+    CONSTRAINT "fk_disputes_order" FOREIGN KEY ("id_order") REFERENCES "orders"("id_order"),
+    CONSTRAINT "fk_disputes_buyer" FOREIGN KEY ("id_buyer") REFERENCES "users"("id_user"),
+    CONSTRAINT "fk_disputes_courier" FOREIGN KEY ("courier_id") REFERENCES "users"("id_user")
+);
+CREATE INDEX IF NOT EXISTS "idx_disputes_status" ON "disputes" ("status");
+CREATE INDEX IF NOT EXISTS "idx_disputes_courier_id" ON "disputes" ("courier_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_disputes_order_id" ON "disputes" ("id_order");
+
+CREATE TABLE IF NOT EXISTS "dispute_messages" (
+    "id_message" text,
+    "id_dispute" text,
+    "sender_id" text,
+    "message" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id_message"),
+    CONSTRAINT "fk_dispute_messages_dispute" FOREIGN KEY ("id_dispute") REFERENCES "disputes"("id_dispute") ON DELETE CASCADE,
+    CONSTRAINT "fk_dispute_messages_sender" FOREIGN KEY ("sender_id") REFERENCES "users"("id_user")
+);
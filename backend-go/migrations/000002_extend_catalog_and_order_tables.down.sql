@@ -0,0 +1,28 @@
+-- Menghapus kolom yang ditambahkan ke tabel skema awal (isi kolom tersebut hilang)
+ALTER TABLE "wishlists" DROP COLUMN IF EXISTS "price_at_add";
+
+DROP INDEX IF EXISTS "idx_orders_expires_at";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "expires_at";
+
+DROP INDEX IF EXISTS "idx_categories_parent_id";
+DROP INDEX IF EXISTS "idx_categories_slug";
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "icon_url";
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "sort_order";
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "slug";
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "parent_id";
+
+DROP INDEX IF EXISTS "idx_product_variants_sku";
+DROP INDEX IF EXISTS "idx_product_variants_deleted_at";
+ALTER TABLE "product_variants" DROP COLUMN IF EXISTS "deleted_at";
+
+DROP INDEX IF EXISTS "idx_products_supplier_sku";
+DROP INDEX IF EXISTS "idx_products_status";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "reviewed_by";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "reviewed_at";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "submitted_at";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "rejection_reason";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "status";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "low_stock_alerted_at";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "auto_hide_out_of_stock";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "low_stock_threshold";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "sku";
@@ -0,0 +1,41 @@
+-- Kolom baru di tabel skema awal: stok menipis & auto-hide, SKU supplier, moderasi produk,
+-- soft delete varian, kategori bertingkat, batas waktu pembayaran, dan harga saat masuk wishlist.
+-- Baris lama diisi nilai bawaan terlebih dahulu, baru kemudian indeks dibuat.
+
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "sku" text;
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "low_stock_threshold" bigint DEFAULT 5;
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "auto_hide_out_of_stock" boolean DEFAULT false;
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "low_stock_alerted_at" timestamptz;
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "status" text DEFAULT 'ACTIVE';
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "rejection_reason" text;
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "submitted_at" timestamptz;
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "reviewed_at" timestamptz;
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "reviewed_by" text;
+
+-- Produk yang sudah tayang sebelum moderasi ada tetap tayang
+UPDATE "products" SET "status" = 'ACTIVE' WHERE "status" IS NULL OR "status" = '';
+UPDATE "products" SET "low_stock_threshold" = 5 WHERE "low_stock_threshold" IS NULL;
+UPDATE "products" SET "auto_hide_out_of_stock" = false WHERE "auto_hide_out_of_stock" IS NULL;
+UPDATE "products" SET "sku" = '' WHERE "sku" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "idx_products_status" ON "products" ("status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_products_supplier_sku" ON "products" ("supplier_id","sku") WHERE sku <> '' AND deleted_at IS NULL;
+
+ALTER TABLE "product_variants" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_product_variants_deleted_at" ON "product_variants" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_product_variants_sku" ON "product_variants" ("sku_code") WHERE sku_code <> '' AND deleted_at IS NULL;
+
+ALTER TABLE "categories" ADD COLUMN IF NOT EXISTS "parent_id" text;
+ALTER TABLE "categories" ADD COLUMN IF NOT EXISTS "slug" text;
+ALTER TABLE "categories" ADD COLUMN IF NOT EXISTS "sort_order" bigint DEFAULT 0;
+ALTER TABLE "categories" ADD COLUMN IF NOT EXISTS "icon_url" text;
+UPDATE "categories" SET "sort_order" = 0 WHERE "sort_order" IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_categories_slug" ON "categories" ("slug") WHERE slug <> '' AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_categories_parent_id" ON "categories" ("parent_id");
+
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "expires_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_orders_expires_at" ON "orders" ("expires_at");
+
+-- Entri wishlist lama bernilai 0: tetap diberi tahu untuk setiap penurunan harga
+ALTER TABLE "wishlists" ADD COLUMN IF NOT EXISTS "price_at_add" decimal;
+UPDATE "wishlists" SET "price_at_add" = 0 WHERE "price_at_add" IS NULL;
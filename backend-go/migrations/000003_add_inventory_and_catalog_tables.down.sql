@@ -0,0 +1,11 @@
+-- Menghapus tabel yang ditambahkan sejak skema awal (semua datanya hilang)
+DROP TABLE IF EXISTS "dispute_return_items" CASCADE;
+DROP TABLE IF EXISTS "wishlist_notifications" CASCADE;
+DROP TABLE IF EXISTS "search_queries" CASCADE;
+DROP TABLE IF EXISTS "import_jobs" CASCADE;
+DROP TABLE IF EXISTS "product_images" CASCADE;
+DROP TABLE IF EXISTS "variant_option_values" CASCADE;
+DROP TABLE IF EXISTS "product_option_values" CASCADE;
+DROP TABLE IF EXISTS "product_options" CASCADE;
+DROP TABLE IF EXISTS "stock_movements" CASCADE;
+DROP TABLE IF EXISTS "stock_reservations" CASCADE;
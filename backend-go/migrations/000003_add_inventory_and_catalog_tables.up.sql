@@ -0,0 +1,151 @@
+-- Tabel baru sejak skema awal: reservasi dan ledger stok, opsi & matriks varian, galeri gambar,
+-- job impor massal, riwayat pencarian, notifikasi wishlist, dan barang retur sengketa.
+
+CREATE TABLE IF NOT EXISTS "stock_reservations" (
+    "id_reservation" text,
+    "id_order" text,
+    "id_order_item" text,
+    "id_product" text,
+    "id_variant" text,
+    "quantity" bigint,
+    "status" text,
+    "expires_at" timestamptz,
+    "released_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id_reservation")
+);
+CREATE INDEX IF NOT EXISTS "idx_stock_reservations_expires_at" ON "stock_reservations" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_stock_reservations_status" ON "stock_reservations" ("status");
+CREATE INDEX IF NOT EXISTS "idx_stock_reservations_variant_id" ON "stock_reservations" ("id_variant");
+CREATE INDEX IF NOT EXISTS "idx_stock_reservations_product_id" ON "stock_reservations" ("id_product");
+CREATE INDEX IF NOT EXISTS "idx_stock_reservations_order_id" ON "stock_reservations" ("id_order");
+
+CREATE TABLE IF NOT EXISTS "stock_movements" (
+    "id_movement" text,
+    "id_product" text,
+    "id_variant" text,
+    "delta" bigint,
+    "reason" text,
+    "id_order" text,
+    "id_dispute" text,
+    "id_user" text,
+    "balance_after" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id_movement")
+);
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_created_at" ON "stock_movements" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_order_id" ON "stock_movements" ("id_order");
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_reason" ON "stock_movements" ("reason");
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_variant_id" ON "stock_movements" ("id_variant");
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_product_id" ON "stock_movements" ("id_product");
+
+CREATE TABLE IF NOT EXISTS "product_options" (
+    "id_option" text,
+    "id_product" text,
+    "name" text,
+    "type" text,
+    "unit" text,
+    "position" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id_option"),
+    CONSTRAINT "fk_products_options" FOREIGN KEY ("id_product") REFERENCES "products"("id_product")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_options_product_id" ON "product_options" ("id_product");
+
+CREATE TABLE IF NOT EXISTS "product_option_values" (
+    "id_option_value" text,
+    "id_option" text,
+    "value" text,
+    "position" bigint,
+    PRIMARY KEY ("id_option_value"),
+    CONSTRAINT "fk_product_options_values" FOREIGN KEY ("id_option") REFERENCES "product_options"("id_option")
+);
+
+CREATE TABLE IF NOT EXISTS "variant_option_values" (
+    "id_variant" text,
+    "id_option_value" text,
+    PRIMARY KEY ("id_variant","id_option_value"),
+    CONSTRAINT "fk_variant_option_values_product_variant" FOREIGN KEY ("id_variant") REFERENCES "product_variants"("id_variant"),
+    CONSTRAINT "fk_variant_option_values_product_option_value" FOREIGN KEY ("id_option_value") REFERENCES "product_option_values"("id_option_value")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_option_values_option_id" ON "product_option_values" ("id_option");
+
+CREATE TABLE IF NOT EXISTS "product_images" (
+    "id_image" text,
+    "id_product" text,
+    "id_variant" text,
+    "position" bigint,
+    "alt_text" text,
+    "hash" text,
+    "width" bigint,
+    "height" bigint,
+    "thumb_url" text,
+    "medium_url" text,
+    "large_url" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id_image"),
+    CONSTRAINT "fk_products_images" FOREIGN KEY ("id_product") REFERENCES "products"("id_product")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_images_hash" ON "product_images" ("hash");
+CREATE INDEX IF NOT EXISTS "idx_product_images_variant_id" ON "product_images" ("id_variant");
+CREATE INDEX IF NOT EXISTS "idx_product_images_product_id" ON "product_images" ("id_product");
+
+CREATE TABLE IF NOT EXISTS "import_jobs" (
+    "id_job" text,
+    "supplier_id" text,
+    "status" text DEFAULT 'PENDING',
+    "dry_run" boolean,
+    "file_name" text,
+    "format" text,
+    "total_rows" bigint,
+    "created_count" bigint,
+    "updated_count" bigint,
+    "unchanged_count" bigint,
+    "error_count" bigint,
+    "errors" text,
+    "message" text,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id_job")
+);
+CREATE INDEX IF NOT EXISTS "idx_import_jobs_supplier_id" ON "import_jobs" ("supplier_id");
+
+CREATE TABLE IF NOT EXISTS "search_queries" (
+    "query" text,
+    "hits" bigint DEFAULT 1,
+    "last_searched_at" timestamptz,
+    PRIMARY KEY ("query")
+);
+
+CREATE TABLE IF NOT EXISTS "wishlist_notifications" (
+    "id_notification" text,
+    "id_user" text,
+    "id_product" text,
+    "type" text,
+    "old_price" decimal,
+    "new_price" decimal,
+    "sent_at" timestamptz,
+    PRIMARY KEY ("id_notification")
+);
+CREATE INDEX IF NOT EXISTS "idx_wishlist_notifications_sent_at" ON "wishlist_notifications" ("sent_at");
+CREATE INDEX IF NOT EXISTS "idx_wishlist_notif_user_product" ON "wishlist_notifications" ("id_user","id_product");
+
+CREATE TABLE IF NOT EXISTS "dispute_return_items" (
+    "id_return_item" text,
+    "id_dispute" text,
+    "id_order_item" text,
+    "id_product" text,
+    "id_variant" text,
+    "returned_quantity" bigint,
+    "restocked_quantity" bigint,
+    "written_off_quantity" bigint,
+    "inspected_by" text,
+    "note" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id_return_item"),
+    CONSTRAINT "fk_disputes_return_items" FOREIGN KEY ("id_dispute") REFERENCES "disputes"("id_dispute")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_dispute_return_item" ON "dispute_return_items" ("id_dispute","id_order_item");
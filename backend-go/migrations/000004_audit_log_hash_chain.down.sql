@@ -0,0 +1,19 @@
+-- Melepas trigger append-only dan kolom rantai hash audit_logs (hash dan konteks request hilang)
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only ON audit_logs;
+DROP FUNCTION IF EXISTS audit_logs_append_only();
+
+DROP INDEX IF EXISTS "idx_audit_logs_seq";
+DROP INDEX IF EXISTS "idx_audit_logs_user_id";
+DROP INDEX IF EXISTS "idx_audit_logs_action";
+DROP INDEX IF EXISTS "idx_audit_logs_parent_id";
+DROP INDEX IF EXISTS "idx_audit_logs_request_id";
+DROP INDEX IF EXISTS "idx_audit_logs_created_at";
+
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "hash";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "prev_hash";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "user_agent";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "ip_address";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "request_id";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "actor_role";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "parent_id";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "seq";
@@ -0,0 +1,85 @@
+-- Rantai hash dan konteks request audit_logs. Baris lama diberi seq (urut created_at) dan hash
+-- sebelum indeks unik seq dan trigger append-only dipasang. Hash dihitung persis seperti
+-- domain.AuditLog.ChainHash: SHA-256 dari setiap bagian diakhiri byte 0, created_at dalam UTC
+-- format RFC3339 dengan presisi mikrodetik tanpa nol di belakang.
+
+LOCK TABLE "audit_logs" IN EXCLUSIVE MODE;
+-- Trigger dari pemasangan sebelumnya (jika ada) dilepas agar backfill bisa UPDATE
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only ON audit_logs;
+
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "seq" bigint;
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "parent_id" text;
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "actor_role" text;
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "request_id" text;
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "ip_address" text;
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "user_agent" text;
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "prev_hash" varchar(64) NOT NULL DEFAULT '';
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "hash" varchar(64) NOT NULL DEFAULT '';
+
+-- seq setara bigserial: sequence milik kolom, baris lama dinomori urut waktu pembuatan
+CREATE SEQUENCE IF NOT EXISTS "audit_logs_seq_seq" OWNED BY "audit_logs"."seq";
+UPDATE "audit_logs" AS a SET "seq" = numbered.seq
+FROM (
+    SELECT "id_audit_log",
+        (SELECT COALESCE(MAX("seq"), 0) FROM "audit_logs") + row_number() OVER (ORDER BY "created_at", "id_audit_log") AS seq
+    FROM "audit_logs" WHERE "seq" IS NULL
+) AS numbered
+WHERE a."id_audit_log" = numbered."id_audit_log";
+SELECT setval('audit_logs_seq_seq', COALESCE(MAX("seq"), 0) + 1, false) FROM "audit_logs";
+ALTER TABLE "audit_logs" ALTER COLUMN "seq" SET DEFAULT nextval('audit_logs_seq_seq');
+ALTER TABLE "audit_logs" ALTER COLUMN "seq" SET NOT NULL;
+
+-- Hash hanya diisi jika belum ada baris berhash. Jika rantai sudah berjalan, baris lama di awal
+-- tabel dibiarkan sebagai legacy (lihat VerifyChain) karena hash baris pertama merujuk prev_hash kosong.
+DO $$
+DECLARE
+    r RECORD;
+    prev text := '';
+    part text;
+    created text;
+    frac text;
+    data bytea;
+BEGIN
+    IF EXISTS (SELECT 1 FROM audit_logs WHERE hash <> '') THEN
+        RETURN;
+    END IF;
+    FOR r IN SELECT * FROM audit_logs ORDER BY seq LOOP
+        IF r.created_at IS NULL THEN
+            created := '0001-01-01T00:00:00Z';
+        ELSE
+            created := to_char(r.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS');
+            frac := rtrim(to_char(r.created_at AT TIME ZONE 'UTC', 'US'), '0');
+            IF frac <> '' THEN
+                created := created || '.' || frac;
+            END IF;
+            created := created || 'Z';
+        END IF;
+
+        data := ''::bytea;
+        FOREACH part IN ARRAY ARRAY[prev, r.id_audit_log, r.id_user, r.action, r.entity, r.entity_id,
+                r.parent_id, r.old_values::text, r.new_values::text, created] LOOP
+            data := data || convert_to(COALESCE(part, ''), 'UTF8') || decode('00', 'hex');
+        END LOOP;
+
+        UPDATE audit_logs SET prev_hash = prev, hash = encode(sha256(data), 'hex')
+        WHERE id_audit_log = r.id_audit_log
+        RETURNING hash INTO prev;
+    END LOOP;
+END;
+$$;
+
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_created_at" ON "audit_logs" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_request_id" ON "audit_logs" ("request_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_parent_id" ON "audit_logs" ("parent_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_action" ON "audit_logs" ("action");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_user_id" ON "audit_logs" ("id_user");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_audit_logs_seq" ON "audit_logs" ("seq");
+
+-- audit_logs bersifat append-only: UPDATE dan DELETE ditolak, TRUNCATE (wipe_db lokal) tidak terpengaruh
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs bersifat append-only';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER trg_audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
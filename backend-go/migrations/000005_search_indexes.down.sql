@@ -0,0 +1,5 @@
+-- Ekstensi pg_trgm dibiarkan karena bisa dipakai objek lain di database
+DROP INDEX IF EXISTS idx_search_queries_query_trgm;
+DROP INDEX IF EXISTS idx_categories_name_trgm;
+DROP INDEX IF EXISTS idx_products_name_trgm;
+DROP INDEX IF EXISTS idx_products_search;
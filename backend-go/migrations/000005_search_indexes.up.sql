@@ -0,0 +1,10 @@
+-- Full-text search katalog (ekspresi harus identik dengan productSearchVector di product_repository.go)
+CREATE INDEX IF NOT EXISTS idx_products_search ON products USING GIN (
+    (setweight(to_tsvector('simple', coalesce(name, '')), 'A') || setweight(to_tsvector('simple', coalesce(description, '')), 'C'))
+);
+
+-- Autocomplete pencarian (pg_trgm)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_categories_name_trgm ON categories USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_search_queries_query_trgm ON search_queries USING GIN (query gin_trgm_ops);
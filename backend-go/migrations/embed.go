@@ -0,0 +1,8 @@
+// Package migrations berisi file migrasi SQL berversi yang di-embed ke binary.
+// Buat file baru dengan `go run ./cmd/migrate create <nama>`; jangan ubah file yang sudah dirilis.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
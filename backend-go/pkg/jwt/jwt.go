@@ -2,33 +2,32 @@ package jwt
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// getSecretKey returns the JWT secret from environment variables.
-// [B2] Fallback hardcoded key dihapus. JWT_SECRET WAJIB diisi di .env.
-// Jika tidak diset, server akan menghasilkan token yang tidak valid dan mudah ditolak.
-func getSecretKey() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		// Hanya untuk keperluan dev lokal tanpa .env, log peringatan keras
-		secret = "CHANGE-ME-SET-JWT_SECRET-IN-ENV"
-	}
-	return []byte(secret)
+// Manager signs and validates JWT tokens with the secret and expiry from config.Config.
+// [B2] Tidak ada fallback hardcoded di sini; config.Load menolak JWT_SECRET kosong di production.
+type Manager struct {
+	secret []byte
+	expiry time.Duration
+}
+
+// NewManager creates a token manager for the given HMAC secret and token lifetime.
+func NewManager(secret string, expiry time.Duration) *Manager {
+	return &Manager{secret: []byte(secret), expiry: expiry}
 }
 
-// GenerateToken creates a new JWT token for a user ID and role with a 24-hour expiration.
-func GenerateToken(userID, role string) (string, error) {
+// GenerateToken creates a new JWT token for a user ID and role that expires after the configured lifetime.
+func (m *Manager) GenerateToken(userID, role string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id": userID,
 		"role":    role,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"exp":     time.Now().Add(m.expiry).Unix(),
 	})
 
-	tokenString, err := token.SignedString(getSecretKey())
+	tokenString, err := token.SignedString(m.secret)
 	if err != nil {
 		return "", err
 	}
@@ -37,13 +36,13 @@ func GenerateToken(userID, role string) (string, error) {
 }
 
 // ValidateToken parses and validates a JWT token string.
-func ValidateToken(tokenString string) (jwt.MapClaims, error) {
+func (m *Manager) ValidateToken(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		// Validate the alg is what we expect
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return getSecretKey(), nil
+		return m.secret, nil
 	})
 
 	if err != nil {
@@ -0,0 +1,143 @@
+// Package logger membangun *slog.Logger aplikasi: JSON di production, teks di development,
+// dengan request id/pelaku dari context dan penyamaran field sensitif.
+//
+// Gunakan varian *Context (InfoContext, ErrorContext, ...) di jalur request agar request_id,
+// user_id, dan trace_id ikut tercatat.
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	// Redacted menggantikan nilai field sensitif
+	Redacted = "[REDACTED]"
+)
+
+// sensitiveKeys dicocokkan (case-insensitive, substring) dengan nama field log maupun key map
+var sensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie",
+	"signature", "api_key", "access_key", "server_key", "signing_key", "private_key",
+}
+
+// ParseLevel menerjemahkan debug | info | warn | error
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("level log tidak dikenal: %q (gunakan debug, info, warn, atau error)", s)
+	}
+	return level, nil
+}
+
+// New membuat logger yang menulis ke w dengan format json atau text
+func New(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// Discard adalah logger yang membuang semua output, untuk test dan dependency opsional
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// contextHandler menambahkan request_id dan user_id dari reqctx, serta trace_id dan span_id dari
+// span OpenTelemetry yang aktif, ke setiap record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	info := reqctx.From(ctx)
+	if info.RequestID != "" {
+		r.AddAttrs(slog.String("request_id", info.RequestID))
+	}
+	if info.ActorID != "" {
+		r.AddAttrs(slog.String("user_id", info.ActorID))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// IsSensitive melaporkan apakah nama field berisi kredensial yang tidak boleh ditulis ke log
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if IsSensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	if a.Value.Kind() == slog.KindAny {
+		switch v := a.Value.Any().(type) {
+		case map[string]interface{}:
+			return slog.Any(a.Key, RedactMap(v))
+		case map[string]string:
+			redacted := make(map[string]string, len(v))
+			for k, val := range v {
+				if IsSensitive(k) {
+					val = Redacted
+				}
+				redacted[k] = val
+			}
+			return slog.Any(a.Key, redacted)
+		}
+	}
+	return a
+}
+
+// RedactMap menyalin m (misalnya payload webhook) dengan nilai field sensitif disamarkan, termasuk map bersarang
+func RedactMap(m map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if IsSensitive(k) {
+			redacted[k] = Redacted
+			continue
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = RedactMap(nested)
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+// MaskEmail menyamarkan alamat email untuk log: hanya huruf pertama dan domain yang tersisa (b***@example.com)
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return Redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
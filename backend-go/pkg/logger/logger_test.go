@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/nuryanfa/e-commerse-sqa/pkg/reqctx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew_JSONIncludesRequestContext(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, slog.LevelInfo, FormatJSON)
+	ctx := reqctx.WithActor(reqctx.With(context.Background(), reqctx.Info{RequestID: "req-1"}), "user-1", "pembeli")
+
+	log.InfoContext(ctx, "pesanan dibuat", "order_id", "ord-1")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Output production harus JSON valid: %v (%s)", err, buf.String())
+	}
+	if record["request_id"] != "req-1" || record["user_id"] != "user-1" || record["order_id"] != "ord-1" {
+		t.Errorf("request_id, user_id, dan atribut harus tercatat, didapat %v", record)
+	}
+	if _, ok := record["trace_id"]; ok {
+		t.Errorf("trace_id hanya dicatat jika ada span aktif, didapat %v", record)
+	}
+}
+
+func TestNew_JSONIncludesTraceID(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, slog.LevelInfo, FormatJSON)
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled,
+	}))
+
+	log.ErrorContext(ctx, "gagal membuat Snap Token Midtrans")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["trace_id"] != traceID.String() || record["span_id"] != spanID.String() {
+		t.Errorf("trace_id dan span_id dari span aktif harus tercatat, didapat %v", record)
+	}
+}
+
+func TestNew_RedactsSensitiveFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, slog.LevelInfo, FormatJSON)
+
+	log.Info("webhook diterima",
+		"password", "rahasia123",
+		slog.Group("auth", "Authorization", "Bearer abc.def"),
+		"payload", map[string]interface{}{
+			"order_id":      "ord-1",
+			"signature_key": "sig-xyz",
+			"customer":      map[string]interface{}{"token": "tok-1"},
+		},
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"rahasia123", "abc.def", "sig-xyz", "tok-1"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("Nilai sensitif %q tidak boleh muncul di log: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "ord-1") {
+		t.Errorf("Field biasa tetap harus tercatat: %s", out)
+	}
+}
+
+func TestNew_LevelAndTextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, slog.LevelWarn, FormatText)
+
+	log.Info("tidak tercatat")
+	log.Warn("stok menipis", "product_id", "p-1")
+
+	out := buf.String()
+	if strings.Contains(out, "tidak tercatat") {
+		t.Error("Log di bawah level minimum harus dibuang")
+	}
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "product_id=p-1") {
+		t.Errorf("Format development harus teks key=value, didapat %s", out)
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Level tidak dikenal seharusnya ditolak")
+	}
+}
+
+func TestMaskEmail(t *testing.T) {
+	cases := map[string]string{
+		"budi@example.com": "b***@example.com",
+		"a@b.id":           "a***@b.id",
+		"bukan-email":      Redacted,
+		"@example.com":     Redacted,
+	}
+	for in, want := range cases {
+		if got := MaskEmail(in); got != want {
+			t.Errorf("MaskEmail(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
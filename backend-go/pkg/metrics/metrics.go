@@ -0,0 +1,131 @@
+// Package metrics berisi collector Prometheus aplikasi yang diekspos di GET /metrics pada listener
+// internal METRICS_ADDR (bukan port API publik).
+//
+// Collector didaftarkan ke Registry milik paket ini (bukan registry global Prometheus)
+// agar isi /metrics hanya metrik aplikasi, Go runtime, dan proses.
+// Rasio cache hit dihitung di PromQL, misalnya:
+//
+//	sum(rate(ecommerce_cache_requests_total{result="hit"}[5m])) / sum(rate(ecommerce_cache_requests_total[5m]))
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ecommerce"
+
+// Label hasil yang dipakai bersama oleh beberapa collector
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultHit     = "hit"
+	ResultMiss    = "miss"
+)
+
+// Registry menampung semua collector aplikasi
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration dicatat oleh middleware.MetricsMiddleware; route memakai pola gin (/products/:id)
+	// agar jumlah seri tidak meledak karena ID
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latensi request HTTP per method, route, dan status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DBQueryDuration dicatat oleh GormPlugin untuk setiap statement GORM
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Durasi statement GORM per operasi dan tabel.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "table"})
+
+	// CacheRequests mencatat hit/miss cache Redis per jenis cache
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Jumlah pembacaan cache Redis per cache dan hasil (hit/miss).",
+	}, []string{"cache", "result"})
+
+	// RateLimitRejections mencatat request yang ditolak rate limiter (HTTP 429)
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Jumlah request yang ditolak rate limiter per route.",
+	}, []string{"route"})
+
+	// Checkouts mencatat hasil checkout keranjang (cart) dan beli langsung (instant)
+	Checkouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "checkouts_total",
+		Help:      "Jumlah checkout per jenis (cart/instant) dan hasil (success/failure).",
+	}, []string{"type", "result"})
+
+	// PaymentWebhooks mencatat hasil pemrosesan webhook payment gateway
+	PaymentWebhooks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payment_webhooks_total",
+		Help:      "Jumlah webhook Midtrans per hasil (processed, failed, invalid_payload, invalid_signature).",
+	}, []string{"outcome"})
+
+	// JobRuns dan JobDuration mencatat setiap eksekusi scheduler dan worker latar belakang
+	JobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Jumlah eksekusi job latar belakang per job dan hasil (success/failure).",
+	}, []string{"job", "result"})
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Durasi eksekusi job latar belakang.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 15, 30, 60, 300},
+	}, []string{"job"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		DBQueryDuration,
+		CacheRequests,
+		RateLimitRejections,
+		Checkouts,
+		PaymentWebhooks,
+		JobRuns,
+		JobDuration,
+	)
+}
+
+// Handler menyajikan isi Registry dalam format exposition Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDBStats mengekspos statistik pool koneksi (open, in use, idle, wait) dengan label db_name
+func RegisterDBStats(name string, db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveJob mencatat satu eksekusi job yang dimulai pada start
+func ObserveJob(job string, start time.Time, err error) {
+	JobRuns.WithLabelValues(job, Result(err)).Inc()
+	JobDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+}
+
+// Result mengubah error menjadi label success/failure
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
@@ -0,0 +1,364 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TableName adalah tabel pencatat versi migrasi yang sudah dijalankan
+const TableName = "schema_migrations"
+
+// NoTransaction ditulis di file migrasi yang tidak boleh berjalan di dalam transaksi
+// (misalnya CREATE INDEX CONCURRENTLY). Pernyataan di file seperti ini harus idempoten.
+const NoTransaction = "-- migrate:no-transaction"
+
+// lockKey adalah kunci advisory lock agar dua proses migrasi tidak berjalan bersamaan. Kunci level
+// transaksi (pg_advisory_xact_lock) dan level sesi (pg_advisory_lock) dengan kunci yang sama saling menunggu.
+const lockKey = 4600461
+
+// ErrSchemaBehind dikembalikan Check jika masih ada migrasi yang belum dijalankan
+var ErrSchemaBehind = errors.New("skema database tertinggal")
+
+// errAlreadyDone menandai migrasi yang sudah dijalankan proses lain saat menunggu lock
+var errAlreadyDone = errors.New("migrasi sudah dijalankan proses lain")
+
+var fileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration adalah pasangan file NNNNNN_nama.up.sql dan NNNNNN_nama.down.sql
+type Migration struct {
+	Version int64
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%06d_%s", m.Version, m.Name)
+}
+
+// Status adalah keadaan satu versi migrasi. Missing berarti versi tercatat di database
+// tetapi filenya tidak ada (database lebih baru dari binary).
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Missing   bool
+}
+
+// record adalah baris tabel schema_migrations
+type record struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (record) TableName() string {
+	return TableName
+}
+
+// Parse membaca semua file migrasi di root fsys. Setiap versi wajib punya file up dan down.
+func Parse(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := fileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("nama file migrasi tidak valid: %s (format: 000001_nama.up.sql)", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		if version <= 0 {
+			return nil, fmt.Errorf("versi migrasi harus lebih dari 0: %s", entry.Name())
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("versi %d dipakai dua migrasi berbeda: %s dan %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.UpSQL = string(body)
+		} else {
+			m.DownSQL = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.UpSQL) == "" || strings.TrimSpace(m.DownSQL) == "" {
+			return nil, fmt.Errorf("migrasi %s harus punya file up dan down yang tidak kosong", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Create membuat pasangan file migrasi kosong dengan versi berikutnya di dir
+func Create(dir string, name string) (string, string, error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", errors.New("nama migrasi wajib diisi (huruf/angka)")
+	}
+	existing, err := Parse(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	var next int64 = 1
+	if len(existing) > 0 {
+		next = existing[len(existing)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%06d_%s", next, name))
+	up, down := base+".up.sql", base+".down.sql"
+	if err := os.WriteFile(up, []byte("-- Migrasi "+name+"\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- Membatalkan migrasi "+name+"\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
+
+// Migrator menjalankan migrasi berversi terhadap database
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New membaca file migrasi dari fsys (biasanya migrations.FS yang di-embed ke binary)
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Parse(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec(`CREATE TABLE IF NOT EXISTS ` + TableName + ` (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`).Error
+}
+
+// applied membaca versi yang sudah dijalankan; tabel yang belum ada berarti belum ada migrasi
+func (m *Migrator) applied(ctx context.Context) (map[int64]record, error) {
+	db := m.db.WithContext(ctx)
+	result := map[int64]record{}
+	if !db.Migrator().HasTable(TableName) {
+		return result, nil
+	}
+	var rows []record
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		result[r.Version] = r
+	}
+	return result, nil
+}
+
+// Status mengembalikan semua versi (dari file maupun database) terurut naik
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []Status
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if r, ok := applied[mig.Version]; ok {
+			at := r.AppliedAt
+			s.AppliedAt = &at
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, r := range applied {
+		at := r.AppliedAt
+		statuses = append(statuses, Status{Version: r.Version, Name: r.Name, AppliedAt: &at, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending mengembalikan migrasi yang belum dijalankan, terurut naik
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Check mengembalikan ErrSchemaBehind jika database belum menjalankan semua migrasi yang dikenal binary
+func (m *Migrator) Check(ctx context.Context) error {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d migrasi belum dijalankan (mulai dari %s), jalankan `go run ./cmd/migrate up`",
+			ErrSchemaBehind, len(pending), pending[0])
+	}
+	return nil
+}
+
+// Up menjalankan maksimal steps migrasi tertunda (steps <= 0 berarti semua)
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if steps > 0 && steps < len(pending) {
+		pending = pending[:steps]
+	}
+
+	var done []Migration
+	for _, mig := range pending {
+		err := m.run(ctx, mig, true)
+		if errors.Is(err, errAlreadyDone) {
+			continue
+		}
+		if err != nil {
+			return done, fmt.Errorf("migrasi %s gagal: %w", mig, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down membatalkan maksimal steps migrasi terakhir yang sudah dijalankan (steps <= 0 berarti semua)
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files := map[int64]Migration{}
+	for _, mig := range m.migrations {
+		files[mig.Version] = mig
+	}
+
+	var targets []Migration
+	for i := len(statuses) - 1; i >= 0; i-- {
+		s := statuses[i]
+		if s.AppliedAt == nil {
+			continue
+		}
+		if s.Missing {
+			return nil, fmt.Errorf("file migrasi versi %d (%s) tidak ditemukan, tidak bisa di-rollback", s.Version, s.Name)
+		}
+		targets = append(targets, files[s.Version])
+		if steps > 0 && len(targets) == steps {
+			break
+		}
+	}
+
+	var done []Migration
+	for _, mig := range targets {
+		err := m.run(ctx, mig, false)
+		if errors.Is(err, errAlreadyDone) {
+			continue
+		}
+		if err != nil {
+			return done, fmt.Errorf("rollback %s gagal: %w", mig, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// run menjalankan satu arah migrasi beserta pencatatannya dalam satu transaksi, kecuali file
+// ditandai NoTransaction. Keduanya memegang advisory lock yang sama selama berjalan.
+func (m *Migrator) run(ctx context.Context, mig Migration, up bool) error {
+	sql := mig.DownSQL
+	if up {
+		sql = mig.UpSQL
+	}
+	track := func(tx *gorm.DB) error {
+		if up {
+			return tx.Create(&record{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+		}
+		return tx.Delete(&record{}, "version = ?", mig.Version).Error
+	}
+
+	// Periksa ulang setelah lock: proses lain mungkin sudah menjalankan versi ini
+	recheck := func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&record{}).Where("version = ?", mig.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if (up && count > 0) || (!up && count == 0) {
+			return errAlreadyDone
+		}
+		return nil
+	}
+
+	db := m.db.WithContext(ctx)
+	if strings.Contains(sql, NoTransaction) {
+		// Tanpa transaksi, lock level sesi dipegang di satu koneksi sampai migrasi dan pencatatannya selesai
+		return db.Connection(func(conn *gorm.DB) error {
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+			// Tetap dilepas walau ctx dibatalkan, agar koneksi tidak kembali ke pool sambil memegang lock
+			defer conn.WithContext(context.WithoutCancel(ctx)).Exec("SELECT pg_advisory_unlock(?)", lockKey)
+
+			if err := recheck(conn); err != nil {
+				return err
+			}
+			if err := conn.Exec(sql).Error; err != nil {
+				return err
+			}
+			return track(conn)
+		})
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+			return err
+		}
+		if err := recheck(tx); err != nil {
+			return err
+		}
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+		return track(tx)
+	})
+}
@@ -0,0 +1,291 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nuryanfa/e-commerse-sqa/internal/domain"
+	"github.com/nuryanfa/e-commerse-sqa/migrations"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestParse_SortsAndPairsFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_add_index.up.sql":        {Data: []byte("CREATE INDEX a ON t (c);")},
+		"000002_add_index.down.sql":      {Data: []byte("DROP INDEX a;")},
+		"000001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE t (c text);")},
+		"000001_initial_schema.down.sql": {Data: []byte("DROP TABLE t;")},
+		"embed.go":                       {Data: []byte("package migrations")},
+	}
+
+	migrations, err := Parse(fsys)
+	if err != nil {
+		t.Fatalf("File migrasi valid seharusnya terbaca: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].String() != "000002_add_index" {
+		t.Fatalf("Migrasi harus terurut berdasarkan versi, didapat %v", migrations)
+	}
+	if migrations[0].UpSQL != "CREATE TABLE t (c text);" || migrations[0].DownSQL != "DROP TABLE t;" {
+		t.Errorf("Isi file up/down tertukar: %+v", migrations[0])
+	}
+}
+
+func TestParse_RejectsInvalidFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"tanpa down": {
+			"000001_a.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"nama tidak valid": {
+			"1-a.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"versi ganda": {
+			"000001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"000001_a.down.sql": {Data: []byte("SELECT 1;")},
+			"000001_b.up.sql":   {Data: []byte("SELECT 1;")},
+			"000001_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"file kosong": {
+			"000001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"000001_a.down.sql": {Data: []byte("  \n")},
+		},
+	}
+	for name, fsys := range cases {
+		if _, err := Parse(fsys); err == nil {
+			t.Errorf("%s: seharusnya ditolak", name)
+		}
+	}
+}
+
+func TestCreate_UsesNextVersion(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"000001_initial_schema.up.sql", "000001_initial_schema.down.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	up, down, err := Create(dir, "Add Product Weight")
+	if err != nil {
+		t.Fatalf("Create gagal: %v", err)
+	}
+	if filepath.Base(up) != "000002_add_product_weight.up.sql" || filepath.Base(down) != "000002_add_product_weight.down.sql" {
+		t.Errorf("Nama file tidak sesuai, didapat %s dan %s", up, down)
+	}
+	if _, err := Parse(os.DirFS(dir)); err != nil {
+		t.Errorf("File hasil Create harus bisa dibaca Parse: %v", err)
+	}
+
+	if _, _, err := Create(dir, "  !! "); err == nil {
+		t.Error("Nama migrasi kosong seharusnya ditolak")
+	}
+}
+
+// Migrasi yang di-embed ke binary harus selalu valid agar API dan cmd/migrate bisa start
+func TestEmbeddedMigrations(t *testing.T) {
+	embedded, err := Parse(migrations.FS)
+	if err != nil {
+		t.Fatalf("Migrasi ter-embed tidak valid: %v", err)
+	}
+	for i, m := range embedded {
+		if m.Version != int64(i+1) {
+			t.Errorf("Versi migrasi harus berurutan tanpa celah, %s di posisi %d", m, i+1)
+		}
+	}
+	if len(embedded) == 0 || !strings.Contains(embedded[0].UpSQL, `CREATE TABLE IF NOT EXISTS "users"`) {
+		t.Error("Migrasi awal harus membuat skema dasar")
+	}
+	// Migrasi awal harus sama dengan skema AutoMigrate lama; kolom baru masuk migrasi lanjutan
+	for _, column := range []string{`"sku"`, `"low_stock_threshold"`, `"seq"`, `"hash"`, `"expires_at"`} {
+		if strings.Contains(embedded[0].UpSQL, column) {
+			t.Errorf("Kolom %s tidak ada di skema awal dan harus ditambahkan lewat ALTER TABLE", column)
+		}
+	}
+}
+
+// Baris lama harus diisi sebelum indeks yang bergantung pada nilainya dibuat
+func TestEmbeddedMigrations_BackfillBeforeIndex(t *testing.T) {
+	embedded, err := Parse(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := ""
+	for _, m := range embedded[1:] {
+		all += m.UpSQL
+	}
+	cases := []struct{ backfill, index string }{
+		{`UPDATE "products" SET "status" = 'ACTIVE'`, `"idx_products_status"`},
+		{`UPDATE "products" SET "status" = 'ACTIVE'`, `"idx_products_supplier_sku"`},
+		{`UPDATE "audit_logs" AS a SET "seq"`, `"idx_audit_logs_seq"`},
+		{`UPDATE audit_logs SET prev_hash = prev, hash`, `"idx_audit_logs_seq"`},
+		{`UPDATE audit_logs SET prev_hash = prev, hash`, `CREATE TRIGGER trg_audit_logs_append_only`},
+	}
+	for _, tc := range cases {
+		b, i := strings.Index(all, tc.backfill), strings.Index(all, tc.index)
+		if b < 0 || i < 0 || b > i {
+			t.Errorf("%q harus ada dan dijalankan sebelum %q", tc.backfill, tc.index)
+		}
+	}
+}
+
+// openTestDB membuka schema sementara di database dari TEST_DATABASE_URL; test dilewati jika tidak diisi
+func openTestDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL tidak diisi, test migrasi ke PostgreSQL dilewati")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Gagal terhubung ke database test: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Satu koneksi agar search_path berlaku untuk semua query
+	sqlDB.SetMaxOpenConns(1)
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("SET search_path TO " + schema + ", public").Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	})
+	return db
+}
+
+// Database yang dibuat AutoMigrate lama (berisi data) harus bisa dinaikkan ke skema terbaru
+func TestUp_MigratesBaselineSchemaWithData(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	migrator, err := New(db, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx, 1); err != nil {
+		t.Fatalf("Skema awal gagal dibuat: %v", err)
+	}
+
+	base := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	seed := []string{
+		`INSERT INTO users (id_user, nama, email, password, role) VALUES ('u1', 'Supplier', 's@example.com', 'x', 'SUPPLIER')`,
+		`INSERT INTO categories (id_category, name) VALUES ('c1', 'Sayur')`,
+		`INSERT INTO products (id_product, name, price, stock, id_category, supplier_id) VALUES ('p1', 'Bayam', 5000, 3, 'c1', 'u1'), ('p2', 'Kangkung', 4000, 0, 'c1', 'u1')`,
+	}
+	for _, stmt := range seed {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Seed data lama gagal: %v", err)
+		}
+	}
+	// Urutan sisip sengaja berbeda dari urutan waktu: seq harus mengikuti created_at
+	audits := []struct {
+		id  string
+		at  time.Time
+		old string
+	}{
+		{"a3", base.Add(2 * time.Second), `{"stock": 3}`},
+		{"a1", base, ""},
+		{"a2", base.Add(1500 * time.Microsecond), `{"price":5000}`},
+	}
+	for _, a := range audits {
+		var old interface{}
+		if a.old != "" {
+			old = a.old
+		}
+		err := db.Exec(`INSERT INTO audit_logs (id_audit_log, id_user, action, entity, entity_id, old_values, new_values, created_at)
+			VALUES (?, 'u1', 'UPDATE_STOCK', 'products', 'p1', ?, '{"stock": 5}', ?)`, a.id, old, a.at).Error
+		if err != nil {
+			t.Fatalf("Seed audit log gagal: %v", err)
+		}
+	}
+
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("Migrasi lanjutan gagal pada database lama: %v", err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatalf("Semua migrasi seharusnya sudah dijalankan: %v", err)
+	}
+
+	var products []domain.Product
+	if err := db.Order("id_product").Find(&products).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range products {
+		if p.Status != domain.ProductStatusActive || p.LowStockThreshold != 5 || p.AutoHideOutOfStock {
+			t.Errorf("Produk lama %s harus ACTIVE dengan ambang bawaan, didapat %s/%d/%v", p.ID, p.Status, p.LowStockThreshold, p.AutoHideOutOfStock)
+		}
+	}
+
+	var logs []domain.AuditLog
+	if err := db.Order("seq").Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	prev := ""
+	for i, l := range logs {
+		if want := fmt.Sprintf("a%d", i+1); l.ID != want || l.Seq != int64(i+1) {
+			t.Errorf("Seq %d seharusnya %s, didapat %s (seq %d)", i+1, want, l.ID, l.Seq)
+		}
+		if l.PrevHash != prev || l.Hash == "" || l.ChainHash() != l.Hash {
+			t.Errorf("Hash hasil backfill %s tidak sama dengan AuditLog.ChainHash", l.ID)
+		}
+		prev = l.Hash
+	}
+
+	// Baris baru melanjutkan seq, dan trigger append-only aktif
+	if err := db.Exec(`INSERT INTO audit_logs (id_audit_log, action, created_at) VALUES ('a4', 'X', now())`).Error; err != nil {
+		t.Fatal(err)
+	}
+	var seq int64
+	db.Raw(`SELECT seq FROM audit_logs WHERE id_audit_log = 'a4'`).Scan(&seq)
+	if seq != 4 {
+		t.Errorf("Seq baris baru seharusnya 4, didapat %d", seq)
+	}
+	if err := db.Exec(`UPDATE audit_logs SET action = 'Y' WHERE id_audit_log = 'a1'`).Error; err == nil {
+		t.Error("UPDATE audit_logs seharusnya ditolak trigger append-only")
+	}
+
+	if _, err := migrator.Down(ctx, 0); err != nil {
+		t.Fatalf("Rollback semua migrasi gagal: %v", err)
+	}
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("Migrasi ulang setelah rollback gagal: %v", err)
+	}
+}
+
+// Migrasi NoTransaction tetap memakai advisory lock dan tidak dijalankan dua kali
+func TestUp_NoTransactionMigration(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"000001_t.up.sql":     {Data: []byte("CREATE TABLE t (c text);")},
+		"000001_t.down.sql":   {Data: []byte("DROP TABLE t;")},
+		"000002_idx.up.sql":   {Data: []byte(NoTransaction + "\nCREATE INDEX CONCURRENTLY IF NOT EXISTS idx_t_c ON t (c);")},
+		"000002_idx.down.sql": {Data: []byte(NoTransaction + "\nDROP INDEX CONCURRENTLY IF EXISTS idx_t_c;")},
+	}
+	migrator, err := New(db, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done, err := migrator.Up(ctx, 0); err != nil || len(done) != 2 {
+		t.Fatalf("Seharusnya 2 migrasi dijalankan, didapat %d (%v)", len(done), err)
+	}
+	if err := migrator.run(ctx, migrator.migrations[1], true); err != errAlreadyDone {
+		t.Errorf("Versi yang sudah tercatat harus dilewati setelah lock, didapat %v", err)
+	}
+	var locks int64
+	db.Raw("SELECT count(*) FROM pg_locks WHERE locktype = 'advisory' AND pid = pg_backend_pid()").Scan(&locks)
+	if locks != 0 {
+		t.Errorf("Advisory lock harus dilepas setelah migrasi, masih ada %d", locks)
+	}
+}
@@ -0,0 +1,119 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 20  // Dipakai jika query ?limit kosong
+	MaxLimit     = 100 // Batas atas agar satu request tidak memuat seluruh tabel
+)
+
+var (
+	ErrInvalidCursor = errors.New("cursor tidak valid")
+	ErrInvalidLimit  = errors.New("limit harus berupa angka positif")
+)
+
+// Cursor menunjuk baris terakhir halaman sebelumnya. Urutan selalu (created_at DESC, id DESC)
+// sehingga baris dengan created_at sama tetap punya urutan yang stabil.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// Encode menghasilkan token opaque (base64url JSON) untuk dikirim sebagai next_cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode membaca token dari query ?cursor
+func Decode(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Params adalah parameter satu halaman. After nil berarti halaman pertama.
+type Params struct {
+	Limit int
+	After *Cursor
+}
+
+// NewParams memvalidasi limit & cursor. limit <= 0 memakai DefaultLimit, limit > MaxLimit dipotong.
+func NewParams(limit int, cursor string) (Params, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	p := Params{Limit: limit}
+	if cursor != "" {
+		after, err := Decode(cursor)
+		if err != nil {
+			return Params{}, err
+		}
+		p.After = after
+	}
+	return p, nil
+}
+
+// FromQuery membaca ?limit= dan ?cursor= dari request
+func FromQuery(c *gin.Context) (Params, error) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return Params{}, ErrInvalidLimit
+		}
+		limit = parsed
+	}
+	return NewParams(limit, c.Query("cursor"))
+}
+
+// Apply menambahkan filter keyset, urutan, dan LIMIT (+1 baris untuk mendeteksi halaman berikutnya).
+// createdAtColumn dan idColumn sebaiknya memakai nama tabel jika query memakai JOIN.
+func Apply(db *gorm.DB, p Params, createdAtColumn string, idColumn string) *gorm.DB {
+	if p.After != nil {
+		db = db.Where("("+createdAtColumn+", "+idColumn+") < (?, ?)", p.After.CreatedAt, p.After.ID)
+	}
+	return db.Order(createdAtColumn + " DESC").Order(idColumn + " DESC").Limit(p.Limit + 1)
+}
+
+// Trim membuang baris ekstra dari Apply dan mengembalikan next_cursor ("" jika sudah halaman terakhir)
+func Trim[T any](items []T, p Params, key func(T) Cursor) ([]T, string) {
+	if len(items) <= p.Limit {
+		return items, ""
+	}
+	items = items[:p.Limit]
+	return items, key(items[len(items)-1]).Encode()
+}
+
+// Respond mengirim envelope standar daftar berhalaman: data, next_cursor, limit.
+// extra dipakai untuk field tambahan yang sudah ada di endpoint lama (misalnya "message").
+func Respond(c *gin.Context, code int, data interface{}, nextCursor string, p Params, extra gin.H) {
+	body := gin.H{
+		"data":        data,
+		"next_cursor": nextCursor,
+		"limit":       p.Limit,
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	c.JSON(code, body)
+}
@@ -0,0 +1,58 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	original := Cursor{CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.UTC), ID: "order-1"}
+
+	decoded, err := Decode(original.Encode())
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(original.CreatedAt) || decoded.ID != original.ID {
+		t.Errorf("Expected %+v, got %+v", original, decoded)
+	}
+
+	if _, err := Decode("bukan-cursor"); err == nil {
+		t.Error("Expected error for malformed cursor")
+	}
+}
+
+func TestNewParams_Limits(t *testing.T) {
+	p, _ := NewParams(0, "")
+	if p.Limit != DefaultLimit {
+		t.Errorf("Expected default limit %d, got %d", DefaultLimit, p.Limit)
+	}
+	p, _ = NewParams(1000, "")
+	if p.Limit != MaxLimit {
+		t.Errorf("Expected limit capped at %d, got %d", MaxLimit, p.Limit)
+	}
+}
+
+func TestTrim_NextCursor(t *testing.T) {
+	type row struct {
+		id string
+		at time.Time
+	}
+	now := time.Now()
+	rows := []row{{"c", now}, {"b", now}, {"a", now.Add(-time.Minute)}}
+	key := func(r row) Cursor { return Cursor{CreatedAt: r.at, ID: r.id} }
+	p := Params{Limit: 2}
+
+	page, next := Trim(rows, p, key)
+	if len(page) != 2 || next == "" {
+		t.Fatalf("Expected 2 rows and a next cursor, got %d rows, cursor %q", len(page), next)
+	}
+	cursor, _ := Decode(next)
+	if cursor.ID != "b" {
+		t.Errorf("Expected cursor to point at last returned row 'b', got %s", cursor.ID)
+	}
+
+	page, next = Trim(rows[:2], p, key)
+	if len(page) != 2 || next != "" {
+		t.Errorf("Expected last page without next cursor, got %d rows, cursor %q", len(page), next)
+	}
+}
@@ -0,0 +1,47 @@
+// Package reqctx menyimpan informasi per-request (request id, pelaku, IP, user agent) di
+// context.Context agar audit log, log aplikasi, dan query database memakai request id yang sama.
+package reqctx
+
+import "context"
+
+// HeaderRequestID adalah header yang dibaca dari klien/proxy dan dikembalikan di response
+const HeaderRequestID = "X-Request-ID"
+
+// Info adalah data request yang dibawa context. Field kosong berarti tidak diketahui,
+// misalnya ActorID pada endpoint publik atau IP pada job background.
+type Info struct {
+	RequestID string
+	ActorID   string
+	ActorRole string
+	IP        string
+	UserAgent string
+}
+
+type ctxKey struct{}
+
+// With mengganti Info yang dibawa ctx
+func With(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+// From mengembalikan Info dari ctx, atau Info kosong jika ctx tidak membawanya
+func From(ctx context.Context) Info {
+	if ctx == nil {
+		return Info{}
+	}
+	info, _ := ctx.Value(ctxKey{}).(Info)
+	return info
+}
+
+// WithActor menambahkan pelaku ke Info yang sudah ada (dipakai setelah JWT divalidasi atau oleh worker)
+func WithActor(ctx context.Context, actorID string, role string) context.Context {
+	info := From(ctx)
+	info.ActorID = actorID
+	info.ActorRole = role
+	return With(ctx, info)
+}
+
+// RequestID adalah jalan pintas untuk From(ctx).RequestID
+func RequestID(ctx context.Context) string {
+	return From(ctx).RequestID
+}
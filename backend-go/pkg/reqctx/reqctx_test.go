@@ -0,0 +1,31 @@
+package reqctx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithActor_KeepsRequestInfo(t *testing.T) {
+	ctx := With(context.Background(), Info{RequestID: "req-1", IP: "10.0.0.1", UserAgent: "curl/8"})
+	ctx = WithActor(ctx, "user-1", "supplier")
+
+	info := From(ctx)
+	if info.RequestID != "req-1" || info.IP != "10.0.0.1" || info.UserAgent != "curl/8" {
+		t.Errorf("Info request hilang setelah WithActor: %+v", info)
+	}
+	if info.ActorID != "user-1" || info.ActorRole != "supplier" {
+		t.Errorf("Pelaku tidak tersimpan: %+v", info)
+	}
+	if RequestID(ctx) != "req-1" {
+		t.Errorf("Expected request id req-1, got %s", RequestID(ctx))
+	}
+}
+
+func TestFrom_EmptyContext(t *testing.T) {
+	if info := From(context.Background()); info != (Info{}) {
+		t.Errorf("Context tanpa Info harus menghasilkan Info kosong, got %+v", info)
+	}
+	if info := From(nil); info != (Info{}) {
+		t.Errorf("Context nil harus menghasilkan Info kosong, got %+v", info)
+	}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Local menyimpan file di disk. Cocok untuk pengembangan atau deployment satu instance;
+// untuk banyak replika gunakan S3.
+type Local struct {
+	dir     string
+	baseURL string
+	signKey []byte
+	nowFunc func() time.Time
+}
+
+// NewLocal membuat store di dir (misalnya "uploads") yang disajikan di baseURL (misalnya "/uploads").
+// signKey dipakai untuk menandatangani URL objek privat.
+func NewLocal(dir string, baseURL string, signKey []byte) *Local {
+	return &Local{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		signKey: signKey,
+		nowFunc: time.Now,
+	}
+}
+
+// Path mengembalikan lokasi file di disk untuk key yang valid
+func (s *Local) Path(key string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put menulis file secara atomik (tulis ke file sementara lalu rename) agar pembaca
+// tidak pernah melihat file setengah jadi
+func (s *Local) Put(_ context.Context, key string, data []byte, _ string) error {
+	full, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), full)
+}
+
+func (s *Local) Get(_ context.Context, key string) ([]byte, error) {
+	full, err := s.Path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(full)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *Local) Delete(_ context.Context, key string) error {
+	full, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *Local) Exists(_ context.Context, key string) (bool, error) {
+	full, err := s.Path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(full)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *Local) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *Local) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signKey)
+	fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedURL menambahkan expires dan signature (HMAC-SHA256) yang diverifikasi oleh Verify
+func (s *Local) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	if len(s.signKey) == 0 {
+		return "", errors.New("kunci penandatangan storage belum diatur")
+	}
+	expires := s.nowFunc().Add(ttl).Unix()
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("signature", s.signature(key, expires))
+	return s.URL(key) + "?" + q.Encode(), nil
+}
+
+// Verify memeriksa parameter expires dan signature dari URL hasil SignedURL
+func (s *Local) Verify(key string, expiresParam string, signature string) error {
+	if len(s.signKey) == 0 {
+		return ErrInvalidToken
+	}
+	expires, err := strconv.ParseInt(expiresParam, 10, 64)
+	if err != nil || s.nowFunc().Unix() > expires {
+		return ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(key, expires))) {
+		return ErrInvalidToken
+	}
+	return nil
+}
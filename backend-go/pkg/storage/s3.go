@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPresignExpiry adalah batas masa berlaku presigned URL pada Signature V4
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// S3Config berisi parameter koneksi ke object storage S3-compatible
+type S3Config struct {
+	Endpoint  string // Contoh: https://s3.ap-southeast-1.amazonaws.com atau http://localhost:9000 (MinIO)
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle memakai endpoint/bucket/key (wajib untuk MinIO); false memakai bucket.endpoint/key
+	PathStyle bool
+	// PublicURL opsional, misalnya domain CDN di depan bucket. Default: URL objek di endpoint.
+	PublicURL string
+	Client    *http.Client
+}
+
+// S3 adalah BlobStore untuk AWS S3 dan layanan kompatibel seperti MinIO. Request ditandatangani
+// dengan AWS Signature Version 4 secara langsung agar tidak perlu SDK penuh.
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	nowFunc  func() time.Time
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("konfigurasi S3 tidak lengkap: endpoint, bucket, access key, dan secret key wajib diisi")
+	}
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("endpoint S3 tidak valid: %s", cfg.Endpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &S3{cfg: cfg, endpoint: endpoint, client: client, nowFunc: time.Now}, nil
+}
+
+// encodeKey melakukan URI-encode per segmen sesuai aturan SigV4 (garis miring tidak di-encode)
+func encodeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// objectURL mengembalikan host dan path kanonik objek
+func (s *S3) objectURL(key string) (host string, path string) {
+	if s.cfg.PathStyle {
+		return s.endpoint.Host, s.endpoint.Path + "/" + s.cfg.Bucket + "/" + encodeKey(key)
+	}
+	return s.cfg.Bucket + "." + s.endpoint.Host, s.endpoint.Path + "/" + encodeKey(key)
+}
+
+func (s *S3) scope(date string) string {
+	return date + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+func (s *S3) signingKey(date string) []byte {
+	mac := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	k := mac([]byte("AWS4"+s.cfg.SecretKey), date)
+	k = mac(k, s.cfg.Region)
+	k = mac(k, "s3")
+	return mac(k, "aws4_request")
+}
+
+// sign menghitung signature dari canonical request sesuai SigV4
+func (s *S3) sign(amzDate string, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + s.scope(amzDate[:8]) + "\n" + hex.EncodeToString(hash[:])
+	h := hmac.New(sha256.New, s.signingKey(amzDate[:8]))
+	h.Write([]byte(stringToSign))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func (s *S3) do(ctx context.Context, method string, key string, body []byte, contentType string) (*http.Response, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	host, path := s.objectURL(key)
+	payloadHash := sha256.Sum256(body)
+	payloadHex := hex.EncodeToString(payloadHash[:])
+	amzDate := s.nowFunc().UTC().Format("20060102T150405Z")
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		"",
+		"host:" + host + "\nx-amz-content-sha256:" + payloadHex + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHex,
+	}, "\n")
+	signature := s.sign(amzDate, canonicalRequest)
+
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint.Scheme+"://"+host+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawPath = path
+	req.Host = host
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHex)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, s.scope(amzDate[:8]), signedHeaders, signature))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return s.client.Do(req)
+}
+
+func responseError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 %s gagal: %s %s", op, resp.Status, strings.TrimSpace(string(body)))
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError("put", resp)
+	}
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, responseError("get", resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// S3 mengembalikan 204 walaupun objek tidak ada
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return responseError("delete", resp)
+	}
+	return nil
+}
+
+func (s *S3) Exists(ctx context.Context, key string) (bool, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, "")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode/100 == 2:
+		return true, nil
+	default:
+		return false, fmt.Errorf("s3 head gagal: %s", resp.Status)
+	}
+}
+
+// URL objek publik. Bucket (atau CDN di depannya) harus mengizinkan baca publik untuk prefix non-privat.
+func (s *S3) URL(key string) string {
+	if s.cfg.PublicURL != "" {
+		return strings.TrimRight(s.cfg.PublicURL, "/") + "/" + encodeKey(key)
+	}
+	host, path := s.objectURL(key)
+	return s.endpoint.Scheme + "://" + host + path
+}
+
+// SignedURL membuat presigned GET URL (SigV4 query string) yang berlaku selama ttl, maksimal 7 hari
+func (s *S3) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	if ttl <= 0 || ttl > maxPresignExpiry {
+		return "", fmt.Errorf("masa berlaku URL harus antara 1 detik dan %s", maxPresignExpiry)
+	}
+	host, path := s.objectURL(key)
+	amzDate := s.nowFunc().UTC().Format("20060102T150405Z")
+
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+s.scope(amzDate[:8]))
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+	query := canonicalQuery(q)
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		path,
+		query,
+		"host:" + host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	signature := s.sign(amzDate, canonicalRequest)
+	return s.endpoint.Scheme + "://" + host + path + "?" + query + "&X-Amz-Signature=" + signature, nil
+}
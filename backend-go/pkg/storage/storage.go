@@ -0,0 +1,50 @@
+// Package storage menyediakan penyimpanan file (blob) yang bisa diganti antara disk lokal dan
+// object storage S3-compatible (AWS S3, MinIO) tanpa mengubah kode pemanggil.
+package storage
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+)
+
+// PrivatePrefix menandai objek privat (misalnya bukti sengketa). Objek dengan prefix ini tidak
+// pernah disajikan lewat URL publik, hanya lewat SignedURL yang kedaluwarsa.
+const PrivatePrefix = "private/"
+
+var (
+	ErrNotFound     = errors.New("file tidak ditemukan")
+	ErrInvalidKey   = errors.New("nama file tidak valid")
+	ErrInvalidToken = errors.New("tautan file tidak valid atau sudah kedaluwarsa")
+)
+
+// BlobStore adalah abstraksi penyimpanan file. Key memakai garis miring sebagai pemisah,
+// misalnya "products/<hash>_thumb.jpg" atau "private/disputes/<hash>.jpg".
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	// URL mengembalikan alamat publik permanen. Jangan dipakai untuk objek privat.
+	URL(key string) string
+	// SignedURL mengembalikan alamat sementara yang berlaku selama ttl
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// IsPrivate melaporkan apakah key berada di ruang privat
+func IsPrivate(key string) bool {
+	return strings.HasPrefix(key, PrivatePrefix)
+}
+
+// ValidateKey menolak key kosong, absolut, atau yang mengandung ".." agar tidak bisa keluar dari root
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	if path.Clean(key) != key || key == "." || strings.HasPrefix(key, "../") || key == ".." {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook membuat span untuk setiap perintah dan pipeline Redis.
+// Dipasang lewat client.AddHook(tracing.RedisHook{}); argumen perintah (nilai cache) tidak dicatat.
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ctx, span := startRedis(ctx, "redis.dial", "dial")
+		conn, err := next(ctx, network, addr)
+		End(span, err)
+		return conn, err
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := startRedis(ctx, "redis."+cmd.Name(), cmd.Name())
+		err := next(ctx, cmd)
+		End(span, redisError(err))
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := startRedis(ctx, "redis.pipeline", "pipeline")
+		span.SetAttributes(semconv.DBOperationBatchSize(len(cmds)))
+		err := next(ctx, cmds)
+		End(span, redisError(err))
+		return err
+	}
+}
+
+func startRedis(ctx context.Context, name string, operation string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameRedis, semconv.DBOperationName(operation)))
+}
+
+// redisError mengabaikan redis.Nil: cache miss bukan kegagalan
+func redisError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+// Package tracing berisi span OpenTelemetry milik aplikasi: helper Start/End untuk usecase dan job,
+// plugin GORM, dan hook Redis. Provider dan exporter dipasang oleh config.InitTracing; tanpa itu
+// semua span menjadi no-op sehingga package ini aman dipakai di test.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName adalah nama tracer untuk span yang dibuat aplikasi ini
+const InstrumentationName = "github.com/nuryanfa/e-commerse-sqa"
+
+// HeaderTraceID adalah header response berisi trace id request
+const HeaderTraceID = "X-Trace-ID"
+
+func tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Start membuka span anak dari span di ctx (atau span root jika ctx belum punya span)
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartClient membuka span untuk panggilan keluar ke layanan lain (misalnya Midtrans)
+func StartClient(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindClient))
+}
+
+// End menutup span dan menandainya gagal jika err tidak nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID mengembalikan trace id dari span di ctx, atau string kosong jika tidak ada span yang valid
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
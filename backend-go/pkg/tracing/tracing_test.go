@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestRedisHook_SpanPerCommand(t *testing.T) {
+	recorder := setupRecorder(t)
+	ctx, parent := Start(context.Background(), "checkout")
+
+	process := RedisHook{}.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "get" {
+			return redis.Nil
+		}
+		return errors.New("koneksi terputus")
+	})
+	_ = process(ctx, redis.NewStringCmd(ctx, "get", "product:1"))
+	_ = process(ctx, redis.NewStatusCmd(ctx, "set", "product:1", "{}"))
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Seharusnya 2 span Redis + 1 span induk, didapat %d", len(spans))
+	}
+	get, set := spans[0], spans[1]
+	if get.Name() != "redis.get" || get.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("Span Redis harus menjadi anak span di ctx, didapat %s", get.Name())
+	}
+	if get.Status().Code == codes.Error {
+		t.Error("Cache miss (redis.Nil) tidak boleh ditandai error")
+	}
+	if set.Status().Code != codes.Error {
+		t.Error("Perintah yang gagal harus ditandai error")
+	}
+}
+
+func TestGormPlugin_SpanPerStatement(t *testing.T) {
+	recorder := setupRecorder(t)
+	conn, err := sql.Open("pgx", "host=localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(GormPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	type product struct {
+		ID    string
+		Price float64
+	}
+	ctx, parent := Start(context.Background(), "order.CheckoutTransaction")
+	db.WithContext(ctx).Where("id = ?", "p-rahasia").Find(&[]product{})
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "gorm.query" || spans[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("Seharusnya satu span gorm.query di bawah span transaksi, didapat %d span", len(spans))
+	}
+	var query string
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == "db.query.text" {
+			query = attr.Value.AsString()
+		}
+	}
+	if query != `SELECT * FROM "products" WHERE id = $1` {
+		t.Errorf("SQL harus dicatat dengan placeholder tanpa nilai parameter, didapat %q", query)
+	}
+}
@@ -75,6 +75,11 @@ export default function OrderDetail() {
               <ShieldAlert className="w-4 h-4" /> BERSENGKETA
             </span>
           )}
+          {order.status === 'PAYMENT_REVIEW' && (
+            <span className="px-3 py-1 rounded-full text-xs font-black bg-amber-100 dark:bg-amber-900/50 text-amber-700 dark:text-amber-400 font-mono tracking-widest flex items-center gap-1" title="Pembayaran diterima setelah batas bayar, stok sudah habis. Admin akan menghubungi Anda untuk refund.">
+              <ShieldAlert className="w-4 h-4" /> DITINJAU ADMIN
+            </span>
+          )}
         </div>
 
         {/* Timeline */}
@@ -2,16 +2,25 @@ import { useState, useEffect } from 'react';
 import { Link } from 'react-router-dom';
 import api from '../services/api';
 import { motion } from 'framer-motion';
-import { Box, Clock, CreditCard, Truck, CheckCircle, PackageOpen, ChevronRight, FileText } from 'lucide-react';
+import { Box, Clock, CreditCard, Truck, CheckCircle, PackageOpen, ChevronRight, FileText, Loader2 } from 'lucide-react';
 
 export default function Orders() {
   const [orders, setOrders] = useState([]);
+  const [nextCursor, setNextCursor] = useState('');
   const [loading, setLoading] = useState(true);
+  const [loadingMore, setLoadingMore] = useState(false);
 
   useEffect(() => {
-    api.get('/orders').then(res => setOrders(res.data.data || [])).catch(() => {}).finally(() => setLoading(false));
+    api.get('/orders').then(res => { setOrders(res.data.data || []); setNextCursor(res.data.next_cursor || ''); }).catch(() => {}).finally(() => setLoading(false));
   }, []);
 
+  const loadMore = () => {
+    setLoadingMore(true);
+    api.get('/orders', { params: { cursor: nextCursor } })
+      .then(res => { setOrders(prev => [...prev, ...(res.data.data || [])]); setNextCursor(res.data.next_cursor || ''); })
+      .catch(() => {}).finally(() => setLoadingMore(false));
+  };
+
   const statusConfig = {
     PENDING: { bg: 'bg-amber-100 dark:bg-amber-900/20', text: 'text-amber-700 dark:text-amber-400', icon: <Clock className="w-5 h-5" /> },
     PAID: { bg: 'bg-blue-100 dark:bg-blue-900/20', text: 'text-blue-700 dark:text-blue-400', icon: <CreditCard className="w-5 h-5" /> },
@@ -55,6 +64,11 @@ export default function Orders() {
               </Link>
             );
           })}
+          {nextCursor && (
+            <button onClick={loadMore} disabled={loadingMore} className="btn-primary w-full py-2.5 text-sm flex items-center justify-center gap-2 disabled:opacity-50">
+              {loadingMore ? <><Loader2 className="w-4 h-4 animate-spin" /> Memuat...</> : 'Muat Pesanan Lainnya'}
+            </button>
+          )}
         </div>
       )}
     </div>
@@ -23,13 +23,16 @@ export default function ProductDetail() {
   const [loading, setLoading] = useState(true);
   const [adding, setAdding] = useState(false);
   const [hoverRating, setHoverRating] = useState(0);
+  const [reviewCount, setReviewCount] = useState(0);
+  const [reviewCursor, setReviewCursor] = useState('');
+  const [loadingReviews, setLoadingReviews] = useState(false);
   const toast = useToast();
 
   useEffect(() => {
     setLoading(true);
     setQty(1);
     
-    const fetches = [api.get(`/products/${id}`), api.get(`/products/${id}/reviews`), api.get('/products')];
+    const fetches = [api.get(`/products/${id}`), api.get(`/products/${id}/reviews`)];
     if (user) fetches.push(api.get(`/wishlist/check/${id}`).catch(() => ({ data: { is_wishlisted: false } })));
     
     Promise.all(fetches).then(r => {
@@ -39,14 +42,14 @@ export default function ProductDetail() {
         setSelectedVariant(p.variants[0]);
       }
       
-      if (r[1].data.data) { setReviews(r[1].data.data.reviews || []); setAvgRating(r[1].data.data.average || 0); }
-      
-      // Filter related products
-      const allProducts = r[2].data.data || [];
-      const related = allProducts.filter(item => item.id_category === p.id_category && item.id_product !== p.id_product).slice(0, 4);
-      setRelatedProducts(related);
+      applyReviews(r[1].data);
+
+      // Produk terkait dicari per kategori di server, bukan disaring dari halaman pertama katalog
+      api.get('/products/search', { params: { category: p.id_category, limit: 5 } })
+        .then(res => setRelatedProducts((res.data.data || []).filter(item => item.id_product !== p.id_product).slice(0, 4)))
+        .catch(() => setRelatedProducts([]));
 
-      if (user && r[3]) setIsWishlisted(r[3].data.is_wishlisted);
+      if (user && r[2]) setIsWishlisted(r[2].data.is_wishlisted);
     }).catch(() => navigate('/products')).finally(() => setLoading(false));
   }, [id, navigate, user]);
 
@@ -56,6 +59,24 @@ export default function ProductDetail() {
     catch { toast.error('Gagal memperbarui wishlist'); }
   };
 
+  // Halaman pertama ulasan menggantikan daftar; count adalah total seluruh ulasan dari server
+  const applyReviews = (body, append = false) => {
+    if (!body.data) return;
+    setReviews(prev => append ? [...prev, ...(body.data.reviews || [])] : (body.data.reviews || []));
+    setAvgRating(body.data.average || 0);
+    setReviewCount(body.data.count || 0);
+    setReviewCursor(body.next_cursor || '');
+  };
+
+  const loadMoreReviews = async () => {
+    setLoadingReviews(true);
+    try {
+      const r = await api.get(`/products/${id}/reviews`, { params: { cursor: reviewCursor } });
+      applyReviews(r.data, true);
+    } catch { toast.error('Gagal memuat ulasan'); }
+    finally { setLoadingReviews(false); }
+  };
+
   const submitReview = async (e) => {
     e.preventDefault();
     if (!user) { toast.info('Login untuk ulasan'); return navigate('/login'); }
@@ -65,7 +86,7 @@ export default function ProductDetail() {
       toast.success('Ulasan ditambahkan!');
       setMyReview({ rating: 5, comment: '' });
       const r = await api.get(`/products/${id}/reviews`);
-      if (r.data.data) { setReviews(r.data.data.reviews || []); setAvgRating(r.data.data.average || 0); }
+      applyReviews(r.data);
     } catch (err) { toast.error(err.response?.data?.error || 'Gagal kirim ulasan'); }
     finally { setSubmittingReview(false); }
   };
@@ -165,7 +186,7 @@ export default function ProductDetail() {
                 )}
                 <div className="flex items-center gap-1.5 text-sm font-bold text-amber-500 bg-amber-50 dark:bg-amber-900/20 px-3 py-1 rounded-lg">
                   <Star className="w-4 h-4 fill-amber-500" /> {avgRating.toFixed(1)} 
-                  <span className="text-gray-400 ml-1 font-medium">({reviews.length})</span>
+                  <span className="text-gray-400 ml-1 font-medium">({reviewCount})</span>
                 </div>
               </div>
 
@@ -321,6 +342,11 @@ export default function ProductDetail() {
                         <p className="text-sm text-gray-600 dark:text-gray-400 leading-relaxed ml-13 bg-gray-50 dark:bg-slate-800/30 p-4 rounded-xl rounded-tl-none">{r.comment}</p>
                       </motion.div>
                     ))}
+                    {reviewCursor && (
+                      <button type="button" onClick={loadMoreReviews} disabled={loadingReviews} className="w-full py-2.5 text-sm font-bold rounded-xl border border-emerald-200 dark:border-slate-700 text-emerald-700 dark:text-emerald-400 hover:bg-emerald-50 dark:hover:bg-slate-800 transition-all flex items-center justify-center gap-2 disabled:opacity-50">
+                        {loadingReviews ? <><Loader2 className="w-4 h-4 animate-spin" /> Memuat...</> : `Lihat Ulasan Lainnya (${reviewCount - reviews.length})`}
+                      </button>
+                    )}
                   </div>
                 )}
               </div>
@@ -1,5 +1,5 @@
 import { useState, useEffect } from 'react';
-import api from '../../services/api';
+import api, { fetchAllPages } from '../../services/api';
 import { useToast } from '../../context/ToastContext';
 import { useModal } from '../../context/ModalContext';
 import { BarChart, Bar, XAxis, YAxis, CartesianGrid, Tooltip, ResponsiveContainer, PieChart, Pie, Cell, Legend } from 'recharts';
@@ -26,8 +26,9 @@ export default function AdminDashboard() {
 
   const fetchData = () => {
     setLoading(true);
-    Promise.all([api.get('/products'), api.get('/categories')]).then(([p, c]) => {
-      setProducts(p.data.data || []); setCategories(c.data.data || []);
+    // Semua halaman produk dimuat karena tabel & grafik kategori dihitung dari seluruh katalog
+    Promise.all([fetchAllPages('/products'), api.get('/categories')]).then(([p, c]) => {
+      setProducts(p); setCategories(c.data.data || []);
     }).catch(() => {}).finally(() => setLoading(false));
   };
   useEffect(fetchData, []);
@@ -1,5 +1,5 @@
 import { useState, useEffect, useMemo } from 'react';
-import api from '../../services/api';
+import api, { fetchAllPages } from '../../services/api';
 import { useToast } from '../../context/ToastContext';
 import { useModal } from '../../context/ModalContext';
 import { AreaChart, Area, BarChart, Bar, XAxis, YAxis, CartesianGrid, Tooltip, ResponsiveContainer, Cell } from 'recharts';
@@ -27,11 +27,11 @@ export default function SupplierDashboard() {
     setLoading(true);
     Promise.all([
       api.get('/supplier/products'),
-      api.get('/supplier/orders'),
+      fetchAllPages('/supplier/orders'), // Omzet & grafik penjualan dihitung dari seluruh pesanan
       api.get('/categories'),
     ]).then(([p, o, c]) => {
       setProducts(p.data.data || []);
-      setOrders(o.data.data || []);
+      setOrders(o);
       setCategories(c.data.data || []);
     }).catch(() => {}).finally(() => setLoading(false));
   };
@@ -50,8 +50,7 @@ export default function SupplierDashboard() {
           await api.put(`/supplier/orders/${orderId}/process`);
           toast.success('Pesanan berhasil diproses menjadi PROCESSED!');
           // Refresh order list smoothly
-          const { data } = await api.get('/supplier/orders');
-          setOrders(data.data || []);
+          setOrders(await fetchAllPages('/supplier/orders'));
         } catch (err) {
           toast.error(err.response?.data?.error || 'Gagal merubah status pesanan');
         }
@@ -70,8 +69,7 @@ export default function SupplierDashboard() {
         try {
           await api.post('/supplier/orders/batch-process', { order_ids: selectedOrders });
           toast.success(`${selectedOrders.length} pesanan berhasil diproses!`);
-          const { data } = await api.get('/supplier/orders');
-          setOrders(data.data || []);
+          setOrders(await fetchAllPages('/supplier/orders'));
           setSelectedOrders([]);
         } catch (err) {
           toast.error(err.response?.data?.error || 'Gagal memproses massal');
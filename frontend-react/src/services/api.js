@@ -22,4 +22,17 @@ api.interceptors.request.use(
   }
 );
 
+// Mengambil seluruh halaman endpoint ber-cursor (envelope { data, next_cursor }). Dipakai dashboard
+// yang menghitung omzet/grafik dari semua data, bukan hanya halaman pertama.
+export const fetchAllPages = async (url, params = {}) => {
+  const items = [];
+  let cursor = "";
+  do {
+    const res = await api.get(url, { params: { ...params, limit: 100, ...(cursor && { cursor }) } });
+    items.push(...(res.data.data || []));
+    cursor = res.data.next_cursor || "";
+  } while (cursor);
+  return items;
+};
+
 export default api;